@@ -16,6 +16,23 @@ type API struct {
 
 	RequestLog bool `split_words:"true" default:"false"`
 	RunSwagger bool `split_words:"true" default:"true"`
+
+	// MaxBudgetVersions caps the budget versions a company can keep per year
+	MaxBudgetVersions int `split_words:"true" default:"10"`
+
+	// GramsPerTroyOz is used in doré import and report ounce calculations; override it to match a reference
+	// model that uses a different constant (e.g. 31.103477)
+	GramsPerTroyOz float64 `split_words:"true" default:"31.1035"`
+
+	// TokenType is the login token: "opaque" (a sessions row per login, looked up on each
+	// request) or "jwt" (signed with JWTSecret and verified without the database)
+	TokenType string `split_words:"true" default:"opaque"`
+	JWTSecret string `split_words:"true"`
+
+	// Password policy of SetPassword and ChangePassword; relax it for local development.
+	// PasswordComplexity requires an uppercase letter, a lowercase letter and a digit.
+	PasswordMinLength  int  `split_words:"true" default:"10"`
+	PasswordComplexity bool `split_words:"true" default:"true"`
 }
 
 func NewAPI() API {
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sslModes are the sslmode values accepted by Postgres
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// tokenTypes are the login token types (see usecase.TokenTypeOpaque and TokenTypeJWT)
+var tokenTypes = []string{"opaque", "jwt"}
+
+// minJWTSecretLength is the shortest accepted HS256 secret: the 256 bits of the hash
+const minJWTSecretLength = 32
+
+// ValidationError lists every missing or invalid configuration value found at startup
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks required values and ranges, reporting all problems at once
+// (keyed by environment variable) instead of failing later at runtime
+func (c *Config) Validate() error {
+	var problems []string
+	required := func(key, value string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, key+" is required")
+		}
+	}
+
+	// API
+	if port, err := strconv.Atoi(c.API.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("NEWAPI_PORT (or PORT) must be a port number between 1 and 65535, got %q", c.API.Port))
+	}
+	if c.API.ReadHeaderTimeout <= 0 {
+		problems = append(problems, "NEWAPI_READ_HEADER_TIMEOUT must be greater than 0")
+	}
+	if c.API.GracefulTimeout < 0 {
+		problems = append(problems, "NEWAPI_GRACEFUL_TIMEOUT must not be negative")
+	}
+	if c.API.MaxBudgetVersions < 1 {
+		problems = append(problems, "NEWAPI_MAX_BUDGET_VERSIONS must be at least 1")
+	}
+	if c.API.GramsPerTroyOz <= 0 {
+		problems = append(problems, "NEWAPI_GRAMS_PER_TROY_OZ must be greater than 0")
+	}
+	if c.API.PasswordMinLength < 1 {
+		problems = append(problems, "NEWAPI_PASSWORD_MIN_LENGTH must be at least 1")
+	}
+	if !slices.Contains(tokenTypes, c.API.TokenType) {
+		problems = append(problems, fmt.Sprintf("NEWAPI_TOKEN_TYPE must be one of %s, got %q", strings.Join(tokenTypes, ", "), c.API.TokenType))
+	}
+	if c.API.TokenType == "jwt" && len(c.API.JWTSecret) < minJWTSecretLength {
+		problems = append(problems, fmt.Sprintf("NEWAPI_JWT_SECRET must be at least %d characters with NEWAPI_TOKEN_TYPE=jwt", minJWTSecretLength))
+	}
+
+	// Database
+	required("DB_DRIVER", c.Database.Driver)
+	required("DB_HOST", c.Database.Host)
+	required("DB_NAME", c.Database.Name)
+	required("DB_USER", c.Database.User)
+	if c.Database.Port == 0 {
+		problems = append(problems, "DB_PORT is required")
+	}
+	if !slices.Contains(sslModes, c.Database.SslMode) {
+		problems = append(problems, fmt.Sprintf("DB_SSL_MODE must be one of %s, got %q", strings.Join(sslModes, ", "), c.Database.SslMode))
+	}
+	if c.Database.MaxConnectionPool < 1 {
+		problems = append(problems, "DB_MAX_CONNECTION_POOL must be at least 1")
+	}
+	if c.Database.MaxIdleConnections < 0 {
+		problems = append(problems, "DB_MAX_IDLE_CONNECTIONS must not be negative")
+	}
+	if c.Database.ConnectionsMaxLifeTime < 0 {
+		problems = append(problems, "DB_CONNECTIONS_MAX_LIFE_TIME must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
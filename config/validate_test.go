@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig() *Config {
+	return &Config{
+		API: API{
+			Port:              "3080",
+			ReadHeaderTimeout: 60 * time.Second,
+			GracefulTimeout:   8 * time.Second,
+			MaxBudgetVersions: 10,
+			GramsPerTroyOz:    31.1035,
+			TokenType:         "opaque",
+			PasswordMinLength: 10,
+		},
+		Database: Database{
+			Driver:            "postgres",
+			Host:              "localhost",
+			Port:              5432,
+			Name:              "postgres",
+			User:              "postgres",
+			SslMode:           "disable",
+			MaxConnectionPool: 4,
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidate_ListsEveryMissingKey(t *testing.T) {
+	cfg := validConfig()
+	cfg.API.Port = ""
+	cfg.Database.Host = ""
+	cfg.Database.Name = " "
+	cfg.Database.User = ""
+	cfg.Database.Port = 0
+	cfg.Database.SslMode = "sometimes"
+
+	err := cfg.Validate()
+
+	var validationErr *ValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Len(t, validationErr.Problems, 6)
+	}
+	for _, key := range []string{"NEWAPI_PORT", "DB_HOST", "DB_NAME", "DB_USER", "DB_PORT", "DB_SSL_MODE"} {
+		assert.Contains(t, err.Error(), key)
+	}
+}
+
+func TestValidate_JWTNeedsSecret(t *testing.T) {
+	cfg := validConfig()
+	cfg.API.TokenType = "jwt"
+	assert.ErrorContains(t, cfg.Validate(), "NEWAPI_JWT_SECRET")
+
+	cfg.API.JWTSecret = "0123456789abcdef0123456789abcdef"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.API.TokenType = "paseto"
+	assert.ErrorContains(t, cfg.Validate(), "NEWAPI_TOKEN_TYPE")
+}
@@ -43,6 +43,17 @@ CREATE TABLE company_settings (
     mining_type VARCHAR(50) DEFAULT 'underground',
     country VARCHAR(100),
     royalty_percentage DECIMAL(5,2) DEFAULT 0.00,
+    net_cash_flow_capex_types VARCHAR(100) DEFAULT 'sustaining', -- CAPEX types subtracted in PBR Net Cash Flow
+    excluded_expense_types VARCHAR(200) DEFAULT '', -- OPEX expense types excluded from Production Based Costs
+    dore_grade_basis VARCHAR(20) DEFAULT 'oz', -- Dore grade derivation: 'oz' (ounce share) or 'atomic'
+    realized_price_fallback BOOLEAN DEFAULT false, -- Use PBR price when Dore realized price is zero
+    data_retention_years INTEGER DEFAULT 0, -- Years of data kept out of the archive tables (0 = all)
+    zero_row_checks VARCHAR(200) DEFAULT '', -- Per import type all-zero row handling, e.g. 'pbr:reject,dore:warn'
+    primary_metal VARCHAR(20) DEFAULT 'silver', -- Cash cost basis: 'silver' (gold by-product credit) or 'co_product'
+    cost_centers VARCHAR(500) DEFAULT '', -- OPEX cost centers, e.g. 'Mine,Processing,G&A,Transport & Shipping,Exploration' ('' = those four without Exploration)
+    capex_categories TEXT DEFAULT '', -- CAPEX categories always listed in the CAPEX detail ('' = built-in list)
+    capex_projects TEXT DEFAULT '', -- CAPEX projects ('CAR number - project name') always listed in the CAPEX detail ('' = built-in list)
+    opex_subcategories TEXT DEFAULT '', -- JSON subcategories OPEX imports accept per cost center, e.g. '{"Mine": ["Drilling"]}' ('' = any)
     notes TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
@@ -11,6 +11,10 @@ DROP TABLE IF EXISTS opex_data CASCADE;
 DROP TABLE IF EXISTS capex_data CASCADE;
 DROP TABLE IF EXISTS revenue_data CASCADE;
 DROP TABLE IF EXISTS financial_data CASCADE;
+DROP TABLE IF EXISTS company_import_formats CASCADE;
+DROP TABLE IF EXISTS currency_rates CASCADE;
+DROP TABLE IF EXISTS import_log CASCADE;
+DROP TABLE IF EXISTS import_idempotency_keys CASCADE;
 
 -- Production Data
 CREATE TABLE production_data (
@@ -23,6 +27,7 @@ CREATE TABLE production_data (
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
@@ -53,10 +58,13 @@ CREATE TABLE dore_data (
     refining_deductions_au DECIMAL(15,2) NOT NULL,
     -- Streaming agreement (usually negative)
     streaming DECIMAL(15,2) DEFAULT 0,
+    -- Basis the grades were derived with ('oz' or 'atomic')
+    grade_basis VARCHAR(20) NOT NULL DEFAULT 'oz',
     -- Metadata
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
@@ -107,6 +115,7 @@ CREATE TABLE pbr_data (
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
@@ -120,11 +129,16 @@ CREATE TABLE opex_data (
     cost_center VARCHAR(100) NOT NULL,
     subcategory VARCHAR(100) NOT NULL,
     expense_type VARCHAR(50) NOT NULL,
-    amount DECIMAL(15,2) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL, -- USD
     currency VARCHAR(10) NOT NULL,
+    original_amount DECIMAL(15,2), -- Amount as imported, in original_currency
+    original_currency VARCHAR(10),
+    exchange_rate DECIMAL(18,6), -- original_currency units per USD used on import (1 for USD)
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
+    is_adjustment BOOLEAN NOT NULL DEFAULT false,  -- Delta imported with mode=adjust
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
@@ -142,9 +156,14 @@ CREATE TABLE capex_data (
     amount DECIMAL(15,2) NOT NULL,
     accretion_of_mine_closure_liability DECIMAL(15,2) DEFAULT 0,
     currency VARCHAR(10) NOT NULL,
+    original_amount DECIMAL(15,2), -- Amount as imported, in original_currency
+    original_currency VARCHAR(10),
+    exchange_rate DECIMAL(18,6), -- original_currency units per USD used on import (1 for USD)
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
+    is_adjustment BOOLEAN NOT NULL DEFAULT false,  -- Delta imported with mode=adjust
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
@@ -162,12 +181,15 @@ CREATE TABLE revenue_data (
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
 
 -- Financial Data (Shipping, Sales Taxes, Royalties, Other Deductions)
+-- Databases created before the split still have sales_taxes_royalties: apply
+-- migrations/001_split_sales_taxes_royalties.sql before importing financial data.
 CREATE TABLE financial_data (
     id BIGSERIAL PRIMARY KEY,
     company_id BIGINT NOT NULL REFERENCES mining_companies(id) ON DELETE CASCADE,
@@ -181,11 +203,57 @@ CREATE TABLE financial_data (
     data_type VARCHAR(20) NOT NULL DEFAULT 'actual',
     version INT NOT NULL DEFAULT 1,
     description TEXT DEFAULT '',
+    notes TEXT DEFAULT '',
     deleted_at TIMESTAMP,
     created_by BIGINT NOT NULL REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
 
+-- Company CSV format profile (recorded by the first successful import)
+CREATE TABLE company_import_formats (
+    company_id BIGINT PRIMARY KEY REFERENCES mining_companies(id) ON DELETE CASCADE,
+    delimiter VARCHAR(2) NOT NULL DEFAULT ',',
+    decimal_separator VARCHAR(1) NOT NULL DEFAULT '.',
+    date_layout VARCHAR(20) NOT NULL DEFAULT '2006-01-02', -- Go time layout
+    currency_symbol VARCHAR(10) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Monthly exchange rates: OPEX and CAPEX imports convert amounts to USD at the row's month
+CREATE TABLE currency_rates (
+    id BIGSERIAL PRIMARY KEY,
+    currency VARCHAR(10) NOT NULL,
+    month DATE NOT NULL, -- First day of the month
+    units_per_usd DECIMAL(18,6) NOT NULL CHECK (units_per_usd > 0), -- e.g. ARS 950 = 1 USD
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (currency, month)
+);
+
+-- Import history: one row per successful import, written with the imported rows
+CREATE TABLE import_log (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES mining_companies(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL,     -- production, dore, pbr, opex, capex, revenue, financial
+    data_type VARCHAR(20) NOT NULL, -- actual, budget, forecast
+    version INT NOT NULL,
+    mode VARCHAR(20) NOT NULL,     -- insert, adjust, replace
+    filename TEXT NOT NULL DEFAULT '',
+    row_count INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Idempotency-Key of successful imports per user, with the response replayed on a retry
+CREATE TABLE import_idempotency_keys (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL DEFAULT '', -- Hash of the company, import and file of the key
+    response JSONB,                -- Set when the import commits
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
 -- Indexes for performance
 CREATE INDEX idx_production_data_company ON production_data(company_id);
 CREATE INDEX idx_production_data_date ON production_data(date);
@@ -199,12 +267,16 @@ CREATE INDEX idx_dore_data_date ON dore_data(date);
 CREATE INDEX idx_dore_data_type ON dore_data(data_type);
 CREATE INDEX idx_dore_data_deleted ON dore_data(deleted_at);
 CREATE INDEX idx_dore_data_company_date_type ON dore_data(company_id, date, data_type) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_dore_data_unique_date ON dore_data(company_id, date, data_type, version) WHERE deleted_at IS NULL; -- One Dore row per date
 
 CREATE INDEX idx_pbr_data_company ON pbr_data(company_id);
 CREATE INDEX idx_pbr_data_date ON pbr_data(date);
 CREATE INDEX idx_pbr_data_type ON pbr_data(data_type);
 CREATE INDEX idx_pbr_data_deleted ON pbr_data(deleted_at);
 CREATE INDEX idx_pbr_data_company_date_type ON pbr_data(company_id, date, data_type) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_pbr_data_unique_date ON pbr_data(company_id, date, data_type, version) WHERE deleted_at IS NULL; -- One PBR row per date (Dore lookup)
+
+CREATE INDEX idx_import_log_company_created ON import_log(company_id, created_at);
 
 CREATE INDEX idx_opex_data_company ON opex_data(company_id);
 CREATE INDEX idx_opex_data_date ON opex_data(date);
@@ -233,3 +305,20 @@ CREATE INDEX idx_financial_data_type ON financial_data(data_type);
 CREATE INDEX idx_financial_data_deleted ON financial_data(deleted_at);
 CREATE INDEX idx_financial_data_company_date_type ON financial_data(company_id, date, data_type) WHERE deleted_at IS NULL;
 
+-- Archive tables (data retention): same columns as their data table, rows moved
+-- here by POST /api/v1/data/archive and listed with include_archived=true
+CREATE TABLE production_data_archive (LIKE production_data INCLUDING DEFAULTS);
+CREATE TABLE dore_data_archive (LIKE dore_data INCLUDING DEFAULTS);
+CREATE TABLE pbr_data_archive (LIKE pbr_data INCLUDING DEFAULTS);
+CREATE TABLE opex_data_archive (LIKE opex_data INCLUDING DEFAULTS);
+CREATE TABLE capex_data_archive (LIKE capex_data INCLUDING DEFAULTS);
+CREATE TABLE revenue_data_archive (LIKE revenue_data INCLUDING DEFAULTS);
+CREATE TABLE financial_data_archive (LIKE financial_data INCLUDING DEFAULTS);
+
+CREATE INDEX idx_production_data_archive_company_date ON production_data_archive(company_id, date);
+CREATE INDEX idx_dore_data_archive_company_date ON dore_data_archive(company_id, date);
+CREATE INDEX idx_pbr_data_archive_company_date ON pbr_data_archive(company_id, date);
+CREATE INDEX idx_opex_data_archive_company_date ON opex_data_archive(company_id, date);
+CREATE INDEX idx_capex_data_archive_company_date ON capex_data_archive(company_id, date);
+CREATE INDEX idx_revenue_data_archive_company_date ON revenue_data_archive(company_id, date);
+CREATE INDEX idx_financial_data_archive_company_date ON financial_data_archive(company_id, date);
@@ -0,0 +1,12 @@
+-- Migration: Configurable CAPEX types in PBR Net Cash Flow
+-- Date: 2026-10-16
+-- Description: Adds net_cash_flow_capex_types to company_settings.
+--   Comma-separated list of CAPEX types (sustaining, project, leasing)
+--   subtracted from Production Based Margin to get PBR Net Cash Flow.
+--   Existing companies keep the previous behaviour (sustaining only).
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS net_cash_flow_capex_types VARCHAR(100) DEFAULT 'sustaining';
+
+UPDATE company_settings
+SET net_cash_flow_capex_types = 'sustaining'
+WHERE net_cash_flow_capex_types IS NULL OR net_cash_flow_capex_types = '';
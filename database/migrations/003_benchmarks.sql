@@ -0,0 +1,18 @@
+-- Migration: Peer group benchmarks
+-- Date: 2026-10-16
+-- Description: Adds the benchmarks table with annual peer-group KPI values
+--   (AISC/oz, recovery, strip ratio, ...) compared against a company's
+--   monthly KPIs in GET /api/v1/reports/benchmark.
+
+CREATE TABLE IF NOT EXISTS benchmarks (
+    id BIGSERIAL PRIMARY KEY,
+    peer_group VARCHAR(100) NOT NULL DEFAULT 'default',
+    year INT NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    value DECIMAL(15,4) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE(peer_group, year, metric)
+);
+
+CREATE INDEX IF NOT EXISTS idx_benchmarks_peer_group_year ON benchmarks(peer_group, year);
@@ -0,0 +1,8 @@
+-- Migration: OPEX/CAPEX adjustment entries
+-- Date: 2026-10-16
+-- Description: Adds is_adjustment to opex_data and capex_data.
+--   Rows imported with mode=adjust are deltas posted by accounting that
+--   sum on top of the existing amounts (they may be negative).
+
+ALTER TABLE opex_data ADD COLUMN IF NOT EXISTS is_adjustment BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE capex_data ADD COLUMN IF NOT EXISTS is_adjustment BOOLEAN NOT NULL DEFAULT false;
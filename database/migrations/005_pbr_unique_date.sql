@@ -0,0 +1,10 @@
+-- Migration: Unique PBR date per company/data type/version
+-- Date: 2026-10-16
+-- Description: Dore import derives metrics from the single PBR row of each
+--   date. Two active PBR rows for the same date in one version would let the
+--   lookup pick either one, so duplicates are rejected at the database level.
+--   Resolve existing duplicates (soft delete one of them) before applying.
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pbr_data_unique_date
+    ON pbr_data(company_id, date, data_type, version)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+-- Migration: Expense types excluded from Production Based Costs
+-- Date: 2026-10-16
+-- Description: Adds excluded_expense_types to company_settings.
+--   Comma-separated list of OPEX expense types (e.g. "Other") left out of
+--   the cost centers and Production Based Costs and reported separately.
+--   Empty (default) keeps the previous behaviour: every expense type counts.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS excluded_expense_types VARCHAR(200) DEFAULT '';
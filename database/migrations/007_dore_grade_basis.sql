@@ -0,0 +1,9 @@
+-- Migration: Dore grade basis
+-- Date: 2026-10-16
+-- Description: Adds dore_grade_basis to company_settings and grade_basis to dore_data.
+--   'oz' (default): grades are each metal's share of the doré troy ounces.
+--   'atomic': grades are each metal's share of atoms (ounces / atomic weight).
+--   dore_data records the basis used at import so metal ounces can be recovered.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS dore_grade_basis VARCHAR(20) DEFAULT 'oz';
+ALTER TABLE dore_data ADD COLUMN IF NOT EXISTS grade_basis VARCHAR(20) NOT NULL DEFAULT 'oz';
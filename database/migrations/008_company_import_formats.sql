@@ -0,0 +1,14 @@
+-- Migration: Company CSV format profiles
+-- Date: 2026-10-16
+-- Description: Adds company_import_formats. The first successful import records the
+--   company's CSV format (delimiter, decimal separator, date layout, currency symbol);
+--   later imports default to it and may override any field.
+
+CREATE TABLE IF NOT EXISTS company_import_formats (
+    company_id BIGINT PRIMARY KEY REFERENCES mining_companies(id) ON DELETE CASCADE,
+    delimiter VARCHAR(2) NOT NULL DEFAULT ',',
+    decimal_separator VARCHAR(1) NOT NULL DEFAULT '.',
+    date_layout VARCHAR(20) NOT NULL DEFAULT '2006-01-02', -- Go time layout
+    currency_symbol VARCHAR(10) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
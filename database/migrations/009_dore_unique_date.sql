@@ -0,0 +1,10 @@
+-- Migration: Unique Dore date per company/data type/version
+-- Date: 2026-10-16
+-- Description: Same rule as PBR (005): one active Dore row per date and version.
+--   Soft-deleted rows are excluded from the index, so a deleted month can be
+--   imported again without creating a second active row.
+--   Resolve existing duplicates (soft delete one of them) before applying.
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dore_data_unique_date
+    ON dore_data(company_id, date, data_type, version)
+    WHERE deleted_at IS NULL;
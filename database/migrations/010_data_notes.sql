@@ -0,0 +1,13 @@
+-- Migration: Optional notes column on imported data rows
+-- Date: 2026-10-16
+-- Description: Client files may carry a trailing free-text `notes` column
+--   (e.g. explaining an adjustment). It is stored per row and returned by the
+--   row-listing endpoints. Files without the column are unaffected.
+
+ALTER TABLE production_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+ALTER TABLE dore_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+ALTER TABLE pbr_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+ALTER TABLE opex_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+ALTER TABLE capex_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+ALTER TABLE revenue_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+ALTER TABLE financial_data ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
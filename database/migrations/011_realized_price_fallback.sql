@@ -0,0 +1,8 @@
+-- Migration: PBR price fallback for missing Dore realized prices
+-- Date: 2026-10-16
+-- Description: Adds realized_price_fallback to company_settings.
+--   When true, Dore metal with a zero (or missing) realized price is valued
+--   at the PBR price for revenue, gold credit and cash cost.
+--   False (default) keeps the previous behaviour: such metal earns nothing.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS realized_price_fallback BOOLEAN DEFAULT false;
@@ -0,0 +1,26 @@
+-- Migration: Per-company data retention and archive tables
+-- Date: 2026-10-16
+-- Description: Adds data_retention_years to company_settings and an archive
+--   table per data table. POST /api/v1/data/archive moves a company's soft
+--   deleted rows, and rows older than data_retention_years (when > 0), out of
+--   the hot tables. Listings include archived rows with include_archived=true.
+--   Archive tables mirror their data table column for column: a migration that
+--   adds a column to a data table must add it to the archive table too.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS data_retention_years INTEGER DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS production_data_archive (LIKE production_data INCLUDING DEFAULTS);
+CREATE TABLE IF NOT EXISTS dore_data_archive (LIKE dore_data INCLUDING DEFAULTS);
+CREATE TABLE IF NOT EXISTS pbr_data_archive (LIKE pbr_data INCLUDING DEFAULTS);
+CREATE TABLE IF NOT EXISTS opex_data_archive (LIKE opex_data INCLUDING DEFAULTS);
+CREATE TABLE IF NOT EXISTS capex_data_archive (LIKE capex_data INCLUDING DEFAULTS);
+CREATE TABLE IF NOT EXISTS revenue_data_archive (LIKE revenue_data INCLUDING DEFAULTS);
+CREATE TABLE IF NOT EXISTS financial_data_archive (LIKE financial_data INCLUDING DEFAULTS);
+
+CREATE INDEX IF NOT EXISTS idx_production_data_archive_company_date ON production_data_archive(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_dore_data_archive_company_date ON dore_data_archive(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_pbr_data_archive_company_date ON pbr_data_archive(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_opex_data_archive_company_date ON opex_data_archive(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_capex_data_archive_company_date ON capex_data_archive(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_revenue_data_archive_company_date ON revenue_data_archive(company_id, date);
+CREATE INDEX IF NOT EXISTS idx_financial_data_archive_company_date ON financial_data_archive(company_id, date);
@@ -0,0 +1,8 @@
+-- Migration: Per import type checks for all-zero rows
+-- Date: 2026-10-16
+-- Description: Adds zero_row_checks to company_settings, a comma-separated list
+--   of "type:action" pairs (e.g. 'pbr:reject,dore:warn'). On import, rows whose
+--   required numeric fields are all zero produce a warning (warn) or fail the
+--   import (reject). Types not listed are not checked (default).
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS zero_row_checks VARCHAR(200) DEFAULT '';
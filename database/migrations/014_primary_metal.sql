@@ -0,0 +1,8 @@
+-- Migration: Co-product cash costs
+-- Date: 2026-10-16
+-- Description: Adds primary_metal to company_settings. 'silver' (default) keeps
+--   the by-product method: all cash costs per silver ounce, less the gold credit.
+--   'co_product' splits cash costs and AISC between silver and gold by their
+--   share of gross revenue and reports both per payable ounce.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS primary_metal VARCHAR(20) DEFAULT 'silver';
@@ -0,0 +1,7 @@
+-- Migration: Revoked JWTs in sessions
+-- Date: 2026-10-16
+-- Description: With NEWAPI_TOKEN_TYPE=jwt, tokens are verified without a session
+--   row and sessions only lists revoked token IDs (jti) until they expire. The
+--   revoked flag keeps those rows from ever being accepted as opaque tokens.
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS revoked BOOLEAN DEFAULT false NOT NULL;
@@ -0,0 +1,5 @@
+-- Migration: Last login per user
+-- Date: 2026-10-16
+-- Description: Login stamps last_login_at; it stays NULL for users who never logged in.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP;
@@ -0,0 +1,8 @@
+-- Migration: Configurable OPEX cost centers
+-- Date: 2026-10-16
+-- Description: Adds cost_centers to company_settings, a comma-separated list of
+--   the OPEX cost centers a company imports (e.g. 'Mine,Processing,G&A,
+--   Transport & Shipping,Exploration'). Production Based Costs sums all of them.
+--   Empty (default) keeps Mine, Processing, G&A and Transport & Shipping.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS cost_centers VARCHAR(500) DEFAULT '';
@@ -0,0 +1,9 @@
+-- Migration: Per-company CAPEX categories and projects
+-- Date: 2026-10-16
+-- Description: Adds capex_categories and capex_projects to company_settings,
+--   comma-separated lists of the CAPEX categories and projects (CAR number -
+--   project name) always reported in the CAPEX detail, zero when a month has
+--   no data for them. Empty (default) keeps the built-in lists.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS capex_categories TEXT DEFAULT '';
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS capex_projects TEXT DEFAULT '';
@@ -0,0 +1,31 @@
+-- Migration: Currency conversion on import
+-- Date: 2026-10-16
+-- Description: Adds currency_rates, the monthly exchange rate of each non-USD
+--   currency as units of the currency per USD (e.g. ARS 950 = 1 USD). OPEX and
+--   CAPEX imports convert amounts to USD at the rate of the row's month and keep
+--   the amount as imported in original_amount/original_currency; exchange_rate
+--   is the rate used (1 for USD rows, NULL for rows imported before conversion).
+
+CREATE TABLE IF NOT EXISTS currency_rates (
+    id BIGSERIAL PRIMARY KEY,
+    currency VARCHAR(10) NOT NULL,
+    month DATE NOT NULL, -- First day of the month
+    units_per_usd DECIMAL(18,6) NOT NULL CHECK (units_per_usd > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (currency, month)
+);
+
+ALTER TABLE opex_data ADD COLUMN IF NOT EXISTS original_amount DECIMAL(15,2);
+ALTER TABLE opex_data ADD COLUMN IF NOT EXISTS original_currency VARCHAR(10);
+ALTER TABLE opex_data ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,6);
+ALTER TABLE capex_data ADD COLUMN IF NOT EXISTS original_amount DECIMAL(15,2);
+ALTER TABLE capex_data ADD COLUMN IF NOT EXISTS original_currency VARCHAR(10);
+ALTER TABLE capex_data ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,6);
+
+-- Archive tables keep the same columns as their data table
+ALTER TABLE opex_data_archive ADD COLUMN IF NOT EXISTS original_amount DECIMAL(15,2);
+ALTER TABLE opex_data_archive ADD COLUMN IF NOT EXISTS original_currency VARCHAR(10);
+ALTER TABLE opex_data_archive ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,6);
+ALTER TABLE capex_data_archive ADD COLUMN IF NOT EXISTS original_amount DECIMAL(15,2);
+ALTER TABLE capex_data_archive ADD COLUMN IF NOT EXISTS original_currency VARCHAR(10);
+ALTER TABLE capex_data_archive ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,6);
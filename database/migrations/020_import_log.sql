@@ -0,0 +1,20 @@
+-- Migration: Import history
+-- Date: 2026-10-16
+-- Description: Adds import_log, one row per successful data import (who, which
+--   company, type, data type, version, file and row count), written in the same
+--   transaction as the imported rows. Listed by GET /api/v1/data/imports.
+
+CREATE TABLE IF NOT EXISTS import_log (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES mining_companies(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL,
+    data_type VARCHAR(20) NOT NULL,
+    version INT NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    filename TEXT NOT NULL DEFAULT '',
+    row_count INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_log_company_created ON import_log(company_id, created_at);
@@ -0,0 +1,15 @@
+-- Migration: Per-cost-center OPEX subcategories
+-- Date: 2026-10-16
+-- Description: Adds opex_subcategories to company_settings, a JSON object of the
+--   subcategories OPEX imports accept per cost center (e.g. '{"Mine": ["Drilling"]}').
+--   Empty (default) or cost centers left out accept any subcategory. Imports now
+--   store inventory variations as 'Inventory Variations'; existing rows spelled
+--   'Inventory Variation' or 'Stockpile/WIP' are renamed to match.
+
+ALTER TABLE company_settings ADD COLUMN IF NOT EXISTS opex_subcategories TEXT DEFAULT '';
+
+UPDATE opex_data SET subcategory = 'Inventory Variations'
+WHERE LOWER(subcategory) IN ('inventory variation', 'inventory variations', 'stockpile/wip');
+
+UPDATE opex_data_archive SET subcategory = 'Inventory Variations'
+WHERE LOWER(subcategory) IN ('inventory variation', 'inventory variations', 'stockpile/wip');
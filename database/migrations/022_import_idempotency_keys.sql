@@ -0,0 +1,15 @@
+-- Migration: Import idempotency keys
+-- Date: 2026-10-16
+-- Description: Adds import_idempotency_keys, the Idempotency-Key headers of successful
+--   imports per user with the response returned, so a retried upload gets the same
+--   response instead of inserting its rows again. Keys expire after a day (see
+--   data.IdempotencyKeyTTL); expired keys of a user are removed on their next keyed import.
+
+CREATE TABLE IF NOT EXISTS import_idempotency_keys (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    response JSONB,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
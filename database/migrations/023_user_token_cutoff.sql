@@ -0,0 +1,8 @@
+-- Migration: Per-user token cutoff
+-- Date: 2026-10-16
+-- Description: Adds users.tokens_valid_after. Ending a user's sessions (password change,
+--   deactivation, company assignment or role change) sets it to now, and JWTs issued
+--   before it are rejected: they carry the user's company roles and are otherwise only
+--   checked against the token IDs revoked at logout.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP;
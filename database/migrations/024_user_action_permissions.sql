@@ -0,0 +1,13 @@
+-- Migration: User management action permissions
+-- Date: 2026-10-16
+-- Description: Adds the per-action permissions of the company user management routes
+--   (/api/v1/company/{company_id}/users). Company admins now also need users.create,
+--   users.update, users.deactivate or users.roles for the matching action; super admins
+--   hold all of them without a grant.
+
+INSERT INTO permissions (name, description) VALUES
+('users.create', 'Create users in companies the user administers'),
+('users.update', 'Update users of companies the user administers'),
+('users.deactivate', 'Deactivate users of companies the user administers'),
+('users.roles', 'Change or remove company roles in companies the user administers')
+ON CONFLICT (name) DO NOTHING;
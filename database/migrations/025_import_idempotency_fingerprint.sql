@@ -0,0 +1,9 @@
+-- Migration: Import idempotency key fingerprints
+-- Date: 2026-10-16
+-- Description: Adds import_idempotency_keys.fingerprint, a hash of the company, import
+--   (type, data type, version, mode) and file a key was used for, so reusing a key for
+--   another upload is rejected instead of replaying the response of the first one. Keys
+--   recorded before this migration have an empty fingerprint and are rejected if reused
+--   until they expire.
+
+ALTER TABLE import_idempotency_keys ADD COLUMN IF NOT EXISTS fingerprint VARCHAR(64) NOT NULL DEFAULT '';
@@ -25,3 +25,21 @@ CREATE INDEX idx_saved_reports_company_year ON saved_reports(company_id, year);
 COMMENT ON TABLE saved_reports IS 'Saved budget scenarios for comparison (what-if analysis)';
 COMMENT ON COLUMN saved_reports.report_data IS 'Budget projections for the year (12 months)';
 COMMENT ON COLUMN saved_reports.budget_version IS 'Which budget version this scenario represents (1=optimistic, 2=conservative, 3=realistic, etc)';
+
+-- Peer Group Benchmarks
+DROP TABLE IF EXISTS benchmarks CASCADE;
+
+CREATE TABLE IF NOT EXISTS benchmarks (
+    id BIGSERIAL PRIMARY KEY,
+    peer_group VARCHAR(100) NOT NULL DEFAULT 'default',
+    year INT NOT NULL,
+    metric VARCHAR(50) NOT NULL,  -- KPI key, e.g. aisc_per_oz_silver, recovery_rate_silver_pct, stripping_ratio
+    value DECIMAL(15,4) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE(peer_group, year, metric)
+);
+
+CREATE INDEX idx_benchmarks_peer_group_year ON benchmarks(peer_group, year);
+
+COMMENT ON TABLE benchmarks IS 'Annual peer group KPI benchmarks compared against company monthly KPIs';
@@ -19,6 +19,8 @@ CREATE TABLE users (
     work_area VARCHAR(100) NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     active BOOLEAN DEFAULT true NOT NULL,
+    last_login_at TIMESTAMP,
+    tokens_valid_after TIMESTAMP, -- JWTs issued before are rejected (set when all sessions are ended)
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
@@ -40,11 +42,13 @@ CREATE TABLE user_permissions (
 -- Sessions table
 -- company_roles stores the user's roles per company at login time (cached for performance)
 -- Format: {"company_id": "role", ...} e.g. {"1": "admin", "2": "viewer"}
+-- With JWT tokens, rows are only the revoked token IDs (jti), flagged revoked
 CREATE TABLE sessions (
     token VARCHAR(64) PRIMARY KEY,
     user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     company_roles JSONB DEFAULT '{}' NOT NULL,
     expires_at TIMESTAMP NOT NULL,
+    revoked BOOLEAN DEFAULT false NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
 );
 
@@ -73,7 +77,11 @@ INSERT INTO permissions (name, description) VALUES
 ('super_admin', 'Global admin - can manage users and companies across the entire system'),
 ('admin', 'Company admin - can manage users and data within assigned companies'),
 ('editor', 'Can create and edit data'),
-('viewer', 'Read-only access to data');
+('viewer', 'Read-only access to data'),
+('users.create', 'Create users in companies the user administers'),
+('users.update', 'Update users of companies the user administers'),
+('users.deactivate', 'Deactivate users of companies the user administers'),
+('users.roles', 'Change or remove company roles in companies the user administers');
 
 -- Create test super admin user
 -- DNI: 99999999, Password: admin123
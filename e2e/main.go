@@ -226,6 +226,32 @@ func testImportFlow() {
 		log.Fatalf("Expected 1 row inserted, got %d", importResult.RowsInserted)
 	}
 
+	// A rejected file is 422 with the validation errors, not 200
+	invalidCSV := []byte(`date,ore_mined_t,waste_mined_t,developments_m,total_tonnes_processed,feed_grade_silver_gpt,feed_grade_gold_gpt,recovery_rate_silver_pct,recovery_rate_gold_pct
+2025-02-15,abc,262591,598,35951,209.79,7.35,94.01,95.36`)
+
+	importResp, err = doMultipartRequest("/api/v1/data/import", map[string]string{
+		"type":       "pbr",
+		"data_type":  "actual",
+		"company_id": fmt.Sprintf("%d", companyID),
+	}, "file", "pbr_invalid.csv", invalidCSV, token)
+
+	if err != nil {
+		log.Fatalf("Import invalid PBR failed: %v", err)
+	}
+	if importResp.StatusCode != http.StatusUnprocessableEntity {
+		body, _ := io.ReadAll(importResp.Body)
+		log.Fatalf("Import invalid PBR: got %v want %v, body: %s", importResp.StatusCode, http.StatusUnprocessableEntity, string(body))
+	}
+
+	var rejected data.ImportResponse
+	json.NewDecoder(importResp.Body).Decode(&rejected)
+	importResp.Body.Close()
+
+	if rejected.Success || len(rejected.Errors) == 0 {
+		log.Fatalf("Expected validation errors for the invalid PBR file, got %+v", rejected)
+	}
+
 	log.Println("✓ Import flow passed")
 }
 
@@ -17,6 +17,9 @@ import (
 	"github.com/gmhafiz/go8/internal/utility/respond"
 )
 
+// MaxUserImportSize caps user CSV uploads at 1MB, thousands of rows
+const MaxUserImportSize = 1 << 20
+
 type Handler struct {
 	useCase   usecase.UseCase
 	validator *validator.Validate
@@ -32,9 +35,12 @@ func RegisterHTTPEndPoints(router *chi.Mux, validator *validator.Validate, uc us
 	}
 
 	router.Route("/api/v1/auth", func(r chi.Router) {
+		r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
 		r.Post("/login", h.Login)
 		r.Post("/logout", h.Logout)
+		r.Post("/refresh", h.Refresh)
 		r.Get("/me", h.Me)
+		r.Get("/me/roles", h.MyRoles)
 	})
 
 	return h
@@ -108,6 +114,42 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	respond.JSON(w, http.StatusOK, auth.MessageResponse{Message: "logged out successfully"})
 }
 
+// Refresh replaces the current token with a new one
+// @Summary Refresh session token
+// @Description Exchange a valid, unexpired token for a new one with a fresh expiry. The old token
+// @Description stops working and the company roles of the session are kept.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth.LoginResponse
+// @Failure 401 {object} respond.Error
+// @Failure 403 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/auth/refresh [post]
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	token := extractToken(r)
+	if token == "" {
+		respond.Error(w, http.StatusUnauthorized, errors.New("missing authorization token"))
+		return
+	}
+
+	response, err := h.useCase.Refresh(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidToken) {
+			respond.Error(w, http.StatusUnauthorized, err)
+			return
+		}
+		if errors.Is(err, usecase.ErrUserInactive) {
+			respond.Error(w, http.StatusForbidden, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
 // Me returns current user information
 // @Summary Get current user
 // @Description Get current authenticated user information
@@ -138,6 +180,94 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	respond.JSON(w, http.StatusOK, user)
 }
 
+// MyRoles returns the current user's role in each company they can access
+// @Summary Get current user's company roles
+// @Description Role of the authenticated user in every accessible company, with company names
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} auth.UserCompany
+// @Failure 401 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/auth/me/roles [get]
+func (h *Handler) MyRoles(w http.ResponseWriter, r *http.Request) {
+	token := extractToken(r)
+	if token == "" {
+		respond.Error(w, http.StatusUnauthorized, errors.New("missing authorization token"))
+		return
+	}
+
+	roles, err := h.useCase.GetCurrentUserRoles(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidToken) {
+			respond.Error(w, http.StatusUnauthorized, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, roles)
+}
+
+// ListSessions returns the current user's active sessions
+// @Summary List my sessions
+// @Description Active sessions of the authenticated user, identified by token prefix (tokens are never returned)
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} auth.SessionInfo
+// @Failure 401 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/auth/sessions [get]
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, errors.New("user not authenticated"))
+		return
+	}
+
+	sessions, err := h.useCase.ListSessions(r.Context(), userID, extractToken(r))
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession ends one of the current user's sessions
+// @Summary Revoke one of my sessions
+// @Description End a single session of the authenticated user, e.g. one left open on a shared terminal
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param tokenPrefix path string true "Token prefix, as listed by GET /api/v1/auth/sessions"
+// @Success 200 {object} auth.MessageResponse
+// @Failure 401 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/auth/sessions/{tokenPrefix} [delete]
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, errors.New("user not authenticated"))
+		return
+	}
+
+	err := h.useCase.RevokeSession(r.Context(), userID, chi.URLParam(r, "tokenPrefix"))
+	if err != nil {
+		if errors.Is(err, authRepo.ErrSessionNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, auth.MessageResponse{Message: "session revoked"})
+}
+
 // extractToken extracts the Bearer token from Authorization header
 func extractToken(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
@@ -182,6 +312,10 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 			companyID = cid
 		}
 	}
+	// On company routes, the company checked by ValidateCompanyAccess (the path's)
+	if validated, ok := middleware.GetCompanyID(r.Context()); ok {
+		companyID = validated
+	}
 
 	var users []*auth.User
 	var total int
@@ -224,6 +358,7 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 			Active:      user.Active,
 			Permissions: permissions,
 			Companies:   companies,
+			LastLoginAt: user.LastLoginAt,
 			CreatedAt:   user.CreatedAt,
 		}
 	}
@@ -285,6 +420,7 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 		Active:      user.Active,
 		Permissions: permissions,
 		Companies:   companies,
+		LastLoginAt: user.LastLoginAt,
 		CreatedAt:   user.CreatedAt,
 	}
 
@@ -388,6 +524,7 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		Active:      user.Active,
 		Permissions: permissions,
 		Companies:   companies,
+		LastLoginAt: user.LastLoginAt,
 		CreatedAt:   user.CreatedAt,
 	}
 
@@ -567,6 +704,85 @@ func (h *Handler) AssignPermissions(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// BulkAssignPermissions assigns the same permissions to several users in one transaction
+// @Summary Bulk assign permissions
+// @Description Assigns permissions to a list of users atomically and invalidates their sessions
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.BulkAssignPermissionsRequest true "Users and permissions"
+// @Success 200 {object} auth.BulkPermissionsResponse
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/auth/permissions/bulk [post]
+func (h *Handler) BulkAssignPermissions(w http.ResponseWriter, r *http.Request) {
+	var req auth.BulkAssignPermissionsRequest
+
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.useCase.BulkAssignPermissions(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, authRepo.ErrUserNotFound) || errors.Is(err, authRepo.ErrPermissionNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// ImportUsers creates users from a CSV upload
+// @Summary Import users from CSV
+// @Description Creates a user per row with columns dni,first_name,last_name,work_area,role,company_id,permissions
+// @Description (permissions separated by ";") and an optional birth_date (YYYY-MM-DD). Each user gets a temporary
+// @Description password, returned in the row result. Rows with an existing DNI are skipped, invalid rows are reported
+// @Description and the rest of the file is still imported.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Success 200 {object} auth.UserImportResponse
+// @Failure 400 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/auth/users/import [post]
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUserImportSize)
+	if err := r.ParseMultipartForm(MaxUserImportSize); err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("file too large or invalid form data"))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("file is required"))
+		return
+	}
+	defer file.Close()
+
+	response, err := h.useCase.ImportUsers(r.Context(), file)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidUserCSV) {
+			respond.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
 // SetPassword allows super admin to set a user's password (no current password required)
 func (h *Handler) SetPassword(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "id")
@@ -589,6 +805,10 @@ func (h *Handler) SetPassword(w http.ResponseWriter, r *http.Request) {
 
 	err = h.useCase.SetPassword(r.Context(), userID, req.NewPassword)
 	if err != nil {
+		if errors.Is(err, usecase.ErrWeakPassword) {
+			respond.Error(w, http.StatusBadRequest, err)
+			return
+		}
 		if errors.Is(err, authRepo.ErrUserNotFound) {
 			respond.Error(w, http.StatusNotFound, err)
 			return
@@ -624,6 +844,10 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 	err := h.useCase.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
 	if err != nil {
+		if errors.Is(err, usecase.ErrWeakPassword) {
+			respond.Error(w, http.StatusBadRequest, err)
+			return
+		}
 		if errors.Is(err, usecase.ErrInvalidCredentials) {
 			respond.Error(w, http.StatusUnauthorized, errors.New("current password is incorrect"))
 			return
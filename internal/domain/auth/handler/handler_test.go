@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gmhafiz/go8/internal/domain/auth"
+	authRepo "github.com/gmhafiz/go8/internal/domain/auth/repository"
+	"github.com/gmhafiz/go8/internal/domain/auth/usecase"
+)
+
+// userRepository is an in-memory repository of the users created through the handler
+type userRepository struct {
+	authRepo.Repository
+	users []*auth.User
+}
+
+func (r *userRepository) GetUserByDNI(ctx context.Context, dni string) (*auth.User, error) {
+	for _, user := range r.users {
+		if user.DNI == dni {
+			return user, nil
+		}
+	}
+	return nil, authRepo.ErrUserNotFound
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, id int64) (*auth.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, authRepo.ErrUserNotFound
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *auth.User) error {
+	user.ID = int64(len(r.users) + 1)
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *userRepository) ListUsers(ctx context.Context, page, size int) ([]*auth.User, int, error) {
+	return r.users, len(r.users), nil
+}
+
+func (r *userRepository) GetUserPermissions(ctx context.Context, userID int64) ([]string, error) {
+	return []string{}, nil
+}
+
+func (r *userRepository) GetUserCompanies(ctx context.Context, userID int64) ([]auth.UserCompany, error) {
+	return []auth.UserCompany{}, nil
+}
+
+func (r *userRepository) GetSessionByToken(ctx context.Context, token string) (*auth.Session, error) {
+	return &auth.Session{Token: token, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func TestDNILeadingZerosPreserved(t *testing.T) {
+	repo := &userRepository{}
+	h := &Handler{useCase: usecase.New(repo), validator: validator.New(), repo: repo}
+
+	createUser := func(dni string) *httptest.ResponseRecorder {
+		body := `{"first_name": "Zero", "last_name": "Lead", "dni": ` + dni + `,
+			"birth_date": "1990-01-01T00:00:00Z", "work_area": "Finance", "password": "admin123"}`
+		rec := httptest.NewRecorder()
+		h.CreateUser(rec, httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body)))
+		return rec
+	}
+
+	// Create: stored and returned as sent
+	rec := createUser(`"00123456"`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"dni":"00123456"`)
+	require.Len(t, repo.users, 1)
+	assert.Equal(t, "00123456", repo.users[0].DNI)
+
+	// A number (whose leading zeros would be lost) or a DNI over 20 characters is rejected
+	assert.Equal(t, http.StatusBadRequest, createUser(`123456`).Code)
+	assert.Equal(t, http.StatusBadRequest, createUser(`"`+strings.Repeat("0", 21)+`"`).Code)
+	assert.Len(t, repo.users, 1)
+
+	// List, with the default page size
+	rec = httptest.NewRecorder()
+	h.ListUsers(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"dni":"00123456"`)
+	var list auth.UsersListResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, 10, list.Size)
+
+	// /me
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	h.Me(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"dni":"00123456"`)
+}
@@ -7,16 +7,17 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID           int64     `db:"id" json:"id"`
-	FirstName    string    `db:"first_name" json:"first_name"`
-	LastName     string    `db:"last_name" json:"last_name"`
-	DNI          string    `db:"dni" json:"dni"`
-	BirthDate    time.Time `db:"birth_date" json:"birth_date"`
-	WorkArea     string    `db:"work_area" json:"work_area"`
-	PasswordHash string    `db:"password_hash" json:"-"` // Never send to client
-	Active       bool      `db:"active" json:"active"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID           int64      `db:"id" json:"id"`
+	FirstName    string     `db:"first_name" json:"first_name"`
+	LastName     string     `db:"last_name" json:"last_name"`
+	DNI          string     `db:"dni" json:"dni"` // Always a string: DNIs may have leading zeros (e.g. "00123456")
+	BirthDate    time.Time  `db:"birth_date" json:"birth_date"`
+	WorkArea     string     `db:"work_area" json:"work_area"`
+	PasswordHash string     `db:"password_hash" json:"-"` // Never send to client
+	Active       bool       `db:"active" json:"active"`
+	LastLoginAt  *time.Time `db:"last_login_at" json:"last_login_at"` // nil until the first login
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // Permission represents a permission in the system
@@ -39,6 +40,17 @@ type Session struct {
 	CreatedAt    time.Time    `db:"created_at" json:"created_at"`
 }
 
+// SessionTokenPrefixLength is how much of a session token is shown to identify it
+const SessionTokenPrefixLength = 8
+
+// SessionInfo describes an active session without its token, which is never returned
+type SessionInfo struct {
+	TokenPrefix string    `db:"token_prefix" json:"token_prefix"` // First SessionTokenPrefixLength characters
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expires_at"`
+	Current     bool      `db:"-" json:"current"` // The session of the request
+}
+
 // GetRole returns the user's role for a specific company
 // Returns empty string if user has no access to the company
 func (cr CompanyRoles) GetRole(companyID int64) string {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -15,6 +16,8 @@ var (
 	ErrUserNotFound     = errors.New("user not found")
 	ErrSessionNotFound  = errors.New("session not found")
 	ErrDNIAlreadyExists = errors.New("dni already exists")
+
+	ErrPermissionNotFound = errors.New("permission not found")
 )
 
 // Repository defines the interface for auth data operations
@@ -28,10 +31,12 @@ type Repository interface {
 	DeactivateUser(ctx context.Context, userID int64) error
 	ListUsers(ctx context.Context, page, size int) ([]*auth.User, int, error)
 	ListUsersByCompany(ctx context.Context, companyID int64, page, size int) ([]*auth.User, int, error)
+	ImportUser(ctx context.Context, user *auth.User, companyID int64, role string, permissionNames []string) error
 
 	// Permission operations
 	GetUserPermissions(ctx context.Context, userID int64) ([]string, error)
 	AssignPermissions(ctx context.Context, userID int64, permissionNames []string) error
+	AssignPermissionsBulk(ctx context.Context, userIDs []int64, permissionNames []string) error
 
 	// Company operations
 	GetUserCompanies(ctx context.Context, userID int64) ([]auth.UserCompany, error)
@@ -46,6 +51,17 @@ type Repository interface {
 	GetSessionByToken(ctx context.Context, token string) (*auth.Session, error)
 	DeleteSession(ctx context.Context, token string) error
 	DeleteUserSessions(ctx context.Context, userID int64) error
+	TouchLastLogin(ctx context.Context, userID int64) error
+	ListSessions(ctx context.Context, userID int64) ([]auth.SessionInfo, error)
+	DeleteSessionByPrefix(ctx context.Context, userID int64, tokenPrefix string) error
+
+	// Revoked JWT IDs (jti), kept in sessions until the token expires
+	RevokeToken(ctx context.Context, tokenID string, userID int64, expiresAt time.Time) error
+	GetRevokedTokenIDs(ctx context.Context) ([]string, error)
+
+	// JWTs of a user issued before the user's tokens_valid_after (set by DeleteUserSessions)
+	// are rejected: returns the cutoffs set after since, by user
+	GetTokenCutoffs(ctx context.Context, since time.Time) (map[int64]time.Time, error)
 }
 
 type repository struct {
@@ -62,7 +78,7 @@ func (r *repository) GetUserByDNI(ctx context.Context, dni string) (*auth.User,
 	var user auth.User
 	query := `
 		SELECT id, first_name, last_name, dni, birth_date, work_area, 
-		       password_hash, active, created_at, updated_at
+		       password_hash, active, last_login_at, created_at, updated_at
 		FROM users
 		WHERE dni = $1 AND active = true
 	`
@@ -83,7 +99,7 @@ func (r *repository) GetUserByID(ctx context.Context, id int64) (*auth.User, err
 	var user auth.User
 	query := `
 		SELECT id, first_name, last_name, dni, birth_date, work_area, 
-		       password_hash, active, created_at, updated_at
+		       password_hash, active, last_login_at, created_at, updated_at
 		FROM users
 		WHERE id = $1 AND active = true
 	`
@@ -130,6 +146,75 @@ func (r *repository) CreateUser(ctx context.Context, user *auth.User) error {
 	return nil
 }
 
+// ImportUser creates a user, assigns them to a company and grants permissions in one
+// transaction: nothing is written if the DNI exists or a permission does not
+func (r *repository) ImportUser(ctx context.Context, user *auth.User, companyID int64, role string, permissionNames []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO users (first_name, last_name, dni, birth_date, work_area, password_hash, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (dni) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.DNI,
+		user.BirthDate,
+		user.WorkArea,
+		user.PasswordHash,
+		user.Active,
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDNIAlreadyExists
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO user_companies (user_id, company_id, role) VALUES ($1, $2, $3)`, user.ID, companyID, role)
+	if err != nil {
+		return err
+	}
+
+	if len(permissionNames) > 0 {
+		var permissionIDs []int
+		err = tx.SelectContext(ctx, &permissionIDs, `SELECT id FROM permissions WHERE name = ANY($1)`, permissionNames)
+		if err != nil {
+			return err
+		}
+		// One ID per distinct name: a permission listed twice is not a missing one
+		if len(permissionIDs) != countDistinct(permissionNames) {
+			return ErrPermissionNotFound
+		}
+
+		insertQuery := `
+			INSERT INTO user_permissions (user_id, permission_id)
+			SELECT $1, p.id FROM UNNEST($2::int[]) AS p(id)
+		`
+		_, err = tx.ExecContext(ctx, insertQuery, user.ID, permissionIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// countDistinct returns the number of distinct names
+func countDistinct(names []string) int {
+	distinct := make(map[string]bool, len(names))
+	for _, name := range names {
+		distinct[name] = true
+	}
+	return len(distinct)
+}
+
 // GetUserPermissions retrieves all permission names for a user
 func (r *repository) GetUserPermissions(ctx context.Context, userID int64) ([]string, error) {
 	var permissions []string
@@ -186,6 +271,66 @@ func (r *repository) AssignPermissions(ctx context.Context, userID int64, permis
 	return tx.Commit()
 }
 
+// AssignPermissionsBulk assigns the same permissions to several users in one transaction
+// and ends their sessions, as DeleteUserSessions does, so the new permissions apply on
+// next login.
+// Nothing is written if any user or permission does not exist.
+func (r *repository) AssignPermissionsBulk(ctx context.Context, userIDs []int64, permissionNames []string) error {
+	if len(userIDs) == 0 || len(permissionNames) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userCount int
+	err = tx.GetContext(ctx, &userCount, `SELECT COUNT(*) FROM users WHERE id = ANY($1)`, userIDs)
+	if err != nil {
+		return err
+	}
+	if userCount != len(userIDs) {
+		return ErrUserNotFound
+	}
+
+	var permissionIDs []int
+	err = tx.SelectContext(ctx, &permissionIDs, `SELECT id FROM permissions WHERE name = ANY($1)`, permissionNames)
+	if err != nil {
+		return err
+	}
+	if len(permissionIDs) != len(permissionNames) {
+		return ErrPermissionNotFound
+	}
+
+	insertQuery := `
+		INSERT INTO user_permissions (user_id, permission_id)
+		SELECT u.id, p.id
+		FROM UNNEST($1::bigint[]) AS u(id)
+		CROSS JOIN UNNEST($2::int[]) AS p(id)
+		ON CONFLICT (user_id, permission_id) DO NOTHING
+	`
+	_, err = tx.ExecContext(ctx, insertQuery, userIDs, permissionIDs)
+	if err != nil {
+		return err
+	}
+
+	// Invalidate sessions so permissions are re-read on next login, as DeleteUserSessions
+	// does: revoked rows are the JWT revocation list and stay, and the JWTs already issued
+	// are cut off
+	_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ANY($1) AND NOT revoked`, userIDs)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE users SET tokens_valid_after = NOW() WHERE id = ANY($1)`, userIDs)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // CreateSession creates a new session with company roles
 func (r *repository) CreateSession(ctx context.Context, session *auth.Session) error {
 	// Marshal company roles to JSON
@@ -218,7 +363,7 @@ func (r *repository) GetSessionByToken(ctx context.Context, token string) (*auth
 	query := `
 		SELECT token, user_id, company_roles, expires_at, created_at
 		FROM sessions
-		WHERE token = $1 AND expires_at > NOW()
+		WHERE token = $1 AND expires_at > NOW() AND NOT revoked
 	`
 
 	err := r.db.GetContext(ctx, &row, query, token)
@@ -274,13 +419,104 @@ func (r *repository) DeleteSession(ctx context.Context, token string) error {
 	return nil
 }
 
-// DeleteUserSessions deletes all sessions for a user
+// DeleteUserSessions ends all sessions of a user: it deletes the opaque sessions and
+// invalidates the JWTs issued so far, which carry the user's company roles
 func (r *repository) DeleteUserSessions(ctx context.Context, userID int64) error {
-	query := `DELETE FROM sessions WHERE user_id = $1`
+	query := `DELETE FROM sessions WHERE user_id = $1 AND NOT revoked`
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return err
+	}
+
+	query = `UPDATE users SET tokens_valid_after = NOW() WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, userID)
 	return err
 }
 
+// TouchLastLogin sets the user's last login to now
+func (r *repository) TouchLastLogin(ctx context.Context, userID int64) error {
+	query := `UPDATE users SET last_login_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// ListSessions returns a user's active sessions, newest first, identified by token prefix
+func (r *repository) ListSessions(ctx context.Context, userID int64) ([]auth.SessionInfo, error) {
+	sessions := make([]auth.SessionInfo, 0)
+	query := `
+		SELECT LEFT(token, $2) AS token_prefix, created_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND expires_at > NOW() AND NOT revoked
+		ORDER BY created_at DESC
+	`
+
+	if err := r.db.SelectContext(ctx, &sessions, query, userID, auth.SessionTokenPrefixLength); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// DeleteSessionByPrefix deletes the user's session whose token starts with the prefix
+func (r *repository) DeleteSessionByPrefix(ctx context.Context, userID int64, tokenPrefix string) error {
+	query := `DELETE FROM sessions WHERE user_id = $1 AND LEFT(token, $2) = $3 AND NOT revoked`
+
+	result, err := r.db.ExecContext(ctx, query, userID, auth.SessionTokenPrefixLength, tokenPrefix)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeToken records a JWT ID as revoked until the token expires
+func (r *repository) RevokeToken(ctx context.Context, tokenID string, userID int64, expiresAt time.Time) error {
+	query := `
+		INSERT INTO sessions (token, user_id, expires_at, revoked)
+		VALUES ($1, $2, $3, true)
+		ON CONFLICT (token) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, tokenID, userID, expiresAt)
+	return err
+}
+
+// GetRevokedTokenIDs returns the revoked JWT IDs of tokens not yet expired
+func (r *repository) GetRevokedTokenIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	query := `SELECT token FROM sessions WHERE revoked AND expires_at > NOW()`
+
+	if err := r.db.SelectContext(ctx, &ids, query); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetTokenCutoffs returns the tokens_valid_after of the users whose cutoff is after since
+func (r *repository) GetTokenCutoffs(ctx context.Context, since time.Time) (map[int64]time.Time, error) {
+	var rows []struct {
+		UserID           int64     `db:"id"`
+		TokensValidAfter time.Time `db:"tokens_valid_after"`
+	}
+	query := `SELECT id, tokens_valid_after FROM users WHERE tokens_valid_after > $1`
+
+	if err := r.db.SelectContext(ctx, &rows, query, since); err != nil {
+		return nil, err
+	}
+	cutoffs := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		cutoffs[row.UserID] = row.TokensValidAfter
+	}
+	return cutoffs, nil
+}
+
 // ListUsers retrieves paginated list of users
 func (r *repository) ListUsers(ctx context.Context, page, size int) ([]*auth.User, int, error) {
 	offset := (page - 1) * size
@@ -288,7 +524,7 @@ func (r *repository) ListUsers(ctx context.Context, page, size int) ([]*auth.Use
 	var users []*auth.User
 	query := `
 		SELECT id, first_name, last_name, dni, birth_date, work_area, 
-		       password_hash, active, created_at, updated_at
+		       password_hash, active, last_login_at, created_at, updated_at
 		FROM users
 		WHERE active = true
 		ORDER BY id
@@ -448,7 +684,7 @@ func (r *repository) ListUsersByCompany(ctx context.Context, companyID int64, pa
 	var users []*auth.User
 	query := `
 		SELECT u.id, u.first_name, u.last_name, u.dni, u.birth_date, u.work_area, 
-		       u.password_hash, u.active, u.created_at, u.updated_at
+		       u.password_hash, u.active, u.last_login_at, u.created_at, u.updated_at
 		FROM users u
 		INNER JOIN user_companies uc ON u.id = uc.user_id
 		WHERE uc.company_id = $1 AND u.active = true
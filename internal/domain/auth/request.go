@@ -12,7 +12,7 @@ type LoginRequest struct {
 type CreateUserRequest struct {
 	FirstName   string    `json:"first_name" validate:"required"`
 	LastName    string    `json:"last_name" validate:"required"`
-	DNI         string    `json:"dni" validate:"required"`
+	DNI         string    `json:"dni" validate:"required,max=20"` // String, leading zeros are kept
 	BirthDate   time.Time `json:"birth_date" validate:"required"`
 	WorkArea    string    `json:"work_area" validate:"required"`
 	Password    string    `json:"password" validate:"required,min=6"`
@@ -50,6 +50,12 @@ type AssignPermissionsRequest struct {
 	Permissions []string `json:"permissions" validate:"required"`
 }
 
+// BulkAssignPermissionsRequest represents a request to assign the same permissions to several users
+type BulkAssignPermissionsRequest struct {
+	UserIDs     []int64  `json:"user_ids" validate:"required,min=1,dive,gt=0"`
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,required"`
+}
+
 // ListUsersRequest represents pagination parameters for listing users
 type ListUsersRequest struct {
 	Page      int   `json:"page"`
@@ -32,6 +32,7 @@ type UserDetailResponse struct {
 	Active      bool          `json:"active"`
 	Permissions []string      `json:"permissions"`
 	Companies   []UserCompany `json:"companies"`
+	LastLoginAt *time.Time    `json:"last_login_at"` // null for users who never logged in
 	CreatedAt   time.Time     `json:"created_at"`
 }
 
@@ -44,6 +45,43 @@ type UsersListResponse struct {
 	TotalPages int                  `json:"total_pages"`
 }
 
+// UserPermissionsResponse lists the permissions a user holds
+type UserPermissionsResponse struct {
+	UserID      int64    `json:"user_id"`
+	Permissions []string `json:"permissions"`
+}
+
+// BulkPermissionsResponse represents the permissions of each user after a bulk assignment
+type BulkPermissionsResponse struct {
+	Users []UserPermissionsResponse `json:"users"`
+}
+
+// User import row statuses
+const (
+	UserImportCreated = "created"
+	UserImportSkipped = "skipped" // DNI already exists
+	UserImportError   = "error"
+)
+
+// UserImportResult is the outcome of a row of a user CSV import
+type UserImportResult struct {
+	Row               int    `json:"row"` // Line in the file, the header is row 1
+	DNI               string `json:"dni"`
+	Status            string `json:"status" enums:"created,skipped,error"`
+	UserID            int64  `json:"user_id,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"` // To hand to the user, who should change it
+	Column            string `json:"column,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// UserImportResponse represents the result of each row of a user CSV import
+type UserImportResponse struct {
+	Created int                `json:"created"`
+	Skipped int                `json:"skipped"`
+	Errors  int                `json:"errors"`
+	Results []UserImportResult `json:"results"`
+}
+
 // MessageResponse represents a simple message response
 type MessageResponse struct {
 	Message string `json:"message"`
@@ -13,6 +13,7 @@ const (
 	TestDNI          = "99999999"
 	TestPassword     = "admin123"
 	TestPasswordHash = "$argon2id$v=19$m=65536,t=1,p=11$26wRAe/3D66n2EZzzR0QNw$FLiJupf5T0vQCFLryzB2gWdrR4jLMX8sFVAfq2UbnwE"
+	TestJWTSecret    = "test-secret-of-at-least-32-bytes!"
 )
 
 // newTestAdminUser creates a standard test admin user
@@ -50,6 +51,13 @@ func setupUseCase() (*MockRepository, UseCase) {
 	return mockRepo, uc
 }
 
+// setupJWTUseCase is setupUseCase issuing JWTs
+func setupJWTUseCase() (*MockRepository, UseCase) {
+	mockRepo := new(MockRepository)
+	uc := New(mockRepo, WithJWT([]byte(TestJWTSecret)))
+	return mockRepo, uc
+}
+
 // getTestContext returns a standard test context
 func getTestContext() context.Context {
 	return context.Background()
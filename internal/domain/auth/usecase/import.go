@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gmhafiz/go8/internal/domain/auth"
+	"github.com/gmhafiz/go8/internal/domain/auth/repository"
+)
+
+var ErrInvalidUserCSV = errors.New("invalid user CSV")
+
+// userImportColumns are the required columns of a user import, in any order.
+// An optional birth_date column (YYYY-MM-DD) may follow.
+var userImportColumns = []string{"dni", "first_name", "last_name", "work_area", "role", "company_id", "permissions"}
+
+// userImportRoles are the company roles an imported user may get
+var userImportRoles = map[string]bool{"viewer": true, "editor": true, "admin": true}
+
+// ImportUsers creates a user per CSV row with a generated temporary password, assigns
+// them to the row's company and grants the row's permissions (separated by ";"). Each
+// row is written in its own transaction: a row with an existing DNI is skipped and an
+// invalid row is reported, without aborting the rest of the file.
+func (uc *useCase) ImportUsers(ctx context.Context, file io.Reader) (*auth.UserImportResponse, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty file", ErrInvalidUserCSV)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUserCSV, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range userImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidUserCSV, name)
+		}
+	}
+
+	response := &auth.UserImportResponse{Results: []auth.UserImportResult{}}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidUserCSV, row, err)
+		}
+		value := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		result, err := uc.importUser(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		result.Row = row
+		switch result.Status {
+		case auth.UserImportCreated:
+			response.Created++
+		case auth.UserImportSkipped:
+			response.Skipped++
+		default:
+			response.Errors++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// importUser validates and imports a row. Row problems are reported in the result;
+// the error is only for failures that should stop the import, such as a cancelled request.
+func (uc *useCase) importUser(ctx context.Context, value func(name string) string) (auth.UserImportResult, error) {
+	result := auth.UserImportResult{DNI: value("dni"), Status: auth.UserImportError}
+	rowError := func(column, message string) (auth.UserImportResult, error) {
+		result.Column, result.Error = column, message
+		return result, nil
+	}
+
+	for _, name := range []string{"dni", "first_name", "last_name", "work_area", "role", "company_id"} {
+		if value(name) == "" {
+			return rowError(name, "is required")
+		}
+	}
+	if len(result.DNI) > 20 {
+		return rowError("dni", "must be at most 20 characters")
+	}
+	role := strings.ToLower(value("role"))
+	if !userImportRoles[role] {
+		return rowError("role", "must be viewer, editor or admin")
+	}
+	companyID, err := strconv.ParseInt(value("company_id"), 10, 64)
+	if err != nil || companyID <= 0 {
+		return rowError("company_id", "must be a positive integer")
+	}
+	var birthDate time.Time
+	if raw := value("birth_date"); raw != "" {
+		if birthDate, err = time.Parse("2006-01-02", raw); err != nil {
+			return rowError("birth_date", "must be a date as YYYY-MM-DD")
+		}
+	}
+	var permissions []string
+	for _, permission := range strings.Split(value("permissions"), ";") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			permissions = append(permissions, permission)
+		}
+	}
+	permissions = uniqueValues(permissions)
+
+	password, err := uc.passwordPolicy.Generate()
+	if err != nil {
+		return result, err
+	}
+	user, err := uc.newUser(ctx, &auth.CreateUserRequest{
+		FirstName:   value("first_name"),
+		LastName:    value("last_name"),
+		DNI:         result.DNI,
+		BirthDate:   birthDate,
+		WorkArea:    value("work_area"),
+		Password:    password,
+		Permissions: permissions,
+	})
+	if err == nil {
+		err = uc.repo.ImportUser(ctx, user, companyID, role, permissions)
+	}
+
+	switch {
+	case err == nil:
+		result.Status = auth.UserImportCreated
+		result.UserID = user.ID
+		result.TemporaryPassword = password
+		return result, nil
+	case errors.Is(err, repository.ErrDNIAlreadyExists):
+		result.Status = auth.UserImportSkipped
+		result.Column, result.Error = "dni", err.Error()
+		return result, nil
+	case errors.Is(err, repository.ErrPermissionNotFound):
+		return rowError("permissions", err.Error())
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	default:
+		// e.g. a company that does not exist
+		return rowError("", err.Error())
+	}
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gmhafiz/go8/internal/domain/auth"
+	"github.com/gmhafiz/go8/internal/domain/auth/repository"
+)
+
+// Token types issued at login
+const (
+	// TokenTypeOpaque is a random token with a sessions row per login, looked up on each request
+	TokenTypeOpaque = "opaque"
+
+	// TokenTypeJWT is a signed JWT verified without the database. Sessions only holds the
+	// token IDs revoked at logout; ending all of a user's sessions (password change,
+	// deactivation, company role change) rejects the tokens issued before it.
+	TokenTypeJWT = "jwt"
+)
+
+// Option configures the use case
+type Option func(*useCase)
+
+// WithJWT issues HS256-signed JWTs with the secret instead of opaque tokens
+func WithJWT(secret []byte) Option {
+	return func(uc *useCase) {
+		uc.jwtSecret = secret
+	}
+}
+
+// jwtHeader is the only header issued and accepted
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtClaims are the claims of an issued token
+type jwtClaims struct {
+	ID           string            `json:"jti"`
+	UserID       int64             `json:"user_id"`
+	CompanyRoles auth.CompanyRoles `json:"company_roles"`
+	IssuedAt     int64             `json:"iat"`
+	ExpiresAt    int64             `json:"exp"`
+}
+
+// signJWT returns the compact serialization of the claims signed with HMAC-SHA256
+func signJWT(claims *jwtClaims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + jwtSignature(signingInput, secret), nil
+}
+
+// parseJWT verifies the header, signature and expiry of a token and returns its claims
+func parseJWT(token string, secret []byte, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(jwtSignature(parts[0]+"."+parts[1], secret))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.ID == "" || claims.UserID <= 0 || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func jwtSignature(signingInput string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// revocationRefresh is how often the revoked token IDs and the users' token cutoffs are
+// reloaded from the database. A logout, or a user's sessions ended, takes up to this
+// long to be honoured.
+const revocationRefresh = 30 * time.Second
+
+// revocationList caches the revoked token IDs and the users' token cutoffs so verifying
+// a JWT does not hit the database
+type revocationList struct {
+	mu       sync.Mutex
+	ids      map[string]bool
+	cutoffs  map[int64]time.Time
+	loadedAt time.Time
+}
+
+// isRevoked reports whether a token is revoked, by its ID or because it was issued
+// before its user's sessions were ended, reloading the list when it is stale
+func (l *revocationList) isRevoked(ctx context.Context, repo repository.Repository, claims *jwtClaims) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.ids == nil || time.Since(l.loadedAt) > revocationRefresh {
+		revoked, err := repo.GetRevokedTokenIDs(ctx)
+		if err != nil {
+			return false, err
+		}
+		// Cutoffs older than a token's lifetime cannot reject any valid token
+		cutoffs, err := repo.GetTokenCutoffs(ctx, time.Now().Add(-SessionDuration))
+		if err != nil {
+			return false, err
+		}
+		l.ids = make(map[string]bool, len(revoked))
+		for _, revokedID := range revoked {
+			l.ids[revokedID] = true
+		}
+		l.cutoffs = cutoffs
+		l.loadedAt = time.Now()
+	}
+
+	// iat has a resolution of seconds: a token issued in the second of the cutoff is
+	// kept, so that logging in right after a password change works
+	if cutoff, ok := l.cutoffs[claims.UserID]; ok && claims.IssuedAt < cutoff.Unix() {
+		return true, nil
+	}
+	return l.ids[claims.ID], nil
+}
+
+// add records a revocation made by this instance without waiting for a reload
+func (l *revocationList) add(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ids != nil {
+		l.ids[id] = true
+	}
+}
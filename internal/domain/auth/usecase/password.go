@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+var ErrWeakPassword = errors.New("password is too weak")
+
+// PasswordPolicy is the strength required of new passwords
+type PasswordPolicy struct {
+	MinLength    int
+	RequireUpper bool
+	RequireLower bool
+	RequireDigit bool
+}
+
+// DefaultPasswordPolicy is enforced unless the use case is configured WithPasswordPolicy
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    10,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+}
+
+// WithPasswordPolicy replaces DefaultPasswordPolicy, e.g. to relax it for local development
+func WithPasswordPolicy(policy PasswordPolicy) Option {
+	return func(uc *useCase) {
+		uc.passwordPolicy = policy
+	}
+}
+
+// Check returns ErrWeakPassword listing every rule the password does not meet, or nil
+func (p PasswordPolicy) Check(password string) error {
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+
+	var unmet []string
+	if length := len([]rune(password)); length < p.MinLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.RequireUpper && !hasUpper {
+		unmet = append(unmet, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		unmet = append(unmet, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		unmet = append(unmet, "a digit")
+	}
+
+	if len(unmet) > 0 {
+		return fmt.Errorf("%w: it needs %s", ErrWeakPassword, strings.Join(unmet, ", "))
+	}
+	return nil
+}
+
+const (
+	passwordUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordLower  = "abcdefghijkmnopqrstuvwxyz"
+	passwordDigits = "23456789"
+
+	// generatedPasswordLength is the least length of generated passwords
+	generatedPasswordLength = 16
+)
+
+// Generate returns a random password that meets the policy, for users created without
+// one (see ImportUsers). Look-alike characters (I, l, O, 0, 1) are left out.
+func (p PasswordPolicy) Generate() (string, error) {
+	length := max(p.MinLength, generatedPasswordLength)
+	// One character of each class, so every rule is met, then any class
+	classes := []string{passwordUpper, passwordLower, passwordDigits}
+	for len(classes) < length {
+		classes = append(classes, passwordUpper+passwordLower+passwordDigits)
+	}
+
+	password := make([]byte, length)
+	for i, class := range classes {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(class))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = class[n.Int64()]
+	}
+
+	// Shuffle so the first characters are not always upper, lower and digit
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/argon2id"
@@ -32,20 +34,35 @@ const (
 type UseCase interface {
 	Login(ctx context.Context, req *auth.LoginRequest) (*auth.LoginResponse, error)
 	Logout(ctx context.Context, token string) error
+	Refresh(ctx context.Context, token string) (*auth.LoginResponse, error)
+	ListSessions(ctx context.Context, userID int64, currentToken string) ([]auth.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID int64, tokenPrefix string) error
 	GetCurrentUser(ctx context.Context, token string) (*auth.UserWithPermissions, error)
+	GetCurrentUserRoles(ctx context.Context, token string) ([]auth.UserCompany, error)
 	ValidateToken(ctx context.Context, token string) (*auth.Session, error)
 	CreateUser(ctx context.Context, req *auth.CreateUserRequest) (*auth.User, error)
 	SetPassword(ctx context.Context, userID int64, newPassword string) error
 	ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string) error
+	BulkAssignPermissions(ctx context.Context, req *auth.BulkAssignPermissionsRequest) (*auth.BulkPermissionsResponse, error)
+	ImportUsers(ctx context.Context, file io.Reader) (*auth.UserImportResponse, error)
 }
 
 type useCase struct {
 	repo repository.Repository
+
+	jwtSecret []byte // Set by WithJWT: issue JWTs instead of opaque tokens
+	revoked   revocationList
+
+	passwordPolicy PasswordPolicy
 }
 
-// New creates a new auth use case
-func New(repo repository.Repository) UseCase {
-	return &useCase{repo: repo}
+// New creates a new auth use case; it issues opaque tokens unless configured WithJWT
+func New(repo repository.Repository, opts ...Option) UseCase {
+	uc := &useCase{repo: repo, passwordPolicy: DefaultPasswordPolicy}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 // Login authenticates a user and creates a session
@@ -92,22 +109,13 @@ func (uc *useCase) Login(ctx context.Context, req *auth.LoginRequest) (*auth.Log
 		companyRoles[fmt.Sprintf("%d", c.CompanyID)] = c.Role
 	}
 
-	// Generate session token
-	token, err := generateToken()
+	// Create session with cached company roles
+	token, err := uc.issueToken(ctx, user.ID, companyRoles)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create session with cached company roles
-	session := &auth.Session{
-		Token:        token,
-		UserID:       user.ID,
-		CompanyRoles: companyRoles,
-		ExpiresAt:    time.Now().Add(SessionDuration),
-	}
-
-	err = uc.repo.CreateSession(ctx, session)
-	if err != nil {
+	if err := uc.repo.TouchLastLogin(ctx, user.ID); err != nil {
 		return nil, err
 	}
 
@@ -126,8 +134,117 @@ func (uc *useCase) Login(ctx context.Context, req *auth.LoginRequest) (*auth.Log
 	return response, nil
 }
 
-// Logout invalidates a session
+// Refresh replaces a valid token with a new one that expires SessionDuration from now.
+// The company roles cached in the old session carry over unchanged, and the old token
+// is invalidated.
+func (uc *useCase) Refresh(ctx context.Context, token string) (*auth.LoginResponse, error) {
+	session, err := uc.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrUserInactive
+	}
+
+	permissions, err := uc.repo.GetUserPermissions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	companies, err := uc.repo.GetUserCompanies(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	newToken, err := uc.issueToken(ctx, user.ID, session.CompanyRoles)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.Logout(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return &auth.LoginResponse{
+		Token: newToken,
+		User: auth.UserWithPermissions{
+			User:        *user,
+			Permissions: permissions,
+			Companies:   companies,
+		},
+	}, nil
+}
+
+// ListSessions returns the user's active sessions, marking the one of currentToken.
+// JWTs have no session rows, so with TokenTypeJWT the list is empty.
+func (uc *useCase) ListSessions(ctx context.Context, userID int64, currentToken string) ([]auth.SessionInfo, error) {
+	sessions, err := uc.repo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = strings.HasPrefix(currentToken, sessions[i].TokenPrefix)
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions, identified by its token prefix
+func (uc *useCase) RevokeSession(ctx context.Context, userID int64, tokenPrefix string) error {
+	if len(tokenPrefix) != auth.SessionTokenPrefixLength {
+		return repository.ErrSessionNotFound
+	}
+	return uc.repo.DeleteSessionByPrefix(ctx, userID, tokenPrefix)
+}
+
+// issueToken creates a session for the user: a signed JWT, or an opaque token stored in sessions
+func (uc *useCase) issueToken(ctx context.Context, userID int64, companyRoles auth.CompanyRoles) (string, error) {
+	tokenID, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	if uc.jwtSecret != nil {
+		return signJWT(&jwtClaims{
+			ID:           tokenID,
+			UserID:       userID,
+			CompanyRoles: companyRoles,
+			IssuedAt:     now.Unix(),
+			ExpiresAt:    now.Add(SessionDuration).Unix(),
+		}, uc.jwtSecret)
+	}
+
+	session := &auth.Session{
+		Token:        tokenID,
+		UserID:       userID,
+		CompanyRoles: companyRoles,
+		ExpiresAt:    now.Add(SessionDuration),
+	}
+	if err := uc.repo.CreateSession(ctx, session); err != nil {
+		return "", err
+	}
+	return tokenID, nil
+}
+
+// Logout invalidates a session; a JWT is added to the revoked token IDs
 func (uc *useCase) Logout(ctx context.Context, token string) error {
+	if uc.jwtSecret != nil {
+		claims, err := parseJWT(token, uc.jwtSecret, time.Now())
+		if err != nil {
+			// Invalid or expired, so already unusable: not an error
+			return nil
+		}
+		if err := uc.repo.RevokeToken(ctx, claims.ID, claims.UserID, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			return err
+		}
+		uc.revoked.add(claims.ID)
+		return nil
+	}
+
 	err := uc.repo.DeleteSession(ctx, token)
 	if err != nil {
 		if errors.Is(err, repository.ErrSessionNotFound) {
@@ -143,11 +260,8 @@ func (uc *useCase) Logout(ctx context.Context, token string) error {
 // GetCurrentUser retrieves the current user from a session token
 func (uc *useCase) GetCurrentUser(ctx context.Context, token string) (*auth.UserWithPermissions, error) {
 	// Validate session
-	session, err := uc.repo.GetSessionByToken(ctx, token)
+	session, err := uc.ValidateToken(ctx, token)
 	if err != nil {
-		if errors.Is(err, repository.ErrSessionNotFound) {
-			return nil, ErrInvalidToken
-		}
 		return nil, err
 	}
 
@@ -178,8 +292,49 @@ func (uc *useCase) GetCurrentUser(ctx context.Context, token string) (*auth.User
 	return userWithPerms, nil
 }
 
-// ValidateToken validates a session token and returns the session with company roles
+// GetCurrentUserRoles returns the session user's role in each accessible company, by company name
+func (uc *useCase) GetCurrentUserRoles(ctx context.Context, token string) ([]auth.UserCompany, error) {
+	session, err := uc.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Roles from the database rather than the session cache, which is only refreshed on login
+	companies, err := uc.repo.GetUserCompanies(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if companies == nil {
+		companies = []auth.UserCompany{}
+	}
+
+	return companies, nil
+}
+
+// ValidateToken validates a session token and returns the session with company roles.
+// A JWT is verified from its claims and the cached revocation list, without a session lookup.
 func (uc *useCase) ValidateToken(ctx context.Context, token string) (*auth.Session, error) {
+	if uc.jwtSecret != nil {
+		claims, err := parseJWT(token, uc.jwtSecret, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		revoked, err := uc.revoked.isRevoked(ctx, uc.repo, claims)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrInvalidToken
+		}
+		return &auth.Session{
+			Token:        token,
+			UserID:       claims.UserID,
+			CompanyRoles: claims.CompanyRoles,
+			ExpiresAt:    time.Unix(claims.ExpiresAt, 0),
+			CreatedAt:    time.Unix(claims.IssuedAt, 0),
+		}, nil
+	}
+
 	session, err := uc.repo.GetSessionByToken(ctx, token)
 	if err != nil {
 		if errors.Is(err, repository.ErrSessionNotFound) {
@@ -204,6 +359,29 @@ func generateToken() (string, error) {
 
 // CreateUser creates a new user with the given request data
 func (uc *useCase) CreateUser(ctx context.Context, req *auth.CreateUserRequest) (*auth.User, error) {
+	user, err := uc.newUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.repo.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assign permissions if provided
+	if len(req.Permissions) > 0 {
+		err = uc.repo.AssignPermissions(ctx, user.ID, req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// newUser checks the DNI is free and returns the active user to insert, with the password hashed
+func (uc *useCase) newUser(ctx context.Context, req *auth.CreateUserRequest) (*auth.User, error) {
 	// Check if DNI already exists
 	existing, err := uc.repo.GetUserByDNI(ctx, req.DNI)
 	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
@@ -230,24 +408,15 @@ func (uc *useCase) CreateUser(ctx context.Context, req *auth.CreateUserRequest)
 		Active:       true,
 	}
 
-	err = uc.repo.CreateUser(ctx, user)
-	if err != nil {
-		return nil, err
-	}
-
-	// Assign permissions if provided
-	if len(req.Permissions) > 0 {
-		err = uc.repo.AssignPermissions(ctx, user.ID, req.Permissions)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return user, nil
 }
 
 // SetPassword sets a new password for a user (admin action, no current password required)
 func (uc *useCase) SetPassword(ctx context.Context, userID int64, newPassword string) error {
+	if err := uc.passwordPolicy.Check(newPassword); err != nil {
+		return err
+	}
+
 	// Verify user exists
 	_, err := uc.repo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -274,6 +443,10 @@ func (uc *useCase) SetPassword(ctx context.Context, userID int64, newPassword st
 
 // ChangePassword allows a user to change their own password (requires current password)
 func (uc *useCase) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string) error {
+	if err := uc.passwordPolicy.Check(newPassword); err != nil {
+		return err
+	}
+
 	// Get user to verify current password
 	user, err := uc.repo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -309,6 +482,47 @@ func (uc *useCase) ChangePassword(ctx context.Context, userID int64, currentPass
 	return nil
 }
 
+// BulkAssignPermissions assigns permissions to several users at once and returns
+// the resulting permissions of each user. Affected sessions are invalidated.
+func (uc *useCase) BulkAssignPermissions(ctx context.Context, req *auth.BulkAssignPermissionsRequest) (*auth.BulkPermissionsResponse, error) {
+	userIDs := uniqueValues(req.UserIDs)
+	permissions := uniqueValues(req.Permissions)
+
+	if err := uc.repo.AssignPermissionsBulk(ctx, userIDs, permissions); err != nil {
+		return nil, err
+	}
+
+	response := &auth.BulkPermissionsResponse{
+		Users: make([]auth.UserPermissionsResponse, 0, len(userIDs)),
+	}
+	for _, userID := range userIDs {
+		userPermissions, err := uc.repo.GetUserPermissions(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		response.Users = append(response.Users, auth.UserPermissionsResponse{
+			UserID:      userID,
+			Permissions: userPermissions,
+		})
+	}
+
+	return response, nil
+}
+
+// uniqueValues removes duplicates keeping the first occurrence order
+func uniqueValues[T comparable](values []T) []T {
+	seen := make(map[T]bool, len(values))
+	result := make([]T, 0, len(values))
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
+
 // HashPassword hashes a password using argon2id
 func HashPassword(password string) (string, error) {
 	return argon2id.CreateHash(password, argon2id.DefaultParams)
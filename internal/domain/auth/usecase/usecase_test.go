@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -134,6 +136,55 @@ func (m *MockRepository) RemoveUserFromCompany(ctx context.Context, userID int64
 	return args.Error(0)
 }
 
+func (m *MockRepository) AssignPermissionsBulk(ctx context.Context, userIDs []int64, permissionNames []string) error {
+	args := m.Called(ctx, userIDs, permissionNames)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ImportUser(ctx context.Context, user *auth.User, companyID int64, role string, permissionNames []string) error {
+	args := m.Called(ctx, user, companyID, role, permissionNames)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchLastLogin(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListSessions(ctx context.Context, userID int64) ([]auth.SessionInfo, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth.SessionInfo), args.Error(1)
+}
+
+func (m *MockRepository) DeleteSessionByPrefix(ctx context.Context, userID int64, tokenPrefix string) error {
+	args := m.Called(ctx, userID, tokenPrefix)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RevokeToken(ctx context.Context, tokenID string, userID int64, expiresAt time.Time) error {
+	args := m.Called(ctx, tokenID, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRevokedTokenIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) GetTokenCutoffs(ctx context.Context, since time.Time) (map[int64]time.Time, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]time.Time), args.Error(1)
+}
+
 // TestLogin tests the login flow
 func TestLogin_Success(t *testing.T) {
 	mockRepo, uc := setupUseCase()
@@ -148,6 +199,7 @@ func TestLogin_Success(t *testing.T) {
 	mockRepo.On("GetUserPermissions", ctx, testUser.ID).Return([]string{"admin"}, nil)
 	mockRepo.On("GetUserCompanies", ctx, testUser.ID).Return(testCompanies, nil)
 	mockRepo.On("CreateSession", ctx, mock.AnythingOfType("*auth.Session")).Return(nil)
+	mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(nil)
 
 	req := &auth.LoginRequest{
 		DNI:      testUser.DNI,
@@ -220,3 +272,313 @@ func TestValidateToken_Invalid(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetCurrentUserRoles_RolePerCompany(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+	token := "valid_token_123"
+
+	mockRepo.On("GetSessionByToken", ctx, token).Return(newTestSession(token, TestUserID), nil)
+	mockRepo.On("GetUserCompanies", ctx, TestUserID).Return([]auth.UserCompany{
+		{CompanyID: 1, CompanyName: "Cerro Moro", Role: "admin"},
+		{CompanyID: 2, CompanyName: "San José", Role: "viewer"},
+	}, nil)
+
+	roles, err := uc.GetCurrentUserRoles(ctx, token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []auth.UserCompany{
+		{CompanyID: 1, CompanyName: "Cerro Moro", Role: "admin"},
+		{CompanyID: 2, CompanyName: "San José", Role: "viewer"},
+	}, roles)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetCurrentUserRoles_InvalidToken(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+
+	mockRepo.On("GetSessionByToken", ctx, "expired").Return(nil, repository.ErrSessionNotFound)
+
+	roles, err := uc.GetCurrentUserRoles(ctx, "expired")
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, roles)
+}
+
+func TestBulkAssignPermissions_GrantsEachUser(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+
+	userIDs := []int64{2, 3, 4}
+
+	// Permissions become visible to GetUserPermissions only once the bulk assignment ran
+	mockRepo.On("AssignPermissionsBulk", ctx, userIDs, []string{"admin"}).
+		Run(func(args mock.Arguments) {
+			for _, id := range args.Get(1).([]int64) {
+				mockRepo.On("GetUserPermissions", ctx, id).Return(args.Get(2).([]string), nil)
+			}
+		}).
+		Return(nil)
+
+	req := &auth.BulkAssignPermissionsRequest{
+		UserIDs:     []int64{2, 3, 4, 3},
+		Permissions: []string{"admin"},
+	}
+
+	response, err := uc.BulkAssignPermissions(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Len(t, response.Users, 3)
+	for i, user := range response.Users {
+		assert.Equal(t, userIDs[i], user.UserID)
+		assert.Contains(t, user.Permissions, "admin")
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestJWT_LoginValidateLogout(t *testing.T) {
+	mockRepo, uc := setupJWTUseCase()
+	ctx := getTestContext()
+
+	testUser := newTestAdminUser()
+	mockRepo.On("GetUserByDNI", ctx, testUser.DNI).Return(testUser, nil)
+	mockRepo.On("GetUserPermissions", ctx, testUser.ID).Return([]string{"admin"}, nil)
+	mockRepo.On("GetUserCompanies", ctx, testUser.ID).Return([]auth.UserCompany{{CompanyID: 1, Role: "editor"}}, nil)
+	mockRepo.On("GetRevokedTokenIDs", ctx).Return([]string{}, nil).Once()
+	mockRepo.On("GetTokenCutoffs", ctx, mock.Anything).Return(map[int64]time.Time{}, nil).Once()
+	mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(nil)
+
+	response, err := uc.Login(ctx, &auth.LoginRequest{DNI: testUser.DNI, Password: TestPassword})
+	assert.NoError(t, err)
+
+	// Verified from the claims: no session lookup
+	session, err := uc.ValidateToken(ctx, response.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, testUser.ID, session.UserID)
+	assert.Equal(t, "editor", session.CompanyRoles.GetRole(1))
+	assert.WithinDuration(t, time.Now().Add(SessionDuration), session.ExpiresAt, time.Minute)
+
+	claims, err := parseJWT(response.Token, []byte(TestJWTSecret), time.Now())
+	assert.NoError(t, err)
+	mockRepo.On("RevokeToken", ctx, claims.ID, testUser.ID, time.Unix(claims.ExpiresAt, 0)).Return(nil)
+	assert.NoError(t, uc.Logout(ctx, response.Token))
+
+	_, err = uc.ValidateToken(ctx, response.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	mockRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "GetSessionByToken", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestJWT_RejectsTokensIssuedBeforeUserCutoff(t *testing.T) {
+	mockRepo, uc := setupJWTUseCase()
+	ctx := getTestContext()
+	secret := []byte(TestJWTSecret)
+	now := time.Now()
+
+	issue := func(id string, userID int64, issuedAt time.Time) string {
+		token, err := signJWT(&jwtClaims{ID: id, UserID: userID, IssuedAt: issuedAt.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}, secret)
+		assert.NoError(t, err)
+		return token
+	}
+
+	// The user was demoted a minute ago: their sessions were ended
+	mockRepo.On("GetRevokedTokenIDs", ctx).Return([]string{}, nil).Once()
+	mockRepo.On("GetTokenCutoffs", ctx, mock.Anything).Return(map[int64]time.Time{TestUserID: now.Add(-time.Minute)}, nil).Once()
+
+	_, err := uc.ValidateToken(ctx, issue("before", TestUserID, now.Add(-time.Hour)))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = uc.ValidateToken(ctx, issue("after", TestUserID, now))
+	assert.NoError(t, err)
+
+	_, err = uc.ValidateToken(ctx, issue("other-user", TestUserID+1, now.Add(-time.Hour)))
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestParseJWT_Rejects(t *testing.T) {
+	secret := []byte(TestJWTSecret)
+	now := time.Now()
+	token, err := signJWT(&jwtClaims{ID: "abc", UserID: TestUserID, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}, secret)
+	assert.NoError(t, err)
+
+	_, err = parseJWT(token, secret, now)
+	assert.NoError(t, err)
+
+	_, err = parseJWT(token, []byte("another-secret-of-at-least-32-bytes"), now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "wrong secret")
+
+	_, err = parseJWT(token, secret, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidToken, "expired")
+
+	_, err = parseJWT(token[:len(token)-2]+"xx", secret, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "tampered signature")
+
+	_, err = parseJWT("0123456789abcdef", secret, now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "opaque token")
+}
+
+func TestRefresh_KeepsCompanyRoles(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+	oldToken := "old_token_123"
+
+	session := newTestSession(oldToken, TestUserID)
+	session.CompanyRoles = auth.CompanyRoles{"1": "viewer"}
+	testUser := newTestAdminUser()
+
+	mockRepo.On("GetSessionByToken", ctx, oldToken).Return(session, nil)
+	mockRepo.On("GetUserByID", ctx, TestUserID).Return(testUser, nil)
+	mockRepo.On("GetUserPermissions", ctx, TestUserID).Return([]string{}, nil)
+	// The database now says admin: the refreshed session still carries the cached viewer role
+	mockRepo.On("GetUserCompanies", ctx, TestUserID).Return([]auth.UserCompany{{CompanyID: 1, Role: "admin"}}, nil)
+	mockRepo.On("CreateSession", ctx, mock.MatchedBy(func(s *auth.Session) bool {
+		return s.Token != oldToken && s.CompanyRoles.GetRole(1) == "viewer" && s.ExpiresAt.After(session.ExpiresAt.Add(-time.Minute))
+	})).Return(nil)
+	mockRepo.On("DeleteSession", ctx, oldToken).Return(nil)
+
+	response, err := uc.Refresh(ctx, oldToken)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEqual(t, oldToken, response.Token)
+	assert.Equal(t, testUser.ID, response.User.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefresh_InvalidToken(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+
+	mockRepo.On("GetSessionByToken", ctx, "expired").Return(nil, repository.ErrSessionNotFound)
+
+	response, err := uc.Refresh(ctx, "expired")
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, response)
+	mockRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything)
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	tests := []struct {
+		password string
+		unmet    []string
+	}{
+		{"Str0ngPassword", nil},
+		{"admin123", []string{"at least 10 characters", "an uppercase letter"}},
+		{"ALLUPPERCASE1", []string{"a lowercase letter"}},
+		{"NoDigitsAtAll", []string{"a digit"}},
+		{"", []string{"at least 10 characters", "an uppercase letter", "a lowercase letter", "a digit"}},
+	}
+
+	for _, tt := range tests {
+		err := DefaultPasswordPolicy.Check(tt.password)
+		if tt.unmet == nil {
+			assert.NoError(t, err, tt.password)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrWeakPassword, tt.password)
+		for _, rule := range tt.unmet {
+			assert.Contains(t, err.Error(), rule, tt.password)
+		}
+	}
+
+	relaxed := PasswordPolicy{MinLength: 6}
+	assert.NoError(t, relaxed.Check("admin123"))
+}
+
+func TestSetPassword_WeakPassword(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+
+	err := uc.SetPassword(getTestContext(), TestUserID, TestPassword)
+
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	mockRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListSessions_MarksCurrent(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+
+	mockRepo.On("ListSessions", ctx, TestUserID).Return([]auth.SessionInfo{
+		{TokenPrefix: "0a1b2c3d"},
+		{TokenPrefix: "ffeeddcc"},
+	}, nil)
+
+	sessions, err := uc.ListSessions(ctx, TestUserID, "ffeeddcc"+"0123456789")
+
+	assert.NoError(t, err)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestRevokeSession_ByPrefix(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+
+	mockRepo.On("DeleteSessionByPrefix", ctx, TestUserID, "0a1b2c3d").Return(nil)
+
+	assert.NoError(t, uc.RevokeSession(ctx, TestUserID, "0a1b2c3d"))
+	// A shorter prefix could match several sessions
+	assert.ErrorIs(t, uc.RevokeSession(ctx, TestUserID, "0a1b"), repository.ErrSessionNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPasswordPolicy_Generate(t *testing.T) {
+	for _, policy := range []PasswordPolicy{DefaultPasswordPolicy, {MinLength: 24, RequireDigit: true}} {
+		password, err := policy.Generate()
+		assert.NoError(t, err)
+		assert.NoError(t, DefaultPasswordPolicy.Check(password), password)
+		assert.GreaterOrEqual(t, len(password), policy.MinLength)
+	}
+}
+
+func TestImportUsers_ReportsEachRow(t *testing.T) {
+	mockRepo, uc := setupUseCase()
+	ctx := getTestContext()
+
+	file := "dni,first_name,last_name,work_area,role,company_id,permissions\n" +
+		"00123456,Ana,Paz,Mine,editor,5,viewer;editor;viewer\n" +
+		TestDNI + ",Admin,User,IT,admin,5,\n" +
+		"22222222,Juan,Sosa,Plant,owner,5,\n" +
+		"33333333,Eva,Diaz,Plant,viewer,5,auditor\n"
+
+	mockRepo.On("GetUserByDNI", ctx, "00123456").Return(nil, repository.ErrUserNotFound)
+	mockRepo.On("GetUserByDNI", ctx, TestDNI).Return(newTestAdminUser(), nil)
+	mockRepo.On("GetUserByDNI", ctx, "33333333").Return(nil, repository.ErrUserNotFound)
+	// A permission listed twice is passed once
+	mockRepo.On("ImportUser", ctx, mock.MatchedBy(func(u *auth.User) bool { return u.DNI == "00123456" }), int64(5), "editor", []string{"viewer", "editor"}).
+		Run(func(args mock.Arguments) { args.Get(1).(*auth.User).ID = 42 }).Return(nil)
+	mockRepo.On("ImportUser", ctx, mock.MatchedBy(func(u *auth.User) bool { return u.DNI == "33333333" }), int64(5), "viewer", []string{"auditor"}).
+		Return(repository.ErrPermissionNotFound)
+
+	response, err := uc.ImportUsers(ctx, strings.NewReader(file))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Created)
+	assert.Equal(t, 1, response.Skipped)
+	assert.Equal(t, 2, response.Errors)
+	if assert.Len(t, response.Results, 4) {
+		created := response.Results[0]
+		assert.Equal(t, auth.UserImportResult{Row: 2, DNI: "00123456", Status: auth.UserImportCreated, UserID: 42, TemporaryPassword: created.TemporaryPassword}, created)
+		assert.NoError(t, DefaultPasswordPolicy.Check(created.TemporaryPassword))
+		assert.Equal(t, auth.UserImportSkipped, response.Results[1].Status)
+		assert.Equal(t, "role", response.Results[2].Column)
+		assert.Equal(t, "permissions", response.Results[3].Column)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportUsers_MissingColumn(t *testing.T) {
+	_, uc := setupUseCase()
+
+	_, err := uc.ImportUsers(getTestContext(), strings.NewReader("dni,first_name,last_name\n1,A,B\n"))
+
+	assert.ErrorIs(t, err, ErrInvalidUserCSV)
+}
@@ -196,7 +196,19 @@ func (r *repository) AssignMinerals(ctx context.Context, companyID int64, minera
 func (r *repository) GetSettings(ctx context.Context, companyID int64) (*config.CompanySettings, error) {
 	var settings config.CompanySettings
 	query := `
-		SELECT company_id, mining_type, country, royalty_percentage, notes, created_at, updated_at
+		SELECT company_id, mining_type, country, royalty_percentage,
+		       COALESCE(net_cash_flow_capex_types, 'sustaining') AS net_cash_flow_capex_types,
+		       COALESCE(excluded_expense_types, '') AS excluded_expense_types,
+		       COALESCE(dore_grade_basis, 'oz') AS dore_grade_basis,
+		       COALESCE(realized_price_fallback, false) AS realized_price_fallback,
+		       COALESCE(primary_metal, 'silver') AS primary_metal,
+		       COALESCE(data_retention_years, 0) AS data_retention_years,
+		       COALESCE(zero_row_checks, '') AS zero_row_checks,
+		       COALESCE(cost_centers, '') AS cost_centers,
+		       COALESCE(capex_categories, '') AS capex_categories,
+		       COALESCE(capex_projects, '') AS capex_projects,
+		       COALESCE(opex_subcategories, '') AS opex_subcategories,
+		       notes, created_at, updated_at
 		FROM company_settings
 		WHERE company_id = $1
 	`
@@ -214,10 +226,14 @@ func (r *repository) GetSettings(ctx context.Context, companyID int64) (*config.
 
 func (r *repository) UpsertSettings(ctx context.Context, settings *config.CompanySettings) error {
 	query := `
-		INSERT INTO company_settings (company_id, mining_type, country, royalty_percentage, notes)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO company_settings (company_id, mining_type, country, royalty_percentage, notes, net_cash_flow_capex_types, excluded_expense_types, dore_grade_basis, realized_price_fallback, data_retention_years, zero_row_checks, primary_metal, cost_centers, capex_categories, capex_projects, opex_subcategories)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		ON CONFLICT (company_id) DO UPDATE
-		SET mining_type = $2, country = $3, royalty_percentage = $4, notes = $5, updated_at = CURRENT_TIMESTAMP
+		SET mining_type = $2, country = $3, royalty_percentage = $4, notes = $5,
+		    net_cash_flow_capex_types = $6, excluded_expense_types = $7, dore_grade_basis = $8,
+		    realized_price_fallback = $9, data_retention_years = $10, zero_row_checks = $11, primary_metal = $12,
+		    cost_centers = $13, capex_categories = $14, capex_projects = $15, opex_subcategories = $16,
+		    updated_at = CURRENT_TIMESTAMP
 		RETURNING created_at, updated_at
 	`
 
@@ -229,6 +245,17 @@ func (r *repository) UpsertSettings(ctx context.Context, settings *config.Compan
 		settings.Country,
 		settings.RoyaltyPercentage,
 		settings.Notes,
+		settings.NetCashFlowCapexTypes,
+		settings.ExcludedExpenseTypes,
+		settings.DoreGradeBasis,
+		settings.RealizedPriceFallback,
+		settings.DataRetentionYears,
+		settings.ZeroRowChecks,
+		settings.PrimaryMetal,
+		settings.CostCenters,
+		settings.CAPEXCategories,
+		settings.CAPEXProjects,
+		settings.OPEXSubcategories,
 	).Scan(&settings.CreatedAt, &settings.UpdatedAt)
 
 	return err
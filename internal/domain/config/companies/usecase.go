@@ -2,6 +2,7 @@ package companies
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gmhafiz/go8/internal/domain/config"
 )
@@ -80,9 +81,12 @@ func (uc *useCase) Create(ctx context.Context, req *config.CreateCompanyRequest)
 	// Create settings if provided
 	if req.MiningType != "" || req.Country != "" || req.RoyaltyPercentage != nil {
 		settings := &config.CompanySettings{
-			CompanyID:  company.ID,
-			MiningType: req.MiningType,
-			Country:    req.Country,
+			CompanyID:             company.ID,
+			MiningType:            req.MiningType,
+			Country:               req.Country,
+			NetCashFlowCapexTypes: config.DefaultNetCashFlowCapexTypes,
+			DoreGradeBasis:        config.DefaultDoreGradeBasis,
+			PrimaryMetal:          config.DefaultPrimaryMetal,
 		}
 		if req.MiningType == "" {
 			settings.MiningType = "underground" // default
@@ -162,7 +166,12 @@ func (uc *useCase) UpdateSettings(ctx context.Context, companyID int64, req *con
 	}
 
 	if settings == nil {
-		settings = &config.CompanySettings{CompanyID: companyID}
+		settings = &config.CompanySettings{
+			CompanyID:             companyID,
+			NetCashFlowCapexTypes: config.DefaultNetCashFlowCapexTypes,
+			DoreGradeBasis:        config.DefaultDoreGradeBasis,
+			PrimaryMetal:          config.DefaultPrimaryMetal,
+		}
 	}
 
 	// Update fields
@@ -178,6 +187,39 @@ func (uc *useCase) UpdateSettings(ctx context.Context, companyID int64, req *con
 	if req.Notes != "" {
 		settings.Notes = req.Notes
 	}
+	if len(req.NetCashFlowCapexTypes) > 0 {
+		settings.NetCashFlowCapexTypes = strings.Join(req.NetCashFlowCapexTypes, ",")
+	}
+	if req.ExcludedExpenseTypes != nil {
+		settings.ExcludedExpenseTypes = strings.Join(*req.ExcludedExpenseTypes, ",")
+	}
+	if req.DoreGradeBasis != "" {
+		settings.DoreGradeBasis = req.DoreGradeBasis
+	}
+	if req.RealizedPriceFallback != nil {
+		settings.RealizedPriceFallback = *req.RealizedPriceFallback
+	}
+	if req.PrimaryMetal != "" {
+		settings.PrimaryMetal = req.PrimaryMetal
+	}
+	if req.DataRetentionYears != nil {
+		settings.DataRetentionYears = *req.DataRetentionYears
+	}
+	if req.ZeroRowChecks != nil {
+		settings.ZeroRowChecks = config.FormatZeroRowChecks(*req.ZeroRowChecks)
+	}
+	if req.CostCenters != nil {
+		settings.CostCenters = strings.Join(*req.CostCenters, ",")
+	}
+	if req.CAPEXCategories != nil {
+		settings.CAPEXCategories = strings.Join(*req.CAPEXCategories, ",")
+	}
+	if req.CAPEXProjects != nil {
+		settings.CAPEXProjects = strings.Join(*req.CAPEXProjects, ",")
+	}
+	if req.OPEXSubcategories != nil {
+		settings.OPEXSubcategories = config.FormatOPEXSubcategories(*req.OPEXSubcategories)
+	}
 
 	err = uc.repo.UpsertSettings(ctx, settings)
 	if err != nil {
@@ -29,10 +29,42 @@ type Mineral struct {
 
 // CompanySettings represents company-specific settings
 type CompanySettings struct {
-	CompanyID         int64     `db:"company_id" json:"company_id"`
-	MiningType        string    `db:"mining_type" json:"mining_type"` // "open_pit", "underground", "both"
-	Country           string    `db:"country" json:"country"`
-	RoyaltyPercentage float64   `db:"royalty_percentage" json:"royalty_percentage"`
+	CompanyID         int64   `db:"company_id" json:"company_id"`
+	MiningType        string  `db:"mining_type" json:"mining_type"` // "open_pit", "underground", "both"
+	Country           string  `db:"country" json:"country"`
+	RoyaltyPercentage float64 `db:"royalty_percentage" json:"royalty_percentage"`
+	// NetCashFlowCapexTypes is a comma-separated list of CAPEX types subtracted
+	// from Production Based Margin in PBR Net Cash Flow (default "sustaining")
+	NetCashFlowCapexTypes string `db:"net_cash_flow_capex_types" json:"net_cash_flow_capex_types"`
+	// ExcludedExpenseTypes is a comma-separated list of OPEX expense types left out
+	// of Production Based Costs and reported separately (default none)
+	ExcludedExpenseTypes string `db:"excluded_expense_types" json:"excluded_expense_types"`
+	// DoreGradeBasis is how Dore imports derive grades: "oz" (ounce share, default)
+	// or "atomic" (ounces weighted by atomic weight)
+	DoreGradeBasis string `db:"dore_grade_basis" json:"dore_grade_basis"`
+	// RealizedPriceFallback values Dore metal at the PBR price when the
+	// realized price is zero or absent (default false: such metal earns nothing)
+	RealizedPriceFallback bool `db:"realized_price_fallback" json:"realized_price_fallback"`
+	// PrimaryMetal is how cash costs are reported per ounce: "silver" (gold is a
+	// by-product credit, default) or "co_product" (costs split by revenue share)
+	PrimaryMetal string `db:"primary_metal" json:"primary_metal"`
+	// DataRetentionYears keeps data newer than this many years in the hot tables;
+	// older rows are moved to the archive tables (default 0: keep everything)
+	DataRetentionYears int `db:"data_retention_years" json:"data_retention_years"`
+	// ZeroRowChecks lists per import type what to do with rows whose required numeric
+	// fields are all zero, as "type:action" pairs with action warn or reject (default none)
+	ZeroRowChecks string `db:"zero_row_checks" json:"zero_row_checks"`
+	// CostCenters is a comma-separated list of the OPEX cost centers imports accept and
+	// Production Based Costs sums (default none: Mine, Processing, G&A, Transport & Shipping)
+	CostCenters string `db:"cost_centers" json:"cost_centers"`
+	// CAPEXCategories and CAPEXProjects are comma-separated lists of the CAPEX categories
+	// and projects ("CAR number - project name") the CAPEX detail always reports, zero when
+	// a month has no data for them (default none: the built-in lists)
+	CAPEXCategories string `db:"capex_categories" json:"capex_categories"`
+	CAPEXProjects   string `db:"capex_projects" json:"capex_projects"`
+	// OPEXSubcategories is a JSON object of the subcategories OPEX imports accept per cost
+	// center, e.g. {"Mine": ["Drilling"]} (default none: any subcategory)
+	OPEXSubcategories string    `db:"opex_subcategories" json:"opex_subcategories"`
 	Notes             string    `db:"notes" json:"notes"`
 	CreatedAt         time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
@@ -30,6 +30,27 @@ type UpdateCompanySettingsRequest struct {
 	Country           string   `json:"country"`
 	RoyaltyPercentage *float64 `json:"royalty_percentage" validate:"omitempty,gte=0,lte=100"`
 	Notes             string   `json:"notes"`
+	// CAPEX types subtracted in PBR Net Cash Flow, e.g. ["sustaining"] or ["sustaining", "project", "leasing"]
+	NetCashFlowCapexTypes []string `json:"net_cash_flow_capex_types" validate:"omitempty,min=1,dive,oneof=sustaining project leasing"`
+	// OPEX expense types excluded from Production Based Costs, e.g. ["Other"]; an empty list clears the exclusions
+	ExcludedExpenseTypes *[]string `json:"excluded_expense_types" validate:"omitempty,dive,oneof=Labour Materials 'Third Party' Other"`
+	// Basis for doré grades derived on Dore import: "oz" (ounce share) or "atomic" (atomic share)
+	DoreGradeBasis string `json:"dore_grade_basis" validate:"omitempty,oneof=oz atomic"`
+	// Value Dore metal at the PBR price when the realized price is zero or absent
+	RealizedPriceFallback *bool `json:"realized_price_fallback"`
+	// Cash cost basis: "silver" (gold as by-product credit) or "co_product" (costs split between silver and gold by revenue)
+	PrimaryMetal string `json:"primary_metal" validate:"omitempty,oneof=silver co_product"`
+	// Years of data kept out of the archive; 0 keeps everything
+	DataRetentionYears *int `json:"data_retention_years" validate:"omitempty,gte=0,lte=100"`
+	// Per import type handling of all-zero rows, e.g. {"pbr": "reject", "dore": "warn"}; an empty map clears the checks
+	ZeroRowChecks *map[string]string `json:"zero_row_checks" validate:"omitempty,dive,keys,oneof=production dore pbr opex capex revenue financial,endkeys,oneof=off warn reject"`
+	// OPEX cost centers, e.g. ["Mine", "Processing", "G&A", "Transport & Shipping", "Exploration"]; an empty list restores the default four
+	CostCenters *[]string `json:"cost_centers" validate:"omitempty,dive,required,max=50,excludesall=0x2C"`
+	// CAPEX categories and projects ("CAR number - project name") always listed in the CAPEX detail; an empty list restores the built-in one
+	CAPEXCategories *[]string `json:"capex_categories" validate:"omitempty,dive,required,max=100,excludesall=0x2C"`
+	CAPEXProjects   *[]string `json:"capex_projects" validate:"omitempty,dive,required,max=100,excludesall=0x2C"`
+	// Subcategories OPEX imports accept per cost center, e.g. {"Mine": ["Drilling", "Blasting"]}; cost centers left out accept any, an empty map clears the lists
+	OPEXSubcategories *map[string][]string `json:"opex_subcategories" validate:"omitempty,dive,keys,required,max=50,endkeys,dive,required,max=100"`
 }
 
 // AssignMineralsRequest represents request to assign minerals to a company
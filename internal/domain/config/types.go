@@ -1,5 +1,44 @@
 package config
 
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// DefaultNetCashFlowCapexTypes is the PBR Net Cash Flow definition used when a
+// company has not configured one: only sustaining CAPEX is subtracted.
+const DefaultNetCashFlowCapexTypes = "sustaining"
+
+// DefaultDoreGradeBasis derives doré grades as each metal's share of troy ounces.
+const DefaultDoreGradeBasis = "oz"
+
+// DefaultPrimaryMetal reports cash costs per silver ounce with gold as a by-product credit.
+const DefaultPrimaryMetal = "silver"
+
+// FormatZeroRowChecks stores per-import-type all-zero row checks as "type:action" pairs,
+// e.g. {"pbr": "reject", "dore": "warn"} -> "dore:warn,pbr:reject". "off" entries are dropped.
+func FormatZeroRowChecks(checks map[string]string) string {
+	pairs := make([]string, 0, len(checks))
+	for importType, action := range checks {
+		if action != "off" {
+			pairs = append(pairs, importType+":"+action)
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// FormatOPEXSubcategories stores the valid OPEX subcategories per cost center as a JSON
+// object, e.g. {"Mine": ["Drilling", "Blasting"]}. An empty map is stored as "" (any subcategory).
+func FormatOPEXSubcategories(subcategories map[string][]string) string {
+	if len(subcategories) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(subcategories)
+	return string(raw)
+}
+
 // UnitOfMeasure represents units for mineral measurements
 type UnitOfMeasure string
 
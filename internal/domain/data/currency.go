@@ -0,0 +1,91 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// CurrencyRates are monthly exchange rates, as units of the currency per USD
+// (e.g. ARS 950 means 1 USD = 950 ARS), keyed by currency and month ("2006-01")
+type CurrencyRates map[Currency]map[string]float64
+
+// Set records the rate of a currency for a date's month
+func (r CurrencyRates) Set(currency Currency, month time.Time, unitsPerUSD float64) {
+	if r[currency] == nil {
+		r[currency] = make(map[string]float64)
+	}
+	r[currency][month.Format("2006-01")] = unitsPerUSD
+}
+
+// Rate returns the rate of a currency in a date's month. USD is always 1.
+func (r CurrencyRates) Rate(currency Currency, date time.Time) (float64, bool) {
+	if currency == CurrencyUSD {
+		return 1, true
+	}
+	rate, ok := r[currency][date.Format("2006-01")]
+	return rate, ok && rate > 0
+}
+
+// foreignCurrencies returns the distinct non-USD currencies among the given ones
+func foreignCurrencies(currencies []string) []Currency {
+	var foreign []Currency
+	for _, code := range currencies {
+		currency := Currency(code)
+		if currency != CurrencyUSD && !slices.Contains(foreign, currency) {
+			foreign = append(foreign, currency)
+		}
+	}
+	return foreign
+}
+
+// toUSD converts an amount at a rate in units per USD, rounded to cents as stored
+func toUSD(amount, unitsPerUSD float64) float64 {
+	return math.Round(amount/unitsPerUSD*100) / 100
+}
+
+// missingRateError reports a row whose currency has no rate for its month
+func missingRateError(row int, currency string, date time.Time) ValidationError {
+	return ValidationError{
+		Row:    row,
+		Column: "currency",
+		Error:  fmt.Sprintf("no exchange rate for %s in %s", currency, date.Format("2006-01")),
+	}
+}
+
+// convertOPEXToUSD converts OPEX amounts to USD at the rate of each row's month, keeping
+// the amount as imported in OriginalAmount/OriginalCurrency. Rows without a rate are
+// reported (row numbers count the header as row 1) and left unconverted.
+func convertOPEXToUSD(records []*OPEXData, rates CurrencyRates) []ValidationError {
+	var errors []ValidationError
+	for i, record := range records {
+		rate, ok := rates.Rate(Currency(record.Currency), record.Date)
+		if !ok {
+			errors = append(errors, missingRateError(i+2, record.Currency, record.Date))
+			continue
+		}
+		record.OriginalAmount, record.OriginalCurrency, record.ExchangeRate = record.Amount, record.Currency, rate
+		record.Amount = toUSD(record.Amount, rate)
+		record.Currency = string(CurrencyUSD)
+	}
+	return errors
+}
+
+// convertCAPEXToUSD is convertOPEXToUSD for CAPEX rows. The accretion of mine closure
+// liability is in the row's currency too and is converted at the same rate.
+func convertCAPEXToUSD(records []*CAPEXData, rates CurrencyRates) []ValidationError {
+	var errors []ValidationError
+	for i, record := range records {
+		rate, ok := rates.Rate(Currency(record.Currency), record.Date)
+		if !ok {
+			errors = append(errors, missingRateError(i+2, record.Currency, record.Date))
+			continue
+		}
+		record.OriginalAmount, record.OriginalCurrency, record.ExchangeRate = record.Amount, record.Currency, rate
+		record.Amount = toUSD(record.Amount, rate)
+		record.AccretionOfMineClosureLiability = toUSD(record.AccretionOfMineClosureLiability, rate)
+		record.Currency = string(CurrencyUSD)
+	}
+	return errors
+}
@@ -1,5 +1,14 @@
 package data
 
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
 // Test constants - SINGLE SOURCE OF TRUTH for all data tests
 const (
 	testCompanyID   = int64(1)
@@ -26,6 +35,14 @@ func buildPBRCSV(rows []string) []byte {
 	return []byte(csv)
 }
 
+func buildDoreCSV(rows []string) []byte {
+	csv := "date,pbr_price_silver,pbr_price_gold,realized_price_silver,realized_price_gold,silver_adjustment_oz,gold_adjustment_oz,ag_deductions_pct,au_deductions_pct,treatment_charge,refining_deductions_au,streaming\n"
+	for _, row := range rows {
+		csv += row + "\n"
+	}
+	return []byte(csv)
+}
+
 func buildOPEXCSV(rows []string) []byte {
 	csv := "date,cost_center,subcategory,expense_type,amount,currency\n"
 	for _, row := range rows {
@@ -34,6 +51,59 @@ func buildOPEXCSV(rows []string) []byte {
 	return []byte(csv)
 }
 
+func buildCAPEXCSV(rows []string) []byte {
+	csv := "date,category,car_number,project_name,type,amount,accretion_of_mine_closure_liability,currency\n"
+	for _, row := range rows {
+		csv += row + "\n"
+	}
+	return []byte(csv)
+}
+
+// buildXLSX builds a one-sheet workbook. Cells are strings (shared strings), float64
+// (number cells) or time.Time (number cells with a date style, as Excel stores dates).
+func buildXLSX(rows [][]any) []byte {
+	var sheet, sharedStrings strings.Builder
+	var stringCount int
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := fmt.Sprintf("%c%d", 'A'+j, i+1)
+			switch v := cell.(type) {
+			case string:
+				fmt.Fprintf(&sheet, `<c r="%s" t="s"><v>%d</v></c>`, ref, stringCount)
+				fmt.Fprintf(&sharedStrings, `<si><t>%s</t></si>`, html.EscapeString(v))
+				stringCount++
+			case float64:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%v</v></c>`, ref, v)
+			case time.Time:
+				serial := v.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+				fmt.Fprintf(&sheet, `<c r="%s" s="1"><v>%v</v></c>`, ref, serial)
+			}
+		}
+		sheet.WriteString("</row>")
+	}
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<cellXfs count="2"><xf numFmtId="0"/><xf numFmtId="14" applyNumberFormat="1"/></cellXfs></styleSheet>`,
+		"xl/sharedStrings.xml":     `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings.String() + `</sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheet.String() + `</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, _ := archive.Create(name)
+		_, _ = w.Write([]byte(content))
+	}
+	_ = archive.Close()
+	return buf.Bytes()
+}
+
 func buildFinancialCSV(rows []string) []byte {
 	csv := "date,shipping_selling,sales_taxes,royalties,other_sales_deductions,other_adjustments\n"
 	for _, row := range rows {
@@ -61,6 +131,7 @@ func getTestMineralMap() map[string]int {
 const (
 	validProductionRow = "2024-01-15,AU,150.5,kilograms"
 	validPBRRow        = "2024-01-15,24859,262591,598,35951,209.79,7.35,94.01,95.36"
+	validDoreRow       = "2024-01-15,24.50,2000,24.30,1985,10,5,2.5,1.5,5000,1200,-969121"
 	validOPEXRow       = "2024-01-15,Mine,Drilling,Labour,50000,USD"
 	validFinancialRow  = "2024-01-15,-202,465867,0,0,0"
 )
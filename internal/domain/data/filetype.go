@@ -0,0 +1,86 @@
+package data
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// importTypeLabels are the human readable names used in type mismatch messages
+var importTypeLabels = map[DataImportType]string{
+	ImportProduction: "a Production",
+	ImportDore:       "a Dore",
+	ImportPBR:        "a PBR",
+	ImportOPEX:       "an OPEX",
+	ImportCAPEX:      "a CAPEX",
+	ImportRevenue:    "a Revenue",
+	ImportFinancial:  "a Financial",
+}
+
+// allImportTypes lists the import types in a stable order for detection
+var allImportTypes = []DataImportType{
+	ImportProduction, ImportDore, ImportPBR, ImportOPEX, ImportCAPEX, ImportRevenue, ImportFinancial,
+}
+
+// detectImportType returns the import type whose headers match the given headers, in any order
+func detectImportType(headers []string) (DataImportType, bool) {
+	trimmed := make([]string, len(headers))
+	for i, header := range headers {
+		trimmed[i] = strings.TrimSpace(header)
+	}
+	slices.Sort(trimmed)
+
+	for _, importType := range allImportTypes {
+		expected, _ := importHeaders(importType)
+		if slices.Equal(trimmed, slices.Sorted(slices.Values(expected))) {
+			return importType, true
+		}
+	}
+	return "", false
+}
+
+// headerTypeMismatch returns a descriptive error when the file headers belong to a
+// different import type than the declared one (identified by its expected headers)
+func headerTypeMismatch(headers, expectedHeaders []string) error {
+	detected, ok := detectImportType(headers)
+	if !ok {
+		return nil
+	}
+	declared, ok := detectImportType(expectedHeaders)
+	if !ok || declared == detected {
+		return nil
+	}
+	return fmt.Errorf("this looks like %s file but type=%s", importTypeLabels[detected], declared)
+}
+
+// filenameTypeWarning checks the uploaded filename against the declared import type.
+// It never blocks the import: an empty string means nothing looked inconsistent.
+func filenameTypeWarning(filename string, importType DataImportType) string {
+	if filename == "" {
+		return ""
+	}
+
+	base := strings.ToLower(filepath.Base(filename))
+	if ext := filepath.Ext(base); ext != "" && ext != ".csv" && ext != ".xlsx" {
+		return fmt.Sprintf("file '%s' does not have a .csv or .xlsx extension", filename)
+	}
+
+	tokens := strings.FieldsFunc(strings.TrimSuffix(base, filepath.Ext(base)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var named []DataImportType
+	for _, candidate := range allImportTypes {
+		if slices.Contains(tokens, string(candidate)) {
+			named = append(named, candidate)
+		}
+	}
+
+	// Only warn when the filename names other types and not the declared one
+	if len(named) == 0 || slices.Contains(named, importType) {
+		return ""
+	}
+	return fmt.Sprintf("filename '%s' looks like %s file but type=%s", filename, importTypeLabels[named[0]], importType)
+}
@@ -0,0 +1,246 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FormatProfile describes how a company's CSV files are written. The first successful
+// import records it; later imports default to it and may override any field.
+type FormatProfile struct {
+	Delimiter        string `db:"delimiter" json:"delimiter"`                 // "," ";" "\t" or "|"
+	DecimalSeparator string `db:"decimal_separator" json:"decimal_separator"` // "." or ","
+	DateLayout       string `db:"date_layout" json:"date_layout"`             // Go layout, e.g. "2006-01-02"
+	CurrencySymbol   string `db:"currency_symbol" json:"currency_symbol"`     // e.g. "$", "AR$" (empty: none)
+}
+
+// canonicalDateLayout is the layout the parsers expect after normalization
+const canonicalDateLayout = "2006-01-02"
+
+// DefaultFormatProfile is the format expected when nothing is detected or saved
+var DefaultFormatProfile = FormatProfile{
+	Delimiter:        ",",
+	DecimalSeparator: ".",
+	DateLayout:       canonicalDateLayout,
+}
+
+// NumberFormat names the number conventions of a locale: a shorthand for the decimal separator
+type NumberFormat string
+
+const (
+	NumberFormatUS NumberFormat = "us" // 1,234.56
+	NumberFormatES NumberFormat = "es" // 1.234,56
+	NumberFormatAR NumberFormat = "ar" // 1.234,56
+)
+
+// DecimalSeparator returns the decimal separator of the format; thousands use the other one
+func (f NumberFormat) DecimalSeparator() (string, bool) {
+	switch f {
+	case NumberFormatUS:
+		return ".", true
+	case NumberFormatES, NumberFormatAR:
+		return ",", true
+	}
+	return "", false
+}
+
+// Supported format values, in detection order
+var (
+	Delimiters        = []string{",", ";", "\t", "|"}
+	DecimalSeparators = []string{".", ","}
+	DateLayouts       = []string{canonicalDateLayout, "02/01/2006", "01/02/2006", "2006/01/02", "02-01-2006"}
+)
+
+// Validate checks the non-empty fields of a (possibly partial) profile
+func (p FormatProfile) Validate() error {
+	if p.Delimiter != "" && !slices.Contains(Delimiters, p.Delimiter) {
+		return fmt.Errorf("invalid delimiter: %q", p.Delimiter)
+	}
+	if p.DecimalSeparator != "" && !slices.Contains(DecimalSeparators, p.DecimalSeparator) {
+		return fmt.Errorf("invalid decimal_separator: %q (must be '.' or ',')", p.DecimalSeparator)
+	}
+	if p.DateLayout != "" && !slices.Contains(DateLayouts, p.DateLayout) {
+		return fmt.Errorf("invalid date_layout: %q (supported: %s)", p.DateLayout, strings.Join(DateLayouts, ", "))
+	}
+	if len(p.CurrencySymbol) > 10 {
+		return fmt.Errorf("invalid currency_symbol: %q", p.CurrencySymbol)
+	}
+	return nil
+}
+
+// withOverrides returns the profile with every non-empty field of overrides applied
+func (p FormatProfile) withOverrides(overrides FormatProfile) FormatProfile {
+	if overrides.Delimiter != "" {
+		p.Delimiter = overrides.Delimiter
+	}
+	if overrides.DecimalSeparator != "" {
+		p.DecimalSeparator = overrides.DecimalSeparator
+	}
+	if overrides.DateLayout != "" {
+		p.DateLayout = overrides.DateLayout
+	}
+	if overrides.CurrencySymbol != "" {
+		p.CurrencySymbol = overrides.CurrencySymbol
+	}
+	return p
+}
+
+// resolveFormatProfile picks the format for an import: the company's saved profile when
+// there is one, otherwise the one detected from the file; explicit params win over both.
+// certain reports whether the decimal separator is known (saved, explicit or detected
+// without conflicting cells), so that the profile may be saved for the company.
+func resolveFormatProfile(saved *FormatProfile, explicit FormatProfile, fileContent []byte, importType DataImportType) (profile FormatProfile, certain bool) {
+	var base FormatProfile
+	if saved != nil {
+		base, certain = DefaultFormatProfile.withOverrides(*saved), true
+	} else {
+		base, certain = detectFormatProfile(fileContent, numberColumns(importType))
+	}
+	return base.withOverrides(explicit), certain || explicit.DecimalSeparator != ""
+}
+
+// sniffDelimiter switches a comma (or unset) delimiter to a semicolon when the file's header
+// line has more semicolons than commas, as in European exports. Other delimiters are kept.
+func (p FormatProfile) sniffDelimiter(fileContent []byte) FormatProfile {
+	if p.Delimiter != "" && p.Delimiter != "," {
+		return p
+	}
+	headerLine, _, _ := bytes.Cut(fileContent, []byte("\n"))
+	if bytes.Count(headerLine, []byte(";")) > bytes.Count(headerLine, []byte(",")) {
+		p.Delimiter = ";"
+	}
+	return p
+}
+
+// decimalCommaPattern matches numbers written with a decimal comma: "1.234,56", "(12,5)", "AR$ 0,75"
+var decimalCommaPattern = regexp.MustCompile(`^[^\d]*\(?-?\d{1,3}(\.\d{3})*,\d{1,2}\)?[^\d]*$`)
+
+// decimalPointPattern matches numbers that can only be written with a decimal point:
+// "1234.56", "1,234.5", "(0.75)". "2.300" is left out: it may be 2300 with a thousands dot.
+var decimalPointPattern = regexp.MustCompile(`^[^\d]*\(?-?(\d+|\d{1,3}(,\d{3})+)\.(\d{1,2}|\d{4,})\)?[^\d]*$`)
+
+// detectFormatProfile guesses the format of a file, falling back to the defaults. Only the
+// cells of numberColumns are sampled for the decimal separator and currency symbol: a text
+// cell such as "Phase 1,2" says nothing about the numbers. certain is false when no cell
+// tells the decimal separator, or when cells disagree (the default "." is then kept).
+func detectFormatProfile(fileContent []byte, numberColumns map[string]bool) (profile FormatProfile, certain bool) {
+	profile = DefaultFormatProfile
+
+	headerLine, _, _ := bytes.Cut(fileContent, []byte("\n"))
+	best := 0
+	for _, delimiter := range Delimiters {
+		if count := strings.Count(string(headerLine), delimiter); count > best {
+			best = count
+			profile.Delimiter = delimiter
+		}
+	}
+
+	records, err := newCSVReader(fileContent, profile.Delimiter).ReadAll()
+	if err != nil || len(records) < 2 {
+		return profile, false
+	}
+
+	dateColumn := 0
+	numeric := make([]bool, len(records[0]))
+	for i, header := range records[0] {
+		header = strings.TrimSpace(strings.TrimPrefix(header, "\ufeff"))
+		if header == "date" {
+			dateColumn = i
+		}
+		numeric[i] = numberColumns[header]
+	}
+
+	var dates []string
+	var decimalCommas, decimalPoints int
+	affixes := currencyAffixList()
+	for _, row := range records[1:] {
+		if dateColumn < len(row) && strings.TrimSpace(row[dateColumn]) != "" {
+			dates = append(dates, strings.TrimSpace(row[dateColumn]))
+		}
+		for i, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if i >= len(numeric) || !numeric[i] || cell == "" {
+				continue
+			}
+			switch {
+			case decimalCommaPattern.MatchString(cell):
+				decimalCommas++
+			case decimalPointPattern.MatchString(cell):
+				decimalPoints++
+			}
+			if profile.CurrencySymbol == "" {
+				for _, affix := range affixes {
+					if strings.HasPrefix(cell, affix) && strings.ContainsAny(cell, "0123456789") {
+						profile.CurrencySymbol = affix
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for _, layout := range DateLayouts {
+		if allParse(dates, layout) {
+			profile.DateLayout = layout
+			break
+		}
+	}
+
+	if decimalCommas > 0 && decimalPoints == 0 {
+		profile.DecimalSeparator = ","
+	}
+	return profile, (decimalCommas > 0) != (decimalPoints > 0)
+}
+
+func allParse(values []string, layout string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, value := range values {
+		if _, err := time.Parse(layout, value); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeRecord rewrites a data row into the canonical format the parsers expect:
+// dates as YYYY-MM-DD and numbers with a "." decimal point. In semicolon files a comma in
+// a number is always the decimal point: commas cannot be thousand separators there.
+func normalizeRecord(row []string, headers []string, profile FormatProfile) {
+	convertDates := profile.DateLayout != "" && profile.DateLayout != canonicalDateLayout
+	convertDecimals := profile.DecimalSeparator == ","
+	semicolon := profile.Delimiter == ";"
+	if !convertDates && !convertDecimals && !semicolon && profile.CurrencySymbol == "" {
+		return
+	}
+
+	for i, cell := range row {
+		if i >= len(headers) {
+			break
+		}
+		switch columnType(strings.TrimSpace(headers[i])) {
+		case ColumnDate:
+			if convertDates {
+				if t, err := time.Parse(profile.DateLayout, strings.TrimSpace(cell)); err == nil {
+					row[i] = t.Format(canonicalDateLayout)
+				}
+			}
+		case ColumnNumber:
+			if profile.CurrencySymbol != "" {
+				cell = strings.TrimSpace(cell)
+				cell = strings.TrimPrefix(cell, profile.CurrencySymbol)
+				cell = strings.TrimSuffix(cell, profile.CurrencySymbol)
+			}
+			if convertDecimals || (semicolon && strings.Contains(cell, ",")) {
+				cell = strings.ReplaceAll(cell, ".", "")
+				cell = strings.Replace(cell, ",", ".", 1)
+			}
+			row[i] = cell
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidGzip = errors.New("invalid gzip file")
+
+// maxDecompressedSize caps a decompressed upload: CSVs compress about 10x, so a full
+// MaxUploadSize of gzip may hold far more than MaxUploadSize of CSV
+const maxDecompressedSize = 16 * MaxUploadSize
+
+// isGzipUpload reports whether an uploaded file part is gzip-compressed, by its
+// Content-Encoding or a .gz filename
+func isGzipUpload(filename, contentEncoding string) bool {
+	return strings.EqualFold(strings.TrimSpace(contentEncoding), "gzip") || strings.EqualFold(filepath.Ext(filename), ".gz")
+}
+
+// gunzipUpload decompresses a gzip-compressed upload. The filename loses its .gz
+// extension, so the type checks on it (e.g. isXLSX) see the name of the file inside.
+func gunzipUpload(filename string, content []byte) ([]byte, string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidGzip, err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidGzip, err)
+	}
+	if len(decompressed) > maxDecompressedSize {
+		return nil, "", fmt.Errorf("%w: decompressed file is larger than %d MB", ErrInvalidGzip, maxDecompressedSize>>20)
+	}
+
+	if strings.EqualFold(filepath.Ext(filename), ".gz") {
+		filename = filename[:len(filename)-len(".gz")]
+	}
+	return decompressed, filename, nil
+}
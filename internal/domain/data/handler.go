@@ -1,10 +1,14 @@
 package data
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
@@ -16,6 +20,9 @@ import (
 const (
 	// MaxUploadSize is 10MB
 	MaxUploadSize = 10 << 20
+
+	// MaxBundleUploadSize is 50MB, for the files of a bundle import together
+	MaxBundleUploadSize = 5 * MaxUploadSize
 )
 
 type Handler struct {
@@ -38,22 +45,42 @@ func RegisterHTTPEndPoints(router *chi.Mux, validator *validator.Validate, uc Us
 
 	router.Route("/api/v1/data", func(r chi.Router) {
 		r.Post("/import", h.Import)
+		r.Post("/import/bundle", h.ImportBundle)
+		r.Get("/schema", h.Schema)
 		r.Get("/{type}/list", h.List)
 		r.Delete("/{type}/{id}", h.Delete)
+		r.Post("/versions/prune", h.PruneVersions)
+		r.Post("/archive", h.Archive)
 	})
 }
 
 // Import handles CSV data import
-// @Summary Import data from CSV
-// @Description Import production, dore, pbr, opex, capex or revenue data from CSV
+// @Summary Import data from CSV or Excel
+// @Description Import production, dore, pbr, opex, capex or revenue data from CSV, or from the first sheet of an .xlsx workbook
+// @Description (same columns; detected by extension, content type or content). An optional trailing "notes" column is stored per row
 // @Tags data
 // @Accept multipart/form-data
 // @Produce json
 // @Param type formData string true "Data type" Enums(production, dore, pbr, opex, capex, revenue)
 // @Param company_id formData integer true "Company ID"
-// @Param file formData file true "CSV file"
+// @Param version formData integer false "Data version, defaults to 1 (new budget versions are capped per company/year)"
+// @Param year formData integer false "Year the file is for: rows dated in another year are rejected"
+// @Param file formData file true "CSV or .xlsx file, optionally gzip-compressed (.gz filename or Content-Encoding: gzip)"
+// @Param column_map formData string false "JSON object mapping file headers to expected headers"
+// @Param expense_type_map formData string false "JSON object mapping ledger expense types to Labour, Materials, Third Party or Other (common synonyms such as Consumables are built in)"
+// @Param mode formData string false "Import mode (adjust: OPEX/CAPEX deltas on top of existing amounts; replace: soft-delete the active rows of the file's months first)" Enums(insert, adjust, replace)
+// @Param allow_empty formData boolean false "Accept a header-only file as a successful zero-row import"
+// @Param validate_only formData boolean false "Dry run: parse and validate the file and report errors without inserting anything"
+// @Param delimiter formData string false "CSV delimiter, defaults to the company's saved format" Enums(",", ";", tab, |)
+// @Param decimal_separator formData string false "Decimal separator, defaults to the company's saved format" Enums(., ",")
+// @Param number_format formData string false "Number format preset (us: 1,234.56; es, ar: 1.234,56), sets the decimal separator" Enums(us, es, ar)
+// @Param date_layout formData string false "Go date layout, defaults to the company's saved format (e.g. 02/01/2006)"
+// @Param currency_symbol formData string false "Currency symbol decorating amounts, defaults to the company's saved format"
+// @Param Idempotency-Key header string false "Client key of the upload: a retry with the key of a successful import (same user, company, import and file, within 24h) returns its response without importing again; reusing it for another upload is a 422"
 // @Success 200 {object} ImportResponse
 // @Failure 400 {object} respond.Error
+// @Failure 409 {object} respond.Error
+// @Failure 422 {object} ImportResponse "The file was rejected: nothing was inserted, see errors (a respond.Error when the Idempotency-Key was used for another upload)"
 // @Failure 500 {object} respond.Error
 // @Router /api/v1/data/import [post]
 func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
@@ -81,61 +108,306 @@ func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get data_type (actual or budget)
-	dataType := DataType(r.FormValue("data_type"))
-	if !dataType.IsValid() {
-		respond.Error(w, http.StatusBadRequest, errors.New("invalid data_type: must be 'actual' or 'budget'"))
+	// Get the form fields common to single and bundle imports
+	importReq, err := parseImportForm(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
 		return
 	}
-
-	// Get company ID
-	companyIDStr := r.FormValue("company_id")
-	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
-	if err != nil || companyID <= 0 {
-		respond.Error(w, http.StatusBadRequest, errors.New("invalid company_id"))
+	importReq.Type = importType
+	if !importType.SupportsMode(importReq.Mode) {
+		respond.Error(w, http.StatusBadRequest, ErrInvalidMode)
 		return
 	}
 
 	// Get file
-	file, _, err := r.FormFile("file")
-	if err != nil {
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
 		respond.Error(w, http.StatusBadRequest, errors.New("missing or invalid file"))
 		return
 	}
-	defer file.Close()
-
-	// Read file content
-	fileContent, err := io.ReadAll(file)
-	if err != nil {
-		respond.Error(w, http.StatusInternalServerError, errors.New("error reading file"))
+	if err := readUpload(importReq, files[0]); err != nil {
+		respond.Error(w, uploadErrorStatus(err), err)
 		return
 	}
 
-	// Create import request
-	importReq := &ImportRequest{
-		Type:      importType,
-		DataType:  string(dataType),
-		CompanyID: companyID,
-		File:      fileContent,
-	}
+	// A retried upload with the same key gets the first response instead of importing again
+	importReq.IdempotencyKey = strings.TrimSpace(r.Header.Get("Idempotency-Key"))
 
 	// Process import
 	response, err := h.useCase.ImportData(r.Context(), importReq, userID)
 	if err != nil {
+		if errors.Is(err, ErrInvalidIdempotencyKey) {
+			respond.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, ErrIdempotencyKeyMismatch) {
+			respond.Error(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if errors.Is(err, ErrDuplicatePBRDate) || errors.Is(err, ErrDuplicateDoreDate) || errors.Is(err, ErrTooManyBudgetVersions) {
+			respond.Error(w, http.StatusConflict, err)
+			return
+		}
 		respond.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	// If validation failed, return 400 with errors
+	// The file was rejected (nothing is inserted): 422 with the validation errors
 	if !response.Success {
-		respond.JSON(w, http.StatusBadRequest, response)
+		respond.JSON(w, http.StatusUnprocessableEntity, response)
 		return
 	}
 
 	respond.JSON(w, http.StatusOK, response)
 }
 
-// List returns imported data
+// ImportBundle handles the import of several files in one transaction
+// @Summary Import several data files together
+// @Description Import files of several types for the same company, data type and version in one request: each file
+// @Description part is named after its type (e.g. pbr, dore, opex). Files are imported PBR before Dore, in one transaction:
+// @Description either all of them are inserted or none is. Form fields apply to every file as in /import.
+// @Tags data
+// @Accept multipart/form-data
+// @Produce json
+// @Param company_id formData integer true "Company ID"
+// @Param data_type formData string true "Data type" Enums(actual, budget, forecast)
+// @Param version formData integer false "Data version, defaults to 1"
+// @Param year formData integer false "Year the files are for: rows dated in another year are rejected"
+// @Param production formData file false "Production CSV or .xlsx file"
+// @Param pbr formData file false "PBR CSV or .xlsx file"
+// @Param dore formData file false "Dore CSV or .xlsx file, checked against the PBR of the bundle"
+// @Param opex formData file false "OPEX CSV or .xlsx file"
+// @Param capex formData file false "CAPEX CSV or .xlsx file"
+// @Param revenue formData file false "Revenue CSV or .xlsx file"
+// @Param financial formData file false "Financial CSV or .xlsx file"
+// @Param mode formData string false "Import mode for every file" Enums(insert, adjust, replace)
+// @Param validate_only formData boolean false "Dry run: import and validate every file, then roll back"
+// @Success 200 {object} BundleImportResponse
+// @Failure 400 {object} respond.Error
+// @Failure 409 {object} respond.Error
+// @Failure 422 {object} BundleImportResponse "A file was rejected: nothing was inserted, see each file's errors"
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/data/import/bundle [post]
+func (h *Handler) ImportBundle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, errors.New("user not authenticated"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBundleUploadSize)
+	if err := r.ParseMultipartForm(MaxBundleUploadSize); err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("file too large or invalid form data"))
+		return
+	}
+
+	common, err := parseImportForm(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// One request per file part, named after its import type
+	var reqs []*ImportRequest
+	for name, files := range r.MultipartForm.File {
+		importType := DataImportType(name)
+		if !importType.IsValid() {
+			respond.Error(w, http.StatusBadRequest, fmt.Errorf("%w: unknown file %q, parts must be named after their type", ErrInvalidBundle, name))
+			return
+		}
+		if len(files) > 1 {
+			respond.Error(w, http.StatusBadRequest, fmt.Errorf("%w: more than one %s file", ErrInvalidBundle, importType))
+			return
+		}
+		req := *common
+		req.Type = importType
+		if err := readUpload(&req, files[0]); err != nil {
+			respond.Error(w, uploadErrorStatus(err), err)
+			return
+		}
+		reqs = append(reqs, &req)
+	}
+
+	response, err := h.useCase.ImportBundle(r.Context(), reqs, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidBundle), errors.Is(err, ErrInvalidMode), errors.Is(err, ErrInvalidDataType):
+			respond.Error(w, http.StatusBadRequest, err)
+		case errors.Is(err, ErrCompanyNotFound):
+			respond.Error(w, http.StatusNotFound, err)
+		case errors.Is(err, ErrDuplicatePBRDate), errors.Is(err, ErrDuplicateDoreDate), errors.Is(err, ErrTooManyBudgetVersions):
+			respond.Error(w, http.StatusConflict, err)
+		default:
+			respond.Error(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if !response.Success {
+		respond.JSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// parseImportForm reads the form fields of an import other than the type and the file,
+// shared by Import and ImportBundle
+func parseImportForm(r *http.Request) (*ImportRequest, error) {
+	// Get data_type (actual, budget or forecast)
+	dataType := DataType(r.FormValue("data_type"))
+	if !dataType.IsValid() {
+		return nil, errors.New("invalid data_type: must be 'actual', 'budget' or 'forecast'")
+	}
+
+	// Get company ID
+	companyIDStr := r.FormValue("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		return nil, errors.New("invalid company_id")
+	}
+
+	// Get version (optional, defaults to 1)
+	version := 0
+	if raw := r.FormValue("version"); raw != "" {
+		version, err = strconv.Atoi(raw)
+		if err != nil || version < 1 {
+			return nil, errors.New("invalid version: must be a positive integer")
+		}
+	}
+
+	// Get year (optional: rows dated in another year are rejected)
+	year := 0
+	if raw := r.FormValue("year"); raw != "" {
+		year, err = strconv.Atoi(raw)
+		if err != nil || year <= 2000 {
+			return nil, errors.New("invalid year: must be greater than 2000")
+		}
+	}
+
+	// Get import mode (optional, defaults to insert)
+	mode := ImportMode(r.FormValue("mode"))
+	if mode == "" {
+		mode = ImportModeInsert
+	}
+
+	// Get optional column mapping (file header -> expected header)
+	var columnMap map[string]string
+	if raw := r.FormValue("column_map"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &columnMap); err != nil {
+			return nil, errors.New("invalid column_map: must be a JSON object of header names")
+		}
+	}
+
+	// Get optional expense type synonyms (client name -> canonical expense type)
+	var expenseTypeMap map[string]ExpenseType
+	if raw := r.FormValue("expense_type_map"); raw != "" {
+		var synonyms map[string]string
+		if err := json.Unmarshal([]byte(raw), &synonyms); err != nil {
+			return nil, errors.New("invalid expense_type_map: must be a JSON object of expense type names")
+		}
+		expenseTypeMap, err = ParseExpenseTypeMap(synonyms)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Get allow_empty flag (optional, defaults to false)
+	allowEmpty := false
+	if raw := r.FormValue("allow_empty"); raw != "" {
+		allowEmpty, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("invalid allow_empty: must be true or false")
+		}
+	}
+
+	// Get validate_only flag (optional, defaults to false)
+	validateOnly := false
+	if raw := r.FormValue("validate_only"); raw != "" {
+		validateOnly, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("invalid validate_only: must be true or false")
+		}
+	}
+
+	// Get optional CSV format overrides (default to the company's saved profile)
+	format := FormatProfile{
+		Delimiter:        r.FormValue("delimiter"),
+		DecimalSeparator: r.FormValue("decimal_separator"),
+		DateLayout:       r.FormValue("date_layout"),
+		CurrencySymbol:   strings.TrimSpace(r.FormValue("currency_symbol")),
+	}
+	if format.Delimiter == "tab" {
+		format.Delimiter = "\t"
+	}
+	if raw := r.FormValue("number_format"); raw != "" {
+		separator, ok := NumberFormat(strings.ToLower(strings.TrimSpace(raw))).DecimalSeparator()
+		if !ok {
+			return nil, errors.New("invalid number_format: must be 'us', 'es' or 'ar'")
+		}
+		if format.DecimalSeparator != "" && format.DecimalSeparator != separator {
+			return nil, fmt.Errorf("number_format %q conflicts with decimal_separator %q", raw, format.DecimalSeparator)
+		}
+		format.DecimalSeparator = separator
+	}
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &ImportRequest{
+		DataType:       string(dataType),
+		CompanyID:      companyID,
+		Version:        version,
+		Year:           year,
+		ColumnMap:      columnMap,
+		ExpenseTypeMap: expenseTypeMap,
+		Mode:           mode,
+		AllowEmpty:     allowEmpty,
+		ValidateOnly:   validateOnly,
+		Format:         format,
+	}, nil
+}
+
+// errReadUpload is a file that could not be read from the request
+var errReadUpload = errors.New("error reading file")
+
+// readUpload sets the file of an import request from an uploaded file part.
+// Remote sites upload gzip-compressed files over slow links: those are decompressed.
+func readUpload(req *ImportRequest, fileHeader *multipart.FileHeader) error {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return errReadUpload
+	}
+	defer file.Close()
+
+	fileContent, err := io.ReadAll(file)
+	if err != nil {
+		return errReadUpload
+	}
+
+	filename := fileHeader.Filename
+	if isGzipUpload(filename, fileHeader.Header.Get("Content-Encoding")) {
+		fileContent, filename, err = gunzipUpload(filename, fileContent)
+		if err != nil {
+			return err
+		}
+	}
+
+	req.File = fileContent
+	req.Filename = filename
+	req.ContentType = fileHeader.Header.Get("Content-Type")
+	return nil
+}
+
+// uploadErrorStatus is the status of a readUpload error: a bad gzip file is the client's
+func uploadErrorStatus(err error) int {
+	if errors.Is(err, errReadUpload) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// List returns imported data, and rows archived by the retention policy with include_archived=true
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	dataTypeStr := chi.URLParam(r, "type")
 	dataType := DataImportType(dataTypeStr)
@@ -173,7 +445,16 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	data, err := h.useCase.ListData(r.Context(), dataType, companyID, year, typeFilter, version)
+	includeArchived := false
+	if archivedStr := r.URL.Query().Get("include_archived"); archivedStr != "" {
+		includeArchived, err = strconv.ParseBool(archivedStr)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid include_archived"))
+			return
+		}
+	}
+
+	data, err := h.useCase.ListData(r.Context(), dataType, companyID, year, typeFilter, version, includeArchived)
 	if err != nil {
 		respond.Error(w, http.StatusInternalServerError, err)
 		return
@@ -182,6 +463,72 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	respond.JSON(w, http.StatusOK, data)
 }
 
+// ListImports returns a company's import history
+// @Summary List import history
+// @Description Returns who imported what and when: one entry per successful import, newest first
+// @Tags data
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer false "Only imports made in this year"
+// @Success 200 {array} ImportLog
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/data/imports [get]
+func (h *Handler) ListImports(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.ParseInt(r.URL.Query().Get("company_id"), 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	year := 0
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		year, err = strconv.Atoi(yearStr)
+		if err != nil || year < 2000 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid year"))
+			return
+		}
+	}
+
+	logs, err := h.useCase.ListImports(r.Context(), companyID, year)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, logs)
+}
+
+// Schema returns the column metadata expected by an import type
+// @Summary Get import schema
+// @Description Returns the columns (name, required, type, enum values) expected by an import type
+// @Tags data
+// @Produce json
+// @Param type query string true "Data type" Enums(production, dore, pbr, opex, capex, revenue, financial)
+// @Success 200 {object} ImportSchema
+// @Failure 400 {object} respond.Error
+// @Router /api/v1/data/schema [get]
+func (h *Handler) Schema(w http.ResponseWriter, r *http.Request) {
+	dataType := DataImportType(r.URL.Query().Get("type"))
+	if !dataType.IsValid() {
+		respond.Error(w, http.StatusBadRequest, ErrInvalidDataType)
+		return
+	}
+
+	schema, err := h.useCase.GetImportSchema(r.Context(), dataType)
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, schema)
+}
+
 // Delete soft deletes imported data
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	dataTypeStr := chi.URLParam(r, "type")
@@ -206,3 +553,167 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	respond.JSON(w, http.StatusOK, MessageResponse{Message: "data deleted successfully"})
 }
+
+// DeleteScope soft deletes all of a month's data of a type
+// @Summary Delete a month of data
+// @Description Soft deletes every active row of a company/month/data type/version of a data type, e.g. before importing the month again
+// @Tags data
+// @Produce json
+// @Param type path string true "Data type" Enums(production, dore, pbr, opex, capex, revenue, financial)
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param month query integer true "Month (1-12)"
+// @Param data_type query string false "Data type, defaults to actual" Enums(actual, budget, forecast)
+// @Param version query integer false "Data version, defaults to 1"
+// @Success 200 {object} DeleteScopeResponse
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/data/{type} [delete]
+func (h *Handler) DeleteScope(w http.ResponseWriter, r *http.Request) {
+	dataType := DataImportType(chi.URLParam(r, "type"))
+	if !dataType.IsValid() {
+		respond.Error(w, http.StatusBadRequest, ErrInvalidDataType)
+		return
+	}
+
+	query := r.URL.Query()
+	companyID, err := strconv.ParseInt(query.Get("company_id"), 10, 64)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	year, err := strconv.Atoi(query.Get("year"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	month, err := strconv.Atoi(query.Get("month"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing month"))
+		return
+	}
+
+	req := &DeleteScopeRequest{
+		Type:      dataType,
+		CompanyID: companyID,
+		Year:      year,
+		Month:     month,
+		DataType:  query.Get("data_type"),
+		Version:   1,
+	}
+	if req.DataType == "" {
+		req.DataType = string(DataTypeActual)
+	}
+	if versionStr := query.Get("version"); versionStr != "" {
+		req.Version, err = strconv.Atoi(versionStr)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version"))
+			return
+		}
+	}
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.useCase.DeleteDataScope(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// PruneVersions archives old budget versions of a company/year
+// @Summary Prune budget versions
+// @Description Soft deletes all but the newest `keep` budget versions of a company/year
+// @Tags data
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param keep query integer true "Number of newest versions to keep"
+// @Success 200 {object} PruneVersionsResponse
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/data/versions/prune [post]
+func (h *Handler) PruneVersions(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.ParseInt(r.URL.Query().Get("company_id"), 10, 64)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	keep, err := strconv.Atoi(r.URL.Query().Get("keep"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing keep"))
+		return
+	}
+
+	req := &PruneVersionsRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Keep:      keep,
+	}
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.useCase.PruneBudgetVersions(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
+
+// Archive runs the company's data retention policy
+// @Summary Archive expired data
+// @Description Moves soft deleted rows, and rows older than the company's data_retention_years setting, to the archive tables. Archived rows are listed with include_archived=true
+// @Tags data
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Success 200 {object} ArchiveResponse
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/data/archive [post]
+func (h *Handler) Archive(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.ParseInt(r.URL.Query().Get("company_id"), 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	response, err := h.useCase.ArchiveData(r.Context(), companyID)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, response)
+}
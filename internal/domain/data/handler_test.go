@@ -0,0 +1,168 @@
+package data
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gmhafiz/go8/internal/middleware"
+)
+
+// postImport posts a PBR import with the file part headers given and returns the response
+func postImport(t *testing.T, h *Handler, filename string, partHeader textproto.MIMEHeader, content []byte) (*httptest.ResponseRecorder, ImportResponse) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("type", string(ImportPBR)))
+	require.NoError(t, form.WriteField("data_type", "actual"))
+	require.NoError(t, form.WriteField("company_id", "1"))
+
+	partHeader.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	part, err := form.CreatePart(partHeader)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/data/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, testUserID))
+	rec := httptest.NewRecorder()
+	h.Import(rec, req)
+
+	var response ImportResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+	return rec, response
+}
+
+func TestImport_GzipUpload(t *testing.T) {
+	csvContent := buildPBRCSV([]string{validPBRRow, "2024-02-15,24859,262591,598,35951,209.79,7.35,94.01,95.36"})
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write(csvContent)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	plainRepo := &softDeleteRepository{}
+	rec, plain := postImport(t, NewHandler(NewUseCase(plainRepo, DefaultMaxBudgetVersions, GramsPerTroyOz), validator.New()), "pbr_2024.csv", textproto.MIMEHeader{}, csvContent)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.True(t, plain.Success)
+
+	for name, upload := range map[string]struct {
+		filename string
+		header   textproto.MIMEHeader
+	}{
+		".gz filename":     {"pbr_2024.csv.gz", textproto.MIMEHeader{}},
+		"Content-Encoding": {"pbr_2024.csv", textproto.MIMEHeader{"Content-Encoding": {"gzip"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := &softDeleteRepository{}
+			rec, response := postImport(t, NewHandler(NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz), validator.New()), upload.filename, upload.header, compressed.Bytes())
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, plain, response)
+			require.Len(t, repo.pbr, len(plainRepo.pbr))
+			for i := range repo.pbr {
+				assert.Equal(t, *plainRepo.pbr[i], *repo.pbr[i])
+			}
+			require.Len(t, repo.imports, 1)
+			assert.Equal(t, "pbr_2024.csv", repo.imports[0].Filename)
+		})
+	}
+
+	rec, _ = postImport(t, NewHandler(NewUseCase(&softDeleteRepository{}, DefaultMaxBudgetVersions, GramsPerTroyOz), validator.New()), "pbr_2024.csv.gz", textproto.MIMEHeader{}, csvContent)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestImportBundle_Handler(t *testing.T) {
+	post := func(files map[string][]byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("data_type", "actual"))
+		require.NoError(t, form.WriteField("company_id", "1"))
+		for name, content := range files {
+			part, err := form.CreateFormFile(name, name+"_2024.csv")
+			require.NoError(t, err)
+			_, err = part.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/data/import/bundle", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, testUserID))
+		rec := httptest.NewRecorder()
+		NewHandler(NewUseCase(&bundleRepository{}, DefaultMaxBudgetVersions, GramsPerTroyOz), validator.New()).ImportBundle(rec, req)
+		return rec
+	}
+
+	rec := post(map[string][]byte{
+		"pbr":  buildPBRCSV([]string{validPBRRow}),
+		"dore": buildDoreCSV([]string{validDoreRow}),
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response BundleImportResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, 1, response.Results[ImportPBR].RowsInserted)
+	assert.Equal(t, 1, response.Results[ImportDore].RowsInserted)
+
+	rec = post(map[string][]byte{"file": buildPBRCSV([]string{validPBRRow})})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Dore without PBR is rejected: 422 with the file's errors
+	rec = post(map[string][]byte{"dore": buildDoreCSV([]string{validDoreRow})})
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	response = BundleImportResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.False(t, response.Success)
+	assert.NotEmpty(t, response.Results[ImportDore].Errors)
+}
+
+func TestImport_RejectedFileIsUnprocessable(t *testing.T) {
+	repo := &softDeleteRepository{}
+	csvContent := buildPBRCSV([]string{validPBRRow, "2024-02-15,abc,262591,598,35951,209.79,7.35,94.01,95.36"})
+	rec, response := postImport(t, NewHandler(NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz), validator.New()), "pbr_2024.csv", textproto.MIMEHeader{}, csvContent)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.False(t, response.Success)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, 3, response.Errors[0].Row)
+	assert.Zero(t, response.RowsInserted)
+	assert.Empty(t, repo.pbr)
+}
+
+func TestParseImportForm_NumberFormat(t *testing.T) {
+	parse := func(fields url.Values) (*ImportRequest, error) {
+		fields.Set("data_type", "actual")
+		fields.Set("company_id", "1")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/data/import", strings.NewReader(fields.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return parseImportForm(req)
+	}
+
+	for numberFormat, separator := range map[string]string{"us": ".", "es": ",", "AR": ","} {
+		req, err := parse(url.Values{"number_format": {numberFormat}})
+		require.NoError(t, err, numberFormat)
+		assert.Equal(t, separator, req.Format.DecimalSeparator, numberFormat)
+	}
+
+	req, err := parse(url.Values{})
+	require.NoError(t, err)
+	assert.Empty(t, req.Format.DecimalSeparator) // The company's saved or detected format
+
+	_, err = parse(url.Values{"number_format": {"fr"}})
+	assert.Error(t, err)
+	_, err = parse(url.Values{"number_format": {"es"}, "decimal_separator": {"."}})
+	assert.Error(t, err)
+}
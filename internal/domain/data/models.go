@@ -13,6 +13,7 @@ type ProductionData struct {
 	DataType    string     `db:"data_type" json:"data_type"`
 	Version     int        `db:"version" json:"version"`
 	Description string     `db:"description" json:"description,omitempty"`
+	Notes       string     `db:"notes" json:"notes,omitempty"`
 	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 	CreatedBy   int64      `db:"created_by" json:"created_by"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
@@ -36,15 +37,41 @@ type DoreData struct {
 	AuDeductionsPct      float64    `db:"au_deductions_pct" json:"au_deductions_pct"`
 	TreatmentCharge      float64    `db:"treatment_charge" json:"treatment_charge"`
 	RefiningDeductionsAu float64    `db:"refining_deductions_au" json:"refining_deductions_au"`
-	Streaming            float64    `db:"streaming" json:"streaming"` // Streaming agreement value (usually negative)
+	Streaming            float64    `db:"streaming" json:"streaming"`     // Streaming agreement value (usually negative)
+	GradeBasis           string     `db:"grade_basis" json:"grade_basis"` // Basis of the grades: "oz" (default) or "atomic"
 	DataType             string     `db:"data_type" json:"data_type"`
 	Version              int        `db:"version" json:"version"`
 	Description          string     `db:"description" json:"description,omitempty"`
+	Notes                string     `db:"notes" json:"notes,omitempty"`
 	DeletedAt            *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 	CreatedBy            int64      `db:"created_by" json:"created_by"`
 	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
 }
 
+// MetalOz returns the silver and gold ounces contained in the doré, converting
+// the grades back according to the basis they were derived with
+func (d *DoreData) MetalOz() (silverOz, goldOz float64) {
+	if DoreGradeBasis(d.GradeBasis) != DoreGradeBasisAtomic {
+		return d.DoreProducedOz * (d.SilverGradePct / 100), d.DoreProducedOz * (d.GoldGradePct / 100)
+	}
+
+	silverMass := d.SilverGradePct * atomicWeightSilver
+	goldMass := d.GoldGradePct * atomicWeightGold
+	if total := silverMass + goldMass; total > 0 {
+		return d.DoreProducedOz * silverMass / total, d.DoreProducedOz * goldMass / total
+	}
+	return 0, 0
+}
+
+// PayableOz returns the silver and gold ounces paid for: metal in doré plus
+// adjustments, less the contractual deductions
+func (d *DoreData) PayableOz() (silverOz, goldOz float64) {
+	metalSilverOz, metalGoldOz := d.MetalOz()
+	silverOz = (metalSilverOz + d.SilverAdjustmentOz) * (1 - d.AgDeductionsPct/100)
+	goldOz = (metalGoldOz + d.GoldAdjustmentOz) * (1 - d.AuDeductionsPct/100)
+	return silverOz, goldOz
+}
+
 // PBRData represents Plan Beneficio Regional data
 type PBRData struct {
 	ID        int64     `db:"id" json:"id"`
@@ -90,6 +117,7 @@ type PBRData struct {
 	DataType    string     `db:"data_type" json:"data_type"`
 	Version     int        `db:"version" json:"version"`
 	Description string     `db:"description" json:"description,omitempty"`
+	Notes       string     `db:"notes" json:"notes,omitempty"`
 	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 	CreatedBy   int64      `db:"created_by" json:"created_by"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
@@ -97,20 +125,25 @@ type PBRData struct {
 
 // OPEXData represents operational expenditure data
 type OPEXData struct {
-	ID          int64      `db:"id" json:"id"`
-	CompanyID   int64      `db:"company_id" json:"company_id"`
-	Date        time.Time  `db:"date" json:"date"`
-	CostCenter  string     `db:"cost_center" json:"cost_center"`
-	Subcategory string     `db:"subcategory" json:"subcategory"`
-	ExpenseType string     `db:"expense_type" json:"expense_type"`
-	Amount      float64    `db:"amount" json:"amount"`
-	Currency    string     `db:"currency" json:"currency"`
-	DataType    string     `db:"data_type" json:"data_type"`
-	Version     int        `db:"version" json:"version"`
-	Description string     `db:"description" json:"description,omitempty"`
-	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
-	CreatedBy   int64      `db:"created_by" json:"created_by"`
-	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	ID               int64      `db:"id" json:"id"`
+	CompanyID        int64      `db:"company_id" json:"company_id"`
+	Date             time.Time  `db:"date" json:"date"`
+	CostCenter       string     `db:"cost_center" json:"cost_center"`
+	Subcategory      string     `db:"subcategory" json:"subcategory"`
+	ExpenseType      string     `db:"expense_type" json:"expense_type"`
+	Amount           float64    `db:"amount" json:"amount"` // USD
+	Currency         string     `db:"currency" json:"currency"`
+	OriginalAmount   float64    `db:"original_amount" json:"original_amount"` // Amount as imported, in OriginalCurrency
+	OriginalCurrency string     `db:"original_currency" json:"original_currency"`
+	ExchangeRate     float64    `db:"exchange_rate" json:"exchange_rate"` // OriginalCurrency units per USD (1 for USD)
+	DataType         string     `db:"data_type" json:"data_type"`
+	Version          int        `db:"version" json:"version"`
+	Description      string     `db:"description" json:"description,omitempty"`
+	Notes            string     `db:"notes" json:"notes,omitempty"`
+	IsAdjustment     bool       `db:"is_adjustment" json:"is_adjustment"` // Delta on top of existing amounts (mode=adjust)
+	DeletedAt        *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	CreatedBy        int64      `db:"created_by" json:"created_by"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
 }
 
 // CAPEXData represents capital expenditure data
@@ -122,12 +155,17 @@ type CAPEXData struct {
 	CARNumber                       string     `db:"car_number" json:"car_number"`
 	ProjectName                     string     `db:"project_name" json:"project_name"`
 	Type                            string     `db:"type" json:"type"`
-	Amount                          float64    `db:"amount" json:"amount"`
-	AccretionOfMineClosureLiability float64    `db:"accretion_of_mine_closure_liability" json:"accretion_of_mine_closure_liability"`
+	Amount                          float64    `db:"amount" json:"amount"`                                                           // USD
+	AccretionOfMineClosureLiability float64    `db:"accretion_of_mine_closure_liability" json:"accretion_of_mine_closure_liability"` // USD
 	Currency                        string     `db:"currency" json:"currency"`
+	OriginalAmount                  float64    `db:"original_amount" json:"original_amount"` // Amount as imported, in OriginalCurrency
+	OriginalCurrency                string     `db:"original_currency" json:"original_currency"`
+	ExchangeRate                    float64    `db:"exchange_rate" json:"exchange_rate"` // OriginalCurrency units per USD (1 for USD)
 	DataType                        string     `db:"data_type" json:"data_type"`
 	Version                         int        `db:"version" json:"version"`
 	Description                     string     `db:"description" json:"description,omitempty"`
+	Notes                           string     `db:"notes" json:"notes,omitempty"`
+	IsAdjustment                    bool       `db:"is_adjustment" json:"is_adjustment"` // Delta on top of existing amounts (mode=adjust)
 	DeletedAt                       *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 	CreatedBy                       int64      `db:"created_by" json:"created_by"`
 	CreatedAt                       time.Time  `db:"created_at" json:"created_at"`
@@ -145,6 +183,7 @@ type RevenueData struct {
 	DataType     string     `db:"data_type" json:"data_type"`
 	Version      int        `db:"version" json:"version"`
 	Description  string     `db:"description" json:"description,omitempty"`
+	Notes        string     `db:"notes" json:"notes,omitempty"`
 	DeletedAt    *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 	CreatedBy    int64      `db:"created_by" json:"created_by"`
 	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
@@ -164,11 +203,26 @@ type FinancialData struct {
 	DataType             string     `db:"data_type" json:"data_type"`
 	Version              int        `db:"version" json:"version"`
 	Description          string     `db:"description" json:"description,omitempty"`
+	Notes                string     `db:"notes" json:"notes,omitempty"`
 	DeletedAt            *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 	CreatedBy            int64      `db:"created_by" json:"created_by"`
 	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
 }
 
+// ImportLog records a successful import: who imported what and when
+type ImportLog struct {
+	ID        int64          `db:"id" json:"id"`
+	CompanyID int64          `db:"company_id" json:"company_id"`
+	UserID    int64          `db:"user_id" json:"user_id"`
+	Type      DataImportType `db:"type" json:"type"`
+	DataType  string         `db:"data_type" json:"data_type"`
+	Version   int            `db:"version" json:"version"`
+	Mode      ImportMode     `db:"mode" json:"mode"`
+	Filename  string         `db:"filename" json:"filename"`
+	RowCount  int            `db:"row_count" json:"row_count"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
 // SalesTaxesRoyalties returns the combined sales taxes + royalties (backward compatibility)
 func (f *FinancialData) SalesTaxesRoyalties() float64 {
 	return f.SalesTaxes + f.Royalties
@@ -3,7 +3,10 @@ package data
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,19 +16,30 @@ import (
 
 // Helper functions for parsing CSV
 
-// Helper functions for parsing CSV
+// ParseDateLayouts are the date layouts accepted in date columns, tried in order. ISO and
+// YYYY/MM/DD come first: the day-first 02/01/2006 is only tried when both fail, and the
+// month-first 01-02-2006 last, so an ambiguous date is never read month-first by accident.
+var ParseDateLayouts = []string{"2006-01-02", "2006/01/02", "02/01/2006", "01-02-2006"}
+
+// parseDateFormats lists ParseDateLayouts in the error message
+const parseDateFormats = "YYYY-MM-DD, YYYY/MM/DD, DD/MM/YYYY or MM-DD-YYYY"
+
+// GramsPerTroyOz converts grams of metal to troy ounces
+const GramsPerTroyOz = 31.1035
 
 func parseDate(value string) (time.Time, error) {
-	t, err := time.Parse("2006-01-02", value)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %s", value)
+	for _, layout := range ParseDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
 	}
-	return t, nil
+	return time.Time{}, fmt.Errorf("invalid date format, expected %s: %s", parseDateFormats, value)
 }
 
 // parseFloat robustly parses numeric values handling:
 // - Commas as thousand separators: "24,859" -> 24859
-// - Currency symbols: "$ 123.45" or "$123.45" -> 123.45
+// - Currency symbols and codes, as prefix or suffix: "$ 123.45", "AR$ 123.45", "123.45 USD" -> 123.45
+// - Decimal comma when the comma comes after the dots: "1.234,56" -> 1234.56
 // - Percentage signs: "94.01%" -> 94.01
 // - Negative values in parentheses: "(30,989)" -> -30989
 // - Whitespace: " 123 " -> 123
@@ -34,8 +48,7 @@ func parseFloat(value string, required bool) (float64, error) {
 	value = strings.TrimSpace(value)
 
 	// Check for empty/dash values (before any processing)
-	isEmpty := value == "" || value == "-" || value == "$ -" || value == "$ -   "
-	if isEmpty {
+	if IsBlankNumber(value) {
 		if required {
 			return 0, fmt.Errorf("required field cannot be empty or dash")
 		}
@@ -64,16 +77,19 @@ func parseFloat(value string, required bool) (float64, error) {
 		}
 	}
 
-	// Remove currency symbols ($, USD, etc.) - AFTER handling parentheses
-	value = strings.TrimPrefix(value, "$")
-	value = strings.TrimSpace(value)
-	value = strings.TrimPrefix(value, "USD")
-	value = strings.TrimSpace(value)
+	// Remove currency symbols and codes (AR$, USD, etc.) - AFTER handling parentheses
+	value = stripCurrencyAffixes(value)
 
 	// Remove percentage sign
 	value = strings.TrimSuffix(value, "%")
 	value = strings.TrimSpace(value)
 
+	// "1.234,56": dots are thousand separators and the comma is the decimal point
+	if lastComma := strings.LastIndex(value, ","); lastComma >= 0 && lastComma > strings.LastIndex(value, ".") && strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	}
+
 	// Remove commas (thousand separators)
 	value = strings.ReplaceAll(value, ",", "")
 
@@ -91,60 +107,250 @@ func parseFloat(value string, required bool) (float64, error) {
 	return f, nil
 }
 
-func readCSV(fileContent []byte, expectedHeaders []string) ([][]string, error) {
-	reader := csv.NewReader(bytes.NewReader(fileContent))
+// IsBlankNumber reports whether a number cell is an empty or zero placeholder: blank, or a
+// dash ("-", en dash or em dash) with or without a currency symbol and spaces ("$ -", "$-",
+// "$ —"), as accounting formats in spreadsheets write zero
+func IsBlankNumber(value string) bool {
+	switch stripCurrencyAffixes(strings.Join(strings.Fields(value), "")) {
+	case "", "-", "\u2013", "\u2014":
+		return true
+	}
+	return false
+}
+
+// stripCurrencyAffixes removes currency symbols and codes from both ends of a value.
+// Longer affixes are tried first so "AR$" is not left as "AR" after removing "$".
+func stripCurrencyAffixes(value string) string {
+	affixes := currencyAffixList()
+	for {
+		before := value
+		for _, affix := range affixes {
+			value = strings.TrimSpace(strings.TrimPrefix(value, affix))
+			value = strings.TrimSpace(strings.TrimSuffix(value, affix))
+		}
+		if value == before {
+			return value
+		}
+	}
+}
+
+// csvOptions customizes how an uploaded CSV is read and parsed
+type csvOptions struct {
+	// ColumnMap renames client headers to our expected headers (e.g. ERP exports):
+	// {"Fecha": "date", "Centro de Costo": "cost_center"}
+	ColumnMap map[string]string
+
+	// Adjust parses OPEX/CAPEX rows as adjustment deltas
+	Adjust bool
+
+	// DoreGradeBasis is the company's basis for deriving doré grades (defaults to oz)
+	DoreGradeBasis DoreGradeBasis
+
+	// GramsPerTroyOz converts PBR grams to doré ounces (defaults to GramsPerTroyOz)
+	GramsPerTroyOz float64
+
+	// AllowEmpty accepts a header-only file (no data rows) instead of rejecting it
+	AllowEmpty bool
 
-	records, err := reader.ReadAll()
+	// Format is the company's CSV format; rows are normalized to YYYY-MM-DD dates and "." decimals
+	Format FormatProfile
+
+	// ExpenseTypeMap maps client expense type synonyms (lower case) to canonical expense types,
+	// on top of DefaultExpenseTypeSynonyms
+	ExpenseTypeMap map[string]ExpenseType
+
+	// CostCenters are the company's OPEX cost centers (the default CostCenters when empty)
+	CostCenters []CostCenter
+
+	// Subcategories are the company's valid OPEX subcategories per cost center (any when empty)
+	Subcategories OPEXSubcategories
+}
+
+// utf8BOM is the byte order mark Excel on Windows writes at the start of "CSV UTF-8" files
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+// newCSVReader returns a CSV reader for the delimiter (comma when empty). A leading UTF-8
+// BOM is skipped so it does not end up in the first header.
+func newCSVReader(fileContent []byte, delimiter string) *csv.Reader {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(fileContent, utf8BOM)))
+	if delimiter != "" {
+		reader.Comma = []rune(delimiter)[0]
+	}
+	return reader
+}
+
+// readCSV reads the data rows of a file with the expected headers. Columns are matched by
+// header name, in any order: each row is returned in expectedHeaders order, followed by the
+// notes cell when the file has a notes column. Columns that are not expected are ignored.
+func readCSV(fileContent []byte, expectedHeaders []string, opts csvOptions) ([][]string, error) {
+	reader, err := openCSV(fileContent, expectedHeaders, opts)
 	if err != nil {
-		return nil, fmt.Errorf("error reading CSV: %w", err)
+		return nil, err
 	}
 
-	if len(records) < 2 {
+	var rows [][]string
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && !opts.AllowEmpty {
+		return nil, ErrInvalidCSVFormat
+	}
+	return rows, nil
+}
+
+// csvRowReader reads the data rows of a file one at a time, as readCSV returns them, so a
+// large file is never held as a whole in memory
+type csvRowReader struct {
+	reader  *csv.Reader
+	headers []string
+	columns []int // file column of each expected header, then of the notes column if any
+	format  FormatProfile
+}
+
+// openCSV reads the header row of a file and checks it has the expected headers (see readCSV)
+func openCSV(fileContent []byte, expectedHeaders []string, opts csvOptions) (*csvRowReader, error) {
+	opts.Format = opts.Format.sniffDelimiter(fileContent)
+	reader := newCSVReader(fileContent, opts.Format.Delimiter)
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
 		return nil, ErrInvalidCSVFormat
 	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV: %w", err)
+	}
+	// Rows are copied out in Next, so the reader can reuse its record
+	reader.ReuseRecord = true
 
-	headers := records[0]
+	headers := applyColumnMap(header, opts.ColumnMap)
+	colIndex := make(map[string]int, len(headers))
+	for i, header := range headers {
+		name := strings.TrimSpace(header)
+		first, seen := colIndex[name]
+		if !seen {
+			colIndex[name] = i
+			continue
+		}
+		// A repeated expected column is ambiguous; repeated extra columns are ignored anyway
+		if slices.Contains(expectedHeaders, name) || name == notesColumn {
+			return nil, fmt.Errorf("header mismatch: column '%s' appears twice (columns %d and %d)", name, first+1, i+1)
+		}
+	}
 
-	if len(headers) != len(expectedHeaders) {
-		return nil, fmt.Errorf("expected %d columns, got %d", len(expectedHeaders), len(headers))
+	var missing []string
+	for _, expected := range expectedHeaders {
+		if _, ok := colIndex[expected]; !ok {
+			missing = append(missing, expected)
+		}
+	}
+	if len(missing) > 0 {
+		if err := headerTypeMismatch(headers, expectedHeaders); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("header mismatch: missing column(s) '%s'", strings.Join(missing, "', '"))
 	}
 
+	columns := make([]int, len(expectedHeaders))
 	for i, expected := range expectedHeaders {
-		if strings.TrimSpace(headers[i]) != expected {
-			return nil, fmt.Errorf("header mismatch at column %d: expected '%s', got '%s'", i+1, expected, headers[i])
+		columns[i] = colIndex[expected]
+	}
+	if notesIdx, ok := colIndex[notesColumn]; ok {
+		columns = append(columns, notesIdx)
+	}
+
+	return &csvRowReader{reader: reader, headers: expectedHeaders, columns: columns, format: opts.Format}, nil
+}
+
+// Next returns the next data row, or io.EOF after the last one
+func (r *csvRowReader) Next() ([]string, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV: %w", err)
+	}
+
+	row := make([]string, len(r.columns))
+	for j, col := range r.columns {
+		if col < len(record) {
+			row[j] = record[col]
 		}
 	}
+	normalizeRecord(row, r.headers, r.format)
+	return row, nil
+}
+
+// notesColumn is an optional free-text column carried onto each imported row
+const notesColumn = "notes"
 
-	return records[1:], nil
+// splitNotes separates the optional trailing notes cell from a row of expectedColumns cells
+func splitNotes(row []string, expectedColumns int) ([]string, string) {
+	if len(row) == expectedColumns+1 {
+		return row[:expectedColumns], strings.TrimSpace(row[expectedColumns])
+	}
+	return row, ""
 }
 
-func validateRow(row []string, expectedColumns int, rowNum int) error {
-	if len(row) != expectedColumns {
-		return fmt.Errorf("row %d: expected %d columns, got %d", rowNum, expectedColumns, len(row))
+// applyColumnMap renames headers using the client mapping, leaving unmapped headers untouched
+func applyColumnMap(headers []string, columnMap map[string]string) []string {
+	if len(columnMap) == 0 {
+		return headers
 	}
-	return nil
+
+	mapped := make([]string, len(headers))
+	for i, header := range headers {
+		mapped[i] = header
+		if target, ok := columnMap[strings.TrimSpace(header)]; ok {
+			mapped[i] = target
+		}
+	}
+	return mapped
 }
 
-// readCSVForDates reads CSV and returns rows (without validation) - used to get dates before full parsing
-func readCSVForDates(fileContent []byte) ([][]string, error) {
-	reader := csv.NewReader(bytes.NewReader(fileContent))
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("error reading CSV: %w", err)
+// isHeaderOnly reports whether the file has the expected headers for the import type and no data rows
+func isHeaderOnly(fileContent []byte, importType DataImportType, opts csvOptions) bool {
+	headers, ok := importHeaders(importType)
+	if !ok {
+		return false
 	}
-	if len(records) < 2 {
-		return nil, ErrInvalidCSVFormat
+	layouts := [][]string{headers}
+	// Financial files may also use the legacy layout (see parseFinancialCSV)
+	if importType == ImportFinancial {
+		layouts = append(layouts, financialHeadersLegacy)
+	}
+	for _, layout := range layouts {
+		reader, err := openCSV(fileContent, layout, opts)
+		if err != nil {
+			continue
+		}
+		_, err = reader.Next()
+		return errors.Is(err, io.EOF)
 	}
-	// Return data rows (skip header)
-	return records[1:], nil
+	return false
+}
+
+func validateRow(row []string, expectedColumns int, rowNum int) error {
+	if len(row) != expectedColumns {
+		return fmt.Errorf("row %d: expected %d columns, got %d", rowNum, expectedColumns, len(row))
+	}
+	return nil
 }
 
 // Parsers for each data type
 
 var productionHeaders = []string{"date", "mineral_code", "quantity", "unit"}
 
-func parseProductionCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, mineralMap map[string]int) ([]*ProductionData, []ValidationError) {
-	rows, err := readCSV(fileContent, productionHeaders)
+func parseProductionCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, mineralMap map[string]int, opts csvOptions) ([]*ProductionData, []ValidationError) {
+	rows, err := readCSV(fileContent, productionHeaders, opts)
 	if err != nil {
 		return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 	}
@@ -154,6 +360,7 @@ func parseProductionCSV(fileContent []byte, companyID, userID int64, dataType st
 
 	for i, row := range rows {
 		rowNum := i + 2
+		row, notes := splitNotes(row, len(productionHeaders))
 
 		if err := validateRow(row, len(productionHeaders), rowNum); err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Error: err.Error()})
@@ -198,6 +405,7 @@ func parseProductionCSV(fileContent []byte, companyID, userID int64, dataType st
 			DataType:    dataType,
 			Version:     version,
 			Description: description,
+			Notes:       notes,
 			CreatedBy:   userID,
 		})
 	}
@@ -214,8 +422,8 @@ var doreHeaders = []string{
 
 // parseDoreCSV parses Dore CSV and calculates production from PBR data
 // PBR data is required to calculate dore_produced_oz, silver_grade_pct, and gold_grade_pct
-func parseDoreCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, pbrMap map[string]*PBRData) ([]*DoreData, []ValidationError) {
-	rows, err := readCSV(fileContent, doreHeaders)
+func parseDoreCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, pbrMap map[string]*PBRData, opts csvOptions) ([]*DoreData, []ValidationError) {
+	rows, err := readCSV(fileContent, doreHeaders, opts)
 	if err != nil {
 		return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 	}
@@ -223,8 +431,12 @@ func parseDoreCSV(fileContent []byte, companyID, userID int64, dataType string,
 	var records []*DoreData
 	var errors []ValidationError
 
+	// One doré row per date, like PBR: a repeated date would double-count the month
+	seenDates := make(map[string]int) // date -> row number
+
 	for i, row := range rows {
 		rowNum := i + 2
+		row, notes := splitNotes(row, len(doreHeaders))
 
 		if err := validateRow(row, len(doreHeaders), rowNum); err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Error: err.Error()})
@@ -237,8 +449,14 @@ func parseDoreCSV(fileContent []byte, companyID, userID int64, dataType string,
 			continue
 		}
 
-		// Get PBR data for this date
 		dateKey := date.Format("2006-01-02")
+		if firstRow, ok := seenDates[dateKey]; ok {
+			errors = append(errors, ValidationError{Row: rowNum, Column: "date", Error: fmt.Sprintf("duplicate date %s (already in row %d)", dateKey, firstRow)})
+			continue
+		}
+		seenDates[dateKey] = rowNum
+
+		// Get PBR data for this date
 		pbr, exists := pbrMap[dateKey]
 		if !exists {
 			errors = append(errors, ValidationError{
@@ -249,18 +467,22 @@ func parseDoreCSV(fileContent []byte, companyID, userID int64, dataType string,
 			continue
 		}
 
-		// Calculate production from PBR
-		// Formula: Feed Grade (g/t) * Tonnes Processed * Recovery Rate / 31.1035 (grams per oz)
-		silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / 31.1035
-		goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / 31.1035
+		// Calculate production from PBR, with the deployment's grams per troy ounce
+		// Formula: Feed Grade (g/t) * Tonnes Processed * Recovery Rate / grams per troy oz
+		gramsPerTroyOz := opts.GramsPerTroyOz
+		if gramsPerTroyOz <= 0 {
+			gramsPerTroyOz = GramsPerTroyOz
+		}
+		silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / gramsPerTroyOz
+		goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / gramsPerTroyOz
 		doreProducedOz := silverOz + goldOz
 
-		// Calculate grades
-		var silverGradePct, goldGradePct float64
-		if doreProducedOz > 0 {
-			silverGradePct = (silverOz / doreProducedOz) * 100
-			goldGradePct = (goldOz / doreProducedOz) * 100
+		// Calculate grades on the company's basis (ounce share unless configured otherwise)
+		gradeBasis := opts.DoreGradeBasis
+		if gradeBasis == "" {
+			gradeBasis = DoreGradeBasisOz
 		}
+		silverGradePct, goldGradePct := DoreGrades(silverOz, goldOz, gradeBasis)
 
 		// Parse remaining values from CSV (refining-specific data)
 		// Most Dore fields are required, streaming is optional (defaults to 0)
@@ -295,9 +517,11 @@ func parseDoreCSV(fileContent []byte, companyID, userID int64, dataType string,
 			TreatmentCharge:      values[8],      // treatment_charge
 			RefiningDeductionsAu: values[9],      // refining_deductions_au
 			Streaming:            values[10],     // streaming (can be negative)
+			GradeBasis:           string(gradeBasis),
 			DataType:             dataType,
 			Version:              version,
 			Description:          description,
+			Notes:                notes,
 			CreatedBy:            userID,
 		})
 	}
@@ -311,8 +535,8 @@ var pbrHeaders = []string{
 	"recovery_rate_silver_pct", "recovery_rate_gold_pct",
 }
 
-func parsePBRCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string) ([]*PBRData, []ValidationError) {
-	rows, err := readCSV(fileContent, pbrHeaders)
+func parsePBRCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, opts csvOptions) ([]*PBRData, []ValidationError) {
+	rows, err := readCSV(fileContent, pbrHeaders, opts)
 	if err != nil {
 		return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 	}
@@ -320,58 +544,100 @@ func parsePBRCSV(fileContent []byte, companyID, userID int64, dataType string, v
 	var records []*PBRData
 	var errors []ValidationError
 
+	parser := newPBRRowParser(companyID, userID, dataType, version, description)
 	for i, row := range rows {
-		rowNum := i + 2
-
-		if err := validateRow(row, len(pbrHeaders), rowNum); err != nil {
-			errors = append(errors, ValidationError{Row: rowNum, Error: err.Error()})
+		record, rowErrors := parser.parse(row, i+2)
+		if len(rowErrors) > 0 {
+			errors = append(errors, rowErrors...)
 			continue
 		}
+		records = append(records, record)
+	}
 
-		date, err := parseDate(row[0])
-		if err != nil {
-			errors = append(errors, ValidationError{Row: rowNum, Column: "date", Error: err.Error()})
-			continue
-		}
+	return records, errors
+}
 
-		// All PBR fields are required
-		values := make([]float64, 8)
-		for j := 1; j < 9; j++ {
-			values[j-1], err = parseFloat(row[j], true) // Required
-			if err != nil {
-				errors = append(errors, ValidationError{Row: rowNum, Column: pbrHeaders[j], Error: err.Error()})
-				break
-			}
+// pbrRowParser converts PBR rows to records one at a time, so a file can be parsed as a
+// whole (parsePBRCSV) or streamed (see pbrStream)
+type pbrRowParser struct {
+	companyID, userID int64
+	dataType          string
+	version           int
+	description       string
+
+	// Dore derivation looks up one PBR row per date, so dates must be unique
+	seenDates map[string]int // date -> row number
+}
+
+func newPBRRowParser(companyID, userID int64, dataType string, version int, description string) *pbrRowParser {
+	return &pbrRowParser{
+		companyID:   companyID,
+		userID:      userID,
+		dataType:    dataType,
+		version:     version,
+		description: description,
+		seenDates:   make(map[string]int),
+	}
+}
+
+// parse converts a row of the file (rowNum counts the header as row 1). Every bad column of
+// the row is reported, so a file can be fixed in one pass.
+func (p *pbrRowParser) parse(row []string, rowNum int) (*PBRData, []ValidationError) {
+	row, notes := splitNotes(row, len(pbrHeaders))
+
+	if err := validateRow(row, len(pbrHeaders), rowNum); err != nil {
+		return nil, []ValidationError{{Row: rowNum, Error: err.Error()}}
+	}
+
+	var errors []ValidationError
+
+	date, err := parseDate(row[0])
+	if err != nil {
+		errors = append(errors, ValidationError{Row: rowNum, Column: "date", Error: err.Error()})
+	} else {
+		dateKey := date.Format("2006-01-02")
+		if firstRow, ok := p.seenDates[dateKey]; ok {
+			errors = append(errors, ValidationError{Row: rowNum, Column: "date", Error: fmt.Sprintf("duplicate date %s (already in row %d)", dateKey, firstRow)})
+		} else {
+			p.seenDates[dateKey] = rowNum
 		}
+	}
+
+	// All PBR fields are required
+	values := make([]float64, 8)
+	for j := 1; j < 9; j++ {
+		values[j-1], err = parseFloat(row[j], true) // Required
 		if err != nil {
-			continue
+			errors = append(errors, ValidationError{Row: rowNum, Column: pbrHeaders[j], Error: err.Error()})
 		}
-
-		records = append(records, &PBRData{
-			CompanyID:             companyID,
-			Date:                  date,
-			OreMinedT:             values[0],
-			WasteMinedT:           values[1],
-			DevelopmentsM:         values[2],
-			TotalTonnesProcessed:  values[3],
-			FeedGradeSilverGpt:    values[4],
-			FeedGradeGoldGpt:      values[5],
-			RecoveryRateSilverPct: values[6],
-			RecoveryRateGoldPct:   values[7],
-			DataType:              dataType,
-			Version:               version,
-			Description:           description,
-			CreatedBy:             userID,
-		})
+	}
+	if len(errors) > 0 {
+		return nil, errors
 	}
 
-	return records, errors
+	return &PBRData{
+		CompanyID:             p.companyID,
+		Date:                  date,
+		OreMinedT:             values[0],
+		WasteMinedT:           values[1],
+		DevelopmentsM:         values[2],
+		TotalTonnesProcessed:  values[3],
+		FeedGradeSilverGpt:    values[4],
+		FeedGradeGoldGpt:      values[5],
+		RecoveryRateSilverPct: values[6],
+		RecoveryRateGoldPct:   values[7],
+		DataType:              p.dataType,
+		Version:               p.version,
+		Description:           p.description,
+		Notes:                 notes,
+		CreatedBy:             p.userID,
+	}, nil
 }
 
 var opexHeaders = []string{"date", "cost_center", "subcategory", "expense_type", "amount", "currency"}
 
-func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string) ([]*OPEXData, []ValidationError) {
-	rows, err := readCSV(fileContent, opexHeaders)
+func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, opts csvOptions) ([]*OPEXData, []ValidationError) {
+	rows, err := readCSV(fileContent, opexHeaders, opts)
 	if err != nil {
 		return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 	}
@@ -379,8 +645,14 @@ func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 	var records []*OPEXData
 	var errors []ValidationError
 
+	costCenters := opts.CostCenters
+	if len(costCenters) == 0 {
+		costCenters = CostCenters
+	}
+
 	for i, row := range rows {
 		rowNum := i + 2
+		row, notes := splitNotes(row, len(opexHeaders))
 
 		if err := validateRow(row, len(opexHeaders), rowNum); err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Error: err.Error()})
@@ -394,7 +666,7 @@ func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 		}
 
 		costCenter := CostCenter(strings.TrimSpace(row[1]))
-		if !costCenter.IsValid() {
+		if !slices.Contains(costCenters, costCenter) {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "cost_center", Error: fmt.Sprintf("invalid cost center: %s", row[1])})
 			continue
 		}
@@ -404,8 +676,13 @@ func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 			errors = append(errors, ValidationError{Row: rowNum, Column: "subcategory", Error: "subcategory is required"})
 			continue
 		}
+		subcategory, ok := opts.Subcategories.Normalize(costCenter, subcategory)
+		if !ok {
+			errors = append(errors, ValidationError{Row: rowNum, Column: "subcategory", Error: fmt.Sprintf("invalid subcategory for %s: %s", costCenter, subcategory)})
+			continue
+		}
 
-		expenseType := ExpenseType(strings.TrimSpace(row[3]))
+		expenseType := NormalizeExpenseType(row[3], opts.ExpenseTypeMap)
 		if !expenseType.IsValid() {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "expense_type", Error: fmt.Sprintf("invalid expense type: %s", row[3])})
 			continue
@@ -416,10 +693,7 @@ func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 			errors = append(errors, ValidationError{Row: rowNum, Column: "amount", Error: err.Error()})
 			continue
 		}
-		if amount < 0 {
-			errors = append(errors, ValidationError{Row: rowNum, Column: "amount", Error: "amount cannot be negative"})
-			continue
-		}
+		// Negative amounts are credit memos and accrual reversals, as in CAPEX
 
 		currency := Currency(strings.TrimSpace(row[5]))
 		if !currency.IsValid() {
@@ -428,17 +702,19 @@ func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 		}
 
 		records = append(records, &OPEXData{
-			CompanyID:   companyID,
-			Date:        date,
-			CostCenter:  string(costCenter),
-			Subcategory: subcategory,
-			ExpenseType: string(expenseType),
-			Amount:      amount,
-			Currency:    string(currency),
-			DataType:    dataType,
-			Version:     version,
-			Description: description,
-			CreatedBy:   userID,
+			CompanyID:    companyID,
+			Date:         date,
+			CostCenter:   string(costCenter),
+			Subcategory:  subcategory,
+			ExpenseType:  string(expenseType),
+			Amount:       amount,
+			Currency:     string(currency),
+			DataType:     dataType,
+			Version:      version,
+			Description:  description,
+			Notes:        notes,
+			IsAdjustment: opts.Adjust,
+			CreatedBy:    userID,
 		})
 	}
 
@@ -447,8 +723,8 @@ func parseOPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 
 var capexHeaders = []string{"date", "category", "car_number", "project_name", "type", "amount", "accretion_of_mine_closure_liability", "currency"}
 
-func parseCAPEXCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string) ([]*CAPEXData, []ValidationError) {
-	rows, err := readCSV(fileContent, capexHeaders)
+func parseCAPEXCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, opts csvOptions) ([]*CAPEXData, []ValidationError) {
+	rows, err := readCSV(fileContent, capexHeaders, opts)
 	if err != nil {
 		return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 	}
@@ -458,6 +734,7 @@ func parseCAPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 
 	for i, row := range rows {
 		rowNum := i + 2
+		row, notes := splitNotes(row, len(capexHeaders))
 
 		if err := validateRow(row, len(capexHeaders), rowNum); err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Error: err.Error()})
@@ -489,6 +766,11 @@ func parseCAPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 			errors = append(errors, ValidationError{Row: rowNum, Column: "type", Error: fmt.Sprintf("invalid type: %s", row[4])})
 			continue
 		}
+		// Lease payments are only told apart from lease additions on leasing rows
+		if IsLeaseCashOutflow(category) && capexType != CapexLeasing {
+			errors = append(errors, ValidationError{Row: rowNum, Column: "type", Error: fmt.Sprintf("category %s is a lease cash outflow: type must be leasing, got %s", category, capexType)})
+			continue
+		}
 
 		amount, err := parseFloat(row[5], true) // Required
 		if err != nil {
@@ -530,6 +812,8 @@ func parseCAPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 			DataType:                        dataType,
 			Version:                         version,
 			Description:                     description,
+			Notes:                           notes,
+			IsAdjustment:                    opts.Adjust,
 			CreatedBy:                       userID,
 		})
 	}
@@ -539,8 +823,8 @@ func parseCAPEXCSV(fileContent []byte, companyID, userID int64, dataType string,
 
 var revenueHeaders = []string{"date", "mineral_code", "quantity_sold", "unit_price", "currency"}
 
-func parseRevenueCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, mineralMap map[string]int) ([]*RevenueData, []ValidationError) {
-	rows, err := readCSV(fileContent, revenueHeaders)
+func parseRevenueCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, mineralMap map[string]int, opts csvOptions) ([]*RevenueData, []ValidationError) {
+	rows, err := readCSV(fileContent, revenueHeaders, opts)
 	if err != nil {
 		return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 	}
@@ -550,48 +834,47 @@ func parseRevenueCSV(fileContent []byte, companyID, userID int64, dataType strin
 
 	for i, row := range rows {
 		rowNum := i + 2
+		row, notes := splitNotes(row, len(revenueHeaders))
 
 		if err := validateRow(row, len(revenueHeaders), rowNum); err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Error: err.Error()})
 			continue
 		}
 
+		// Every bad column of the row is reported, so a file can be fixed in one pass
+		rowErrors := len(errors)
+
 		date, err := parseDate(row[0])
 		if err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "date", Error: err.Error()})
-			continue
 		}
 
 		mineralCode := strings.TrimSpace(row[1])
 		mineralID, exists := mineralMap[mineralCode]
 		if !exists {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "mineral_code", Error: fmt.Sprintf("mineral not found: %s", mineralCode)})
-			continue
 		}
 
 		quantitySold, err := parseFloat(row[2], true) // Required
 		if err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "quantity_sold", Error: err.Error()})
-			continue
-		}
-		if quantitySold <= 0 {
+		} else if quantitySold <= 0 {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "quantity_sold", Error: "must be greater than 0"})
-			continue
 		}
 
 		unitPrice, err := parseFloat(row[3], true) // Required
 		if err != nil {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "unit_price", Error: err.Error()})
-			continue
-		}
-		if unitPrice <= 0 {
+		} else if unitPrice <= 0 {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "unit_price", Error: "must be greater than 0"})
-			continue
 		}
 
 		currency := Currency(strings.TrimSpace(row[4]))
 		if !currency.IsValid() {
 			errors = append(errors, ValidationError{Row: rowNum, Column: "currency", Error: fmt.Sprintf("invalid currency: %s", row[4])})
+		}
+
+		if len(errors) > rowErrors {
 			continue
 		}
 
@@ -605,6 +888,7 @@ func parseRevenueCSV(fileContent []byte, companyID, userID int64, dataType strin
 			DataType:     dataType,
 			Version:      version,
 			Description:  description,
+			Notes:        notes,
 			CreatedBy:    userID,
 		})
 	}
@@ -618,13 +902,13 @@ var financialHeaders = []string{"date", "shipping_selling", "sales_taxes", "roya
 // Legacy format: combined sales_taxes_royalties (backward compatibility)
 var financialHeadersLegacy = []string{"date", "shipping_selling", "sales_taxes_royalties", "other_adjustments"}
 
-func parseFinancialCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string) ([]*FinancialData, []ValidationError) {
+func parseFinancialCSV(fileContent []byte, companyID, userID int64, dataType string, version int, description string, opts csvOptions) ([]*FinancialData, []ValidationError) {
 	// Try new format first, fall back to legacy format
-	rows, err := readCSV(fileContent, financialHeaders)
+	rows, err := readCSV(fileContent, financialHeaders, opts)
 	useLegacy := false
 	if err != nil {
 		// Try legacy format with combined sales_taxes_royalties
-		rows, err = readCSV(fileContent, financialHeadersLegacy)
+		rows, err = readCSV(fileContent, financialHeadersLegacy, opts)
 		if err != nil {
 			return nil, []ValidationError{{Row: 0, Error: err.Error()}}
 		}
@@ -636,6 +920,11 @@ func parseFinancialCSV(fileContent []byte, companyID, userID int64, dataType str
 
 	for i, row := range rows {
 		rowNum := i + 2
+		expectedColumns := len(financialHeaders)
+		if useLegacy {
+			expectedColumns = len(financialHeadersLegacy)
+		}
+		row, notes := splitNotes(row, expectedColumns)
 
 		if useLegacy {
 			if err := validateRow(row, len(financialHeadersLegacy), rowNum); err != nil {
@@ -718,6 +1007,7 @@ func parseFinancialCSV(fileContent []byte, companyID, userID int64, dataType str
 			DataType:             dataType,
 			Version:              version,
 			Description:          description,
+			Notes:                notes,
 			CreatedBy:            userID,
 		})
 	}
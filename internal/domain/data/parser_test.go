@@ -1,10 +1,13 @@
 package data
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseProductionCSV_Success(t *testing.T) {
@@ -13,7 +16,7 @@ func TestParseProductionCSV_Success(t *testing.T) {
 		"2024-01-16,AG,2300,kilograms",
 	})
 
-	records, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap())
+	records, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{})
 
 	assert.Empty(t, errors)
 	assert.Len(t, records, 2)
@@ -28,7 +31,7 @@ func TestParseProductionCSV_InvalidMineralCode(t *testing.T) {
 		"2024-01-15,XYZ,150.5,kilograms",
 	})
 
-	_, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap())
+	_, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{})
 
 	assert.Len(t, errors, 1)
 	assert.Equal(t, 2, errors[0].Row)
@@ -40,7 +43,7 @@ func TestParseProductionCSV_InvalidQuantity(t *testing.T) {
 		"2024-01-15,AU,-10,kilograms",
 	})
 
-	_, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap())
+	_, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{})
 
 	assert.Len(t, errors, 1)
 	assert.Contains(t, errors[0].Error, "must be greater than 0")
@@ -49,18 +52,57 @@ func TestParseProductionCSV_InvalidQuantity(t *testing.T) {
 func TestParseProductionCSV_InvalidHeaders(t *testing.T) {
 	csvContent := []byte("date,wrong_header,quantity,unit\n2024-01-15,AU,150.5,kilograms")
 
-	_, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap())
+	_, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{})
 
 	assert.Len(t, errors, 1)
 	assert.Contains(t, errors[0].Error, "header mismatch")
 }
 
+func TestParsePBRCSV_OPEXFileDeclaredAsPBR(t *testing.T) {
+	csvContent := buildOPEXCSV([]string{validOPEXRow})
+
+	_, errors := parsePBRCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "this looks like an OPEX file but type=pbr", errors[0].Error)
+}
+
+func TestFilenameTypeWarning(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		importType DataImportType
+		warn       bool
+	}{
+		{"matching type", "opex_2024-01.csv", ImportOPEX, false},
+		{"mismatched type", "opex.csv", ImportPBR, true},
+		{"no type in name", "january.csv", ImportPBR, false},
+		{"type as substring only", "capex_opex.csv", ImportOPEX, false},
+		{"wrong extension", "pbr.txt", ImportPBR, true},
+		{"xlsx extension", "pbr_2024.xlsx", ImportPBR, false},
+		{"no filename", "", ImportPBR, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning := filenameTypeWarning(tt.filename, tt.importType)
+			if tt.warn {
+				assert.NotEmpty(t, warning)
+			} else {
+				assert.Empty(t, warning)
+			}
+		})
+	}
+
+	assert.Equal(t, "filename 'opex.csv' looks like an OPEX file but type=pbr", filenameTypeWarning("opex.csv", ImportPBR))
+}
+
 func TestParsePBRCSV_Success(t *testing.T) {
 	csvContent := buildPBRCSV([]string{
 		validPBRRow,
 	})
 
-	records, errors := parsePBRCSV(csvContent, testCompanyID, testUserID, "budget", testVersion, testDescription)
+	records, errors := parsePBRCSV(csvContent, testCompanyID, testUserID, "budget", testVersion, testDescription, csvOptions{})
 
 	assert.Empty(t, errors)
 	assert.Len(t, records, 1)
@@ -68,13 +110,181 @@ func TestParsePBRCSV_Success(t *testing.T) {
 	assert.Equal(t, "budget", records[0].DataType)
 }
 
+func TestParsePBRCSV_DuplicateDate(t *testing.T) {
+	csvContent := buildPBRCSV([]string{
+		validPBRRow,
+		validPBRRow,
+	})
+
+	records, errors := parsePBRCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+
+	assert.Len(t, records, 1)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, 3, errors[0].Row)
+	assert.Equal(t, "date", errors[0].Column)
+	assert.Contains(t, errors[0].Error, "duplicate date")
+}
+
+func TestParsePBRCSV_ReportsEveryBadColumn(t *testing.T) {
+	csvContent := buildPBRCSV([]string{
+		"2024-01-15,abc,262591,,35951,209.79,x7.35,94.01,95.36",
+		"2024-02-15,24859,262591,598,35951,209.79,7.35,94.01,95.36",
+	})
+
+	records, errors := parsePBRCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+
+	require.Len(t, records, 1)
+	require.Len(t, errors, 3)
+	for i, column := range []string{"ore_mined_t", "developments_m", "feed_grade_gold_gpt"} {
+		assert.Equal(t, 2, errors[i].Row)
+		assert.Equal(t, column, errors[i].Column)
+	}
+}
+
+func TestParseRevenueCSV_ReportsEveryBadColumn(t *testing.T) {
+	csvContent := []byte("date,mineral_code,quantity_sold,unit_price,currency\n2024-01-15,XX,0,abc,USD\n")
+
+	records, errors := parseRevenueCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{})
+
+	assert.Empty(t, records)
+	require.Len(t, errors, 3)
+	assert.Equal(t, "mineral_code", errors[0].Column)
+	assert.Equal(t, "quantity_sold", errors[1].Column)
+	assert.Equal(t, "must be greater than 0", errors[1].Error)
+	assert.Equal(t, "unit_price", errors[2].Column)
+}
+
+func TestParseDoreCSV_DuplicateDate(t *testing.T) {
+	pbrRecords, errors := parsePBRCSV(buildPBRCSV([]string{validPBRRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Empty(t, errors)
+	pbrMap := map[string]*PBRData{"2024-01-15": pbrRecords[0]}
+
+	records, errors := parseDoreCSV(buildDoreCSV([]string{validDoreRow, validDoreRow, validDoreRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, pbrMap, csvOptions{})
+
+	assert.Len(t, records, 1, "the first occurrence is kept")
+	require.Len(t, errors, 2)
+	for i, err := range errors {
+		assert.Equal(t, i+3, err.Row)
+		assert.Equal(t, "date", err.Column)
+		assert.Equal(t, "duplicate date 2024-01-15 (already in row 2)", err.Error)
+	}
+}
+
+func TestParseDoreCSV_GradeBasis(t *testing.T) {
+	pbrRecords, errors := parsePBRCSV(buildPBRCSV([]string{validPBRRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	assert.Empty(t, errors)
+	pbrMap := map[string]*PBRData{"2024-01-15": pbrRecords[0]}
+	csvContent := buildDoreCSV([]string{validDoreRow})
+
+	// Ounce share (default): ~227,961 oz Ag and ~8,101 oz Au -> 96.57% / 3.43%
+	byOz, errors := parseDoreCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, pbrMap, csvOptions{})
+	assert.Empty(t, errors)
+	assert.InDelta(t, 96.568, byOz[0].SilverGradePct, 0.001)
+	assert.InDelta(t, 3.432, byOz[0].GoldGradePct, 0.001)
+	assert.Equal(t, "oz", byOz[0].GradeBasis)
+
+	// Atomic share: each ounce divided by its atomic weight (Ag 107.8682, Au 196.96657) -> 98.09% / 1.91%
+	byAtom, errors := parseDoreCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, pbrMap, csvOptions{DoreGradeBasis: DoreGradeBasisAtomic})
+	assert.Empty(t, errors)
+	assert.InDelta(t, 98.091, byAtom[0].SilverGradePct, 0.001)
+	assert.InDelta(t, 1.909, byAtom[0].GoldGradePct, 0.001)
+	assert.Equal(t, "atomic", byAtom[0].GradeBasis)
+
+	// Both bases recover the same contained ounces
+	silverOz, goldOz := byOz[0].MetalOz()
+	atomSilverOz, atomGoldOz := byAtom[0].MetalOz()
+	assert.InDelta(t, silverOz, atomSilverOz, 0.001)
+	assert.InDelta(t, goldOz, atomGoldOz, 0.001)
+	assert.Equal(t, byOz[0].DoreProducedOz, byAtom[0].DoreProducedOz)
+}
+
+func TestParseDoreCSV_GramsPerTroyOz(t *testing.T) {
+	pbrRecords, errors := parsePBRCSV(buildPBRCSV([]string{validPBRRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	assert.Empty(t, errors)
+	pbrMap := map[string]*PBRData{"2024-01-15": pbrRecords[0]}
+	csvContent := buildDoreCSV([]string{validDoreRow})
+
+	byDefault, errors := parseDoreCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, pbrMap, csvOptions{})
+	assert.Empty(t, errors)
+	configured, errors := parseDoreCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, pbrMap, csvOptions{GramsPerTroyOz: 31.103477})
+	assert.Empty(t, errors)
+
+	// The configured constant gives slightly more ounces than the default 31.1035
+	assert.InDelta(t, byDefault[0].DoreProducedOz*GramsPerTroyOz/31.103477, configured[0].DoreProducedOz, 1e-6)
+	assert.Greater(t, configured[0].DoreProducedOz, byDefault[0].DoreProducedOz)
+}
+
+func TestParsePBRCSV_ColumnsMatchedByHeader(t *testing.T) {
+	// Site export: columns in another order, an extra column and notes in the middle
+	csvContent := []byte("feed_grade_gold_gpt,date,site,ore_mined_t,notes,waste_mined_t,developments_m,recovery_rate_gold_pct,total_tonnes_processed,recovery_rate_silver_pct,feed_grade_silver_gpt\n" +
+		"0.85,2024-01-15,North,24859,reprocessed,262591,598,95.36,35951,94.01,209.79\n")
+
+	records, errors := parsePBRCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Empty(t, errors)
+	require.Len(t, records, 1)
+
+	expected, errors := parsePBRCSV(buildPBRCSV([]string{"2024-01-15,24859,262591,598,35951,209.79,0.85,94.01,95.36"}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Empty(t, errors)
+	expected[0].Notes = "reprocessed"
+	assert.Equal(t, expected[0], records[0])
+}
+
+func TestParsePBRCSV_MissingAndDuplicateColumns(t *testing.T) {
+	_, errors := parsePBRCSV([]byte("date,ore_mined_t\n2024-01-15,24859\n"), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Error, "missing column(s) 'waste_mined_t', 'developments_m'")
+
+	csvContent := []byte(strings.Replace(string(buildPBRCSV([]string{validPBRRow + ",1"})), "recovery_rate_gold_pct\n", "recovery_rate_gold_pct,ore_mined_t\n", 1))
+	_, errors = parsePBRCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Error, "column 'ore_mined_t' appears twice (columns 2 and 10)")
+}
+
+func TestImportData_XLSXMatchesCSV(t *testing.T) {
+	ctx := context.Background()
+	header := []any{}
+	for _, name := range pbrHeaders {
+		header = append(header, name)
+	}
+	file := buildXLSX([][]any{
+		header,
+		// Same values as validPBRRow, with the date stored as an Excel date serial
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 24859.0, 262591.0, 598.0, 35951.0, 209.79, 7.35, 94.01, 95.36},
+	})
+
+	rows, err := readXLSXRows(file)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, strings.Split(validPBRRow, ","), rows[1])
+
+	fromCSV, errs := parsePBRCSV(buildPBRCSV([]string{validPBRRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Empty(t, errs)
+
+	repo := &softDeleteRepository{}
+	response, err := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportData(ctx, &ImportRequest{
+		Type:      ImportPBR,
+		DataType:  "actual",
+		CompanyID: testCompanyID,
+		Version:   testVersion,
+		File:      file,
+		Filename:  "pbr_2024-01.xlsx",
+	}, testUserID)
+	require.NoError(t, err)
+	require.True(t, response.Success, response.Errors)
+	assert.Empty(t, response.Warnings)
+	require.Len(t, repo.pbr, 1)
+	assert.Equal(t, fromCSV[0].Date, repo.pbr[0].Date)
+	assert.Equal(t, fromCSV[0].FeedGradeSilverGpt, repo.pbr[0].FeedGradeSilverGpt)
+	assert.Equal(t, fromCSV[0].TotalTonnesProcessed, repo.pbr[0].TotalTonnesProcessed)
+}
+
 func TestParseOPEXCSV_Success(t *testing.T) {
 	csvContent := buildOPEXCSV([]string{
 		validOPEXRow,
 		"2024-01-15,Processing,CO General Operating,Materials,20000,USD",
 	})
 
-	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription)
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
 
 	assert.Empty(t, errors)
 	assert.Len(t, records, 2)
@@ -82,23 +292,187 @@ func TestParseOPEXCSV_Success(t *testing.T) {
 	assert.Equal(t, 50000.0, records[0].Amount)
 }
 
+func TestParseOPEXCSV_ColumnMap(t *testing.T) {
+	// ERP export with its own header names
+	csvContent := []byte("Fecha,Centro de Costo,Subcategoria,Tipo de Gasto,Importe,Moneda\n" + validOPEXRow + "\n")
+	opts := csvOptions{ColumnMap: map[string]string{
+		"Fecha":           "date",
+		"Centro de Costo": "cost_center",
+		"Subcategoria":    "subcategory",
+		"Tipo de Gasto":   "expense_type",
+		"Importe":         "amount",
+		"Moneda":          "currency",
+	}}
+
+	_, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Error, "header mismatch")
+
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, opts)
+	assert.Empty(t, errors)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "Mine", records[0].CostCenter)
+	assert.Equal(t, 50000.0, records[0].Amount)
+}
+
+func TestParseOPEXCSV_ExpenseTypeSynonyms(t *testing.T) {
+	csvContent := buildOPEXCSV([]string{
+		"2024-01-15,Processing,CO General Operating,Consumables,20000,USD",
+		"2024-01-15,Mine,Drilling,Perforistas,15000,USD",
+		"2024-01-15,Mine,Drilling,labour,5000,USD",
+	})
+
+	// Built-in synonyms and case-insensitive canonical names; unknown names are still rejected
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Len(t, errors, 1)
+	assert.Equal(t, "expense_type", errors[0].Column)
+	require.Len(t, records, 2)
+	assert.Equal(t, "Materials", records[0].ExpenseType)
+	assert.Equal(t, "Labour", records[1].ExpenseType)
+
+	// A client map adds its own ledger names
+	synonyms, err := ParseExpenseTypeMap(map[string]string{"Perforistas": "Third Party"})
+	require.NoError(t, err)
+	records, errors = parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{ExpenseTypeMap: synonyms})
+	assert.Empty(t, errors)
+	require.Len(t, records, 3)
+	assert.Equal(t, "Third Party", records[1].ExpenseType)
+
+	_, err = ParseExpenseTypeMap(map[string]string{"Perforistas": "Drilling"})
+	assert.Error(t, err)
+}
+
+func TestParseOPEXCSV_AdjustMode(t *testing.T) {
+	csvContent := buildOPEXCSV([]string{
+		"2024-01-15,Mine,Drilling,Labour,-5000,USD",
+	})
+
+	// Negative amounts are accepted for absolute rows (credits and reversals)
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	assert.Empty(t, errors)
+	require.Len(t, records, 1)
+	assert.Equal(t, -5000.0, records[0].Amount)
+	assert.False(t, records[0].IsAdjustment)
+
+	// Adjustment deltas are flagged
+	records, errors = parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{Adjust: true})
+	assert.Empty(t, errors)
+	assert.Len(t, records, 1)
+	assert.Equal(t, -5000.0, records[0].Amount)
+	assert.True(t, records[0].IsAdjustment)
+
+	assert.True(t, ImportOPEX.SupportsMode(ImportModeAdjust))
+	assert.True(t, ImportCAPEX.SupportsMode(ImportModeAdjust))
+	assert.False(t, ImportPBR.SupportsMode(ImportModeAdjust))
+}
+
+func TestParseOPEXCSV_EmptyFile(t *testing.T) {
+	csvContent := buildOPEXCSV(nil) // Header only
+
+	// Default: a header-only file is rejected
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	assert.Empty(t, records)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, ErrInvalidCSVFormat.Error(), errors[0].Error)
+
+	// allow_empty: zero rows and no errors
+	records, errors = parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{AllowEmpty: true})
+	assert.Empty(t, errors)
+	assert.Empty(t, records)
+	assert.True(t, isHeaderOnly(csvContent, ImportOPEX, csvOptions{}))
+
+	// Headers are still checked, and files with rows are not "empty"
+	assert.False(t, isHeaderOnly(buildPBRCSV(nil), ImportOPEX, csvOptions{}))
+	assert.False(t, isHeaderOnly(buildOPEXCSV([]string{validOPEXRow}), ImportOPEX, csvOptions{}))
+
+	// Financial files in the legacy layout (combined sales_taxes_royalties) too
+	legacyFinancial := "date,shipping_selling,sales_taxes_royalties,other_adjustments\n"
+	assert.True(t, isHeaderOnly([]byte(legacyFinancial), ImportFinancial, csvOptions{}))
+	assert.False(t, isHeaderOnly([]byte(legacyFinancial+"2024-01-31,-202,-150,0\n"), ImportFinancial, csvOptions{}))
+}
+
 func TestParseOPEXCSV_InvalidCostCenter(t *testing.T) {
 	csvContent := buildOPEXCSV([]string{
 		"2024-01-15,InvalidCenter,Drilling,Labour,50000,USD",
 	})
 
-	_, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription)
+	_, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
 
 	assert.Len(t, errors, 1)
 	assert.Contains(t, errors[0].Error, "invalid cost center")
 }
 
+func TestParseOPEXCSV_Subcategories(t *testing.T) {
+	csvContent := buildOPEXCSV([]string{
+		"2024-01-15,Mine,drilling,Labour,50000,USD",
+		"2024-01-15,Mine,Stockpile/WIP,Other,1740162,USD",
+		"2024-01-15,Processing,Inventory Variation,Other,1000,USD",
+		"2024-01-15,Mine,Drillng,Labour,100,USD",
+		"2024-01-15,G&A,Anything Goes,Labour,200,USD",
+	})
+	opts := csvOptions{Subcategories: ParseOPEXSubcategories(`{"Mine": ["Drilling", "Blasting"], "Processing": ["Crushing"]}`)}
+
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, opts)
+
+	// The allow-list spelling is kept, inventory variation aliases are canonical in any
+	// cost center, and cost centers without a list accept any subcategory
+	require.Len(t, records, 4)
+	assert.Equal(t, "Drilling", records[0].Subcategory)
+	assert.Equal(t, SubcategoryInventoryVariations, records[1].Subcategory)
+	assert.Equal(t, SubcategoryInventoryVariations, records[2].Subcategory)
+	assert.Equal(t, "Anything Goes", records[3].Subcategory)
+	require.Len(t, errors, 1)
+	assert.Equal(t, 5, errors[0].Row)
+	assert.Equal(t, "subcategory", errors[0].Column)
+	assert.Contains(t, errors[0].Error, "invalid subcategory for Mine: Drillng")
+
+	// Without a configured allow-list any subcategory is valid, aliases are still normalized
+	records, errors = parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	assert.Empty(t, errors)
+	require.Len(t, records, 5)
+	assert.Equal(t, SubcategoryInventoryVariations, records[1].Subcategory)
+	assert.Equal(t, "Drillng", records[3].Subcategory)
+	assert.Empty(t, ParseOPEXSubcategories(""))
+}
+
+func TestParseOPEXCSV_CompanyCostCenters(t *testing.T) {
+	csvContent := buildOPEXCSV([]string{
+		"2024-01-15,Exploration,Brownfield Drilling,Third Party,80000,USD",
+	})
+	opts := csvOptions{CostCenters: ParseCostCenters("Mine, Processing,G&A,Transport & Shipping,Exploration")}
+
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, opts)
+
+	assert.Empty(t, errors)
+	assert.Equal(t, "Exploration", records[0].CostCenter)
+
+	// Only the configured ones: Mine is not valid for a company without it
+	_, errors = parseOPEXCSV(buildOPEXCSV([]string{validOPEXRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{CostCenters: []CostCenter{"Exploration"}})
+	assert.Len(t, errors, 1)
+	assert.Equal(t, CostCenters, ParseCostCenters(""))
+}
+
+func TestParseCAPEXCSV_LeaseCashOutflowMustBeLeasing(t *testing.T) {
+	csvContent := buildCAPEXCSV([]string{
+		"2024-01-15,Sustaining Capital Lease Cash Outflows,,,leasing,25000,0,USD",
+		"2024-01-15,IFRS16,,,sustaining,5000,0,USD",
+	})
+
+	records, errors := parseCAPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+
+	assert.Len(t, records, 1)
+	require.Len(t, errors, 1)
+	assert.Equal(t, 3, errors[0].Row)
+	assert.Equal(t, "type", errors[0].Column)
+	assert.Contains(t, errors[0].Error, "lease cash outflow")
+}
+
 func TestParseFinancialCSV_Success(t *testing.T) {
 	csvContent := buildFinancialCSV([]string{
 		validFinancialRow,
 	})
 
-	records, validationErrors := parseFinancialCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription)
+	records, validationErrors := parseFinancialCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
 
 	assert.Empty(t, validationErrors)
 	assert.Len(t, records, 1)
@@ -117,10 +491,20 @@ func TestParseDateFormat(t *testing.T) {
 	assert.Equal(t, time.Month(1), date.Month())
 	assert.Equal(t, 15, date.Day())
 
+	// Other accepted layouts, ISO and YYYY/MM/DD before day-first
+	for _, value := range []string{"2024/01/15", "15/01/2024", "01-15-2024"} {
+		date, err = parseDate(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), date, value)
+	}
+	date, err = parseDate("03/04/2024")
+	assert.NoError(t, err)
+	assert.Equal(t, time.April, date.Month(), "slashed dates are day-first")
+
 	// Invalid date format
-	_, err = parseDate("15/01/2024")
+	_, err = parseDate("Jan 15 2024")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid date format")
+	assert.Contains(t, err.Error(), "invalid date format, expected YYYY-MM-DD, YYYY/MM/DD, DD/MM/YYYY or MM-DD-YYYY")
 
 	// Invalid date
 	_, err = parseDate("2024-13-45")
@@ -151,6 +535,14 @@ func TestParseFloat_RobustParsing(t *testing.T) {
 		{"dollar with comma", "$ 24,859", 24859.0, false},
 		{"dollar negative", "$ -123", -123.0, false},
 
+		// Currency codes and prefixed symbols
+		{"ARS code prefix", "ARS 1,234.56", 1234.56, false},
+		{"AR$ with decimal comma", "AR$ 1.234,56", 1234.56, false},
+		{"US$ prefix", "US$ 500", 500.0, false},
+		{"trailing USD", "1,234.56 USD", 1234.56, false},
+		{"symbol and trailing code", "$ 1,234.56 USD", 1234.56, false},
+		{"parentheses with AR$", "AR$ (1.234,56)", -1234.56, false},
+
 		// Percentages
 		{"percentage", "94.01%", 94.01, false},
 		{"percentage with space", "94.01 %", 94.01, false},
@@ -223,6 +615,45 @@ func TestParseFloat_Required(t *testing.T) {
 	}
 }
 
+func TestIsBlankNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		blank bool
+	}{
+		{"", true},
+		{"   ", true},
+		{"-", true},
+		{" - ", true},
+		{"$ -", true},
+		{"$ -   ", true},
+		{"$-", true},
+		{" $-  ", true},
+		{"\u2014", true},   // Em dash
+		{"$ \u2014", true}, // Em dash with currency
+		{"\u2013", true},   // En dash
+		{"AR$ -", true},
+		{"0", false},
+		{"-5", false},
+		{"$ -5", false},
+		{"$ (202)", false},
+		{"--", false},
+		{"abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.blank, IsBlankNumber(tt.input))
+			if tt.blank {
+				value, err := parseFloat(tt.input, false)
+				assert.NoError(t, err)
+				assert.Zero(t, value)
+				_, err = parseFloat(tt.input, true)
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
 // Test parsing with real Summary.csv format examples
 func TestParseFloat_SummaryCSVExamples(t *testing.T) {
 	examples := map[string]float64{
@@ -247,3 +678,144 @@ func TestParseFloat_SummaryCSVExamples(t *testing.T) {
 		})
 	}
 }
+
+func TestGetImportSchema_OPEX(t *testing.T) {
+	schema, err := GetImportSchema(ImportOPEX)
+	assert.NoError(t, err)
+	assert.Equal(t, ImportOPEX, schema.Type)
+	assert.Len(t, schema.Columns, len(opexHeaders))
+
+	var costCenter *ColumnSchema
+	for i := range schema.Columns {
+		if schema.Columns[i].Name == "cost_center" {
+			costCenter = &schema.Columns[i]
+		}
+	}
+
+	if assert.NotNil(t, costCenter) {
+		assert.True(t, costCenter.Required)
+		assert.Equal(t, ColumnEnum, costCenter.Type)
+		assert.Equal(t, []string{"Mine", "Processing", "G&A", "Transport & Shipping"}, costCenter.EnumValues)
+	}
+
+	assert.Equal(t, ColumnDate, schema.Columns[0].Type)
+	assert.Equal(t, ColumnNumber, schema.Columns[4].Type) // amount
+}
+
+func TestGetImportSchema_InvalidType(t *testing.T) {
+	_, err := GetImportSchema(DataImportType("unknown"))
+	assert.ErrorIs(t, err, ErrInvalidDataType)
+}
+
+func TestResolveFormatProfile_SavedProfileAppliedToLaterImport(t *testing.T) {
+	// First import: semicolon file with decimal commas and dd/mm/yyyy dates is detected
+	first := []byte("date;mineral_code;quantity;unit\n15/01/2024;AU;1.150,5;kilograms\n")
+	detected, certain := resolveFormatProfile(nil, FormatProfile{}, first, ImportProduction)
+	assert.True(t, certain)
+	assert.Equal(t, FormatProfile{Delimiter: ";", DecimalSeparator: ",", DateLayout: "02/01/2006"}, detected)
+
+	// Later import without format params: detection alone would read "2.300" as 2.3
+	later := []byte("date;mineral_code;quantity;unit\n01/02/2024;AG;2.300;kilograms\n")
+	detectedLater, certain := detectFormatProfile(later, numberColumns(ImportProduction))
+	assert.Equal(t, ".", detectedLater.DecimalSeparator)
+	assert.False(t, certain, "2.300 may have a thousands dot")
+	profile, _ := resolveFormatProfile(&detected, FormatProfile{}, later, ImportProduction)
+	assert.Equal(t, detected, profile)
+
+	records, errors := parseProductionCSV(later, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{Format: profile})
+	assert.Empty(t, errors)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), records[0].Date)
+		assert.Equal(t, 2300.0, records[0].Quantity)
+	}
+
+	// Explicit params still override the saved profile field by field
+	profile, _ = resolveFormatProfile(&detected, FormatProfile{DateLayout: "01/02/2006"}, later, ImportProduction)
+	assert.Equal(t, "01/02/2006", profile.DateLayout)
+	assert.Equal(t, ";", profile.Delimiter)
+}
+
+func TestDetectFormatProfile_SamplesNumberColumns(t *testing.T) {
+	header := strings.Join(capexHeaders, ",")
+
+	// A decimal comma in a text column says nothing about the amounts
+	file := []byte(header + "\n2024-01-15,Plant,,\"Phase 1,2\",Project,1234.56,0,USD\n")
+	profile, certain := detectFormatProfile(file, numberColumns(ImportCAPEX))
+	assert.Equal(t, ".", profile.DecimalSeparator)
+	assert.True(t, certain)
+
+	file = []byte(header + "\n2024-01-15,Plant,,Phase 2,Project,\"1.234,56\",0,USD\n")
+	profile, certain = detectFormatProfile(file, numberColumns(ImportCAPEX))
+	assert.Equal(t, ",", profile.DecimalSeparator)
+	assert.True(t, certain)
+
+	// Amounts that disagree keep the default and are not certain
+	file = []byte(header + "\n2024-01-15,Plant,,Phase 2,Project,\"1.234,56\",0,USD\n2024-01-16,Plant,,Phase 2,Project,99.5,0,USD\n")
+	profile, certain = detectFormatProfile(file, numberColumns(ImportCAPEX))
+	assert.Equal(t, ".", profile.DecimalSeparator)
+	assert.False(t, certain)
+
+	// Whole numbers do not tell the separator either
+	file = []byte(header + "\n2024-01-15,Plant,,Phase 2,Project,1234,0,USD\n")
+	_, certain = detectFormatProfile(file, numberColumns(ImportCAPEX))
+	assert.False(t, certain)
+}
+
+func TestParseProductionCSV_DecimalCommaProfile(t *testing.T) {
+	csvContent := []byte("date;mineral_code;quantity;unit\n2024-01-15;AU;1.150,5;kilograms\n")
+
+	records, errors := parseProductionCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, getTestMineralMap(), csvOptions{Format: FormatProfile{Delimiter: ";", DecimalSeparator: ","}})
+
+	assert.Empty(t, errors)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, 1150.5, records[0].Quantity)
+	}
+}
+
+func TestParseOPEXCSV_NumberFormats(t *testing.T) {
+	header := "date,cost_center,subcategory,expense_type,amount,currency\n"
+	files := map[NumberFormat]string{
+		NumberFormatUS: `2024-01-15,Mine,Drilling,Labour,"1,234,567.89",USD` + "\n" + `2024-01-15,G&A,Office,Other,"1,234",USD` + "\n",
+		NumberFormatES: `2024-01-15,Mine,Drilling,Labour,"1.234.567,89",USD` + "\n" + `2024-01-15,G&A,Office,Other,1.234,USD` + "\n",
+	}
+
+	for numberFormat, rows := range files {
+		t.Run(string(numberFormat), func(t *testing.T) {
+			separator, ok := numberFormat.DecimalSeparator()
+			require.True(t, ok)
+			format := DefaultFormatProfile.withOverrides(FormatProfile{DecimalSeparator: separator})
+
+			records, errors := parseOPEXCSV([]byte(header+rows), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{Format: format})
+			require.Empty(t, errors)
+			require.Len(t, records, 2)
+			assert.Equal(t, 1234567.89, records[0].Amount)
+			assert.Equal(t, 1234.0, records[1].Amount)
+		})
+	}
+
+	// Read as US, the ES thousands are decimals
+	records, errors := parseOPEXCSV([]byte(header+files[NumberFormatES]), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{Format: DefaultFormatProfile})
+	require.Empty(t, errors)
+	assert.Equal(t, 1.234, records[1].Amount)
+}
+
+func TestParseOPEXCSV_SemicolonDelimiterSniffed(t *testing.T) {
+	// European export read with the company's comma profile: the header line decides
+	csvContent := []byte("date;cost_center;subcategory;expense_type;amount;currency\n" +
+		"2024-01-15;Mine;Drilling;Labour;50000,5;USD\n" +
+		"2024-01-15;Processing;Reagents;Materials;1.234.567,89;USD\n" +
+		"2024-01-15;G&A;Office;Other;750;USD\n")
+
+	records, errors := parseOPEXCSV(csvContent, testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{Format: DefaultFormatProfile})
+	require.Empty(t, errors)
+	require.Len(t, records, 3)
+	assert.Equal(t, 50000.5, records[0].Amount)
+	assert.Equal(t, 1234567.89, records[1].Amount)
+	assert.Equal(t, 750.0, records[2].Amount)
+
+	// A comma file with a semicolon in a quoted cell keeps the comma delimiter
+	records, errors = parseOPEXCSV([]byte("date,cost_center,subcategory,expense_type,amount,currency\n2024-01-15,Mine,\"Drilling; core\",Labour,\"50,000\",USD\n"),
+		testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{Format: DefaultFormatProfile})
+	require.Empty(t, errors)
+	assert.Equal(t, 50000.0, records[0].Amount)
+}
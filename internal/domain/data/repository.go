@@ -2,48 +2,102 @@ package data
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 )
 
+// pgUniqueViolation is the Postgres error code of a unique violation
+const pgUniqueViolation = "23505"
+
 type Repository interface {
+	// Insert*Bulk insert an import in one transaction. With replace, the active rows in the
+	// months of the records (same company, data type and version) are soft-deleted first,
+	// in the same transaction.
+
 	// Production
-	InsertProductionBulk(ctx context.Context, records []*ProductionData) error
+	InsertProductionBulk(ctx context.Context, records []*ProductionData, replace bool, log *ImportLog) error
 	ListPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*PBRData, error)
 	SoftDeletePBRData(ctx context.Context, id int64) error
 
 	// Dore
-	InsertDoreBulk(ctx context.Context, records []*DoreData) error
+	InsertDoreBulk(ctx context.Context, records []*DoreData, replace bool, log *ImportLog) error
 	ListDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*DoreData, error)
 	SoftDeleteDoreData(ctx context.Context, id int64) error
+	GetDoreGradeBasis(ctx context.Context, companyID int64) (DoreGradeBasis, error)
 
 	// PBR
-	InsertPBRBulk(ctx context.Context, records []*PBRData) error
-	GetPBRByDate(ctx context.Context, companyID int64, date time.Time, dataType string, version int) (*PBRData, error)
+	InsertPBRBulk(ctx context.Context, records []*PBRData, replace bool, log *ImportLog) error
+	InsertPBRStream(ctx context.Context, replace bool, log *ImportLog, next func() ([]*PBRData, error)) error
+	ListPBRDates(ctx context.Context, companyID int64, from, to time.Time, dataType string, version int) ([]time.Time, error)
 
 	// OPEX
-	InsertOPEXBulk(ctx context.Context, records []*OPEXData) error
+	InsertOPEXBulk(ctx context.Context, records []*OPEXData, replace bool, log *ImportLog) error
 	ListOPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*OPEXData, error)
 	SoftDeleteOPEXData(ctx context.Context, id int64) error
 
 	// CAPEX
-	InsertCAPEXBulk(ctx context.Context, records []*CAPEXData) error
+	InsertCAPEXBulk(ctx context.Context, records []*CAPEXData, replace bool, log *ImportLog) error
 	ListCAPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*CAPEXData, error)
 	SoftDeleteCAPEXData(ctx context.Context, id int64) error
 
 	// Revenue
-	InsertRevenueBulk(ctx context.Context, records []*RevenueData) error
+	InsertRevenueBulk(ctx context.Context, records []*RevenueData, replace bool, log *ImportLog) error
 
 	// Financial
-	InsertFinancialBulk(ctx context.Context, records []*FinancialData) error
+	InsertFinancialBulk(ctx context.Context, records []*FinancialData, replace bool, log *ImportLog) error
 	ListFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*FinancialData, error)
 	SoftDeleteFinancialData(ctx context.Context, id int64) error
 
 	// Helpers
 	GetMineralCodeMap(ctx context.Context) (map[string]int, error)
 	CompanyExists(ctx context.Context, companyID int64) (bool, error)
+
+	GetZeroRowChecks(ctx context.Context, companyID int64) (map[DataImportType]ZeroRowCheck, error)
+	GetCostCenters(ctx context.Context, companyID int64) ([]CostCenter, error)
+	GetOPEXSubcategories(ctx context.Context, companyID int64) (OPEXSubcategories, error)
+	GetCurrencyRates(ctx context.Context, currencies []Currency) (CurrencyRates, error)
+
+	// CSV format profile
+	GetFormatProfile(ctx context.Context, companyID int64) (*FormatProfile, error)
+	SaveFormatProfile(ctx context.Context, companyID int64, profile FormatProfile) error
+
+	// Import history
+	ListImportLog(ctx context.Context, companyID int64, year int) ([]*ImportLog, error)
+
+	// Soft deletes every active row of a company/month/data type/version of a data table
+	SoftDeleteByScope(ctx context.Context, table string, companyID int64, year, month int, dataType string, version int) (int64, error)
+
+	// Budget versions
+	ListBudgetVersions(ctx context.Context, companyID int64, year int) ([]int, error)
+	ArchiveBudgetVersions(ctx context.Context, companyID int64, year int, versions []int) (int64, error)
+
+	// Retention: rows moved to the <table>_archive tables
+	GetRetentionYears(ctx context.Context, companyID int64) (int, error)
+	ArchiveExpiredData(ctx context.Context, companyID int64, cutoff *time.Time) (int64, error)
+	ListArchivedPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*PBRData, error)
+	ListArchivedDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*DoreData, error)
+	ListArchivedOPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*OPEXData, error)
+	ListArchivedCAPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*CAPEXData, error)
+	ListArchivedFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*FinancialData, error)
+
+	// InTransaction runs fn in one transaction, committed if fn returns nil and rolled back
+	// otherwise. Repository calls made with the ctx passed to fn run in it: the Insert*
+	// methods join it instead of committing on their own, and reads see its rows.
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Idempotency keys of imports, per user. Claim in the transaction of the import: a
+	// concurrent claim of the same key waits for it and then gets its stored response.
+	ClaimIdempotencyKey(ctx context.Context, userID int64, key, fingerprint string, ttl time.Duration) (stored *ImportResponse, claimed bool, err error)
+	SaveIdempotencyKeyResponse(ctx context.Context, userID int64, key string, response *ImportResponse) error
 }
 
 type repository struct {
@@ -54,104 +108,378 @@ func NewRepository(db *sqlx.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) InsertProductionBulk(ctx context.Context, records []*ProductionData) error {
-	if len(records) == 0 {
+// txKey is the context key of the transaction of InTransaction
+type txKey struct{}
+
+func (r *repository) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn returns the transaction of an InTransaction in ctx, or the database
+func (r *repository) conn(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// txn is the transaction of a write: its own, or the one of an InTransaction in ctx,
+// which it joins without committing or rolling it back
+type txn struct {
+	*sqlx.Tx
+	joined bool
+}
+
+func (t *txn) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *txn) Rollback() error {
+	if t.joined {
 		return nil
 	}
+	return t.Tx.Rollback()
+}
 
+// beginTx starts the transaction of a write, or joins the one of an InTransaction in ctx
+func (r *repository) beginTx(ctx context.Context) (*txn, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return &txn{Tx: tx, joined: true}, nil
+	}
 	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx}, nil
+}
+
+// insertBatchSize is the number of rows per multi-row INSERT. Postgres caps a statement at
+// 65535 parameters: 500 rows of the widest insert (pbr_data, 30 columns) use 15000.
+const insertBatchSize = 500
+
+// insertBatches inserts records with multi-row INSERT statements of up to insertBatchSize
+// rows each. values returns a record's column values, in columns order.
+func insertBatches[T any](ctx context.Context, tx sqlx.ExtContext, table string, columns []string, records []T, values func(T) []any) error {
+	for start := 0; start < len(records); start += insertBatchSize {
+		query, args := multiRowInsert(table, columns, records[start:min(start+insertBatchSize, len(records))], values)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// multiRowInsert builds "INSERT INTO table (columns) VALUES ($1, ...), ($n+1, ...)" and its
+// arguments for records
+func multiRowInsert[T any](table string, columns []string, records []T, values func(T) []any) (string, []any) {
+	var query strings.Builder
+	query.WriteString("INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES ")
+	args := make([]any, 0, len(records)*len(columns))
+	for i, record := range records {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteByte('(')
+		for j := range columns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteByte('$')
+			query.WriteString(strconv.Itoa(len(args) + j + 1))
+		}
+		query.WriteByte(')')
+		args = append(args, values(record)...)
+	}
+	return query.String(), args
+}
+
+// productionColumns are the production_data columns InsertProductionBulk writes, in insert order
+var productionColumns = []string{
+	"company_id", "date", "mineral_id", "quantity", "unit", "data_type", "version", "description", "notes", "created_by",
+}
+
+// doreColumns are the dore_data columns InsertDoreBulk writes, in insert order
+var doreColumns = []string{
+	"company_id", "date", "dore_produced_oz", "silver_grade_pct", "gold_grade_pct",
+	"pbr_price_silver", "pbr_price_gold", "realized_price_silver", "realized_price_gold",
+	"silver_adjustment_oz", "gold_adjustment_oz", "ag_deductions_pct", "au_deductions_pct",
+	"treatment_charge", "refining_deductions_au", "streaming", "grade_basis", "data_type", "version", "description", "notes", "created_by",
+}
+
+// pbrColumns are the pbr_data columns InsertPBRBulk writes, in insert order
+var pbrColumns = []string{
+	"company_id", "date",
+	"open_pit_ore_t", "underground_ore_t", "ore_mined_t",
+	"waste_mined_t", "stripping_ratio",
+	"mining_grade_silver_gpt", "mining_grade_gold_gpt",
+	"open_pit_grade_silver_gpt", "underground_grade_silver_gpt",
+	"open_pit_grade_gold_gpt", "underground_grade_gold_gpt",
+	"primary_development_m", "secondary_development_opex_m", "expansionary_development_m", "developments_m",
+	"total_tonnes_processed", "feed_grade_silver_gpt", "feed_grade_gold_gpt",
+	"recovery_rate_silver_pct", "recovery_rate_gold_pct",
+	"full_time_employees", "contractors", "total_headcount",
+	"data_type", "version", "description", "notes", "created_by",
+}
+
+// opexColumns are the opex_data columns InsertOPEXBulk writes, in insert order
+var opexColumns = []string{
+	"company_id", "date", "cost_center", "subcategory", "expense_type", "amount", "currency", "original_amount", "original_currency", "exchange_rate", "data_type", "version", "description", "notes", "is_adjustment", "created_by",
+}
+
+// capexColumns are the capex_data columns InsertCAPEXBulk writes, in insert order
+var capexColumns = []string{
+	"company_id", "date", "category", "car_number", "project_name", "type", "amount", "accretion_of_mine_closure_liability", "currency", "original_amount", "original_currency", "exchange_rate", "data_type", "version", "description", "notes", "is_adjustment", "created_by",
+}
+
+// revenueColumns are the revenue_data columns InsertRevenueBulk writes, in insert order
+var revenueColumns = []string{
+	"company_id", "date", "mineral_id", "quantity_sold", "unit_price", "currency", "data_type", "version", "description", "notes", "created_by",
+}
+
+// financialColumns are the financial_data columns InsertFinancialBulk writes, in insert order
+var financialColumns = []string{
+	"company_id", "date", "shipping_selling", "sales_taxes", "royalties", "other_sales_deductions", "other_adjustments", "currency", "data_type", "version", "description", "notes", "created_by",
+}
+
+// financialValues are the values of a record for financialColumns. Sales taxes, royalties
+// and other sales deductions are stored in their own columns; the combined
+// sales_taxes_royalties column was dropped by migration 001.
+func financialValues(record *FinancialData) []any {
+	return []any{
+		record.CompanyID, record.Date, record.ShippingSelling,
+		record.SalesTaxes, record.Royalties, record.OtherSalesDeductions,
+		record.OtherAdjustments,
+		record.Currency, record.DataType, record.Version, record.Description, record.Notes, record.CreatedBy,
+	}
+}
+
+// softDeleteMonths soft-deletes the active rows of table in the months of dates for a
+// company, data type and version: the scope an import with mode=replace replaces
+func softDeleteMonths(ctx context.Context, tx sqlx.ExtContext, table string, companyID int64, dataType string, version int, dates []time.Time) error {
+	var months []string
+	for _, date := range dates {
+		if month := date.Format("2006-01"); !slices.Contains(months, month) {
+			months = append(months, month)
+		}
+	}
+
+	query := `UPDATE ` + table + ` SET deleted_at = CURRENT_TIMESTAMP
+		WHERE company_id = $1 AND data_type = $2 AND version = $3
+		      AND to_char(date, 'YYYY-MM') = ANY($4) AND deleted_at IS NULL`
+	_, err := tx.ExecContext(ctx, query, companyID, dataType, version, months)
+	return err
+}
+
+// insertImportLog records an import in the transaction of its rows, so a failed insert
+// leaves no entry. A nil log records nothing.
+func insertImportLog(ctx context.Context, tx sqlx.ExtContext, log *ImportLog) error {
+	if log == nil {
+		return nil
+	}
+	query := `INSERT INTO import_log (company_id, user_id, type, data_type, version, mode, filename, row_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+	return tx.QueryRowxContext(ctx, query,
+		log.CompanyID, log.UserID, log.Type, log.DataType, log.Version, log.Mode, log.Filename, log.RowCount,
+	).Scan(&log.ID, &log.CreatedAt)
+}
+
+func (r *repository) InsertProductionBulk(ctx context.Context, records []*ProductionData, replace bool, log *ImportLog) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO production_data (company_id, date, mineral_id, quantity, unit, data_type, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "production_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+			return err
+		}
+	}
 
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
+	err = insertBatches(ctx, tx, "production_data", productionColumns, records, func(record *ProductionData) []any {
+		return []any{
 			record.CompanyID,
 			record.Date,
 			record.MineralID,
 			record.Quantity,
 			record.Unit,
 			record.DataType,
+			record.Version,
+			record.Description,
+			record.Notes,
 			record.CreatedBy,
-		)
-		if err != nil {
-			return err
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *repository) InsertDoreBulk(ctx context.Context, records []*DoreData) error {
+func (r *repository) InsertDoreBulk(ctx context.Context, records []*DoreData, replace bool, log *ImportLog) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO dore_data (
-			company_id, date, dore_produced_oz, silver_grade_pct, gold_grade_pct,
-			pbr_price_silver, pbr_price_gold, realized_price_silver, realized_price_gold,
-			silver_adjustment_oz, gold_adjustment_oz, ag_deductions_pct, au_deductions_pct,
-			treatment_charge, refining_deductions_au, streaming, data_type, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
-	`
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "dore_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+			return err
+		}
+	}
 
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
+	err = insertBatches(ctx, tx, "dore_data", doreColumns, records, func(record *DoreData) []any {
+		return []any{
 			record.CompanyID, record.Date, record.DoreProducedOz, record.SilverGradePct, record.GoldGradePct,
 			record.PBRPriceSilver, record.PBRPriceGold, record.RealizedPriceSilver, record.RealizedPriceGold,
 			record.SilverAdjustmentOz, record.GoldAdjustmentOz, record.AgDeductionsPct, record.AuDeductionsPct,
-			record.TreatmentCharge, record.RefiningDeductionsAu, record.Streaming, record.DataType, record.CreatedBy,
-		)
-		if err != nil {
-			return err
+			record.TreatmentCharge, record.RefiningDeductionsAu, record.Streaming, record.GradeBasis, record.DataType, record.Version, record.Description, record.Notes, record.CreatedBy,
+		}
+	})
+	if err != nil {
+		// Unique (company, date, data_type, version) among active rows
+		if isUniqueViolation(err, "idx_dore_data_unique_date") {
+			return ErrDuplicateDoreDate
 		}
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *repository) InsertPBRBulk(ctx context.Context, records []*PBRData) error {
+func (r *repository) InsertPBRBulk(ctx context.Context, records []*PBRData, replace bool, log *ImportLog) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO pbr_data (
-			company_id, date,
-			open_pit_ore_t, underground_ore_t, ore_mined_t,
-			waste_mined_t, stripping_ratio,
-			mining_grade_silver_gpt, mining_grade_gold_gpt,
-			open_pit_grade_silver_gpt, underground_grade_silver_gpt,
-			open_pit_grade_gold_gpt, underground_grade_gold_gpt,
-			primary_development_m, secondary_development_opex_m, expansionary_development_m, developments_m,
-			total_tonnes_processed, feed_grade_silver_gpt, feed_grade_gold_gpt,
-			recovery_rate_silver_pct, recovery_rate_gold_pct,
-			full_time_employees, contractors, total_headcount,
-			data_type, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
-	`
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "pbr_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+			return err
+		}
+	}
+
+	if err := insertPBRRecords(ctx, tx, records); err != nil {
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertPBRStream inserts the records next hands out, chunk by chunk, in a single
+// transaction, so a large import never holds all its records at once. next returns no
+// records once the import is read; an error from next rolls the whole import back. A
+// replace import soft-deletes the active rows of a month before its first chunk is
+// inserted, never the rows this import already inserted.
+func (r *repository) InsertPBRStream(ctx context.Context, replace bool, log *ImportLog, next func() ([]*PBRData, error)) error {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	replaced := make(map[string]bool) // months already soft-deleted
+	inserted := 0
+	for {
+		records, err := next()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		if replace {
+			var dates []time.Time
+			for _, record := range records {
+				if month := record.Date.Format("2006-01"); !replaced[month] {
+					replaced[month] = true
+					dates = append(dates, record.Date)
+				}
+			}
+			if len(dates) > 0 {
+				if err := softDeleteMonths(ctx, tx, "pbr_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := insertPBRRecords(ctx, tx, records); err != nil {
+			return err
+		}
+		inserted += len(records)
+	}
+	if inserted == 0 {
+		return nil
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
+// insertPBRRecords inserts PBR records in the transaction of their import
+func insertPBRRecords(ctx context.Context, tx sqlx.ExtContext, records []*PBRData) error {
+	err := insertBatches(ctx, tx, "pbr_data", pbrColumns, records, func(record *PBRData) []any {
+		return []any{
 			record.CompanyID, record.Date,
 			record.OpenPitOreT, record.UndergroundOreT, record.OreMinedT,
 			record.WasteMinedT, record.StrippingRatio,
@@ -162,131 +490,168 @@ func (r *repository) InsertPBRBulk(ctx context.Context, records []*PBRData) erro
 			record.TotalTonnesProcessed, record.FeedGradeSilverGpt, record.FeedGradeGoldGpt,
 			record.RecoveryRateSilverPct, record.RecoveryRateGoldPct,
 			record.FullTimeEmployees, record.Contractors, record.TotalHeadcount,
-			record.DataType, record.CreatedBy,
-		)
-		if err != nil {
-			return err
+			record.DataType, record.Version, record.Description, record.Notes, record.CreatedBy,
+		}
+	})
+	if err != nil {
+		// Unique (company, date, data_type, version) among active rows
+		if isUniqueViolation(err, "idx_pbr_data_unique_date") {
+			return ErrDuplicatePBRDate
 		}
+		return err
 	}
+	return nil
+}
 
-	return tx.Commit()
+// isUniqueViolation reports whether err is a violation of the unique index or constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraint
 }
 
-func (r *repository) InsertOPEXBulk(ctx context.Context, records []*OPEXData) error {
+func (r *repository) InsertOPEXBulk(ctx context.Context, records []*OPEXData, replace bool, log *ImportLog) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO opex_data (company_id, date, cost_center, subcategory, expense_type, amount, currency, data_type, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "opex_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+			return err
+		}
+	}
 
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
+	err = insertBatches(ctx, tx, "opex_data", opexColumns, records, func(record *OPEXData) []any {
+		return []any{
 			record.CompanyID, record.Date, record.CostCenter, record.Subcategory,
-			record.ExpenseType, record.Amount, record.Currency, record.DataType, record.CreatedBy,
-		)
-		if err != nil {
-			return err
+			record.ExpenseType, record.Amount, record.Currency, record.OriginalAmount, record.OriginalCurrency, record.ExchangeRate, record.DataType, record.Version, record.Description, record.Notes, record.IsAdjustment, record.CreatedBy,
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *repository) InsertCAPEXBulk(ctx context.Context, records []*CAPEXData) error {
+func (r *repository) InsertCAPEXBulk(ctx context.Context, records []*CAPEXData, replace bool, log *ImportLog) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO capex_data (company_id, date, category, car_number, project_name, type, amount, accretion_of_mine_closure_liability, currency, data_type, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "capex_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+			return err
+		}
+	}
 
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
+	err = insertBatches(ctx, tx, "capex_data", capexColumns, records, func(record *CAPEXData) []any {
+		return []any{
 			record.CompanyID, record.Date, record.Category, record.CARNumber,
-			record.ProjectName, record.Type, record.Amount, record.AccretionOfMineClosureLiability, record.Currency, record.DataType, record.CreatedBy,
-		)
-		if err != nil {
-			return err
+			record.ProjectName, record.Type, record.Amount, record.AccretionOfMineClosureLiability, record.Currency, record.OriginalAmount, record.OriginalCurrency, record.ExchangeRate, record.DataType, record.Version, record.Description, record.Notes, record.IsAdjustment, record.CreatedBy,
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *repository) InsertRevenueBulk(ctx context.Context, records []*RevenueData) error {
+func (r *repository) InsertRevenueBulk(ctx context.Context, records []*RevenueData, replace bool, log *ImportLog) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO revenue_data (company_id, date, mineral_id, quantity_sold, unit_price, currency, data_type, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "revenue_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
+			return err
+		}
+	}
 
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
+	err = insertBatches(ctx, tx, "revenue_data", revenueColumns, records, func(record *RevenueData) []any {
+		return []any{
 			record.CompanyID, record.Date, record.MineralID,
-			record.QuantitySold, record.UnitPrice, record.Currency, record.DataType, record.CreatedBy,
-		)
-		if err != nil {
-			return err
+			record.QuantitySold, record.UnitPrice, record.Currency, record.DataType, record.Version, record.Description, record.Notes, record.CreatedBy,
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *repository) InsertFinancialBulk(ctx context.Context, records []*FinancialData) error {
+func (r *repository) InsertFinancialBulk(ctx context.Context, records []*FinancialData, replace bool, log *ImportLog) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO financial_data (company_id, date, shipping_selling, sales_taxes, royalties, other_sales_deductions, other_adjustments, currency, data_type, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`
-
-	for _, record := range records {
-		_, err = tx.ExecContext(ctx, query,
-			record.CompanyID, record.Date, record.ShippingSelling,
-			record.SalesTaxes, record.Royalties, record.OtherSalesDeductions,
-			record.OtherAdjustments,
-			record.Currency, record.DataType, record.CreatedBy,
-		)
-		if err != nil {
+	if replace {
+		dates := make([]time.Time, len(records))
+		for i, record := range records {
+			dates[i] = record.Date
+		}
+		if err := softDeleteMonths(ctx, tx, "financial_data", records[0].CompanyID, records[0].DataType, records[0].Version, dates); err != nil {
 			return err
 		}
 	}
 
+	if err := insertBatches(ctx, tx, "financial_data", financialColumns, records, financialValues); err != nil {
+		return err
+	}
+
+	if err := insertImportLog(ctx, tx, log); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -297,7 +662,7 @@ func (r *repository) GetMineralCodeMap(ctx context.Context) (map[string]int, err
 	}
 
 	query := `SELECT id, code FROM minerals WHERE active = true`
-	err := r.db.SelectContext(ctx, &minerals, query)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &minerals, query)
 	if err != nil {
 		return nil, err
 	}
@@ -313,12 +678,53 @@ func (r *repository) GetMineralCodeMap(ctx context.Context) (map[string]int, err
 func (r *repository) CompanyExists(ctx context.Context, companyID int64) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM mining_companies WHERE id = $1 AND active = true)`
-	err := r.db.GetContext(ctx, &exists, query, companyID)
+	err := sqlx.GetContext(ctx, r.conn(ctx), &exists, query, companyID)
 	return exists, err
 }
 
+// GetFormatProfile returns the company's saved CSV format profile, or nil when none was recorded yet
+func (r *repository) GetFormatProfile(ctx context.Context, companyID int64) (*FormatProfile, error) {
+	var profile FormatProfile
+	query := `
+		SELECT delimiter, decimal_separator, date_layout, currency_symbol
+		FROM company_import_formats
+		WHERE company_id = $1
+	`
+
+	err := sqlx.GetContext(ctx, r.conn(ctx), &profile, query, companyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveFormatProfile records the company's CSV format profile. An existing profile is kept:
+// only the first successful import records it.
+func (r *repository) SaveFormatProfile(ctx context.Context, companyID int64, profile FormatProfile) error {
+	query := `
+		INSERT INTO company_import_formats (company_id, delimiter, decimal_separator, date_layout, currency_symbol)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (company_id) DO NOTHING
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query, companyID, profile.Delimiter, profile.DecimalSeparator, profile.DateLayout, profile.CurrencySymbol)
+	return err
+}
+
 // List PBR Data
 func (r *repository) ListPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*PBRData, error) {
+	return r.listPBRData(ctx, "pbr_data", companyID, year, dataType, version)
+}
+
+// ListArchivedPBRData lists PBR rows moved to the archive by the retention policy
+func (r *repository) ListArchivedPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*PBRData, error) {
+	return r.listPBRData(ctx, "pbr_data_archive", companyID, year, dataType, version)
+}
+
+func (r *repository) listPBRData(ctx context.Context, table string, companyID int64, year int, dataType string, version int) ([]*PBRData, error) {
 	var records []*PBRData
 
 	query := `
@@ -332,20 +738,20 @@ func (r *repository) ListPBRData(ctx context.Context, companyID int64, year int,
 		       total_tonnes_processed, feed_grade_silver_gpt, feed_grade_gold_gpt,
 		       recovery_rate_silver_pct, recovery_rate_gold_pct,
 		       full_time_employees, contractors, total_headcount,
-		       data_type, version, description, created_by, created_at
-		FROM pbr_data
+		       data_type, version, description, notes, created_by, created_at
+		FROM ` + table + `
 		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = $3 
 		      AND version = $4 AND deleted_at IS NULL
 		ORDER BY date
 	`
 
-	err := r.db.SelectContext(ctx, &records, query, companyID, year, dataType, version)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &records, query, companyID, year, dataType, version)
 	return records, err
 }
 
 func (r *repository) SoftDeletePBRData(ctx context.Context, id int64) error {
 	query := `UPDATE pbr_data SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -361,57 +767,135 @@ func (r *repository) SoftDeletePBRData(ctx context.Context, id int64) error {
 	return nil
 }
 
-// GetPBRByDate gets PBR data for a specific date
-func (r *repository) GetPBRByDate(ctx context.Context, companyID int64, date time.Time, dataType string, version int) (*PBRData, error) {
-	var record PBRData
-
-	// Get the year from the date
-	year := date.Year()
-
+// ListPBRDates returns the dates from from to to (inclusive) with active PBR data
+func (r *repository) ListPBRDates(ctx context.Context, companyID int64, from, to time.Time, dataType string, version int) ([]time.Time, error) {
+	dates := []time.Time{}
 	query := `
-		SELECT id, company_id, date, ore_mined_t, waste_mined_t, developments_m,
-		       total_tonnes_processed, feed_grade_silver_gpt, feed_grade_gold_gpt,
-		       recovery_rate_silver_pct, recovery_rate_gold_pct, data_type, version,
-		       description, created_by, created_at
+		SELECT date
 		FROM pbr_data
-		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = $3 
-		      AND version = $4 AND deleted_at IS NULL
-		      AND date = $5
+		WHERE company_id = $1 AND data_type = $2 AND version = $3 AND deleted_at IS NULL
+		      AND date BETWEEN $4 AND $5
 		ORDER BY date
-		LIMIT 1
 	`
 
-	err := r.db.GetContext(ctx, &record, query, companyID, year, dataType, version, date)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &dates, query, companyID, dataType, version, from, to)
+	return dates, err
+}
+
+// GetDoreGradeBasis returns the company's doré grade basis (oz when not configured)
+func (r *repository) GetDoreGradeBasis(ctx context.Context, companyID int64) (DoreGradeBasis, error) {
+	var basis string
+	query := `SELECT COALESCE(dore_grade_basis, 'oz') FROM company_settings WHERE company_id = $1`
+
+	err := sqlx.GetContext(ctx, r.conn(ctx), &basis, query, companyID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DoreGradeBasisOz, nil
+		}
+		return "", err
+	}
+
+	if !DoreGradeBasis(basis).IsValid() {
+		return DoreGradeBasisOz, nil
+	}
+	return DoreGradeBasis(basis), nil
+}
+
+// GetZeroRowChecks returns the company's all-zero row check per import type (none when not configured)
+func (r *repository) GetZeroRowChecks(ctx context.Context, companyID int64) (map[DataImportType]ZeroRowCheck, error) {
+	var raw string
+	query := `SELECT COALESCE(zero_row_checks, '') FROM company_settings WHERE company_id = $1`
+
+	err := sqlx.GetContext(ctx, r.conn(ctx), &raw, query, companyID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, err
 	}
+	return ParseZeroRowChecks(raw), nil
+}
 
-	return &record, nil
+// GetCostCenters returns the company's OPEX cost centers (the default CostCenters when not configured)
+func (r *repository) GetCostCenters(ctx context.Context, companyID int64) ([]CostCenter, error) {
+	var raw string
+	query := `SELECT COALESCE(cost_centers, '') FROM company_settings WHERE company_id = $1`
+
+	err := sqlx.GetContext(ctx, r.conn(ctx), &raw, query, companyID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return ParseCostCenters(raw), nil
+}
+
+// GetOPEXSubcategories returns the company's valid OPEX subcategories per cost center
+// (none when not configured: any subcategory is accepted)
+func (r *repository) GetOPEXSubcategories(ctx context.Context, companyID int64) (OPEXSubcategories, error) {
+	var raw string
+	query := `SELECT COALESCE(opex_subcategories, '') FROM company_settings WHERE company_id = $1`
+
+	err := sqlx.GetContext(ctx, r.conn(ctx), &raw, query, companyID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return ParseOPEXSubcategories(raw), nil
+}
+
+// GetCurrencyRates returns the monthly exchange rates of the given currencies
+func (r *repository) GetCurrencyRates(ctx context.Context, currencies []Currency) (CurrencyRates, error) {
+	rates := make(CurrencyRates, len(currencies))
+	if len(currencies) == 0 {
+		return rates, nil
+	}
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = string(currency)
+	}
+
+	var rows []struct {
+		Currency    string    `db:"currency"`
+		Month       time.Time `db:"month"`
+		UnitsPerUSD float64   `db:"units_per_usd"`
+	}
+	query := `SELECT currency, month, units_per_usd FROM currency_rates WHERE currency = ANY($1)`
+	if err := sqlx.SelectContext(ctx, r.conn(ctx), &rows, query, codes); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		rates.Set(Currency(row.Currency), row.Month, row.UnitsPerUSD)
+	}
+	return rates, nil
 }
 
 // List Dore Data
 func (r *repository) ListDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*DoreData, error) {
+	return r.listDoreData(ctx, "dore_data", companyID, year, dataType, version)
+}
+
+// ListArchivedDoreData lists Dore rows moved to the archive by the retention policy
+func (r *repository) ListArchivedDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*DoreData, error) {
+	return r.listDoreData(ctx, "dore_data_archive", companyID, year, dataType, version)
+}
+
+func (r *repository) listDoreData(ctx context.Context, table string, companyID int64, year int, dataType string, version int) ([]*DoreData, error) {
 	var records []*DoreData
 
 	query := `
 		SELECT id, company_id, date, dore_produced_oz, silver_grade_pct, gold_grade_pct,
 		       pbr_price_silver, pbr_price_gold, realized_price_silver, realized_price_gold,
 		       silver_adjustment_oz, gold_adjustment_oz, ag_deductions_pct, au_deductions_pct,
-		       treatment_charge, refining_deductions_au, streaming, data_type, version,
-		       description, created_by, created_at
-		FROM dore_data
+		       treatment_charge, refining_deductions_au, streaming, grade_basis, data_type, version,
+		       description, notes, created_by, created_at
+		FROM ` + table + `
 		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = $3 
 		      AND version = $4 AND deleted_at IS NULL
 		ORDER BY date
 	`
 
-	err := r.db.SelectContext(ctx, &records, query, companyID, year, dataType, version)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &records, query, companyID, year, dataType, version)
 	return records, err
 }
 
 func (r *repository) SoftDeleteDoreData(ctx context.Context, id int64) error {
 	query := `UPDATE dore_data SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -429,24 +913,35 @@ func (r *repository) SoftDeleteDoreData(ctx context.Context, id int64) error {
 
 // List OPEX Data
 func (r *repository) ListOPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*OPEXData, error) {
+	return r.listOPEXData(ctx, "opex_data", companyID, year, dataType, version)
+}
+
+// ListArchivedOPEXData lists OPEX rows moved to the archive by the retention policy
+func (r *repository) ListArchivedOPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*OPEXData, error) {
+	return r.listOPEXData(ctx, "opex_data_archive", companyID, year, dataType, version)
+}
+
+func (r *repository) listOPEXData(ctx context.Context, table string, companyID int64, year int, dataType string, version int) ([]*OPEXData, error) {
 	var records []*OPEXData
 
 	query := `
 		SELECT id, company_id, date, cost_center, subcategory, expense_type,
-		       amount, currency, data_type, version, description, created_by, created_at
-		FROM opex_data
+		       amount, currency, COALESCE(original_amount, amount) AS original_amount,
+		       COALESCE(original_currency, currency) AS original_currency, COALESCE(exchange_rate, 1) AS exchange_rate,
+		       data_type, version, description, notes, is_adjustment, created_by, created_at
+		FROM ` + table + `
 		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = $3 
 		      AND version = $4 AND deleted_at IS NULL
 		ORDER BY date
 	`
 
-	err := r.db.SelectContext(ctx, &records, query, companyID, year, dataType, version)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &records, query, companyID, year, dataType, version)
 	return records, err
 }
 
 func (r *repository) SoftDeleteOPEXData(ctx context.Context, id int64) error {
 	query := `UPDATE opex_data SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -464,24 +959,35 @@ func (r *repository) SoftDeleteOPEXData(ctx context.Context, id int64) error {
 
 // List CAPEX Data
 func (r *repository) ListCAPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*CAPEXData, error) {
+	return r.listCAPEXData(ctx, "capex_data", companyID, year, dataType, version)
+}
+
+// ListArchivedCAPEXData lists CAPEX rows moved to the archive by the retention policy
+func (r *repository) ListArchivedCAPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*CAPEXData, error) {
+	return r.listCAPEXData(ctx, "capex_data_archive", companyID, year, dataType, version)
+}
+
+func (r *repository) listCAPEXData(ctx context.Context, table string, companyID int64, year int, dataType string, version int) ([]*CAPEXData, error) {
 	var records []*CAPEXData
 
 	query := `
 		SELECT id, company_id, date, category, car_number, project_name, type,
-		       amount, accretion_of_mine_closure_liability, currency, data_type, version, description, created_by, created_at
-		FROM capex_data
+		       amount, accretion_of_mine_closure_liability, currency, COALESCE(original_amount, amount) AS original_amount,
+		       COALESCE(original_currency, currency) AS original_currency, COALESCE(exchange_rate, 1) AS exchange_rate,
+		       data_type, version, description, notes, is_adjustment, created_by, created_at
+		FROM ` + table + `
 		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = $3 
 		      AND version = $4 AND deleted_at IS NULL
 		ORDER BY date
 	`
 
-	err := r.db.SelectContext(ctx, &records, query, companyID, year, dataType, version)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &records, query, companyID, year, dataType, version)
 	return records, err
 }
 
 func (r *repository) SoftDeleteCAPEXData(ctx context.Context, id int64) error {
 	query := `UPDATE capex_data SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -499,24 +1005,33 @@ func (r *repository) SoftDeleteCAPEXData(ctx context.Context, id int64) error {
 
 // List Financial Data
 func (r *repository) ListFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*FinancialData, error) {
+	return r.listFinancialData(ctx, "financial_data", companyID, year, dataType, version)
+}
+
+// ListArchivedFinancialData lists Financial rows moved to the archive by the retention policy
+func (r *repository) ListArchivedFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*FinancialData, error) {
+	return r.listFinancialData(ctx, "financial_data_archive", companyID, year, dataType, version)
+}
+
+func (r *repository) listFinancialData(ctx context.Context, table string, companyID int64, year int, dataType string, version int) ([]*FinancialData, error) {
 	var records []*FinancialData
 
 	query := `
 		SELECT id, company_id, date, shipping_selling, sales_taxes, royalties,
-		       other_sales_deductions, other_adjustments, currency, data_type, version, description, created_by, created_at
-		FROM financial_data
+		       other_sales_deductions, other_adjustments, currency, data_type, version, description, notes, created_by, created_at
+		FROM ` + table + `
 		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = $3 
 		      AND version = $4 AND deleted_at IS NULL
 		ORDER BY date
 	`
 
-	err := r.db.SelectContext(ctx, &records, query, companyID, year, dataType, version)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &records, query, companyID, year, dataType, version)
 	return records, err
 }
 
 func (r *repository) SoftDeleteFinancialData(ctx context.Context, id int64) error {
 	query := `UPDATE financial_data SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -531,3 +1046,182 @@ func (r *repository) SoftDeleteFinancialData(ctx context.Context, id int64) erro
 
 	return nil
 }
+
+// versionedTables are the data tables that carry budget versions
+var versionedTables = []string{
+	"production_data", "dore_data", "pbr_data", "opex_data", "capex_data", "revenue_data", "financial_data",
+}
+
+// ClaimIdempotencyKey records a user's key with the fingerprint of its upload, expiring
+// after ttl, and returns claimed true. When the key is already recorded and not expired, it
+// returns the response stored with it instead, or ErrIdempotencyKeyMismatch when it was
+// recorded with another fingerprint. The user's expired keys are removed first.
+func (r *repository) ClaimIdempotencyKey(ctx context.Context, userID int64, key, fingerprint string, ttl time.Duration) (*ImportResponse, bool, error) {
+	conn := r.conn(ctx)
+	if _, err := conn.ExecContext(ctx, `DELETE FROM import_idempotency_keys WHERE user_id = $1 AND expires_at <= NOW()`, userID); err != nil {
+		return nil, false, err
+	}
+
+	query := `
+		INSERT INTO import_idempotency_keys (user_id, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+		ON CONFLICT (user_id, key) DO NOTHING
+	`
+	result, err := conn.ExecContext(ctx, query, userID, key, fingerprint, ttl.Seconds())
+	if err != nil {
+		return nil, false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 1 {
+		return nil, err == nil, err
+	}
+
+	var recorded struct {
+		Fingerprint string `db:"fingerprint"`
+		Response    []byte `db:"response"`
+	}
+	err = sqlx.GetContext(ctx, conn, &recorded, `SELECT fingerprint, response FROM import_idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if recorded.Fingerprint != fingerprint {
+		return nil, false, ErrIdempotencyKeyMismatch
+	}
+	var stored ImportResponse
+	if err := json.Unmarshal(recorded.Response, &stored); err != nil {
+		return nil, false, fmt.Errorf("stored response of idempotency key: %w", err)
+	}
+	return &stored, false, nil
+}
+
+// SaveIdempotencyKeyResponse stores the response of the import of a claimed key
+func (r *repository) SaveIdempotencyKeyResponse(ctx context.Context, userID int64, key string, response *ImportResponse) error {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = r.conn(ctx).ExecContext(ctx, `UPDATE import_idempotency_keys SET response = $3 WHERE user_id = $1 AND key = $2`, userID, key, raw)
+	return err
+}
+
+// ListImportLog returns a company's imports made in a year (every year when 0), newest first
+func (r *repository) ListImportLog(ctx context.Context, companyID int64, year int) ([]*ImportLog, error) {
+	logs := []*ImportLog{}
+	query := `
+		SELECT id, company_id, user_id, type, data_type, version, mode, filename, row_count, created_at
+		FROM import_log
+		WHERE company_id = $1 AND ($2 = 0 OR EXTRACT(YEAR FROM created_at) = $2)
+		ORDER BY created_at DESC, id DESC
+	`
+
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &logs, query, companyID, year)
+	return logs, err
+}
+
+// SoftDeleteByScope soft deletes every active row of a company/month/data type/version
+// of a data table (one of versionedTables) in one statement and returns the number of rows deleted
+func (r *repository) SoftDeleteByScope(ctx context.Context, table string, companyID int64, year, month int, dataType string, version int) (int64, error) {
+	if !slices.Contains(versionedTables, table) {
+		return 0, fmt.Errorf("unknown data table %q", table)
+	}
+
+	query := `UPDATE ` + table + ` SET deleted_at = CURRENT_TIMESTAMP
+		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND EXTRACT(MONTH FROM date) = $3
+		      AND data_type = $4 AND version = $5 AND deleted_at IS NULL`
+	result, err := r.conn(ctx).ExecContext(ctx, query, companyID, year, month, dataType, version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListBudgetVersions returns the active budget versions of a company/year across all data tables
+func (r *repository) ListBudgetVersions(ctx context.Context, companyID int64, year int) ([]int, error) {
+	selects := make([]string, len(versionedTables))
+	for i, table := range versionedTables {
+		selects[i] = `SELECT version FROM ` + table + `
+		WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = 'budget' AND deleted_at IS NULL`
+	}
+	query := `SELECT DISTINCT version FROM (` + strings.Join(selects, " UNION ") + `) v ORDER BY version`
+
+	var versions []int
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &versions, query, companyID, year)
+	return versions, err
+}
+
+// ArchiveBudgetVersions soft deletes every row of the given budget versions of a company/year
+// in one transaction and returns the number of rows archived
+func (r *repository) ArchiveBudgetVersions(ctx context.Context, companyID int64, year int, versions []int) (int64, error) {
+	if len(versions) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var archived int64
+	for _, table := range versionedTables {
+		query := `UPDATE ` + table + ` SET deleted_at = CURRENT_TIMESTAMP
+			WHERE company_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND data_type = 'budget'
+			      AND version = ANY($3) AND deleted_at IS NULL`
+		result, err := tx.ExecContext(ctx, query, companyID, year, versions)
+		if err != nil {
+			return 0, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		archived += rows
+	}
+
+	return archived, tx.Commit()
+}
+
+// GetRetentionYears returns how many years of data the company keeps in the hot tables
+// (0 when not configured: data is kept until soft deleted)
+func (r *repository) GetRetentionYears(ctx context.Context, companyID int64) (int, error) {
+	var years int
+	query := `SELECT COALESCE(data_retention_years, 0) FROM company_settings WHERE company_id = $1`
+
+	err := sqlx.GetContext(ctx, r.conn(ctx), &years, query, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return years, err
+}
+
+// ArchiveExpiredData moves the company's soft deleted rows, and rows dated before cutoff
+// (when not nil), from every data table to its archive table in one transaction.
+// Returns the number of rows moved.
+func (r *repository) ArchiveExpiredData(ctx context.Context, companyID int64, cutoff *time.Time) (int64, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var archived int64
+	for _, table := range versionedTables {
+		// Archive tables are created LIKE their data table: same columns, same order
+		query := `WITH moved AS (
+				DELETE FROM ` + table + `
+				WHERE company_id = $1 AND (deleted_at IS NOT NULL OR date < $2)
+				RETURNING *
+			)
+			INSERT INTO ` + table + `_archive SELECT * FROM moved`
+		result, err := tx.ExecContext(ctx, query, companyID, cutoff)
+		if err != nil {
+			return 0, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		archived += rows
+	}
+
+	return archived, tx.Commit()
+}
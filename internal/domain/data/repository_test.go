@@ -0,0 +1,103 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiRowInsert(t *testing.T) {
+	records := []*OPEXData{{Amount: 1}, {Amount: 2}}
+	columns := []string{"amount", "currency"}
+
+	query, args := multiRowInsert("opex_data", columns, records, func(record *OPEXData) []any {
+		return []any{record.Amount, "USD"}
+	})
+
+	assert.Equal(t, "INSERT INTO opex_data (amount, currency) VALUES ($1, $2), ($3, $4)", query)
+	assert.Equal(t, []any{1.0, "USD", 2.0, "USD"}, args)
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	duplicate := fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505", ConstraintName: "idx_pbr_data_unique_date"})
+	assert.True(t, isUniqueViolation(duplicate, "idx_pbr_data_unique_date"))
+
+	// Another constraint, another error code, or only the index name in the message
+	assert.False(t, isUniqueViolation(duplicate, "idx_dore_data_unique_date"))
+	assert.False(t, isUniqueViolation(&pgconn.PgError{Code: "23503", ConstraintName: "idx_pbr_data_unique_date"}, "idx_pbr_data_unique_date"))
+	assert.False(t, isUniqueViolation(errors.New(`violates "idx_pbr_data_unique_date"`), "idx_pbr_data_unique_date"))
+
+	doreDuplicate := &pgconn.PgError{Code: "23505", ConstraintName: "idx_dore_data_unique_date"}
+	assert.True(t, isUniqueViolation(doreDuplicate, "idx_dore_data_unique_date"))
+}
+
+// TestFinancialValuesRoundTrip checks every inserted financial_data column gets the
+// FinancialData field that ListFinancialData scans it back into (by db tag)
+func TestFinancialValuesRoundTrip(t *testing.T) {
+	record := &FinancialData{
+		CompanyID:            testCompanyID,
+		Date:                 time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ShippingSelling:      -202,
+		SalesTaxes:           -300000,
+		Royalties:            -120000,
+		OtherSalesDeductions: -15000,
+		OtherAdjustments:     42,
+		Currency:             "USD",
+		DataType:             "actual",
+		Version:              testVersion,
+		Description:          "January",
+		Notes:                "split",
+		CreatedBy:            testUserID,
+	}
+
+	values := financialValues(record)
+	assert.Len(t, values, len(financialColumns))
+	assert.NotContains(t, financialColumns, "sales_taxes_royalties")
+
+	fields := make(map[string]any)
+	v := reflect.ValueOf(*record)
+	for i := 0; i < v.NumField(); i++ {
+		fields[v.Type().Field(i).Tag.Get("db")] = v.Field(i).Interface()
+	}
+	for i, column := range financialColumns {
+		assert.Equal(t, fields[column], values[i], column)
+	}
+}
+
+// BenchmarkMultiRowInsert builds the statements for a year of daily OPEX (5,000 rows):
+// ten 500-row statements instead of 5,000 single-row round trips
+func BenchmarkMultiRowInsert(b *testing.B) {
+	records := make([]*OPEXData, 5000)
+	for i := range records {
+		records[i] = &OPEXData{
+			CompanyID:   testCompanyID,
+			Date:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i%366),
+			CostCenter:  "Mine",
+			Subcategory: fmt.Sprintf("Subcategory %d", i%40),
+			ExpenseType: "Labour",
+			Amount:      float64(i),
+			Currency:    "USD",
+			DataType:    "actual",
+			Version:     testVersion,
+			CreatedBy:   testUserID,
+		}
+	}
+	values := func(record *OPEXData) []any {
+		return []any{
+			record.CompanyID, record.Date, record.CostCenter, record.Subcategory,
+			record.ExpenseType, record.Amount, record.Currency, record.OriginalAmount, record.OriginalCurrency, record.ExchangeRate, record.DataType, record.Version, record.Description, record.Notes, record.IsAdjustment, record.CreatedBy,
+		}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for start := 0; start < len(records); start += insertBatchSize {
+			multiRowInsert("opex_data", opexColumns, records[start:min(start+insertBatchSize, len(records))], values)
+		}
+	}
+}
@@ -3,9 +3,76 @@ package data
 // ImportRequest represents a data import request
 type ImportRequest struct {
 	Type        DataImportType `form:"type" validate:"required"`
-	DataType    string         `form:"data_type" validate:"required,oneof=actual budget"`
+	DataType    string         `form:"data_type" validate:"required,oneof=actual budget forecast"`
 	CompanyID   int64          `form:"company_id" validate:"required,gt=0"`
 	Version     int            `form:"version"`     // Optional, defaults to 1
+	Year        int            `form:"year"`        // Optional: every row must be dated in this year
 	Description string         `form:"description"` // Optional
 	File        []byte         `form:"-"`           // File content
+	Filename    string         `form:"-"`           // Uploaded file name: type mismatch warning and .xlsx detection
+	ContentType string         `form:"-"`           // Uploaded file content type, for .xlsx detection
+	Mode        ImportMode     `form:"mode"`        // Optional, defaults to insert
+	AllowEmpty  bool           `form:"allow_empty"` // Optional: a header-only file is a successful zero-row import
+
+	// ValidateOnly runs the import up to the insert (parsing and duplicate checks) and
+	// reports the result without writing anything
+	ValidateOnly bool `form:"validate_only"`
+
+	// ColumnMap maps client headers to expected headers (optional, JSON form field)
+	ColumnMap map[string]string `form:"column_map"`
+
+	// ExpenseTypeMap maps client expense type synonyms to canonical expense types
+	// (optional, JSON form field expense_type_map, parsed by ParseExpenseTypeMap)
+	ExpenseTypeMap map[string]ExpenseType `form:"-"`
+
+	// Format overrides the company's CSV format profile field by field (optional form fields
+	// delimiter, decimal_separator or its number_format preset, date_layout, currency_symbol).
+	// ImportData replaces it with the resolved profile.
+	Format FormatProfile `form:"-"`
+
+	// IdempotencyKey is the Idempotency-Key header (optional): a retry with the key of a
+	// successful import of the same user gets its response without importing again
+	IdempotencyKey string `form:"-"`
+}
+
+// csvOptions returns the CSV reading options for this import
+func (r *ImportRequest) csvOptions() csvOptions {
+	return csvOptions{
+		ColumnMap:      r.ColumnMap,
+		Adjust:         r.Mode == ImportModeAdjust,
+		AllowEmpty:     r.AllowEmpty,
+		Format:         r.Format,
+		ExpenseTypeMap: r.ExpenseTypeMap,
+	}
+}
+
+// importLog is the import history entry of this import by a user
+func (r *ImportRequest) importLog(userID int64, rows int) *ImportLog {
+	return &ImportLog{
+		CompanyID: r.CompanyID,
+		UserID:    userID,
+		Type:      r.Type,
+		DataType:  r.DataType,
+		Version:   r.Version,
+		Mode:      r.Mode,
+		Filename:  r.Filename,
+		RowCount:  rows,
+	}
+}
+
+// DeleteScopeRequest selects the rows of a company/month/data type/version to soft delete
+type DeleteScopeRequest struct {
+	Type      DataImportType
+	CompanyID int64  `validate:"required,gt=0"`
+	Year      int    `validate:"required,gt=2000"`
+	Month     int    `validate:"required,gte=1,lte=12"`
+	DataType  string `validate:"required,oneof=actual budget forecast"`
+	Version   int    `validate:"gte=1"`
+}
+
+// PruneVersionsRequest represents a request to archive old budget versions of a company/year
+type PruneVersionsRequest struct {
+	CompanyID int64 `validate:"required,gt=0"`
+	Year      int   `validate:"required,gt=2000"`
+	Keep      int   `validate:"gte=1"` // Number of newest versions to keep
 }
@@ -1,5 +1,7 @@
 package data
 
+import "time"
+
 // ImportResponse represents the response after importing data
 type ImportResponse struct {
 	Success      bool              `json:"success"`
@@ -8,6 +10,37 @@ type ImportResponse struct {
 	RowsInserted int               `json:"rows_inserted"`
 	RowsFailed   int               `json:"rows_failed"`
 	Errors       []ValidationError `json:"errors,omitempty"`
+	Warnings     []string          `json:"warnings,omitempty"`      // Non-blocking notices, e.g. filename vs type mismatch
+	ValidateOnly bool              `json:"validate_only,omitempty"` // Dry run: nothing was inserted
+	Replayed     bool              `json:"replayed,omitempty"`      // Response of an earlier import with the same Idempotency-Key
+}
+
+// BundleImportResponse is the result of a bundle import, per import type of its files.
+// Either every file was inserted or none was: when a file fails, the others report
+// rows_inserted 0 too.
+type BundleImportResponse struct {
+	Success      bool                               `json:"success"`
+	Results      map[DataImportType]*ImportResponse `json:"results"`
+	ValidateOnly bool                               `json:"validate_only,omitempty"` // Dry run: nothing was inserted
+}
+
+// DeleteScopeResponse reports a soft delete by company/month/data type/version
+type DeleteScopeResponse struct {
+	RowsDeleted int64 `json:"rows_deleted"`
+}
+
+// PruneVersionsResponse lists the budget versions archived and kept by a prune
+type PruneVersionsResponse struct {
+	ArchivedVersions []int `json:"archived_versions"`
+	KeptVersions     []int `json:"kept_versions"`
+	RowsArchived     int64 `json:"rows_archived"`
+}
+
+// ArchiveResponse reports a run of the company's retention policy
+type ArchiveResponse struct {
+	RetentionYears int        `json:"retention_years"`  // 0: only soft deleted rows are archived
+	Cutoff         *time.Time `json:"cutoff,omitempty"` // Rows dated before are archived
+	RowsArchived   int64      `json:"rows_archived"`
 }
 
 // MessageResponse simple message response
@@ -0,0 +1,140 @@
+package data
+
+import (
+	"slices"
+
+	"github.com/gmhafiz/go8/internal/domain/config"
+)
+
+// ColumnType represents the kind of value expected in an import column
+type ColumnType string
+
+const (
+	ColumnDate   ColumnType = "date"   // YYYY-MM-DD
+	ColumnNumber ColumnType = "number" // Accepts "$", "%", thousand separators and (negative)
+	ColumnString ColumnType = "string"
+	ColumnEnum   ColumnType = "enum" // One of EnumValues
+)
+
+// ColumnSchema describes a single CSV column expected by an import type
+type ColumnSchema struct {
+	Name       string     `json:"name"`
+	Required   bool       `json:"required"`
+	Type       ColumnType `json:"type"`
+	EnumValues []string   `json:"enum_values,omitempty"`
+}
+
+// ImportSchema describes the columns expected by an import type, in the template order
+// (files may list them in any order)
+type ImportSchema struct {
+	Type    DataImportType `json:"type"`
+	Columns []ColumnSchema `json:"columns"`
+}
+
+// optionalColumns are columns the parsers accept empty (defaulting to 0 or a fallback value)
+var optionalColumns = map[string]bool{
+	"car_number":                          true,
+	"project_name":                        true, // Falls back to category
+	"accretion_of_mine_closure_liability": true,
+	"streaming":                           true,
+	"other_sales_deductions":              true,
+	"other_adjustments":                   true,
+}
+
+// importHeaders returns the expected CSV headers for an import type
+func importHeaders(importType DataImportType) ([]string, bool) {
+	switch importType {
+	case ImportProduction:
+		return productionHeaders, true
+	case ImportDore:
+		return doreHeaders, true
+	case ImportPBR:
+		return pbrHeaders, true
+	case ImportOPEX:
+		return opexHeaders, true
+	case ImportCAPEX:
+		return capexHeaders, true
+	case ImportRevenue:
+		return revenueHeaders, true
+	case ImportFinancial:
+		return financialHeaders, true
+	}
+	return nil, false
+}
+
+// GetImportSchema returns the column metadata for an import type, built from the
+// parser header definitions and the enum validation sources
+func GetImportSchema(importType DataImportType) (*ImportSchema, error) {
+	headers, ok := importHeaders(importType)
+	if !ok {
+		return nil, ErrInvalidDataType
+	}
+
+	columns := make([]ColumnSchema, 0, len(headers))
+	for _, name := range headers {
+		column := ColumnSchema{
+			Name:     name,
+			Required: !optionalColumns[name],
+			Type:     columnType(name),
+		}
+
+		switch name {
+		case "unit":
+			for _, unit := range config.GetAvailableUnits() {
+				column.EnumValues = append(column.EnumValues, unit["value"])
+			}
+		case "cost_center":
+			column.EnumValues = enumValues(CostCenters)
+		case "expense_type":
+			column.EnumValues = enumValues(ExpenseTypes)
+		case "type":
+			column.EnumValues = enumValues(CapexTypes)
+		case "currency":
+			column.EnumValues = enumValues(Currencies)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return &ImportSchema{
+		Type:    importType,
+		Columns: columns,
+	}, nil
+}
+
+// numberColumns returns the numeric columns an import type's file may have, including
+// those of the legacy financial layout
+func numberColumns(importType DataImportType) map[string]bool {
+	headers, _ := importHeaders(importType)
+	if importType == ImportFinancial {
+		headers = append(slices.Clone(headers), financialHeadersLegacy...)
+	}
+	columns := make(map[string]bool)
+	for _, name := range headers {
+		if columnType(name) == ColumnNumber {
+			columns[name] = true
+		}
+	}
+	return columns
+}
+
+// columnType returns the kind of value expected in a column, by header name
+func columnType(name string) ColumnType {
+	switch name {
+	case "date":
+		return ColumnDate
+	case "mineral_code", "subcategory", "category", "car_number", "project_name", notesColumn:
+		return ColumnString
+	case "unit", "cost_center", "expense_type", "type", "currency":
+		return ColumnEnum
+	}
+	return ColumnNumber
+}
+
+func enumValues[T ~string](values []T) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = string(v)
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"errors"
+	"io"
+)
+
+// pbrStream parses a PBR file row by row for a streaming import: valid records are handed
+// out in chunks and can be dropped once inserted, so a large file (e.g. daily rows over
+// several years) is never held as all its rows plus all its records. Bad rows are
+// collected in errors as parsePBRCSV reports them.
+type pbrStream struct {
+	reader     *csvRowReader // nil once the file is read
+	parser     *pbrRowParser
+	allowEmpty bool
+
+	rowNum  int               // last row read, counting the header as row 1
+	records int               // valid records handed out
+	errors  []ValidationError // bad rows so far
+}
+
+func newPBRStream(fileContent []byte, companyID, userID int64, dataType string, version int, description string, opts csvOptions) *pbrStream {
+	stream := &pbrStream{
+		parser:     newPBRRowParser(companyID, userID, dataType, version, description),
+		allowEmpty: opts.AllowEmpty,
+		rowNum:     1,
+	}
+	reader, err := openCSV(fileContent, pbrHeaders, opts)
+	if err != nil {
+		stream.fail(err)
+		return stream
+	}
+	stream.reader = reader
+	return stream
+}
+
+// next returns up to size valid records, or none once the file is read. Rows that fail
+// validation are added to errors instead.
+func (s *pbrStream) next(size int) []*PBRData {
+	var chunk []*PBRData
+	for s.reader != nil && len(chunk) < size {
+		row, err := s.reader.Next()
+		if errors.Is(err, io.EOF) {
+			s.reader = nil
+			if s.rowNum == 1 && !s.allowEmpty {
+				s.fail(ErrInvalidCSVFormat)
+			}
+			break
+		}
+		if err != nil {
+			s.fail(err)
+			break
+		}
+
+		s.rowNum++
+		record, rowErrors := s.parser.parse(row, s.rowNum)
+		if len(rowErrors) > 0 {
+			s.errors = append(s.errors, rowErrors...)
+			continue
+		}
+		s.records++
+		chunk = append(chunk, record)
+	}
+	return chunk
+}
+
+// fail reports a file that cannot be read as parsePBRCSV does: a single error and no records
+func (s *pbrStream) fail(err error) {
+	s.reader = nil
+	s.records = 0
+	s.errors = []ValidationError{{Row: 0, Error: err.Error()}}
+}
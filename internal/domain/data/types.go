@@ -1,6 +1,12 @@
 package data
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
 
 // DataImportType represents the type of data being imported
 type DataImportType string
@@ -15,18 +21,19 @@ const (
 	ImportFinancial  DataImportType = "financial"
 )
 
-// DataType represents if data is actual or budget
+// DataType represents if data is actual, budget or forecast
 type DataType string
 
 const (
-	DataTypeActual DataType = "actual"
-	DataTypeBudget DataType = "budget"
+	DataTypeActual   DataType = "actual"
+	DataTypeBudget   DataType = "budget"
+	DataTypeForecast DataType = "forecast" // Reforecast of the rest of the year, versioned like actual
 )
 
 // IsValid validates data type
 func (dt DataType) IsValid() bool {
 	switch dt {
-	case DataTypeActual, DataTypeBudget:
+	case DataTypeActual, DataTypeBudget, DataTypeForecast:
 		return true
 	}
 	return false
@@ -41,6 +48,37 @@ func (t DataImportType) IsValid() bool {
 	return false
 }
 
+// Table returns the data table the import type is stored in, e.g. "opex_data"
+func (t DataImportType) Table() string {
+	return string(t) + "_data"
+}
+
+// ImportMode represents how imported rows are applied
+type ImportMode string
+
+const (
+	ImportModeInsert  ImportMode = "insert"  // Rows are absolute amounts (default)
+	ImportModeAdjust  ImportMode = "adjust"  // Rows are deltas summed on top of existing amounts (OPEX/CAPEX only)
+	ImportModeReplace ImportMode = "replace" // Rows replace the active data of their months (same company, data type and version)
+)
+
+// IsValid validates import mode
+func (m ImportMode) IsValid() bool {
+	switch m {
+	case ImportModeInsert, ImportModeAdjust, ImportModeReplace:
+		return true
+	}
+	return false
+}
+
+// SupportsMode reports whether the import type can be imported with the given mode
+func (t DataImportType) SupportsMode(mode ImportMode) bool {
+	if mode == ImportModeAdjust {
+		return t == ImportOPEX || t == ImportCAPEX
+	}
+	return mode.IsValid()
+}
+
 // Currency represents supported currencies
 type Currency string
 
@@ -49,13 +87,35 @@ const (
 	CurrencyARS Currency = "ARS"
 )
 
+// Currencies lists all supported currencies
+var Currencies = []Currency{CurrencyUSD, CurrencyARS}
+
 // IsValid validates if currency is supported
 func (c Currency) IsValid() bool {
-	switch c {
-	case CurrencyUSD, CurrencyARS:
-		return true
+	return slices.Contains(Currencies, c)
+}
+
+// CurrencyAffixes lists the symbols and codes that may decorate amounts of each currency.
+// parseFloat strips them as prefix or suffix; add an entry when a currency is added.
+var CurrencyAffixes = map[Currency][]string{
+	CurrencyUSD: {"USD", "US$", "$"},
+	CurrencyARS: {"ARS", "AR$", "$"},
+}
+
+// currencyAffixList returns every currency affix once, longest first
+func currencyAffixList() []string {
+	var affixes []string
+	for _, currency := range Currencies {
+		for _, affix := range CurrencyAffixes[currency] {
+			if !slices.Contains(affixes, affix) {
+				affixes = append(affixes, affix)
+			}
+		}
 	}
-	return false
+	slices.SortStableFunc(affixes, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return affixes
 }
 
 // CostCenter represents OPEX cost centers
@@ -68,13 +128,82 @@ const (
 	CostCenterTransport  CostCenter = "Transport & Shipping"
 )
 
-// IsValid validates cost center
+// CostCenters lists the default OPEX cost centers, valid unless a company configures its own
+var CostCenters = []CostCenter{CostCenterMine, CostCenterProcessing, CostCenterGA, CostCenterTransport}
+
+// IsValid validates cost center against the default CostCenters
 func (cc CostCenter) IsValid() bool {
-	switch cc {
-	case CostCenterMine, CostCenterProcessing, CostCenterGA, CostCenterTransport:
-		return true
+	return slices.Contains(CostCenters, cc)
+}
+
+// ParseCostCenters reads the company setting, a comma-separated list of cost centers such as
+// "Mine,Processing,G&A,Transport & Shipping,Exploration". An empty setting means CostCenters.
+func ParseCostCenters(raw string) []CostCenter {
+	var centers []CostCenter
+	for _, center := range strings.Split(raw, ",") {
+		if center = strings.TrimSpace(center); center != "" && !slices.Contains(centers, CostCenter(center)) {
+			centers = append(centers, CostCenter(center))
+		}
 	}
-	return false
+	if len(centers) == 0 {
+		return CostCenters
+	}
+	return centers
+}
+
+// SubcategoryInventoryVariations is the canonical OPEX subcategory of inventory variations,
+// which Production Based Costs includes whatever the cost center
+const SubcategoryInventoryVariations = "Inventory Variations"
+
+// subcategoryAliases maps known spellings (lower case) to their canonical subcategory
+var subcategoryAliases = map[string]string{
+	"inventory variation":  SubcategoryInventoryVariations,
+	"inventory variations": SubcategoryInventoryVariations,
+	"stockpile/wip":        SubcategoryInventoryVariations,
+}
+
+// OPEXSubcategories are the valid OPEX subcategories per cost center. Cost centers
+// without an entry accept any subcategory, as does an empty allow-list.
+type OPEXSubcategories map[CostCenter][]string
+
+// ParseOPEXSubcategories reads the company setting, a JSON object of subcategories per cost
+// center such as {"Mine": ["Drilling", "Blasting"]}. An empty or malformed setting allows any.
+func ParseOPEXSubcategories(raw string) OPEXSubcategories {
+	var lists map[string][]string
+	if err := json.Unmarshal([]byte(raw), &lists); err != nil {
+		return nil
+	}
+	subcategories := make(OPEXSubcategories, len(lists))
+	for center, list := range lists {
+		costCenter := CostCenter(strings.TrimSpace(center))
+		for _, subcategory := range list {
+			if subcategory = strings.TrimSpace(subcategory); subcategory != "" {
+				subcategories[costCenter] = append(subcategories[costCenter], subcategory)
+			}
+		}
+	}
+	return subcategories
+}
+
+// Normalize resolves an imported subcategory: known aliases become their canonical value
+// (inventory variations are valid in every cost center), then the cost center's allow-list
+// is matched case-insensitively and its spelling kept. It reports false for a subcategory
+// the allow-list does not have.
+func (s OPEXSubcategories) Normalize(costCenter CostCenter, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if canonical, ok := subcategoryAliases[strings.ToLower(value)]; ok {
+		return canonical, true
+	}
+	allowed, ok := s[costCenter]
+	if !ok {
+		return value, true
+	}
+	for _, subcategory := range allowed {
+		if strings.EqualFold(value, subcategory) {
+			return subcategory, true
+		}
+	}
+	return value, false
 }
 
 // ExpenseType represents OPEX expense types
@@ -87,13 +216,59 @@ const (
 	ExpenseOther      ExpenseType = "Other"
 )
 
+// ExpenseTypes lists all valid OPEX expense types
+var ExpenseTypes = []ExpenseType{ExpenseLabour, ExpenseMaterials, ExpenseThirdParty, ExpenseOther}
+
 // IsValid validates expense type
 func (et ExpenseType) IsValid() bool {
-	switch et {
-	case ExpenseLabour, ExpenseMaterials, ExpenseThirdParty, ExpenseOther:
-		return true
+	return slices.Contains(ExpenseTypes, et)
+}
+
+// DefaultExpenseTypeSynonyms maps common ledger names (lower case) to the canonical expense types.
+// An import's expense_type_map adds to and overrides these.
+var DefaultExpenseTypeSynonyms = map[string]ExpenseType{
+	"salaries":    ExpenseLabour,
+	"wages":       ExpenseLabour,
+	"payroll":     ExpenseLabour,
+	"consumables": ExpenseMaterials,
+	"supplies":    ExpenseMaterials,
+	"contractors": ExpenseThirdParty,
+	"services":    ExpenseThirdParty,
+}
+
+// NormalizeExpenseType resolves an imported expense type to the canonical set: canonical names
+// match case-insensitively, then synonyms are looked up (overrides before the defaults).
+// Unknown values are returned trimmed, for IsValid to reject.
+func NormalizeExpenseType(value string, overrides map[string]ExpenseType) ExpenseType {
+	value = strings.TrimSpace(value)
+	for _, et := range ExpenseTypes {
+		if strings.EqualFold(value, string(et)) {
+			return et
+		}
 	}
-	return false
+
+	key := strings.ToLower(value)
+	if et, ok := overrides[key]; ok {
+		return et
+	}
+	if et, ok := DefaultExpenseTypeSynonyms[key]; ok {
+		return et
+	}
+	return ExpenseType(value)
+}
+
+// ParseExpenseTypeMap validates a client synonym map ({"Contractors": "Third Party"}):
+// every target must be a canonical expense type. Keys are lower-cased for lookup.
+func ParseExpenseTypeMap(raw map[string]string) (map[string]ExpenseType, error) {
+	synonyms := make(map[string]ExpenseType, len(raw))
+	for synonym, target := range raw {
+		et := ExpenseType(strings.TrimSpace(target))
+		if !et.IsValid() {
+			return nil, fmt.Errorf("invalid expense_type_map: %q maps to unknown expense type %q", synonym, target)
+		}
+		synonyms[strings.ToLower(strings.TrimSpace(synonym))] = et
+	}
+	return synonyms, nil
 }
 
 // CapexType represents CAPEX project types
@@ -106,15 +281,62 @@ const (
 	CapexAccretion  CapexType = "accretion"
 )
 
+// CapexTypes lists all valid CAPEX types
+var CapexTypes = []CapexType{CapexSustaining, CapexProject, CapexLeasing, CapexAccretion}
+
 // IsValid validates capex type
 func (ct CapexType) IsValid() bool {
-	switch ct {
-	case CapexSustaining, CapexProject, CapexLeasing, CapexAccretion:
+	return slices.Contains(CapexTypes, ct)
+}
+
+// LeaseCashOutflowCategories are the CAPEX categories of lease payments (IFRS16 cash outflows).
+// Every other leasing row is a lease addition (right-of-use asset recognized).
+var LeaseCashOutflowCategories = []string{"Sustaining Capital Lease Cash Outflows", "IFRS16"}
+
+// IsLeaseCashOutflow reports whether a CAPEX category holds lease cash outflows (case-insensitive)
+func IsLeaseCashOutflow(category string) bool {
+	return slices.ContainsFunc(LeaseCashOutflowCategories, func(c string) bool {
+		return strings.EqualFold(c, strings.TrimSpace(category))
+	})
+}
+
+// DoreGradeBasis defines how SilverGradePct/GoldGradePct are derived from the
+// silver and gold ounces of a doré
+type DoreGradeBasis string
+
+const (
+	DoreGradeBasisOz     DoreGradeBasis = "oz"     // Share of troy ounces, i.e. mass share (default)
+	DoreGradeBasisAtomic DoreGradeBasis = "atomic" // Share of atoms: ounces divided by atomic weight
+)
+
+// Standard atomic weights (g/mol) used by DoreGradeBasisAtomic
+const (
+	atomicWeightSilver = 107.8682
+	atomicWeightGold   = 196.96657
+)
+
+// IsValid validates dore grade basis
+func (b DoreGradeBasis) IsValid() bool {
+	switch b {
+	case DoreGradeBasisOz, DoreGradeBasisAtomic:
 		return true
 	}
 	return false
 }
 
+// DoreGrades returns the silver and gold grades (%) of a doré with the given metal ounces.
+// On the atomic basis gold weighs ~1.83x silver per atom, so its grade is lower than its ounce share.
+func DoreGrades(silverOz, goldOz float64, basis DoreGradeBasis) (silverPct, goldPct float64) {
+	if basis == DoreGradeBasisAtomic {
+		silverOz, goldOz = silverOz/atomicWeightSilver, goldOz/atomicWeightGold
+	}
+	total := silverOz + goldOz
+	if total <= 0 {
+		return 0, 0
+	}
+	return silverOz / total * 100, goldOz / total * 100
+}
+
 // ValidationError represents a validation error for a specific row
 type ValidationError struct {
 	Row    int    `json:"row"`
@@ -123,10 +345,19 @@ type ValidationError struct {
 }
 
 var (
-	ErrInvalidDataType  = errors.New("invalid data type")
-	ErrInvalidCSVFormat = errors.New("invalid CSV format")
-	ErrMissingHeaders   = errors.New("missing required headers")
-	ErrCompanyNotFound  = errors.New("company not found")
-	ErrMineralNotFound  = errors.New("mineral not found")
-	ErrValidationFailed = errors.New("validation failed")
+	ErrInvalidDataType   = errors.New("invalid data type")
+	ErrInvalidCSVFormat  = errors.New("invalid CSV format")
+	ErrMissingHeaders    = errors.New("missing required headers")
+	ErrCompanyNotFound   = errors.New("company not found")
+	ErrMineralNotFound   = errors.New("mineral not found")
+	ErrValidationFailed  = errors.New("validation failed")
+	ErrDuplicatePBRDate  = errors.New("PBR data already exists for this date and version")
+	ErrDuplicateDoreDate = errors.New("Dore data already exists for this date and version")
+	ErrInvalidMode       = errors.New("invalid mode: must be insert, adjust or replace ('adjust' is only supported for opex and capex)")
+
+	ErrTooManyBudgetVersions = errors.New("budget version limit reached")
+	ErrInvalidBundle         = errors.New("invalid import bundle")
+	ErrInvalidIdempotencyKey = errors.New("invalid Idempotency-Key: at most 255 characters")
+
+	ErrIdempotencyKeyMismatch = errors.New("Idempotency-Key already used for another upload (company, import or file)")
 )
@@ -2,23 +2,47 @@ package data
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 )
 
 type UseCase interface {
 	ImportData(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error)
-	ListData(ctx context.Context, dataType DataImportType, companyID int64, year int, typeFilter string, version int) (interface{}, error)
+	ImportBundle(ctx context.Context, reqs []*ImportRequest, userID int64) (*BundleImportResponse, error)
+	ListData(ctx context.Context, dataType DataImportType, companyID int64, year int, typeFilter string, version int, includeArchived bool) (interface{}, error)
 	DeleteData(ctx context.Context, dataType DataImportType, id int64) error
+	DeleteDataScope(ctx context.Context, req *DeleteScopeRequest) (*DeleteScopeResponse, error)
+	GetImportSchema(ctx context.Context, dataType DataImportType) (*ImportSchema, error)
+	PruneBudgetVersions(ctx context.Context, req *PruneVersionsRequest) (*PruneVersionsResponse, error)
+	ArchiveData(ctx context.Context, companyID int64) (*ArchiveResponse, error)
+	ListImports(ctx context.Context, companyID int64, year int) ([]*ImportLog, error)
 }
 
 type useCase struct {
-	repo Repository
+	repo              Repository
+	maxBudgetVersions int
+	gramsPerTroyOz    float64
 }
 
-func NewUseCase(repo Repository) UseCase {
-	return &useCase{repo: repo}
+// NewUseCase creates a data use case; maxBudgetVersions <= 0 uses DefaultMaxBudgetVersions
+// and gramsPerTroyOz <= 0 uses GramsPerTroyOz
+func NewUseCase(repo Repository, maxBudgetVersions int, gramsPerTroyOz float64) UseCase {
+	if maxBudgetVersions <= 0 {
+		maxBudgetVersions = DefaultMaxBudgetVersions
+	}
+	if gramsPerTroyOz <= 0 {
+		gramsPerTroyOz = GramsPerTroyOz
+	}
+	return &useCase{repo: repo, maxBudgetVersions: maxBudgetVersions, gramsPerTroyOz: gramsPerTroyOz}
 }
 
 func (uc *useCase) ImportData(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
+	if req.IdempotencyKey != "" {
+		return uc.importOnce(ctx, req, userID)
+	}
+
 	// Validate data type
 	if !req.Type.IsValid() {
 		return nil, ErrInvalidDataType
@@ -38,6 +62,101 @@ func (uc *useCase) ImportData(ctx context.Context, req *ImportRequest, userID in
 		req.Version = 1
 	}
 
+	// Set default mode if not provided (adjust is only for OPEX/CAPEX deltas)
+	if req.Mode == "" {
+		req.Mode = ImportModeInsert
+	}
+	if !req.Type.SupportsMode(req.Mode) {
+		return nil, ErrInvalidMode
+	}
+
+	// Excel uploads: the first sheet becomes a CSV in the default format, read by the same parsers
+	spreadsheet := isXLSX(req.Filename, req.ContentType, req.File)
+	if spreadsheet {
+		req.File, err = xlsxToCSV(req.File)
+		if err != nil {
+			return &ImportResponse{
+				Success:      false,
+				Type:         req.Type,
+				RowsTotal:    0,
+				RowsInserted: 0,
+				RowsFailed:   0,
+				Errors:       []ValidationError{{Row: 0, Error: err.Error()}},
+			}, nil
+		}
+	}
+
+	// CSV format: the company's saved profile (detected from the file on the first import),
+	// with explicit params taking precedence. Converted spreadsheets are always in the default format.
+	savedFormat, err := uc.repo.GetFormatProfile(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	formatCertain := false
+	if spreadsheet {
+		req.Format = DefaultFormatProfile
+	} else {
+		req.Format, formatCertain = resolveFormatProfile(savedFormat, req.Format, req.File, req.Type)
+	}
+
+	// A budget import may not open a new version beyond the configured cap
+	if err := uc.checkBudgetVersionLimit(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// Filename vs type consistency is a warning only: names are not reliable enough to block
+	var warnings []string
+	if warning := filenameTypeWarning(req.Filename, req.Type); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	// Pipelines may send header-only files for months with no activity
+	if req.AllowEmpty && isHeaderOnly(req.File, req.Type, req.csvOptions()) {
+		return &ImportResponse{
+			Success:      true,
+			Type:         req.Type,
+			RowsTotal:    0,
+			RowsInserted: 0,
+			RowsFailed:   0,
+			Errors:       []ValidationError{},
+			Warnings:     warnings,
+		}, nil
+	}
+
+	// All-zero rows: warn or reject per the company's setting for this type
+	zeroRowChecks, err := uc.repo.GetZeroRowChecks(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	zeroRowWarnings, zeroRowErrors := checkZeroRows(zeroRowChecks[req.Type], req.File, req.Type, req.csvOptions())
+	if len(zeroRowErrors) > 0 {
+		return &ImportResponse{
+			Success:      false,
+			Type:         req.Type,
+			RowsTotal:    0,
+			RowsInserted: 0,
+			RowsFailed:   len(zeroRowErrors),
+			Errors:       zeroRowErrors,
+			Warnings:     warnings,
+		}, nil
+	}
+	warnings = append(warnings, zeroRowWarnings...)
+
+	// A stray row from another year would import fine but vanish from year-filtered reports
+	if req.Year > 0 {
+		if yearErrors := rowsOutsideYear(req.File, req.Year, req.csvOptions()); len(yearErrors) > 0 {
+			return &ImportResponse{
+				Success:      false,
+				Type:         req.Type,
+				RowsTotal:    0,
+				RowsInserted: 0,
+				RowsFailed:   len(yearErrors),
+				Errors:       yearErrors,
+				Warnings:     warnings,
+			}, nil
+		}
+	}
+
 	var response *ImportResponse
 
 	switch req.Type {
@@ -62,6 +181,16 @@ func (uc *useCase) ImportData(ctx context.Context, req *ImportRequest, userID in
 	if err != nil {
 		return nil, err
 	}
+	response.Warnings = warnings
+	response.ValidateOnly = req.ValidateOnly
+
+	// The first successful import records the company's format profile, unless the file
+	// did not tell its decimal separator for sure: a wrong saved profile corrupts every later import
+	if savedFormat == nil && formatCertain && response.Success && response.RowsInserted > 0 {
+		if err := uc.repo.SaveFormatProfile(ctx, req.CompanyID, req.Format); err != nil {
+			slog.Warn("could not save CSV format profile", "company_id", req.CompanyID, "error", err.Error())
+		}
+	}
 
 	// After successful import, validate cross-file consistency
 	// Note: Cross-file validation will be performed when generating reports
@@ -70,6 +199,27 @@ func (uc *useCase) ImportData(ctx context.Context, req *ImportRequest, userID in
 	return response, nil
 }
 
+// failedRows counts the distinct rows with validation errors (a row may have several)
+func failedRows(errors []ValidationError) int {
+	rows := make(map[int]bool, len(errors))
+	for _, e := range errors {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+// validatedResponse reports a validate_only import whose rows all passed validation
+func validatedResponse(req *ImportRequest, rows int) *ImportResponse {
+	return &ImportResponse{
+		Success:      true,
+		Type:         req.Type,
+		RowsTotal:    rows,
+		RowsInserted: 0,
+		RowsFailed:   0,
+		Errors:       []ValidationError{},
+	}
+}
+
 func (uc *useCase) importProduction(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
 	// Get mineral code map
 	mineralMap, err := uc.repo.GetMineralCodeMap(ctx)
@@ -78,22 +228,26 @@ func (uc *useCase) importProduction(ctx context.Context, req *ImportRequest, use
 	}
 
 	// Parse CSV
-	records, validationErrors := parseProductionCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, mineralMap)
+	records, validationErrors := parseProductionCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, mineralMap, req.csvOptions())
 
 	// If any validation errors, fail the entire import
 	if len(validationErrors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    len(records) + failedRows(validationErrors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
+			RowsFailed:   failedRows(validationErrors),
 			Errors:       validationErrors,
 		}, nil
 	}
 
 	// Insert all records in transaction
-	err = uc.repo.InsertProductionBulk(ctx, records)
+	if req.ValidateOnly {
+		return validatedResponse(req, len(records)), nil
+	}
+
+	err = uc.repo.InsertProductionBulk(ctx, records, req.Mode == ImportModeReplace, req.importLog(userID, len(records)))
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +266,7 @@ func (uc *useCase) importDore(ctx context.Context, req *ImportRequest, userID in
 	// Get PBR data for the same year, data type, and version to calculate dore production
 	// We need to parse the CSV first to get the dates, but we'll do a two-pass approach
 	// First, read CSV to get dates
-	rows, err := readCSVForDates(req.File)
+	rows, err := readCSV(req.File, doreHeaders, req.csvOptions())
 	if err != nil {
 		return &ImportResponse{
 			Success:      false,
@@ -156,6 +310,19 @@ func (uc *useCase) importDore(ctx context.Context, req *ImportRequest, userID in
 		return nil, err
 	}
 
+	// Without any PBR data for the year, every row would fail the same way: report it once
+	if len(pbrList) == 0 {
+		return &ImportResponse{
+			Success:      false,
+			Type:         req.Type,
+			RowsTotal:    len(rows),
+			RowsInserted: 0,
+			RowsFailed:   0,
+			Errors: []ValidationError{{Row: 0, Error: fmt.Sprintf(
+				"no %s PBR data for %d in version %d: import PBR data before Dore", req.DataType, year, req.Version)}},
+		}, nil
+	}
+
 	// Create a map of PBR data by date for quick lookup
 	pbrMap := make(map[string]*PBRData)
 	for i := range pbrList {
@@ -163,21 +330,67 @@ func (uc *useCase) importDore(ctx context.Context, req *ImportRequest, userID in
 		pbrMap[dateKey] = pbrList[i]
 	}
 
+	gradeBasis, err := uc.repo.GetDoreGradeBasis(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	opts := req.csvOptions()
+	opts.DoreGradeBasis = gradeBasis
+	opts.GramsPerTroyOz = uc.gramsPerTroyOz
+
 	// Now parse Dore CSV with PBR data
-	records, validationErrors := parseDoreCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, pbrMap)
+	records, validationErrors := parseDoreCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, pbrMap, opts)
 
 	if len(validationErrors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    len(records) + failedRows(validationErrors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
+			RowsFailed:   failedRows(validationErrors),
 			Errors:       validationErrors,
 		}, nil
 	}
 
-	err = uc.repo.InsertDoreBulk(ctx, records)
+	// Reject dates that already have active Dore data in this version (soft-deleted rows
+	// are not listed, so a deleted month can be imported again). A replace import
+	// soft-deletes them itself.
+	existingDates := make(map[string]bool)
+	if req.Mode != ImportModeReplace {
+		existingDore, err := uc.repo.ListDoreData(ctx, req.CompanyID, year, req.DataType, req.Version)
+		if err != nil {
+			return nil, err
+		}
+		for _, existing := range existingDore {
+			existingDates[existing.Date.Format("2006-01-02")] = true
+		}
+	}
+	var duplicateErrors []ValidationError
+	for i, record := range records {
+		if existingDates[record.Date.Format("2006-01-02")] {
+			duplicateErrors = append(duplicateErrors, ValidationError{
+				Row:    i + 2,
+				Column: "date",
+				Error:  fmt.Sprintf("Dore data already exists for %s in version %d", record.Date.Format("2006-01-02"), req.Version),
+			})
+		}
+	}
+	if len(duplicateErrors) > 0 {
+		return &ImportResponse{
+			Success:      false,
+			Type:         req.Type,
+			RowsTotal:    len(records),
+			RowsInserted: 0,
+			RowsFailed:   len(duplicateErrors),
+			Errors:       duplicateErrors,
+		}, nil
+	}
+
+	if req.ValidateOnly {
+		return validatedResponse(req, len(records)), nil
+	}
+
+	err = uc.repo.InsertDoreBulk(ctx, records, req.Mode == ImportModeReplace, req.importLog(userID, len(records)))
 	if err != nil {
 		return nil, err
 	}
@@ -192,50 +405,190 @@ func (uc *useCase) importDore(ctx context.Context, req *ImportRequest, userID in
 	}, nil
 }
 
+// importPBR streams the file: rows are parsed, checked and inserted in chunks of
+// insertBatchSize, in one transaction that is rolled back if any row is rejected. The
+// response is the same as parsing the whole file first.
 func (uc *useCase) importPBR(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
-	records, validationErrors := parsePBRCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description)
+	stream := newPBRStream(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, req.csvOptions())
+	log := req.importLog(userID, 0)
+
+	// Reject dates that already have active PBR data in this version (soft-deleted rows
+	// are ignored, so a deleted month can be imported again), loaded in one query over the
+	// dates of the file. A replace import soft-deletes them itself.
+	existing := make(map[string]bool)
+	if req.Mode != ImportModeReplace {
+		var err error
+		if existing, err = uc.existingPBRDates(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	var duplicateErrors []ValidationError
+	next := func() ([]*PBRData, error) {
+		for {
+			records := stream.next(insertBatchSize)
+			if len(records) == 0 {
+				if len(stream.errors) > 0 || len(duplicateErrors) > 0 {
+					return nil, ErrValidationFailed
+				}
+				return nil, nil
+			}
+			// Once a row is rejected nothing more is inserted: the rest of the file is
+			// only read to report its errors
+			if len(stream.errors) > 0 {
+				continue
+			}
+
+			first := stream.records - len(records)
+			for i, record := range records {
+				dateKey := record.Date.Format("2006-01-02")
+				if existing[dateKey] {
+					duplicateErrors = append(duplicateErrors, ValidationError{
+						Row:    first + i + 2,
+						Column: "date",
+						Error:  fmt.Sprintf("PBR data already exists for %s in version %d", dateKey, req.Version),
+					})
+				}
+			}
+			if len(duplicateErrors) > 0 {
+				continue
+			}
+
+			log.RowCount += len(records)
+			return records, nil
+		}
+	}
 
-	if len(validationErrors) > 0 {
+	var err error
+	if req.ValidateOnly {
+		// Read the whole file for its errors, inserting nothing
+		for {
+			records, nextErr := next()
+			if nextErr != nil || len(records) == 0 {
+				err = nextErr
+				break
+			}
+		}
+	} else {
+		err = uc.repo.InsertPBRStream(ctx, req.Mode == ImportModeReplace, log, next)
+	}
+	if err != nil && !errors.Is(err, ErrValidationFailed) {
+		return nil, err
+	}
+
+	if len(stream.errors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    stream.records + failedRows(stream.errors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
-			Errors:       validationErrors,
+			RowsFailed:   failedRows(stream.errors),
+			Errors:       stream.errors,
+		}, nil
+	}
+	if len(duplicateErrors) > 0 {
+		return &ImportResponse{
+			Success:      false,
+			Type:         req.Type,
+			RowsTotal:    stream.records,
+			RowsInserted: 0,
+			RowsFailed:   len(duplicateErrors),
+			Errors:       duplicateErrors,
 		}, nil
 	}
 
-	err := uc.repo.InsertPBRBulk(ctx, records)
-	if err != nil {
-		return nil, err
+	if req.ValidateOnly {
+		return validatedResponse(req, stream.records), nil
 	}
 
 	return &ImportResponse{
 		Success:      true,
 		Type:         req.Type,
-		RowsTotal:    len(records),
-		RowsInserted: len(records),
+		RowsTotal:    stream.records,
+		RowsInserted: stream.records,
 		RowsFailed:   0,
 		Errors:       []ValidationError{},
 	}, nil
 }
 
+// existingPBRDates returns the dates (YYYY-MM-DD) in the date range of an import's file
+// that already have active PBR data in its data type and version
+func (uc *useCase) existingPBRDates(ctx context.Context, req *ImportRequest) (map[string]bool, error) {
+	var from, to time.Time
+	err := eachImportDate(req.File, req.csvOptions(), func(_ int, date time.Time) {
+		if from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if date.After(to) {
+			to = date
+		}
+	})
+	existing := make(map[string]bool)
+	// An unreadable file or one without valid dates has nothing to check: the stream reports it
+	if err != nil || from.IsZero() {
+		return existing, nil
+	}
+
+	dates, err := uc.repo.ListPBRDates(ctx, req.CompanyID, from, to, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, date := range dates {
+		existing[date.Format("2006-01-02")] = true
+	}
+	return existing, nil
+}
+
 func (uc *useCase) importOPEX(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
-	records, validationErrors := parseOPEXCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description)
+	costCenters, err := uc.repo.GetCostCenters(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	subcategories, err := uc.repo.GetOPEXSubcategories(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	opts := req.csvOptions()
+	opts.CostCenters = costCenters
+	opts.Subcategories = subcategories
+
+	records, validationErrors := parseOPEXCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, opts)
 
 	if len(validationErrors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    len(records) + failedRows(validationErrors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
+			RowsFailed:   failedRows(validationErrors),
 			Errors:       validationErrors,
 		}, nil
 	}
 
-	err := uc.repo.InsertOPEXBulk(ctx, records)
+	// Amounts are stored in USD, converted at the rate of each row's month
+	currencies := make([]string, len(records))
+	for i, record := range records {
+		currencies[i] = record.Currency
+	}
+	rates, err := uc.currencyRates(ctx, currencies)
+	if err != nil {
+		return nil, err
+	}
+	if currencyErrors := convertOPEXToUSD(records, rates); len(currencyErrors) > 0 {
+		return &ImportResponse{
+			Success:      false,
+			Type:         req.Type,
+			RowsTotal:    len(records),
+			RowsInserted: 0,
+			RowsFailed:   failedRows(currencyErrors),
+			Errors:       currencyErrors,
+		}, nil
+	}
+
+	if req.ValidateOnly {
+		return validatedResponse(req, len(records)), nil
+	}
+
+	err = uc.repo.InsertOPEXBulk(ctx, records, req.Mode == ImportModeReplace, req.importLog(userID, len(records)))
 	if err != nil {
 		return nil, err
 	}
@@ -251,20 +604,44 @@ func (uc *useCase) importOPEX(ctx context.Context, req *ImportRequest, userID in
 }
 
 func (uc *useCase) importCAPEX(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
-	records, validationErrors := parseCAPEXCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description)
+	records, validationErrors := parseCAPEXCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, req.csvOptions())
 
 	if len(validationErrors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    len(records) + failedRows(validationErrors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
+			RowsFailed:   failedRows(validationErrors),
 			Errors:       validationErrors,
 		}, nil
 	}
 
-	err := uc.repo.InsertCAPEXBulk(ctx, records)
+	// Amounts are stored in USD, converted at the rate of each row's month
+	currencies := make([]string, len(records))
+	for i, record := range records {
+		currencies[i] = record.Currency
+	}
+	rates, err := uc.currencyRates(ctx, currencies)
+	if err != nil {
+		return nil, err
+	}
+	if currencyErrors := convertCAPEXToUSD(records, rates); len(currencyErrors) > 0 {
+		return &ImportResponse{
+			Success:      false,
+			Type:         req.Type,
+			RowsTotal:    len(records),
+			RowsInserted: 0,
+			RowsFailed:   failedRows(currencyErrors),
+			Errors:       currencyErrors,
+		}, nil
+	}
+
+	if req.ValidateOnly {
+		return validatedResponse(req, len(records)), nil
+	}
+
+	err = uc.repo.InsertCAPEXBulk(ctx, records, req.Mode == ImportModeReplace, req.importLog(userID, len(records)))
 	if err != nil {
 		return nil, err
 	}
@@ -279,26 +656,39 @@ func (uc *useCase) importCAPEX(ctx context.Context, req *ImportRequest, userID i
 	}, nil
 }
 
+// currencyRates loads the exchange rates of the non-USD currencies among an import's rows
+func (uc *useCase) currencyRates(ctx context.Context, currencies []string) (CurrencyRates, error) {
+	foreign := foreignCurrencies(currencies)
+	if len(foreign) == 0 {
+		return CurrencyRates{}, nil
+	}
+	return uc.repo.GetCurrencyRates(ctx, foreign)
+}
+
 func (uc *useCase) importRevenue(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
 	mineralMap, err := uc.repo.GetMineralCodeMap(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	records, validationErrors := parseRevenueCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, mineralMap)
+	records, validationErrors := parseRevenueCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, mineralMap, req.csvOptions())
 
 	if len(validationErrors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    len(records) + failedRows(validationErrors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
+			RowsFailed:   failedRows(validationErrors),
 			Errors:       validationErrors,
 		}, nil
 	}
 
-	err = uc.repo.InsertRevenueBulk(ctx, records)
+	if req.ValidateOnly {
+		return validatedResponse(req, len(records)), nil
+	}
+
+	err = uc.repo.InsertRevenueBulk(ctx, records, req.Mode == ImportModeReplace, req.importLog(userID, len(records)))
 	if err != nil {
 		return nil, err
 	}
@@ -314,20 +704,24 @@ func (uc *useCase) importRevenue(ctx context.Context, req *ImportRequest, userID
 }
 
 func (uc *useCase) importFinancial(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
-	records, validationErrors := parseFinancialCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description)
+	records, validationErrors := parseFinancialCSV(req.File, req.CompanyID, userID, req.DataType, req.Version, req.Description, req.csvOptions())
 
 	if len(validationErrors) > 0 {
 		return &ImportResponse{
 			Success:      false,
 			Type:         req.Type,
-			RowsTotal:    len(records) + len(validationErrors),
+			RowsTotal:    len(records) + failedRows(validationErrors),
 			RowsInserted: 0,
-			RowsFailed:   len(validationErrors),
+			RowsFailed:   failedRows(validationErrors),
 			Errors:       validationErrors,
 		}, nil
 	}
 
-	err := uc.repo.InsertFinancialBulk(ctx, records)
+	if req.ValidateOnly {
+		return validatedResponse(req, len(records)), nil
+	}
+
+	err := uc.repo.InsertFinancialBulk(ctx, records, req.Mode == ImportModeReplace, req.importLog(userID, len(records)))
 	if err != nil {
 		return nil, err
 	}
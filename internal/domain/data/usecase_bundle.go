@@ -0,0 +1,74 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// bundleOrder is the order the files of a bundle are imported in. PBR comes before Dore:
+// a Dore import derives production from the PBR rows of the same dates.
+var bundleOrder = []DataImportType{ImportProduction, ImportPBR, ImportDore, ImportOPEX, ImportCAPEX, ImportRevenue, ImportFinancial}
+
+// errBundleRolledBack ends the transaction of a bundle that must not be committed
+var errBundleRolledBack = errors.New("bundle rolled back")
+
+// ImportBundle imports several files, at most one per import type, in one transaction:
+// either every file is inserted or none is. Each file is imported as by ImportData, in
+// bundleOrder, so a Dore file is read against the PBR file of the same bundle. A
+// validate_only bundle is imported the same way and rolled back.
+func (uc *useCase) ImportBundle(ctx context.Context, reqs []*ImportRequest, userID int64) (*BundleImportResponse, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%w: no files", ErrInvalidBundle)
+	}
+	byType := make(map[DataImportType]*ImportRequest, len(reqs))
+	for _, req := range reqs {
+		if !req.Type.IsValid() {
+			return nil, ErrInvalidDataType
+		}
+		if byType[req.Type] != nil {
+			return nil, fmt.Errorf("%w: more than one %s file", ErrInvalidBundle, req.Type)
+		}
+		byType[req.Type] = req
+	}
+
+	validateOnly := reqs[0].ValidateOnly
+	response := &BundleImportResponse{
+		Success:      true,
+		Results:      make(map[DataImportType]*ImportResponse, len(reqs)),
+		ValidateOnly: validateOnly,
+	}
+
+	err := uc.repo.InTransaction(ctx, func(ctx context.Context) error {
+		for _, importType := range bundleOrder {
+			req, ok := byType[importType]
+			if !ok {
+				continue
+			}
+			// A dry run inserts too, so the Dore file is checked against the bundle's
+			// PBR; the transaction is rolled back below
+			req.ValidateOnly = false
+			result, err := uc.ImportData(ctx, req, userID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", importType, err)
+			}
+			response.Results[importType] = result
+			response.Success = response.Success && result.Success
+		}
+		if !response.Success || validateOnly {
+			return errBundleRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBundleRolledBack) {
+		return nil, err
+	}
+
+	if !response.Success || validateOnly {
+		for _, result := range response.Results {
+			result.RowsInserted = 0
+			result.ValidateOnly = validateOnly
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long a retry with the Idempotency-Key of an import gets its response
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted (the column size)
+const maxIdempotencyKeyLength = 255
+
+// errNotReplayable ends the transaction of a keyed import whose response is not stored
+var errNotReplayable = errors.New("import response not stored")
+
+// importFingerprint identifies the upload a key was used for: the company, the import
+// (type, data type, version and mode) and a hash of the file
+func importFingerprint(req *ImportRequest) string {
+	file := sha256.Sum256(req.File)
+	fingerprint := sha256.Sum256(fmt.Appendf(nil, "%d|%s|%s|%d|%s|%x", req.CompanyID, req.Type, req.DataType, req.Version, req.Mode, file))
+	return hex.EncodeToString(fingerprint[:])
+}
+
+// importOnce imports with an idempotency key. The key is claimed in the import's
+// transaction, so a concurrent retry waits for the first upload: a key already used by the
+// user for a successful import of the same upload returns its stored response, marked
+// Replayed, and imports nothing; reusing it for another company, import or file is
+// ErrIdempotencyKeyMismatch. Only successful imports keep their key: a rejected or
+// validate_only upload inserted nothing, so the same key may be retried with a corrected file.
+func (uc *useCase) importOnce(ctx context.Context, req *ImportRequest, userID int64) (*ImportResponse, error) {
+	key := req.IdempotencyKey
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	fingerprint := importFingerprint(req)
+
+	var response *ImportResponse
+	err := uc.repo.InTransaction(ctx, func(ctx context.Context) error {
+		stored, claimed, err := uc.repo.ClaimIdempotencyKey(ctx, userID, key, fingerprint, IdempotencyKeyTTL)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			stored.Replayed = true
+			response = stored
+			return nil
+		}
+
+		keyed := *req
+		keyed.IdempotencyKey = ""
+		response, err = uc.ImportData(ctx, &keyed, userID)
+		if err != nil {
+			return err
+		}
+		if !response.Success || response.ValidateOnly {
+			return errNotReplayable
+		}
+		return uc.repo.SaveIdempotencyKeyResponse(ctx, userID, key, response)
+	})
+	if err != nil && !errors.Is(err, errNotReplayable) {
+		return nil, err
+	}
+	return response, nil
+}
@@ -2,12 +2,17 @@ package data
 
 import "context"
 
-// ListData returns imported data for a specific type, company, year and version
-func (uc *useCase) ListData(ctx context.Context, dataType DataImportType, companyID int64, year int, typeFilter string, version int) (interface{}, error) {
+// ListData returns imported data for a specific type, company, year and version.
+// Rows moved to the archive by the retention policy are only included on request.
+func (uc *useCase) ListData(ctx context.Context, dataType DataImportType, companyID int64, year int, typeFilter string, version int, includeArchived bool) (interface{}, error) {
 	if version == 0 {
 		version = 1
 	}
 
+	if includeArchived {
+		return uc.listWithArchived(ctx, dataType, companyID, year, typeFilter, version)
+	}
+
 	switch dataType {
 	case ImportPBR:
 		return uc.repo.ListPBRData(ctx, companyID, year, typeFilter, version)
@@ -24,6 +29,65 @@ func (uc *useCase) ListData(ctx context.Context, dataType DataImportType, compan
 	}
 }
 
+// listWithArchived returns the archived rows followed by the active rows of a listing.
+// Archived rows predate the retention cutoff, so the result stays in date order.
+func (uc *useCase) listWithArchived(ctx context.Context, dataType DataImportType, companyID int64, year int, typeFilter string, version int) (interface{}, error) {
+	switch dataType {
+	case ImportPBR:
+		archived, err := uc.repo.ListArchivedPBRData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		active, err := uc.repo.ListPBRData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, active...), nil
+	case ImportDore:
+		archived, err := uc.repo.ListArchivedDoreData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		active, err := uc.repo.ListDoreData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, active...), nil
+	case ImportOPEX:
+		archived, err := uc.repo.ListArchivedOPEXData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		active, err := uc.repo.ListOPEXData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, active...), nil
+	case ImportCAPEX:
+		archived, err := uc.repo.ListArchivedCAPEXData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		active, err := uc.repo.ListCAPEXData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, active...), nil
+	case ImportFinancial:
+		archived, err := uc.repo.ListArchivedFinancialData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		active, err := uc.repo.ListFinancialData(ctx, companyID, year, typeFilter, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(archived, active...), nil
+	default:
+		return nil, ErrInvalidDataType
+	}
+}
+
 // DeleteData soft deletes an imported data record
 func (uc *useCase) DeleteData(ctx context.Context, dataType DataImportType, id int64) error {
 	switch dataType {
@@ -41,3 +105,43 @@ func (uc *useCase) DeleteData(ctx context.Context, dataType DataImportType, id i
 		return ErrInvalidDataType
 	}
 }
+
+// DeleteDataScope soft deletes every active row of a company/month/data type/version,
+// e.g. all of January's actual OPEX before it is imported again
+func (uc *useCase) DeleteDataScope(ctx context.Context, req *DeleteScopeRequest) (*DeleteScopeResponse, error) {
+	if !req.Type.IsValid() {
+		return nil, ErrInvalidDataType
+	}
+
+	exists, err := uc.repo.CompanyExists(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCompanyNotFound
+	}
+
+	deleted, err := uc.repo.SoftDeleteByScope(ctx, req.Type.Table(), req.CompanyID, req.Year, req.Month, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &DeleteScopeResponse{RowsDeleted: deleted}, nil
+}
+
+// ListImports returns the import history of a company, newest first. A year > 0 keeps
+// the imports made in that year.
+func (uc *useCase) ListImports(ctx context.Context, companyID int64, year int) ([]*ImportLog, error) {
+	exists, err := uc.repo.CompanyExists(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCompanyNotFound
+	}
+	return uc.repo.ListImportLog(ctx, companyID, year)
+}
+
+// GetImportSchema returns the columns an import type expects, with their types and valid values
+func (uc *useCase) GetImportSchema(ctx context.Context, dataType DataImportType) (*ImportSchema, error) {
+	return GetImportSchema(dataType)
+}
@@ -0,0 +1,41 @@
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// ArchiveData runs the company's retention policy: soft deleted rows, and rows older
+// than the configured retention (in years, when set), move to the archive tables
+func (uc *useCase) ArchiveData(ctx context.Context, companyID int64) (*ArchiveResponse, error) {
+	exists, err := uc.repo.CompanyExists(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCompanyNotFound
+	}
+
+	years, err := uc.repo.GetRetentionYears(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ArchiveResponse{RetentionYears: years}
+	if years > 0 {
+		cutoff := retentionCutoff(time.Now(), years)
+		response.Cutoff = &cutoff
+	}
+
+	response.RowsArchived, err = uc.repo.ArchiveExpiredData(ctx, companyID, response.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// retentionCutoff is the first day of the month `years` years before now: rows dated
+// earlier are past retention
+func retentionCutoff(now time.Time, years int) time.Time {
+	return time.Date(now.Year()-years, now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,1049 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// softDeleteRepository keeps PBR and Dore rows in memory with deleted_at semantics.
+// Methods not used by these tests fall through to the nil embedded Repository.
+type softDeleteRepository struct {
+	Repository
+	nextID int64
+	pbr    []*PBRData
+	dore   []*DoreData
+
+	pbrDateQueries int
+
+	retentionYears int
+	archivedPBR    []*PBRData
+
+	zeroRowChecks string
+
+	opex  []*OPEXData
+	rates CurrencyRates
+
+	imports []*ImportLog
+}
+
+func (r *softDeleteRepository) CompanyExists(ctx context.Context, companyID int64) (bool, error) {
+	return true, nil
+}
+
+func (r *softDeleteRepository) GetFormatProfile(ctx context.Context, companyID int64) (*FormatProfile, error) {
+	return &DefaultFormatProfile, nil
+}
+
+func (r *softDeleteRepository) GetDoreGradeBasis(ctx context.Context, companyID int64) (DoreGradeBasis, error) {
+	return DoreGradeBasisOz, nil
+}
+
+func (r *softDeleteRepository) GetCostCenters(ctx context.Context, companyID int64) ([]CostCenter, error) {
+	return CostCenters, nil
+}
+
+func (r *softDeleteRepository) GetOPEXSubcategories(ctx context.Context, companyID int64) (OPEXSubcategories, error) {
+	return nil, nil
+}
+
+func (r *softDeleteRepository) GetCurrencyRates(ctx context.Context, currencies []Currency) (CurrencyRates, error) {
+	return r.rates, nil
+}
+
+func (r *softDeleteRepository) InsertOPEXBulk(ctx context.Context, records []*OPEXData, replace bool, log *ImportLog) error {
+	r.opex = append(r.opex, records...)
+	return nil
+}
+
+func (r *softDeleteRepository) GetZeroRowChecks(ctx context.Context, companyID int64) (map[DataImportType]ZeroRowCheck, error) {
+	return ParseZeroRowChecks(r.zeroRowChecks), nil
+}
+
+func (r *softDeleteRepository) InsertPBRBulk(ctx context.Context, records []*PBRData, replace bool, log *ImportLog) error {
+	if log != nil {
+		r.imports = append(r.imports, log)
+	}
+	if replace {
+		for _, existing := range r.pbr {
+			for _, record := range records {
+				if existing.DeletedAt == nil && existing.DataType == record.DataType && existing.Version == record.Version &&
+					existing.Date.Format("2006-01") == record.Date.Format("2006-01") {
+					now := time.Now()
+					existing.DeletedAt = &now
+				}
+			}
+		}
+	}
+	for _, record := range records {
+		r.nextID++
+		record.ID = r.nextID
+		r.pbr = append(r.pbr, record)
+	}
+	return nil
+}
+
+// InsertPBRStream collects the streamed chunks and inserts them as InsertPBRBulk
+func (r *softDeleteRepository) InsertPBRStream(ctx context.Context, replace bool, log *ImportLog, next func() ([]*PBRData, error)) error {
+	var records []*PBRData
+	for {
+		chunk, err := next()
+		if err != nil {
+			return err
+		}
+		if len(chunk) == 0 {
+			break
+		}
+		records = append(records, chunk...)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return r.InsertPBRBulk(ctx, records, replace, log)
+}
+
+func (r *softDeleteRepository) ListPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*PBRData, error) {
+	var active []*PBRData
+	for _, record := range r.pbr {
+		if record.DeletedAt == nil && record.Date.Year() == year && record.DataType == dataType && record.Version == version {
+			active = append(active, record)
+		}
+	}
+	return active, nil
+}
+
+func (r *softDeleteRepository) ListPBRDates(ctx context.Context, companyID int64, from, to time.Time, dataType string, version int) ([]time.Time, error) {
+	r.pbrDateQueries++
+	var dates []time.Time
+	for _, record := range r.pbr {
+		if record.DeletedAt == nil && record.DataType == dataType && record.Version == version &&
+			!record.Date.Before(from) && !record.Date.After(to) {
+			dates = append(dates, record.Date)
+		}
+	}
+	return dates, nil
+}
+
+func (r *softDeleteRepository) SoftDeletePBRData(ctx context.Context, id int64) error {
+	for _, record := range r.pbr {
+		if record.ID == id && record.DeletedAt == nil {
+			now := time.Now()
+			record.DeletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *softDeleteRepository) InsertDoreBulk(ctx context.Context, records []*DoreData, replace bool, log *ImportLog) error {
+	for _, record := range records {
+		r.nextID++
+		record.ID = r.nextID
+		r.dore = append(r.dore, record)
+	}
+	return nil
+}
+
+func (r *softDeleteRepository) ListDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*DoreData, error) {
+	var active []*DoreData
+	for _, record := range r.dore {
+		if record.DeletedAt == nil && record.Date.Year() == year && record.DataType == dataType && record.Version == version {
+			active = append(active, record)
+		}
+	}
+	return active, nil
+}
+
+func (r *softDeleteRepository) SoftDeleteDoreData(ctx context.Context, id int64) error {
+	for _, record := range r.dore {
+		if record.ID == id && record.DeletedAt == nil {
+			now := time.Now()
+			record.DeletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *softDeleteRepository) ListImportLog(ctx context.Context, companyID int64, year int) ([]*ImportLog, error) {
+	return r.imports, nil
+}
+
+func (r *softDeleteRepository) SoftDeleteByScope(ctx context.Context, table string, companyID int64, year, month int, dataType string, version int) (int64, error) {
+	var deleted int64
+	for _, record := range r.pbr {
+		if table == "pbr_data" && record.DeletedAt == nil && record.Date.Year() == year && int(record.Date.Month()) == month &&
+			record.DataType == dataType && record.Version == version {
+			now := time.Now()
+			record.DeletedAt = &now
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *softDeleteRepository) ListBudgetVersions(ctx context.Context, companyID int64, year int) ([]int, error) {
+	var versions []int
+	for _, record := range r.pbr {
+		if record.DeletedAt == nil && record.Date.Year() == year && record.DataType == "budget" && !slices.Contains(versions, record.Version) {
+			versions = append(versions, record.Version)
+		}
+	}
+	slices.Sort(versions)
+	return versions, nil
+}
+
+func (r *softDeleteRepository) GetRetentionYears(ctx context.Context, companyID int64) (int, error) {
+	return r.retentionYears, nil
+}
+
+func (r *softDeleteRepository) ArchiveExpiredData(ctx context.Context, companyID int64, cutoff *time.Time) (int64, error) {
+	var kept []*PBRData
+	var archived int64
+	for _, record := range r.pbr {
+		if record.DeletedAt != nil || (cutoff != nil && record.Date.Before(*cutoff)) {
+			r.archivedPBR = append(r.archivedPBR, record)
+			archived++
+			continue
+		}
+		kept = append(kept, record)
+	}
+	r.pbr = kept
+	return archived, nil
+}
+
+func (r *softDeleteRepository) ListArchivedPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*PBRData, error) {
+	var active []*PBRData
+	for _, record := range r.archivedPBR {
+		if record.DeletedAt == nil && record.Date.Year() == year && record.DataType == dataType && record.Version == version {
+			active = append(active, record)
+		}
+	}
+	return active, nil
+}
+
+func TestImportData_ReimportAfterSoftDelete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		importType DataImportType
+		file       []byte
+		softDelete func(repo *softDeleteRepository)
+	}{
+		{"pbr", ImportPBR, buildPBRCSV([]string{validPBRRow}), func(repo *softDeleteRepository) {
+			for _, record := range repo.pbr {
+				_ = repo.SoftDeletePBRData(ctx, record.ID)
+			}
+		}},
+		{"dore", ImportDore, buildDoreCSV([]string{validDoreRow}), func(repo *softDeleteRepository) {
+			for _, record := range repo.dore {
+				_ = repo.SoftDeleteDoreData(ctx, record.ID)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &softDeleteRepository{}
+			uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+			importFile := func(importType DataImportType, file []byte) *ImportResponse {
+				response, err := uc.ImportData(ctx, &ImportRequest{
+					Type:      importType,
+					DataType:  "actual",
+					CompanyID: testCompanyID,
+					Version:   testVersion,
+					File:      file,
+				}, testUserID)
+				require.NoError(t, err)
+				return response
+			}
+
+			// Dore needs the PBR of the same date
+			if tt.importType == ImportDore {
+				require.True(t, importFile(ImportPBR, buildPBRCSV([]string{validPBRRow})).Success)
+			}
+
+			assert.True(t, importFile(tt.importType, tt.file).Success, "first import")
+
+			tt.softDelete(repo)
+			assert.True(t, importFile(tt.importType, tt.file).Success, "re-import after soft delete")
+
+			response := importFile(tt.importType, tt.file)
+			assert.False(t, response.Success, "re-import of an active month")
+			if assert.Len(t, response.Errors, 1) {
+				assert.Equal(t, "date", response.Errors[0].Column)
+				assert.Contains(t, response.Errors[0].Error, "already exists")
+			}
+		})
+	}
+}
+
+func TestImportData_BudgetVersionCap(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, 2, GramsPerTroyOz)
+
+	importBudget := func(version int, row string) (*ImportResponse, error) {
+		return uc.ImportData(ctx, &ImportRequest{
+			Type:      ImportPBR,
+			DataType:  "budget",
+			CompanyID: testCompanyID,
+			Version:   version,
+			File:      buildPBRCSV([]string{row}),
+		}, testUserID)
+	}
+
+	for _, version := range []int{1, 2} {
+		response, err := importBudget(version, validPBRRow)
+		require.NoError(t, err)
+		require.True(t, response.Success)
+	}
+
+	// A third version is rejected
+	_, err := importBudget(3, validPBRRow)
+	assert.ErrorIs(t, err, ErrTooManyBudgetVersions)
+
+	// Existing versions can still receive data
+	response, err := importBudget(2, strings.Replace(validPBRRow, "-01-", "-02-", 1))
+	require.NoError(t, err)
+	assert.True(t, response.Success)
+}
+
+func TestImportData_NotesColumnReadBack(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+	header := strings.TrimSuffix(string(buildPBRCSV(nil)), "\n")
+	file := []byte(header + ",notes\n" +
+		validPBRRow + ",\"Mill stopped 2 days, adjusted\"\n" +
+		strings.Replace(validPBRRow, "-01-15", "-01-16", 1) + ",\n")
+
+	response, err := uc.ImportData(ctx, &ImportRequest{
+		Type:      ImportPBR,
+		DataType:  "actual",
+		CompanyID: testCompanyID,
+		Version:   testVersion,
+		File:      file,
+	}, testUserID)
+	require.NoError(t, err)
+	require.True(t, response.Success, response.Errors)
+
+	listed, err := uc.ListData(ctx, ImportPBR, testCompanyID, 2024, "actual", testVersion, false)
+	require.NoError(t, err)
+	rows := listed.([]*PBRData)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Mill stopped 2 days, adjusted", rows[0].Notes)
+	assert.Empty(t, rows[1].Notes)
+	assert.Equal(t, 35951.0, rows[0].TotalTonnesProcessed)
+}
+
+func TestArchiveData_ExcludedFromListingUnlessRequested(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{retentionYears: 5}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+	// One row past retention, one recent, one recent but soft deleted
+	old := time.Now().AddDate(-6, 0, 0)
+	recent := time.Now()
+	_ = repo.InsertPBRBulk(ctx, []*PBRData{
+		{CompanyID: testCompanyID, Date: old, DataType: "actual", Version: testVersion},
+		{CompanyID: testCompanyID, Date: recent, DataType: "actual", Version: testVersion},
+		{CompanyID: testCompanyID, Date: recent.AddDate(0, 0, -1), DataType: "actual", Version: testVersion},
+	}, false, nil)
+	require.NoError(t, repo.SoftDeletePBRData(ctx, 3))
+
+	response, err := uc.ArchiveData(ctx, testCompanyID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), response.RowsArchived)
+	require.NotNil(t, response.Cutoff)
+
+	listed, err := uc.ListData(ctx, ImportPBR, testCompanyID, old.Year(), "actual", testVersion, false)
+	require.NoError(t, err)
+	assert.Empty(t, listed.([]*PBRData), "archived rows are excluded by default")
+
+	listed, err = uc.ListData(ctx, ImportPBR, testCompanyID, old.Year(), "actual", testVersion, true)
+	require.NoError(t, err)
+	rows := listed.([]*PBRData)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(1), rows[0].ID)
+
+	// Recent rows stay in the hot table; the soft deleted one is archived but never listed
+	listed, err = uc.ListData(ctx, ImportPBR, testCompanyID, recent.Year(), "actual", testVersion, true)
+	require.NoError(t, err)
+	rows = listed.([]*PBRData)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(2), rows[0].ID)
+}
+
+func TestImportData_AllZeroRowCheck(t *testing.T) {
+	ctx := context.Background()
+	zeroRow := "2024-02-15,0,0,0,0,0,0,0,0"
+
+	tests := []struct {
+		name          string
+		zeroRowChecks string
+		wantSuccess   bool
+		wantWarnings  []string
+		wantErrors    []ValidationError
+	}{
+		{name: "not configured", zeroRowChecks: "", wantSuccess: true},
+		{name: "other type only", zeroRowChecks: "dore:reject", wantSuccess: true},
+		{name: "warn", zeroRowChecks: "pbr:warn", wantSuccess: true, wantWarnings: []string{"row 3: all required numeric fields are zero"}},
+		{name: "reject", zeroRowChecks: "dore:warn,pbr:reject", wantSuccess: false, wantErrors: []ValidationError{{Row: 3, Error: "all required numeric fields are zero"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &softDeleteRepository{zeroRowChecks: tt.zeroRowChecks}
+			uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+			response, err := uc.ImportData(ctx, &ImportRequest{
+				Type:      ImportPBR,
+				DataType:  "actual",
+				CompanyID: testCompanyID,
+				Version:   testVersion,
+				File:      buildPBRCSV([]string{validPBRRow, zeroRow}),
+			}, testUserID)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantSuccess, response.Success)
+			assert.Equal(t, tt.wantWarnings, response.Warnings)
+			if tt.wantSuccess {
+				assert.Len(t, repo.pbr, 2)
+			} else {
+				assert.Equal(t, tt.wantErrors, response.Errors)
+				assert.Empty(t, repo.pbr)
+			}
+		})
+	}
+}
+
+func TestImportData_ValidateOnly(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+	req := func(rows ...string) *ImportRequest {
+		return &ImportRequest{
+			Type:         ImportPBR,
+			DataType:     "actual",
+			CompanyID:    testCompanyID,
+			Version:      testVersion,
+			File:         buildPBRCSV(rows),
+			ValidateOnly: true,
+		}
+	}
+
+	response, err := uc.ImportData(ctx, req(validPBRRow, "2024-02-15,24859,262591,598,35951,209.79,7.35,94.01,95.36"), testUserID)
+	require.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.True(t, response.ValidateOnly)
+	assert.Equal(t, 2, response.RowsTotal)
+	assert.Zero(t, response.RowsInserted)
+	assert.Empty(t, repo.pbr, "a dry run must not insert")
+
+	response, err = uc.ImportData(ctx, req(validPBRRow, "2024-02-15,abc,262591,598,35951,209.79,7.35,94.01,95.36"), testUserID)
+	require.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.True(t, response.ValidateOnly)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "ore_mined_t", response.Errors[0].Column)
+	assert.Empty(t, repo.pbr)
+}
+
+func TestImportData_RowsFailedCountsRows(t *testing.T) {
+	uc := NewUseCase(&softDeleteRepository{}, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+	response, err := uc.ImportData(context.Background(), &ImportRequest{
+		Type:      ImportPBR,
+		DataType:  "actual",
+		CompanyID: testCompanyID,
+		Version:   testVersion,
+		File:      buildPBRCSV([]string{validPBRRow, "2024-02-15,abc,262591,,35951,209.79,x7.35,94.01,95.36"}),
+	}, testUserID)
+	require.NoError(t, err)
+
+	assert.False(t, response.Success)
+	assert.Len(t, response.Errors, 3)
+	assert.Equal(t, 1, response.RowsFailed)
+	assert.Equal(t, 2, response.RowsTotal)
+}
+
+func TestImportData_DoreWithoutPBR(t *testing.T) {
+	doreRows := []string{validDoreRow, strings.Replace(validDoreRow, "2024-01-15", "2024-02-15", 1)}
+	importDore := func(repo *softDeleteRepository) *ImportResponse {
+		response, err := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportData(context.Background(), &ImportRequest{
+			Type:      ImportDore,
+			DataType:  "actual",
+			CompanyID: testCompanyID,
+			Version:   testVersion,
+			File:      buildDoreCSV(doreRows),
+		}, testUserID)
+		require.NoError(t, err)
+		return response
+	}
+
+	// No PBR at all: a single error for the file, not one per row
+	response := importDore(&softDeleteRepository{})
+	assert.False(t, response.Success)
+	require.Len(t, response.Errors, 1)
+	assert.Zero(t, response.Errors[0].Row)
+	assert.Contains(t, response.Errors[0].Error, "import PBR data before Dore")
+	assert.Equal(t, 2, response.RowsTotal)
+
+	// PBR for January only: February is the row that fails
+	repo := &softDeleteRepository{}
+	pbrRecords, errs := parsePBRCSV(buildPBRCSV([]string{validPBRRow}), testCompanyID, testUserID, "actual", testVersion, testDescription, csvOptions{})
+	require.Empty(t, errs)
+	repo.pbr = pbrRecords
+	response = importDore(repo)
+	assert.False(t, response.Success)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, 3, response.Errors[0].Row)
+	assert.Contains(t, response.Errors[0].Error, "PBR data not found for date 2024-02-15")
+}
+
+func TestImportData_UTF8BOM(t *testing.T) {
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+	response, err := uc.ImportData(context.Background(), &ImportRequest{
+		Type:      ImportPBR,
+		DataType:  "actual",
+		CompanyID: testCompanyID,
+		Version:   testVersion,
+		File:      append([]byte("\xEF\xBB\xBF"), buildPBRCSV([]string{validPBRRow})...),
+	}, testUserID)
+	require.NoError(t, err)
+
+	assert.True(t, response.Success, response.Errors)
+	assert.Equal(t, 1, response.RowsInserted)
+	require.Len(t, repo.pbr, 1)
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), repo.pbr[0].Date)
+}
+
+func TestImportData_RowOutsideYear(t *testing.T) {
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+	req := &ImportRequest{
+		Type:      ImportPBR,
+		DataType:  "actual",
+		CompanyID: testCompanyID,
+		Version:   testVersion,
+		Year:      2025,
+		File: buildPBRCSV([]string{
+			"2025-01-15,24859,262591,598,35951,209.79,7.35,94.01,95.36",
+			"2024-12-31,24859,262591,598,35951,209.79,7.35,94.01,95.36",
+		}),
+	}
+
+	response, err := uc.ImportData(context.Background(), req, testUserID)
+	require.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, []ValidationError{{Row: 3, Column: "date", Error: "date 2024-12-31 is outside the import year 2025"}}, response.Errors)
+	assert.Empty(t, repo.pbr)
+
+	// Without a year the file imports as before
+	req.Year = 0
+	response, err = uc.ImportData(context.Background(), req, testUserID)
+	require.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.Len(t, repo.pbr, 2)
+}
+
+func TestImportData_ReplaceMode(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+	importPBR := func(mode ImportMode, rows ...string) *ImportResponse {
+		response, err := uc.ImportData(ctx, &ImportRequest{
+			Type:      ImportPBR,
+			DataType:  "actual",
+			CompanyID: testCompanyID,
+			Version:   testVersion,
+			Mode:      mode,
+			File:      buildPBRCSV(rows),
+		}, testUserID)
+		require.NoError(t, err)
+		return response
+	}
+
+	require.True(t, importPBR("",
+		"2024-01-15,100,262591,598,35951,209.79,7.35,94.01,95.36",
+		"2024-02-15,200,262591,598,35951,209.79,7.35,94.01,95.36",
+	).Success)
+
+	// Re-importing January in the default mode is rejected as a duplicate date
+	assert.False(t, importPBR("", "2024-01-15,150,262591,598,35951,209.79,7.35,94.01,95.36").Success)
+
+	// Replace soft-deletes January (not February) and inserts the corrected row
+	response := importPBR(ImportModeReplace, "2024-01-15,150,262591,598,35951,209.79,7.35,94.01,95.36")
+	require.True(t, response.Success, response.Errors)
+
+	active, err := repo.ListPBRData(ctx, testCompanyID, 2024, "actual", testVersion)
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+	assert.Equal(t, 200.0, active[0].OreMinedT)
+	assert.Equal(t, 150.0, active[1].OreMinedT)
+	assert.NotNil(t, repo.pbr[0].DeletedAt)
+}
+
+func TestImportData_ConvertsCurrencyToUSD(t *testing.T) {
+	rates := CurrencyRates{}
+	rates.Set(CurrencyARS, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 800)
+	repo := &softDeleteRepository{rates: rates}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+	req := func(rows ...string) *ImportRequest {
+		return &ImportRequest{
+			Type:      ImportOPEX,
+			DataType:  "actual",
+			CompanyID: testCompanyID,
+			Version:   testVersion,
+			File:      []byte("date,cost_center,subcategory,expense_type,amount,currency\n" + strings.Join(rows, "\n") + "\n"),
+		}
+	}
+
+	response, err := uc.ImportData(context.Background(), req(
+		"2024-01-31,Mine,Drilling,Labour,1000,USD",
+		"2024-01-31,Mine,Drilling,Labour,2000000,ARS",
+	), testUserID)
+	require.NoError(t, err)
+	require.True(t, response.Success, response.Errors)
+	require.Len(t, repo.opex, 2)
+
+	assert.Equal(t, 1000.0, repo.opex[0].Amount)
+	assert.Equal(t, 1.0, repo.opex[0].ExchangeRate)
+
+	ars := repo.opex[1]
+	assert.Equal(t, 2500.0, ars.Amount)
+	assert.Equal(t, "USD", ars.Currency)
+	assert.Equal(t, 2000000.0, ars.OriginalAmount)
+	assert.Equal(t, "ARS", ars.OriginalCurrency)
+	assert.Equal(t, 800.0, ars.ExchangeRate)
+
+	// No ARS rate for February: the row is rejected and nothing is stored
+	response, err = uc.ImportData(context.Background(), req(
+		"2024-01-31,Mine,Drilling,Labour,1000,ARS",
+		"2024-02-29,Mine,Drilling,Labour,1000,ARS",
+	), testUserID)
+	require.NoError(t, err)
+	assert.False(t, response.Success)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, ValidationError{Row: 3, Column: "currency", Error: "no exchange rate for ARS in 2024-02"}, response.Errors[0])
+	assert.Len(t, repo.opex, 2)
+}
+
+func TestDeleteDataScope(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+	response, err := uc.ImportData(ctx, &ImportRequest{
+		Type:      ImportPBR,
+		DataType:  "actual",
+		CompanyID: testCompanyID,
+		Version:   testVersion,
+		File:      buildPBRCSV([]string{validPBRRow, strings.Replace(validPBRRow, "-01-15", "-01-16", 1), strings.Replace(validPBRRow, "-01-15", "-02-15", 1)}),
+	}, testUserID)
+	require.NoError(t, err)
+	require.True(t, response.Success, response.Errors)
+
+	deleted, err := uc.DeleteDataScope(ctx, &DeleteScopeRequest{Type: ImportPBR, CompanyID: testCompanyID, Year: 2024, Month: 1, DataType: "actual", Version: testVersion})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted.RowsDeleted)
+
+	listed, err := uc.ListData(ctx, ImportPBR, testCompanyID, 2024, "actual", testVersion, false)
+	require.NoError(t, err)
+	rows := listed.([]*PBRData)
+	require.Len(t, rows, 1)
+	assert.Equal(t, time.February, rows[0].Date.Month())
+
+	// Nothing left to delete in January
+	deleted, err = uc.DeleteDataScope(ctx, &DeleteScopeRequest{Type: ImportPBR, CompanyID: testCompanyID, Year: 2024, Month: 1, DataType: "actual", Version: testVersion})
+	require.NoError(t, err)
+	assert.Zero(t, deleted.RowsDeleted)
+
+	_, err = uc.DeleteDataScope(ctx, &DeleteScopeRequest{Type: "unknown", CompanyID: testCompanyID, Year: 2024, Month: 1, DataType: "actual", Version: testVersion})
+	assert.ErrorIs(t, err, ErrInvalidDataType)
+}
+
+func TestImportData_RecordsImportLog(t *testing.T) {
+	ctx := context.Background()
+	repo := &softDeleteRepository{}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+	req := func(rows ...string) *ImportRequest {
+		return &ImportRequest{
+			Type:      ImportPBR,
+			DataType:  "budget",
+			CompanyID: testCompanyID,
+			Version:   2,
+			File:      buildPBRCSV(rows),
+			Filename:  "pbr_budget_2024.csv",
+		}
+	}
+
+	response, err := uc.ImportData(ctx, req(validPBRRow, strings.Replace(validPBRRow, "-01-15", "-02-15", 1)), testUserID)
+	require.NoError(t, err)
+	require.True(t, response.Success, response.Errors)
+
+	// A failed import is not logged
+	response, err = uc.ImportData(ctx, req("2024-03-15,abc,262591,598,35951,209.79,7.35,94.01,95.36"), testUserID)
+	require.NoError(t, err)
+	require.False(t, response.Success)
+
+	logs, err := uc.ListImports(ctx, testCompanyID, 0)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, ImportLog{
+		CompanyID: testCompanyID,
+		UserID:    testUserID,
+		Type:      ImportPBR,
+		DataType:  "budget",
+		Version:   2,
+		Mode:      ImportModeInsert,
+		Filename:  "pbr_budget_2024.csv",
+		RowCount:  2,
+	}, *logs[0])
+}
+
+// chunkRepository records the size of each chunk a streaming PBR import inserts
+type chunkRepository struct {
+	*softDeleteRepository
+	chunks []int
+}
+
+func (r *chunkRepository) InsertPBRStream(ctx context.Context, replace bool, log *ImportLog, next func() ([]*PBRData, error)) error {
+	return r.softDeleteRepository.InsertPBRStream(ctx, replace, log, func() ([]*PBRData, error) {
+		records, err := next()
+		if len(records) > 0 {
+			r.chunks = append(r.chunks, len(records))
+		}
+		return records, err
+	})
+}
+
+func TestImportData_StreamsPBRInChunks(t *testing.T) {
+	ctx := context.Background()
+	repo := &chunkRepository{softDeleteRepository: &softDeleteRepository{}}
+	uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+	// Daily rows over several years, more than two insert batches
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]string, insertBatchSize*2+100)
+	for i := range rows {
+		rows[i] = strings.Replace(validPBRRow, "2024-01-15", start.AddDate(0, 0, i).Format("2006-01-02"), 1)
+	}
+	req := func(rows []string) *ImportRequest {
+		return &ImportRequest{
+			Type:      ImportPBR,
+			DataType:  "actual",
+			CompanyID: testCompanyID,
+			Version:   testVersion,
+			File:      buildPBRCSV(rows),
+		}
+	}
+
+	// A bad row after the first chunks rejects the whole file, with every error reported
+	bad := slices.Clone(rows)
+	bad[insertBatchSize+10] = strings.Replace(bad[insertBatchSize+10], "24859", "abc", 1)
+	bad[len(bad)-1] = strings.Replace(bad[len(bad)-1], "262591", "", 1)
+	response, err := uc.ImportData(ctx, req(bad), testUserID)
+	require.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, len(rows), response.RowsTotal)
+	assert.Equal(t, 2, response.RowsFailed)
+	require.Len(t, response.Errors, 2)
+	assert.Equal(t, insertBatchSize+12, response.Errors[0].Row)
+	assert.Equal(t, len(rows)+1, response.Errors[1].Row)
+	assert.Empty(t, repo.pbr, "a rejected file must not insert any chunk")
+
+	repo.chunks = nil
+	response, err = uc.ImportData(ctx, req(rows), testUserID)
+	require.NoError(t, err)
+	require.True(t, response.Success, response.Errors)
+	assert.Equal(t, len(rows), response.RowsInserted)
+	assert.Equal(t, []int{insertBatchSize, insertBatchSize, 100}, repo.chunks)
+	require.Len(t, repo.imports, 1)
+	assert.Equal(t, len(rows), repo.imports[0].RowCount)
+
+	// Importing it again: every row is a duplicate, found with one query over the file's dates
+	repo.pbrDateQueries = 0
+	response, err = uc.ImportData(ctx, req(rows), testUserID)
+	require.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, len(rows), response.RowsFailed)
+	assert.Equal(t, 1, repo.pbrDateQueries)
+}
+
+// bundleRepository is a softDeleteRepository whose InTransaction drops the PBR, Dore and
+// OPEX rows fn inserted when fn fails, as a rollback would
+type bundleRepository struct {
+	softDeleteRepository
+	commits int
+}
+
+func (r *bundleRepository) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	pbr, dore, opex := len(r.pbr), len(r.dore), len(r.opex)
+	if err := fn(ctx); err != nil {
+		r.pbr, r.dore, r.opex = r.pbr[:pbr], r.dore[:dore], r.opex[:opex]
+		return err
+	}
+	r.commits++
+	return nil
+}
+
+func TestImportBundle(t *testing.T) {
+	ctx := context.Background()
+	bundle := func(validateOnly bool, files map[DataImportType][]byte) []*ImportRequest {
+		var reqs []*ImportRequest
+		// Dore first: the bundle imports PBR before it anyway
+		for _, importType := range []DataImportType{ImportDore, ImportOPEX, ImportPBR} {
+			if file, ok := files[importType]; ok {
+				reqs = append(reqs, &ImportRequest{
+					Type:         importType,
+					DataType:     "actual",
+					CompanyID:    testCompanyID,
+					Version:      testVersion,
+					File:         file,
+					ValidateOnly: validateOnly,
+				})
+			}
+		}
+		return reqs
+	}
+	files := map[DataImportType][]byte{
+		ImportPBR:  buildPBRCSV([]string{validPBRRow}),
+		ImportDore: buildDoreCSV([]string{validDoreRow}),
+		ImportOPEX: buildOPEXCSV([]string{validOPEXRow}),
+	}
+
+	t.Run("all files land together", func(t *testing.T) {
+		repo := &bundleRepository{}
+		response, err := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportBundle(ctx, bundle(false, files), testUserID)
+		require.NoError(t, err)
+
+		assert.True(t, response.Success)
+		require.Len(t, response.Results, 3)
+		assert.Equal(t, 1, response.Results[ImportDore].RowsInserted)
+		assert.Len(t, repo.pbr, 1)
+		assert.Len(t, repo.dore, 1)
+		assert.Len(t, repo.opex, 1)
+		assert.Equal(t, 1, repo.commits)
+	})
+
+	t.Run("a rejected file rolls back the others", func(t *testing.T) {
+		repo := &bundleRepository{}
+		invalid := map[DataImportType][]byte{
+			ImportPBR:  files[ImportPBR],
+			ImportDore: files[ImportDore],
+			ImportOPEX: buildOPEXCSV([]string{"2024-01-15,Mine,Drilling,Labour,abc,USD"}),
+		}
+		response, err := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportBundle(ctx, bundle(false, invalid), testUserID)
+		require.NoError(t, err)
+
+		assert.False(t, response.Success)
+		assert.True(t, response.Results[ImportPBR].Success)
+		assert.Zero(t, response.Results[ImportPBR].RowsInserted)
+		assert.False(t, response.Results[ImportOPEX].Success)
+		assert.Empty(t, repo.pbr)
+		assert.Empty(t, repo.dore)
+		assert.Zero(t, repo.commits)
+	})
+
+	t.Run("validate only checks Dore against the bundle's PBR", func(t *testing.T) {
+		repo := &bundleRepository{}
+		response, err := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportBundle(ctx, bundle(true, files), testUserID)
+		require.NoError(t, err)
+
+		assert.True(t, response.Success)
+		assert.True(t, response.ValidateOnly)
+		assert.True(t, response.Results[ImportDore].Success)
+		assert.True(t, response.Results[ImportDore].ValidateOnly)
+		assert.Zero(t, response.Results[ImportDore].RowsInserted)
+		assert.Empty(t, repo.pbr)
+		assert.Empty(t, repo.dore)
+	})
+
+	t.Run("one file per type", func(t *testing.T) {
+		reqs := bundle(false, files)
+		_, err := NewUseCase(&bundleRepository{}, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportBundle(ctx, append(reqs, reqs[0]), testUserID)
+		assert.ErrorIs(t, err, ErrInvalidBundle)
+
+		_, err = NewUseCase(&bundleRepository{}, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportBundle(ctx, nil, testUserID)
+		assert.ErrorIs(t, err, ErrInvalidBundle)
+	})
+}
+
+// idempotencyKey is a key kept by idempotencyRepository
+type idempotencyKey struct {
+	fingerprint string
+	response    *ImportResponse
+}
+
+// idempotencyRepository is a bundleRepository that keeps idempotency keys, releasing the
+// keys claimed in a transaction that fails, as a rollback would
+type idempotencyRepository struct {
+	bundleRepository
+	keys map[string]idempotencyKey
+}
+
+func (r *idempotencyRepository) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	keys := maps.Clone(r.keys)
+	err := r.bundleRepository.InTransaction(ctx, fn)
+	if err != nil {
+		r.keys = keys
+	}
+	return err
+}
+
+func (r *idempotencyRepository) ClaimIdempotencyKey(ctx context.Context, userID int64, key, fingerprint string, ttl time.Duration) (*ImportResponse, bool, error) {
+	id := fmt.Sprintf("%d/%s", userID, key)
+	if stored, ok := r.keys[id]; ok {
+		if stored.fingerprint != fingerprint {
+			return nil, false, ErrIdempotencyKeyMismatch
+		}
+		replay := *stored.response
+		return &replay, false, nil
+	}
+	if r.keys == nil {
+		r.keys = make(map[string]idempotencyKey)
+	}
+	r.keys[id] = idempotencyKey{fingerprint: fingerprint}
+	return nil, true, nil
+}
+
+func (r *idempotencyRepository) SaveIdempotencyKeyResponse(ctx context.Context, userID int64, key string, response *ImportResponse) error {
+	id := fmt.Sprintf("%d/%s", userID, key)
+	stored := *response
+	r.keys[id] = idempotencyKey{fingerprint: r.keys[id].fingerprint, response: &stored}
+	return nil
+}
+
+func TestImportData_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	req := func(key string, rows ...string) *ImportRequest {
+		return &ImportRequest{
+			Type:           ImportPBR,
+			DataType:       "actual",
+			CompanyID:      testCompanyID,
+			Version:        testVersion,
+			File:           buildPBRCSV(rows),
+			IdempotencyKey: key,
+		}
+	}
+
+	t.Run("a retry replays the first response", func(t *testing.T) {
+		repo := &idempotencyRepository{}
+		uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+		first, err := uc.ImportData(ctx, req("upload-1", validPBRRow), testUserID)
+		require.NoError(t, err)
+		require.True(t, first.Success, first.Errors)
+		assert.False(t, first.Replayed)
+
+		retry, err := uc.ImportData(ctx, req("upload-1", validPBRRow), testUserID)
+		require.NoError(t, err)
+		assert.True(t, retry.Replayed)
+		assert.Equal(t, first.RowsInserted, retry.RowsInserted)
+		assert.Len(t, repo.pbr, 1, "the retry must not import again")
+
+		// Keys are per user
+		_, err = uc.ImportData(ctx, req("upload-1", strings.Replace(validPBRRow, "-01-15", "-01-16", 1)), testUserID+1)
+		require.NoError(t, err)
+		assert.Len(t, repo.pbr, 2)
+	})
+
+	t.Run("a rejected file releases the key", func(t *testing.T) {
+		repo := &idempotencyRepository{}
+		uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+		response, err := uc.ImportData(ctx, req("upload-2", "2024-01-15,abc,262591,598,35951,209.79,7.35,94.01,95.36"), testUserID)
+		require.NoError(t, err)
+		require.False(t, response.Success)
+		assert.Empty(t, repo.keys)
+
+		response, err = uc.ImportData(ctx, req("upload-2", validPBRRow), testUserID)
+		require.NoError(t, err)
+		assert.True(t, response.Success, response.Errors)
+		assert.False(t, response.Replayed)
+		assert.Len(t, repo.pbr, 1)
+	})
+
+	t.Run("a key reused for another upload is rejected", func(t *testing.T) {
+		repo := &idempotencyRepository{}
+		uc := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz)
+
+		_, err := uc.ImportData(ctx, req("upload-3", validPBRRow), testUserID)
+		require.NoError(t, err)
+
+		// Another file
+		_, err = uc.ImportData(ctx, req("upload-3", strings.Replace(validPBRRow, "-01-15", "-01-16", 1)), testUserID)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+
+		// Same file for another company or import
+		otherCompany := req("upload-3", validPBRRow)
+		otherCompany.CompanyID++
+		_, err = uc.ImportData(ctx, otherCompany, testUserID)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+		budget := req("upload-3", validPBRRow)
+		budget.DataType = "budget"
+		_, err = uc.ImportData(ctx, budget, testUserID)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+		assert.Len(t, repo.pbr, 1)
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		_, err := NewUseCase(&idempotencyRepository{}, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportData(ctx, req(strings.Repeat("k", 256), validPBRRow), testUserID)
+		assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+	})
+}
+
+// firstImportRepository is a softDeleteRepository of a company without a saved format profile
+type firstImportRepository struct {
+	*softDeleteRepository
+	saved []FormatProfile
+}
+
+func (r *firstImportRepository) GetFormatProfile(ctx context.Context, companyID int64) (*FormatProfile, error) {
+	return nil, nil
+}
+
+func (r *firstImportRepository) SaveFormatProfile(ctx context.Context, companyID int64, profile FormatProfile) error {
+	r.saved = append(r.saved, profile)
+	return nil
+}
+
+func TestImportData_SavesOnlyCertainFormatProfile(t *testing.T) {
+	ctx := context.Background()
+	importOPEX := func(rows ...string) *firstImportRepository {
+		repo := &firstImportRepository{softDeleteRepository: &softDeleteRepository{}}
+		response, err := NewUseCase(repo, DefaultMaxBudgetVersions, GramsPerTroyOz).ImportData(ctx, &ImportRequest{
+			Type:      ImportOPEX,
+			DataType:  "actual",
+			CompanyID: testCompanyID,
+			Version:   testVersion,
+			File:      buildOPEXCSV(rows),
+		}, testUserID)
+		require.NoError(t, err)
+		require.True(t, response.Success, response.Errors)
+		return repo
+	}
+
+	// A decimal comma in a subcategory leaves the amounts alone
+	repo := importOPEX(`2024-01-15,Mine,"Drilling 1,2",Labour,1234.56,USD`)
+	require.Len(t, repo.opex, 1)
+	assert.Equal(t, 1234.56, repo.opex[0].Amount)
+	require.Len(t, repo.saved, 1)
+	assert.Equal(t, ".", repo.saved[0].DecimalSeparator)
+
+	// Whole amounts do not tell the decimal separator: nothing is saved
+	repo = importOPEX(validOPEXRow)
+	assert.Empty(t, repo.saved)
+}
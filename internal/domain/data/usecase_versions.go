@@ -0,0 +1,128 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// DefaultMaxBudgetVersions caps the budget versions a company can keep per year
+const DefaultMaxBudgetVersions = 10
+
+// checkBudgetVersionLimit rejects a budget import that would create a new version
+// once the company already has the maximum number of versions for a year in the file
+func (uc *useCase) checkBudgetVersionLimit(ctx context.Context, req *ImportRequest) error {
+	if req.DataType != string(DataTypeBudget) {
+		return nil
+	}
+
+	for _, year := range importYears(req.File, req.csvOptions()) {
+		versions, err := uc.repo.ListBudgetVersions(ctx, req.CompanyID, year)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(versions, req.Version) {
+			continue
+		}
+		if len(versions) >= uc.maxBudgetVersions {
+			return fmt.Errorf("%w: %d already has %d budget versions (maximum %d), prune old versions before creating version %d",
+				ErrTooManyBudgetVersions, year, len(versions), uc.maxBudgetVersions, req.Version)
+		}
+	}
+	return nil
+}
+
+// importYears returns the distinct years of the date column of a CSV file.
+// Unreadable files and invalid dates are skipped: the parsers report them.
+func importYears(fileContent []byte, opts csvOptions) []int {
+	var years []int
+	err := eachImportDate(fileContent, opts, func(_ int, date time.Time) {
+		if !slices.Contains(years, date.Year()) {
+			years = append(years, date.Year())
+		}
+	})
+	if err != nil {
+		return nil
+	}
+	return years
+}
+
+// rowsOutsideYear returns a date error for every row of a CSV file dated outside year.
+// Unreadable files and invalid dates are skipped: the parsers report them.
+func rowsOutsideYear(fileContent []byte, year int, opts csvOptions) []ValidationError {
+	var errors []ValidationError
+	err := eachImportDate(fileContent, opts, func(rowNum int, date time.Time) {
+		if date.Year() == year {
+			return
+		}
+		errors = append(errors, ValidationError{
+			Row:    rowNum,
+			Column: "date",
+			Error:  fmt.Sprintf("date %s is outside the import year %d", date.Format("2006-01-02"), year),
+		})
+	})
+	if err != nil {
+		return nil
+	}
+	return errors
+}
+
+// eachImportDate calls fn with the row number and date of each row of a CSV file, reading
+// the file row by row. Rows with an invalid date are skipped.
+func eachImportDate(fileContent []byte, opts csvOptions, fn func(rowNum int, date time.Time)) error {
+	reader, err := openCSV(fileContent, []string{"date"}, opts)
+	if err != nil {
+		return err
+	}
+	for rowNum := 2; ; rowNum++ {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if date, err := parseDate(row[0]); err == nil {
+			fn(rowNum, date)
+		}
+	}
+}
+
+// PruneBudgetVersions archives (soft deletes) all but the newest Keep budget versions of a company/year
+func (uc *useCase) PruneBudgetVersions(ctx context.Context, req *PruneVersionsRequest) (*PruneVersionsResponse, error) {
+	exists, err := uc.repo.CompanyExists(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCompanyNotFound
+	}
+
+	versions, err := uc.repo.ListBudgetVersions(ctx, req.CompanyID, req.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PruneVersionsResponse{
+		KeptVersions:     versions,
+		ArchivedVersions: []int{},
+	}
+	if len(versions) <= req.Keep {
+		return response, nil
+	}
+
+	// Versions are sorted ascending: the oldest go first
+	cut := len(versions) - req.Keep
+	archived, err := uc.repo.ArchiveBudgetVersions(ctx, req.CompanyID, req.Year, versions[:cut])
+	if err != nil {
+		return nil, err
+	}
+
+	response.ArchivedVersions = versions[:cut]
+	response.KeptVersions = versions[cut:]
+	response.RowsArchived = archived
+	return response, nil
+}
@@ -0,0 +1,331 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaTypeXLSX is the content type of an Excel workbook upload
+const MediaTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+var ErrInvalidXLSX = errors.New("invalid .xlsx file")
+
+// isXLSX reports whether an upload is an Excel workbook, by extension, content type
+// or content (a zip archive holding xl/workbook.xml)
+func isXLSX(filename, contentType string, content []byte) bool {
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") || contentType == MediaTypeXLSX {
+		return true
+	}
+	if !bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		return false
+	}
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	for _, file := range archive.File {
+		if file.Name == "xl/workbook.xml" {
+			return true
+		}
+	}
+	return false
+}
+
+// xlsxToCSV converts the first sheet of a workbook to CSV in the default format profile,
+// so the CSV parsers read it unchanged (see readXLSXRows)
+func xlsxToCSV(content []byte) ([]byte, error) {
+	rows, err := readXLSXRows(content)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readXLSXRows reads the first sheet of a workbook as rows of cell text, as a CSV export
+// would write them: numbers in plain decimal notation and date cells as YYYY-MM-DD.
+// Rows are cut or padded to the width of the header (first) row; empty rows are skipped.
+func readXLSXRows(content []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var workbook struct {
+		Properties struct {
+			Date1904 bool `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("%w: workbook has no sheets", ErrInvalidXLSX)
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RelID {
+			sheetPath = rel.Target
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("%w: first sheet not found", ErrInvalidXLSX)
+	}
+	if strings.HasPrefix(sheetPath, "/") {
+		sheetPath = strings.TrimPrefix(sheetPath, "/")
+	} else {
+		sheetPath = path.Join("xl", sheetPath)
+	}
+
+	sharedStrings, err := readXLSXSharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+	dateStyles, err := readXLSXDateStyles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string       `xml:"r,attr"`
+				Type   string       `xml:"t,attr"`
+				Style  int          `xml:"s,attr"`
+				Value  string       `xml:"v"`
+				Inline xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	dateBase := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if workbook.Properties.Date1904 {
+		dateBase = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	var rows [][]string
+	width := 0
+	for _, sheetRow := range sheet.Rows {
+		var row []string
+		for _, cell := range sheetRow.Cells {
+			col := len(row)
+			if cell.Ref != "" {
+				if col, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(value)
+				if err != nil || index < 0 || index >= len(sharedStrings) {
+					return nil, fmt.Errorf("%w: cell %s refers to a missing shared string", ErrInvalidXLSX, cell.Ref)
+				}
+				value = sharedStrings[index]
+			case "inlineStr":
+				value = cell.Inline.text()
+			case "b":
+				value = strings.ToUpper(strconv.FormatBool(value == "1"))
+			case "d":
+				value, _, _ = strings.Cut(value, "T") // ISO 8601 date cell
+			case "", "n":
+				if value == "" {
+					break
+				}
+				number, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%w: cell %s has an invalid number %q", ErrInvalidXLSX, cell.Ref, value)
+				}
+				if dateStyles[cell.Style] {
+					value = dateBase.AddDate(0, 0, int(math.Floor(number))).Format(canonicalDateLayout)
+				} else {
+					value = strconv.FormatFloat(number, 'f', -1, 64)
+				}
+			}
+			row[col] = value
+		}
+
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		if width == 0 {
+			// The header row sets the width: trailing empty header cells do not count
+			width = len(row)
+			for width > 0 && strings.TrimSpace(row[width-1]) == "" {
+				width--
+			}
+		}
+		for len(row) < width {
+			row = append(row, "")
+		}
+		rows = append(rows, row[:width])
+	}
+
+	return rows, nil
+}
+
+// xlsxRichText is a shared or inline string: plain text or runs of formatted text
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) text() string {
+	text := t.Text
+	for _, run := range t.Runs {
+		text += run.Text
+	}
+	return text
+}
+
+func readXLSXSharedStrings(files map[string]*zip.File) ([]string, error) {
+	if files["xl/sharedStrings.xml"] == nil {
+		return nil, nil // Workbooks without text cells may omit the part
+	}
+	var sst struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+	if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		strs[i] = item.text()
+	}
+	return strs, nil
+}
+
+// xlsxBuiltInDateFormats are the built-in number format IDs that display a date
+var xlsxBuiltInDateFormats = map[int]bool{14: true, 15: true, 16: true, 17: true, 22: true}
+
+// readXLSXDateStyles returns which cell style indexes (the s attribute) format a number as a date
+func readXLSXDateStyles(files map[string]*zip.File) (map[int]bool, error) {
+	dateStyles := make(map[int]bool)
+	if files["xl/styles.xml"] == nil {
+		return dateStyles, nil
+	}
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := decodeXLSXPart(files, "xl/styles.xml", &styles); err != nil {
+		return nil, err
+	}
+
+	dateFormats := make(map[int]bool)
+	for id := range xlsxBuiltInDateFormats {
+		dateFormats[id] = true
+	}
+	for _, format := range styles.NumFmts {
+		dateFormats[format.ID] = isDateFormatCode(format.Code)
+	}
+	for i, xf := range styles.CellXfs {
+		dateStyles[i] = dateFormats[xf.NumFmtID]
+	}
+	return dateStyles, nil
+}
+
+// isDateFormatCode reports whether a custom number format displays a date: it has a day
+// or year token outside quoted literals, escapes and [bracketed] sections
+func isDateFormatCode(code string) bool {
+	inQuotes, inBrackets := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '[':
+			inBrackets = true
+		case c == ']':
+			inBrackets = false
+		case inBrackets:
+		case c == '\\':
+			i++
+		case c == 'd' || c == 'D' || c == 'y' || c == 'Y':
+			return true
+		}
+	}
+	return false
+}
+
+// xlsxColumnIndex returns the 0-based column of a cell reference such as "C7" or "AA12"
+func xlsxColumnIndex(ref string) (int, error) {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidXLSX, ref)
+	}
+	return col - 1, nil
+}
+
+func decodeXLSXPart(files map[string]*zip.File, name string, v any) error {
+	file := files[name]
+	if file == nil {
+		return fmt.Errorf("%w: missing %s", ErrInvalidXLSX, name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	defer reader.Close()
+
+	// Parts are small, but cap them like uploads so a zip bomb cannot exhaust memory
+	if err := xml.NewDecoder(io.LimitReader(reader, 8*MaxUploadSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidXLSX, name, err)
+	}
+	return nil
+}
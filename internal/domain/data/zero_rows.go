@@ -0,0 +1,113 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ZeroRowCheck is what an import does with rows whose required numeric fields are all zero,
+// almost always the sign of a broken export
+type ZeroRowCheck string
+
+const (
+	ZeroRowCheckOff    ZeroRowCheck = "off"    // Import the rows (default)
+	ZeroRowCheckWarn   ZeroRowCheck = "warn"   // Import the rows with a warning per row
+	ZeroRowCheckReject ZeroRowCheck = "reject" // Fail the import with an error per row
+)
+
+// IsValid validates the zero row check
+func (c ZeroRowCheck) IsValid() bool {
+	switch c {
+	case ZeroRowCheckOff, ZeroRowCheckWarn, ZeroRowCheckReject:
+		return true
+	}
+	return false
+}
+
+// ParseZeroRowChecks reads the company setting, "type:action" pairs such as
+// "pbr:reject,dore:warn". Unknown types or actions are ignored.
+func ParseZeroRowChecks(raw string) map[DataImportType]ZeroRowCheck {
+	checks := make(map[DataImportType]ZeroRowCheck)
+	for _, pair := range strings.Split(raw, ",") {
+		importType, action, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		t, c := DataImportType(strings.TrimSpace(importType)), ZeroRowCheck(strings.TrimSpace(action))
+		if t.IsValid() && c.IsValid() {
+			checks[t] = c
+		}
+	}
+	return checks
+}
+
+// zeroRowMessage describes an all-zero row in warnings and errors
+const zeroRowMessage = "all required numeric fields are zero"
+
+// zeroRows returns the row numbers (as in ValidationError.Row) of rows whose required
+// numeric columns all parse to zero. Rows the parser would reject anyway (wrong column
+// count, unparsable numbers) are not reported here.
+func zeroRows(fileContent []byte, importType DataImportType, opts csvOptions) []int {
+	headers, ok := importHeaders(importType)
+	if !ok {
+		return nil
+	}
+	reader, err := openCSV(fileContent, headers, opts)
+	if err != nil {
+		return nil
+	}
+
+	var numeric []int
+	for i, name := range headers {
+		if columnType(name) == ColumnNumber && !optionalColumns[name] {
+			numeric = append(numeric, i)
+		}
+	}
+	if len(numeric) == 0 {
+		return nil
+	}
+
+	var result []int
+	for rowNum := 2; ; rowNum++ {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+		row, _ = splitNotes(row, len(headers))
+		if len(row) != len(headers) {
+			continue
+		}
+		allZero := true
+		for _, col := range numeric {
+			if v, err := parseFloat(row[col], false); err != nil || v != 0 {
+				allZero = false
+				break
+			}
+		}
+		if allZero {
+			result = append(result, rowNum)
+		}
+	}
+	return result
+}
+
+// checkZeroRows applies the company's check for the import type: warnings for warn,
+// validation errors for reject
+func checkZeroRows(check ZeroRowCheck, fileContent []byte, importType DataImportType, opts csvOptions) (warnings []string, errors []ValidationError) {
+	if check != ZeroRowCheckWarn && check != ZeroRowCheckReject {
+		return nil, nil
+	}
+	for _, row := range zeroRows(fileContent, importType, opts) {
+		if check == ZeroRowCheckWarn {
+			warnings = append(warnings, fmt.Sprintf("row %d: %s", row, zeroRowMessage))
+		} else {
+			errors = append(errors, ValidationError{Row: row, Error: zeroRowMessage})
+		}
+	}
+	return warnings, errors
+}
@@ -16,6 +16,14 @@ func NewHandler(useCase UseCase) *Handler {
 	}
 }
 
+// ReadinessResponse reports the database check, with its latency for alerting
+type ReadinessResponse struct {
+	Status    int     `json:"status"`
+	Database  string  `json:"database"` // "up" or "down"
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
 // Health checks if api is up
 // @Summary Checks if API is up
 // @Description Hits this API to see if API is running in the server
@@ -26,17 +34,30 @@ func (h *Handler) Health(w http.ResponseWriter, _ *http.Request) {
 	respond.JSON(w, http.StatusOK, map[string]int{"status": 200})
 }
 
+// Liveness checks if the process is up, without touching the database
+// @Summary Checks if API is up
+// @Description Hits this API to see if the API process is running; does not check the database
+// @Success 200
+// @router /api/health/liveness [get]
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.Health(w, r)
+}
+
 // Readiness checks if database is alive
 // @Summary Checks if both API and Database are up
-// @Description Hits this API to see if both API and Database are running in the server
-// @Success 200
-// @Failure 500
+// @Description Runs a query against the database (2s timeout) and returns its latency
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
 // @router /api/health/readiness [get]
-func (h *Handler) Readiness(w http.ResponseWriter, _ *http.Request) {
-	err := h.useCase.Readiness()
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	latency, err := h.useCase.Readiness(r.Context())
+	response := ReadinessResponse{
+		Status:    http.StatusOK,
+		Database:  "up",
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
 	if err != nil {
-		respond.Error(w, http.StatusInternalServerError, err)
-		return
+		response.Status, response.Database, response.Error = http.StatusServiceUnavailable, "down", err.Error()
 	}
-	respond.JSON(w, http.StatusOK, map[string]int{"status": 200})
+	respond.JSON(w, response.Status, response)
 }
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRepository struct {
+	err   error
+	delay time.Duration
+}
+
+func (r *stubRepository) Readiness(ctx context.Context) error {
+	select {
+	case <-time.After(r.delay):
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	readiness := func(repo Repository) (int, ReadinessResponse) {
+		rec := httptest.NewRecorder()
+		NewHandler(New(repo)).Readiness(rec, httptest.NewRequest(http.MethodGet, "/api/health/readiness", nil))
+		var response ReadinessResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+		return rec.Code, response
+	}
+
+	code, response := readiness(&stubRepository{delay: 5 * time.Millisecond})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "up", response.Database)
+	assert.GreaterOrEqual(t, response.LatencyMs, 5.0)
+
+	code, response = readiness(&stubRepository{err: errors.New("connection refused")})
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Status)
+	assert.Equal(t, "down", response.Database)
+	assert.Equal(t, "connection refused", response.Error)
+}
+
+func TestLivenessDoesNotCheckDatabase(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(New(&stubRepository{err: errors.New("connection refused")})).Liveness(rec, httptest.NewRequest(http.MethodGet, "/api/health/liveness", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
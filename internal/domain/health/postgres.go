@@ -1,11 +1,13 @@
 package health
 
 import (
+	"context"
+
 	"github.com/jmoiron/sqlx"
 )
 
 type Repository interface {
-	Readiness() error
+	Readiness(ctx context.Context) error
 }
 
 type repository struct {
@@ -18,6 +20,8 @@ func NewRepo(db *sqlx.DB) *repository {
 	}
 }
 
-func (r *repository) Readiness() error {
-	return r.db.Ping()
+// Readiness runs a round trip to the database, not only a check of the pool
+func (r *repository) Readiness(ctx context.Context) error {
+	var one int
+	return r.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
 }
@@ -13,6 +13,7 @@ func RegisterHTTPEndPoints(router *chi.Mux, uc UseCase) *Handler {
 		router.Use(middleware.JSON)
 
 		router.Get("/", h.Health)
+		router.Get("/liveness", h.Liveness)
 		router.Get("/readiness", h.Readiness)
 	})
 
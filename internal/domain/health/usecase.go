@@ -1,7 +1,16 @@
 package health
 
+import (
+	"context"
+	"time"
+)
+
+// ReadinessTimeout bounds the database check, so an unreachable database fails readiness
+// quickly instead of hanging the probe
+const ReadinessTimeout = 2 * time.Second
+
 type UseCase interface {
-	Readiness() error
+	Readiness(ctx context.Context) (time.Duration, error)
 }
 
 type Health struct {
@@ -14,6 +23,12 @@ func New(health Repository) *Health {
 	}
 }
 
-func (u *Health) Readiness() error {
-	return u.healthRepo.Readiness()
+// Readiness checks the database and returns how long the check took
+func (u *Health) Readiness(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := u.healthRepo.Readiness(ctx)
+	return time.Since(start), err
 }
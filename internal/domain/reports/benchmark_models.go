@@ -0,0 +1,76 @@
+package reports
+
+import "time"
+
+// DefaultPeerGroup is used when no peer_group is requested
+const DefaultPeerGroup = "default"
+
+// Benchmark KPI keys (benchmarks.metric)
+const (
+	BenchmarkAISCPerOzSilver     = "aisc_per_oz_silver"
+	BenchmarkCashCostPerOzSilver = "cash_cost_per_oz_silver"
+	BenchmarkRecoveryRateSilver  = "recovery_rate_silver_pct"
+	BenchmarkRecoveryRateGold    = "recovery_rate_gold_pct"
+	BenchmarkStrippingRatio      = "stripping_ratio"
+)
+
+// benchmarkKPIs are the company KPIs compared against benchmarks (in response order)
+var benchmarkKPIs = []struct {
+	metric string
+	value  func(ds *DataSet) float64
+}{
+	{BenchmarkAISCPerOzSilver, func(ds *DataSet) float64 { return ds.CashCost.AISCPerOzSilver }},
+	{BenchmarkCashCostPerOzSilver, func(ds *DataSet) float64 { return ds.CashCost.CashCostPerOzSilver }},
+	{BenchmarkRecoveryRateSilver, func(ds *DataSet) float64 { return ds.Processing.RecoveryRateSilverPct }},
+	{BenchmarkRecoveryRateGold, func(ds *DataSet) float64 { return ds.Processing.RecoveryRateGoldPct }},
+	{BenchmarkStrippingRatio, func(ds *DataSet) float64 { return ds.Mining.StrippingRatio }},
+}
+
+// Benchmark represents a peer group KPI value for a year
+type Benchmark struct {
+	ID        int64     `db:"id" json:"id"`
+	PeerGroup string    `db:"peer_group" json:"peer_group"`
+	Year      int       `db:"year" json:"year"`
+	Metric    string    `db:"metric" json:"metric"`
+	Value     float64   `db:"value" json:"value"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// BenchmarkMetric is a company KPI next to its benchmark value
+type BenchmarkMetric struct {
+	Metric       string  `json:"metric"`
+	Company      float64 `json:"company"`
+	Benchmark    float64 `json:"benchmark"`
+	Gap          float64 `json:"gap"`           // Company - Benchmark
+	HasBenchmark bool    `json:"has_benchmark"` // False when the peer group has no value for this metric
+}
+
+// BenchmarkReport compares a company's monthly KPIs against a peer group
+type BenchmarkReport struct {
+	CompanyID   int64             `json:"company_id"`
+	CompanyName string            `json:"company_name"`
+	Year        int               `json:"year"`
+	Month       int               `json:"month"`
+	PeerGroup   string            `json:"peer_group"`
+	HasData     bool              `json:"has_data"` // False when the company has no actual data for the month
+	Metrics     []BenchmarkMetric `json:"metrics"`
+}
+
+// buildBenchmarkMetrics pairs the company KPIs with the benchmark values (metric -> value)
+func buildBenchmarkMetrics(ds *DataSet, benchmarks map[string]float64) []BenchmarkMetric {
+	metrics := make([]BenchmarkMetric, 0, len(benchmarkKPIs))
+	for _, kpi := range benchmarkKPIs {
+		metric := BenchmarkMetric{Metric: kpi.metric}
+		if ds != nil {
+			metric.Company = kpi.value(ds)
+		}
+		if value, ok := benchmarks[kpi.metric]; ok {
+			metric.Benchmark = value
+			metric.Gap = metric.Company - value
+			metric.HasBenchmark = true
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
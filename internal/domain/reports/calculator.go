@@ -1,14 +1,95 @@
 package reports
 
 import (
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/gmhafiz/go8/internal/domain/data"
 )
 
+// GramsPerTroyOz converts grams to troy ounces in production calculations
+const GramsPerTroyOz = data.GramsPerTroyOz
+
+// PrimaryMetalCoProduct is the primary_metal company setting that reports cash costs per
+// silver and per gold ounce, split by revenue share, instead of gold as a by-product credit
+const PrimaryMetalCoProduct = "co_product"
+
 // Calculator calculates all derived metrics from raw data
-type Calculator struct{}
+type Calculator struct {
+	// CAPEX types subtracted from Production Based Margin in PBR Net Cash Flow
+	netCashFlowCapexTypes []string
+	excludedExpenseTypes  []string // OPEX expense types kept out of Production Based Costs
+	realizedPriceFallback bool     // Value Dore metal at the PBR price when no realized price was reported
+	gramsPerTroyOz        float64  // GramsPerTroyOz unless overridden for the deployment
+	coProduct             bool     // Split cash costs between silver and gold by revenue (primary_metal "co_product")
+	costCenters           []string // OPEX cost centers summed into Production Based Costs
+}
 
 func NewCalculator() *Calculator {
-	return &Calculator{}
+	return &Calculator{
+		netCashFlowCapexTypes: []string{string(data.CapexSustaining)},
+		gramsPerTroyOz:        GramsPerTroyOz,
+		costCenters:           defaultCostCenters(),
+	}
+}
+
+// defaultCostCenters are the cost centers of companies that have not configured their own
+func defaultCostCenters() []string {
+	centers := make([]string, len(data.CostCenters))
+	for i, center := range data.CostCenters {
+		centers[i] = string(center)
+	}
+	return centers
+}
+
+// companyCostCenters returns the company's configured cost centers, or the default ones
+func companyCostCenters(config *CompanyConfig) []string {
+	if config == nil || len(config.CostCenters) == 0 {
+		return defaultCostCenters()
+	}
+	return config.CostCenters
+}
+
+// WithGramsPerTroyOz overrides the grams per troy ounce of ounce calculations, e.g. 31.103477
+// to match a reference model. Values <= 0 keep the current one.
+func (c *Calculator) WithGramsPerTroyOz(grams float64) *Calculator {
+	if grams > 0 {
+		c.gramsPerTroyOz = grams
+	}
+	return c
+}
+
+// NewCalculatorForCompany returns a calculator using the company's PBR Net Cash Flow definition.
+// Falls back to the default (sustaining CAPEX only) when the company has none configured.
+func NewCalculatorForCompany(config *CompanyConfig) *Calculator {
+	c := NewCalculator()
+	if config != nil && len(config.NetCashFlowCapexTypes) > 0 {
+		c.netCashFlowCapexTypes = config.NetCashFlowCapexTypes
+	}
+	if config != nil {
+		c.excludedExpenseTypes = config.ExcludedExpenseTypes
+		c.realizedPriceFallback = config.RealizedPriceFallback
+		c.coProduct = config.PrimaryMetal == PrimaryMetalCoProduct
+	}
+	c.costCenters = companyCostCenters(config)
+	return c
+}
+
+// realizedPrices returns the silver and gold prices Dore metal is sold at.
+// With the fallback enabled, a zero (or missing) realized price is replaced by the PBR price.
+func (c *Calculator) realizedPrices(dore *data.DoreData) (silver, gold float64) {
+	silver, gold = dore.RealizedPriceSilver, dore.RealizedPriceGold
+	if !c.realizedPriceFallback {
+		return silver, gold
+	}
+	if silver <= 0 {
+		silver = dore.PBRPriceSilver
+	}
+	if gold <= 0 {
+		gold = dore.PBRPriceGold
+	}
+	return silver, gold
 }
 
 // CalculateDataSet calculates all metrics for a dataset
@@ -19,7 +100,7 @@ func (c *Calculator) CalculateDataSet(
 	opexList []*data.OPEXData,
 	capexList []*data.CAPEXData,
 ) *DataSet {
-	ds := &DataSet{}
+	ds := &DataSet{months: 1}
 
 	// Mining & Processing from PBR
 	if pbr != nil {
@@ -52,6 +133,11 @@ func (c *Calculator) CalculateDataSet(
 			Contractors:       pbr.Contractors,
 			TotalHeadcount:    pbr.TotalHeadcount,
 
+			// Productivity (single month: average = month headcount)
+			AverageHeadcount:  float64(pbr.TotalHeadcount),
+			TonnesPerEmployee: tonnesPerEmployee(pbr.OreMinedT, float64(pbr.TotalHeadcount)),
+			headcountMonths:   1,
+
 			HasData: true,
 		}
 
@@ -68,6 +154,13 @@ func (c *Calculator) CalculateDataSet(
 		ds.Production = c.calculateProduction(pbr)
 	}
 
+	// Payable metal comes from the Dore chain (metal in dore + adjustments - deductions),
+	// as in the Dore detail; the PBR recovered ounces are only a fallback without Dore
+	if dore != nil {
+		ds.Production.PayableSilverOz, ds.Production.PayableGoldOz = dore.PayableOz()
+		ds.Production.HasData = true
+	}
+
 	// Calculate costs from OPEX
 	if len(opexList) > 0 {
 		ds.Costs = c.calculateCosts(opexList)
@@ -78,6 +171,7 @@ func (c *Calculator) CalculateDataSet(
 		ds.NSR = c.calculateNSR(dore, financial, pbr, ds.Costs)
 		// Update ProductionBasedMargin in Costs after NSR is calculated
 		ds.Costs.ProductionBasedMargin = ds.NSR.NetSmelterReturn - ds.Costs.ProductionBasedCosts
+		ds.Costs.OperatingMarginPct = marginPct(ds.Costs.ProductionBasedMargin, ds.NSR.NetSmelterReturn)
 	}
 
 	// Calculate CAPEX
@@ -90,68 +184,124 @@ func (c *Calculator) CalculateDataSet(
 		ds.CashCost = c.calculateCashCost(ds.Costs, ds.CAPEX, ds.Production, dore, ds.NSR, financial)
 	}
 
+	// EBITDA needs revenue: like NSR, it is only reported with Dore data
+	if ds.NSR.HasData {
+		ds.Profitability = calculateProfitability(ds.NSR, ds.Costs)
+	}
+
+	setCapitalIntensity(ds)
+
 	return ds
 }
 
+// calculateProfitability calculates EBITDA and its margin (see ProfitabilityMetrics)
+func calculateProfitability(nsr NSRMetrics, costs CostMetrics) ProfitabilityMetrics {
+	ebitda := nsr.NetSmelterReturn - costs.ProductionBasedCosts - costs.ExcludedCosts
+	return ProfitabilityMetrics{
+		EBITDA:             ebitda,
+		OperatingMarginPct: marginPct(ebitda, nsr.NetSmelterReturn),
+		HasData:            true,
+	}
+}
+
+// setCapitalIntensity sets total CAPEX per ounce of annual production, annualizing the
+// payable silver ounces of the months accumulated in the dataset
+func setCapitalIntensity(ds *DataSet) {
+	if ds.months <= 0 {
+		return
+	}
+	ds.CAPEX.AnnualizedPayableSilverOz = ds.Production.PayableSilverOz * 12 / float64(ds.months)
+	ds.CAPEX.CapitalIntensity = 0
+	if ds.CAPEX.AnnualizedPayableSilverOz > 0 {
+		ds.CAPEX.CapitalIntensity = ds.CAPEX.Total / ds.CAPEX.AnnualizedPayableSilverOz
+	}
+}
+
 // calculateProduction calculates production from PBR data
 func (c *Calculator) calculateProduction(pbr *data.PBRData) ProductionMetrics {
-	// Formula: Feed Grade (g/t) * Tonnes Processed * Recovery Rate / 31.1035 (grams per oz)
-	silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / 31.1035
-	goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / 31.1035
+	// Contained metal: Feed Grade (g/t) * Tonnes Processed / grams per troy oz
+	containedSilverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed / c.gramsPerTroyOz
+	containedGoldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed / c.gramsPerTroyOz
+
+	// Formula: Feed Grade (g/t) * Tonnes Processed * Recovery Rate / grams per troy oz
+	silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / c.gramsPerTroyOz
+	goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / c.gramsPerTroyOz
 	doreProductionOz := silverOz + goldOz
 
 	return ProductionMetrics{
 		TotalProductionSilverOz: silverOz,
 		TotalProductionGoldOz:   goldOz,
-		PayableSilverOz:         silverOz, // From dore would be adjusted, using same for now
+		PayableSilverOz:         silverOz, // Replaced by the Dore payable ounces when there is Dore data
 		PayableGoldOz:           goldOz,
 		DoreProductionOz:        doreProductionOz,
+		ContainedSilverOz:       containedSilverOz,
+		ContainedGoldOz:         containedGoldOz,
+		MetalLossSilverOz:       containedSilverOz - silverOz,
+		MetalLossGoldOz:         containedGoldOz - goldOz,
+		MetalLossOz:             (containedSilverOz - silverOz) + (containedGoldOz - goldOz),
 		HasData:                 true,
 	}
 }
 
-// calculateCosts calculates cost breakdown from OPEX
+// calculateCosts calculates cost breakdown from OPEX. Every configured cost center counts in
+// Production Based Costs; the well-known four are also reported as named fields.
 func (c *Calculator) calculateCosts(opexList []*data.OPEXData) CostMetrics {
-	var mine, processing, ga, transport, inventory float64
+	var inventory, excluded float64
+	byCostCenter := make(map[string]float64, len(c.costCenters))
+	for _, center := range c.costCenters {
+		byCostCenter[center] = 0
+	}
 
+	// Adjustment rows (mode=adjust) are deltas: they sum like any other row
 	for _, opex := range opexList {
 		// Inventory variations handling
-		if opex.Subcategory == "Inventory Variation" || opex.Subcategory == "Stockpile/WIP" || opex.Subcategory == "Inventory Variations" {
+		if opex.Subcategory == data.SubcategoryInventoryVariations {
 			inventory += opex.Amount
 			continue
 		}
 
-		switch opex.CostCenter {
-		case "Mine":
-			mine += opex.Amount
-		case "Processing":
-			processing += opex.Amount
-		case "G&A":
-			ga += opex.Amount
-		case "Transport & Shipping":
-			transport += opex.Amount
+		// Company-excluded expense types (e.g. "Other") are reported separately;
+		// inventory variations above always stay in Production Based Costs
+		if slices.Contains(c.excludedExpenseTypes, opex.ExpenseType) {
+			excluded += opex.Amount
+			continue
+		}
+
+		if _, ok := byCostCenter[opex.CostCenter]; ok {
+			byCostCenter[opex.CostCenter] += opex.Amount
 		}
 	}
 
-	productionBasedCosts := mine + processing + ga + transport + inventory
+	productionBasedCosts := inventory
+	for _, amount := range byCostCenter {
+		productionBasedCosts += amount
+	}
 
 	return CostMetrics{
-		Mine:                  mine,
-		Processing:            processing,
-		GA:                    ga,
-		TransportShipping:     transport,
+		Mine:                  byCostCenter[string(data.CostCenterMine)],
+		Processing:            byCostCenter[string(data.CostCenterProcessing)],
+		GA:                    byCostCenter[string(data.CostCenterGA)],
+		TransportShipping:     byCostCenter[string(data.CostCenterTransport)],
 		InventoryVariations:   inventory,
 		ProductionBasedCosts:  productionBasedCosts,
 		ProductionBasedMargin: 0, // Calculated later with NSR
+		ExcludedCosts:         excluded,
+		ByCostCenter:          byCostCenter,
 		HasData:               true,
 	}
 }
 
 // calculateNSR calculates Net Smelter Return from Dore data + Financial adjustments
 func (c *Calculator) calculateNSR(dore *data.DoreData, financial *data.FinancialData, pbr *data.PBRData, costs CostMetrics) NSRMetrics {
+	priceSilver, priceGold := c.realizedPrices(dore)
+	return c.calculateNSRAtPrices(dore, financial, pbr, costs, priceSilver, priceGold)
+}
+
+// calculateNSRAtPrices is calculateNSR with payable metal sold at the given prices instead
+// of the realized ones. Charges, streaming and financial adjustments are kept as recorded.
+func (c *Calculator) calculateNSRAtPrices(dore *data.DoreData, financial *data.FinancialData, pbr *data.PBRData, costs CostMetrics, priceSilver, priceGold float64) NSRMetrics {
 	// Calculate metal in dore
-	metalSilverOz := dore.DoreProducedOz * (dore.SilverGradePct / 100)
-	metalGoldOz := dore.DoreProducedOz * (dore.GoldGradePct / 100)
+	metalSilverOz, metalGoldOz := dore.MetalOz()
 
 	// Apply adjustments
 	metalSilverAdjusted := metalSilverOz + dore.SilverAdjustmentOz
@@ -166,8 +316,8 @@ func (c *Calculator) calculateNSR(dore *data.DoreData, financial *data.Financial
 	payableGoldOz := metalGoldAdjusted - auDeductionsOz
 
 	// Gross revenue
-	grossRevenueSilver := payableSilverOz * dore.RealizedPriceSilver
-	grossRevenueGold := payableGoldOz * dore.RealizedPriceGold
+	grossRevenueSilver := payableSilverOz * priceSilver
+	grossRevenueGold := payableGoldOz * priceGold
 	doreRevenue := grossRevenueSilver + grossRevenueGold
 
 	// Total charges (Smelting & Refining)
@@ -183,20 +333,22 @@ func (c *Calculator) calculateNSR(dore *data.DoreData, financial *data.Financial
 	pbrRevenue := nsrDore + streaming
 
 	// Apply financial adjustments
-	var shippingSelling, salesTaxes, royalties, otherSalesDeductions float64
+	var shippingSelling, salesTaxes, royalties, otherSalesDeductions, otherAdjustments float64
 	if financial != nil {
 		shippingSelling = financial.ShippingSelling
 		salesTaxes = financial.SalesTaxes
 		royalties = financial.Royalties
 		otherSalesDeductions = financial.OtherSalesDeductions
+		otherAdjustments = financial.OtherAdjustments
 	}
 	salesTaxesRoyalties := salesTaxes + royalties
 
 	// Net Smelter Return = NSR Dore + Shipping/Selling + Sales Taxes + Royalties + Other Sales Deductions
-	netSmelterReturn := nsrDore + shippingSelling + salesTaxes + royalties + otherSalesDeductions
+	// + Other Adjustments, the same financial lines the financial detail totals
+	netSmelterReturn := nsrDore + shippingSelling + salesTaxes + royalties + otherSalesDeductions + otherAdjustments
 
 	// Gold credit (by-product credit) - negative value
-	goldCredit := -(payableGoldOz * dore.RealizedPriceGold)
+	goldCredit := -(payableGoldOz * priceGold)
 
 	var nsrPerTonne, costPerTonne, marginPerTonne float64
 	if pbr != nil && pbr.TotalTonnesProcessed > 0 {
@@ -206,29 +358,47 @@ func (c *Calculator) calculateNSR(dore *data.DoreData, financial *data.Financial
 	}
 
 	return NSRMetrics{
+		GrossRevenue:            doreRevenue,
+		GrossRevenueSilver:      grossRevenueSilver,
+		GrossRevenueGold:        grossRevenueGold,
 		NSRDore:                 nsrDore,
 		Streaming:               streaming,
 		PBRRevenue:              pbrRevenue,
 		ShippingSelling:         shippingSelling,
 		SalesTaxes:              salesTaxes,
 		Royalties:               royalties,
+		EffectiveTaxRate:        pctOfRevenue(salesTaxes, doreRevenue),
+		EffectiveRoyaltyRate:    pctOfRevenue(royalties, doreRevenue),
 		SalesTaxesRoyalties:     salesTaxesRoyalties,
 		OtherSalesDeductions:    otherSalesDeductions,
+		OtherAdjustments:        otherAdjustments,
 		SmeltingRefiningCharges: smeltingRefiningCharges,
 		NetSmelterReturn:        netSmelterReturn,
 		GoldCredit:              goldCredit,
-		SilverPricePerOz:        dore.RealizedPriceSilver,
-		GoldPricePerOz:          dore.RealizedPriceGold,
+		SilverPricePerOz:        revenuePerOz(grossRevenueSilver, payableSilverOz),
+		GoldPricePerOz:          revenuePerOz(grossRevenueGold, payableGoldOz),
 		NSRPerTonne:             nsrPerTonne,
 		TotalCostPerTonne:       costPerTonne,
 		MarginPerTonne:          marginPerTonne,
 		HasData:                 true,
+		payableSilverOz:         payableSilverOz,
+		payableGoldOz:           payableGoldOz,
 	}
 }
 
+// revenuePerOz is the blended price of realized revenue over its payable ounces, zero
+// without payable ounces
+func revenuePerOz(revenue, payableOz float64) float64 {
+	if payableOz <= 0 {
+		return 0
+	}
+	return revenue / payableOz
+}
+
 // calculateCAPEX calculates CAPEX breakdown
 func (c *Calculator) calculateCAPEX(capexList []*data.CAPEXData, nsr NSRMetrics, costs CostMetrics) CAPEXMetrics {
 	var sustaining, project, leasing, accretion float64
+	var leaseAdditions, leaseCashOutflows float64
 
 	for _, capex := range capexList {
 		switch capex.Type {
@@ -238,6 +408,12 @@ func (c *Calculator) calculateCAPEX(capexList []*data.CAPEXData, nsr NSRMetrics,
 			project += capex.Amount
 		case "leasing":
 			leasing += capex.Amount
+			// IFRS16: lease payments are reported apart from new right-of-use assets
+			if data.IsLeaseCashOutflow(capex.Category) {
+				leaseCashOutflows += capex.Amount
+			} else {
+				leaseAdditions += capex.Amount
+			}
 		}
 		// Accretion of Mine Closure Liability - now comes from the field
 		accretion += capex.AccretionOfMineClosureLiability
@@ -248,19 +424,76 @@ func (c *Calculator) calculateCAPEX(capexList []*data.CAPEXData, nsr NSRMetrics,
 	// Production Based Margin = Net Smelter Return - Production Based Costs
 	productionBasedMargin := nsr.NetSmelterReturn - costs.ProductionBasedCosts
 
-	// PBR Net Cash Flow = Production Based Margin - AISC Sustaining Capital
-	pbrNetCashFlow := productionBasedMargin - sustaining
-
-	return CAPEXMetrics{
-		Sustaining:                     sustaining,
-		Project:                        project,
-		Leasing:                        leasing,
+	metrics := CAPEXMetrics{
+		Sustaining:                      sustaining,
+		Project:                         project,
+		Leasing:                         leasing,
 		AccretionOfMineClosureLiability: accretion,
-		Total:                          total,
-		ProductionBasedMargin:          productionBasedMargin,
-		PBRNetCashFlow:                 pbrNetCashFlow,
-		HasData:                        len(capexList) > 0,
+		LeaseAdditions:                  leaseAdditions,
+		LeaseCashOutflows:               leaseCashOutflows,
+		Total:                           total,
+		ProductionBasedMargin:           productionBasedMargin,
+		HasData:                         len(capexList) > 0,
+	}
+
+	// PBR Net Cash Flow = Production Based Margin - configured CAPEX (sustaining by default)
+	metrics.PBRNetCashFlow = productionBasedMargin - c.netCashFlowCapex(metrics)
+	metrics.NetCashFlowBridge = c.netCashFlowBridge(nsr.NetSmelterReturn, metrics)
+
+	return metrics
+}
+
+// netCashFlowBridge lays out PBR Net Cash Flow step by step:
+// NSR → less Production Based Costs → Production Based Margin → less each configured CAPEX type → PBR Net Cash Flow
+func (c *Calculator) netCashFlowBridge(netSmelterReturn float64, capex CAPEXMetrics) []BridgeStep {
+	bridge := []BridgeStep{
+		{Label: "Net Smelter Return", Amount: netSmelterReturn, Subtotal: true},
+		{Label: "Production Based Costs", Amount: capex.ProductionBasedMargin - netSmelterReturn},
+		{Label: "Production Based Margin", Amount: capex.ProductionBasedMargin, Subtotal: true},
+	}
+
+	running := capex.ProductionBasedMargin
+	for _, capexType := range c.netCashFlowCapexTypes {
+		var amount float64
+		switch data.CapexType(capexType) {
+		case data.CapexSustaining:
+			amount = capex.Sustaining
+		case data.CapexProject:
+			amount = capex.Project
+		case data.CapexLeasing:
+			amount = capex.Leasing
+		default:
+			continue
+		}
+		running -= amount
+		bridge = append(bridge, BridgeStep{Label: capexBridgeLabel(capexType), Amount: -amount})
 	}
+
+	return append(bridge, BridgeStep{Label: "PBR Net Cash Flow", Amount: running, Subtotal: true})
+}
+
+// capexBridgeLabel names a CAPEX type in the net cash flow bridge, e.g. "Sustaining CAPEX"
+func capexBridgeLabel(capexType string) string {
+	if capexType == "" {
+		return "CAPEX"
+	}
+	return strings.ToUpper(capexType[:1]) + capexType[1:] + " CAPEX"
+}
+
+// netCashFlowCapex sums the CAPEX types included in the PBR Net Cash Flow definition
+func (c *Calculator) netCashFlowCapex(capex CAPEXMetrics) float64 {
+	var amount float64
+	for _, capexType := range c.netCashFlowCapexTypes {
+		switch data.CapexType(capexType) {
+		case data.CapexSustaining:
+			amount += capex.Sustaining
+		case data.CapexProject:
+			amount += capex.Project
+		case data.CapexLeasing:
+			amount += capex.Leasing
+		}
+	}
+	return amount
 }
 
 // calculateCashCost calculates cash cost and AISC per ounce
@@ -270,7 +503,8 @@ func (c *Calculator) calculateCashCost(costs CostMetrics, capex CAPEXMetrics, pr
 	// Gold credit (by-product credit) - positive value, will be subtracted
 	var goldCredit float64
 	if dore != nil && production.PayableGoldOz > 0 {
-		goldCredit = production.PayableGoldOz * dore.RealizedPriceGold
+		_, priceGold := c.realizedPrices(dore)
+		goldCredit = production.PayableGoldOz * priceGold
 	}
 
 	// Get financial components for cash cost calculation
@@ -285,38 +519,76 @@ func (c *Calculator) calculateCashCost(costs CostMetrics, capex CAPEXMetrics, pr
 		otherSalesDeductions = financial.OtherSalesDeductions
 	}
 
-	// Cash costs for silver = production costs + shipping + smelting + taxes + royalties + other - gold credit
-	cashCostsSilver := costs.ProductionBasedCosts +
+	// Cash costs = production costs + shipping + smelting + taxes + royalties + other
+	cashCosts := costs.ProductionBasedCosts +
 		shippingSelling +
 		nsr.SmeltingRefiningCharges +
 		salesTaxes +
 		royalties +
-		otherSalesDeductions -
-		goldCredit
+		otherSalesDeductions
 
-	// Cash cost per payable ounce of silver
-	var cashCostPerOzSilver, aiscPerOzSilver, sustainingCapitalPerOz float64
-	aiscSilver := cashCostsSilver + capex.Sustaining + capex.AccretionOfMineClosureLiability
+	metrics := c.allocateCashCosts(cashCosts, goldCredit, capex, production, nsr)
+	metrics.HasData = production.HasData && costs.HasData
+	return metrics
+}
 
-	if production.PayableSilverOz > 0 {
-		cashCostPerOzSilver = cashCostsSilver / production.PayableSilverOz
+// allocateCashCosts divides cash costs and AISC (cash costs + sustaining CAPEX + accretion of
+// mine closure liability) per payable ounce. By-product (default): everything is charged
+// to silver less the gold credit. Co-product: costs are split between silver and gold by
+// their share of gross revenue, with no gold credit.
+// The breakeven silver price is the AISC left after gold revenue per payable silver ounce in
+// both modes: from accumulated totals it is the YTD breakeven, not an average of months.
+func (c *Calculator) allocateCashCosts(cashCosts, goldCredit float64, capex CAPEXMetrics, production ProductionMetrics, nsr NSRMetrics) CashCostMetrics {
+	var metrics CashCostMetrics
+	var breakevenCost float64
+	if c.coProduct {
+		silverShare := 1.0
+		if revenue := nsr.GrossRevenueSilver + nsr.GrossRevenueGold; revenue != 0 {
+			silverShare = nsr.GrossRevenueSilver / revenue
+		}
+		aisc := cashCosts + capex.Sustaining + capex.AccretionOfMineClosureLiability
+		metrics.CashCostsSilver = cashCosts * silverShare
+		metrics.AISCSilver = aisc * silverShare
+		metrics.CashCostsGold = cashCosts - metrics.CashCostsSilver
+		metrics.AISCGold = aisc - metrics.AISCSilver
+		breakevenCost = aisc - nsr.GrossRevenueGold
+		if production.PayableGoldOz > 0 {
+			metrics.CashCostPerOzGold = metrics.CashCostsGold / production.PayableGoldOz
+			metrics.AISCPerOzGold = metrics.AISCGold / production.PayableGoldOz
+		}
+	} else {
+		metrics.GoldCredit = goldCredit
+		metrics.CashCostsSilver = cashCosts - goldCredit
+		metrics.AISCSilver = metrics.CashCostsSilver + capex.Sustaining + capex.AccretionOfMineClosureLiability
+		breakevenCost = metrics.AISCSilver
+	}
 
-		// AISC = Cash costs + Sustaining CAPEX + Accretion of Mine Closure Liability
-		aiscPerOzSilver = aiscSilver / production.PayableSilverOz
+	if production.PayableSilverOz > 0 {
+		metrics.CashCostPerOzSilver = metrics.CashCostsSilver / production.PayableSilverOz
+		metrics.AISCPerOzSilver = metrics.AISCSilver / production.PayableSilverOz
+		metrics.SustainingCapitalPerOz = capex.Sustaining / production.PayableSilverOz
+		metrics.BreakevenSilverPrice = breakevenCost / production.PayableSilverOz
+		if nsr.SilverPricePerOz > 0 {
+			metrics.AISCMarginPerOz = nsr.SilverPricePerOz - metrics.AISCPerOzSilver
+		}
+	}
+	return metrics
+}
 
-		// Sustaining Capital per oz
-		sustainingCapitalPerOz = capex.Sustaining / production.PayableSilverOz
+// tonnesPerEmployee calculates ore mined per employee (0 when there is no headcount)
+func tonnesPerEmployee(oreMinedT, headcount float64) float64 {
+	if headcount <= 0 {
+		return 0
 	}
+	return oreMinedT / headcount
+}
 
-	return CashCostMetrics{
-		CashCostPerOzSilver:    cashCostPerOzSilver,
-		AISCPerOzSilver:        aiscPerOzSilver,
-		CashCostsSilver:        cashCostsSilver,
-		AISCSilver:             aiscSilver,
-		GoldCredit:             goldCredit,
-		SustainingCapitalPerOz: sustainingCapitalPerOz,
-		HasData:                production.HasData && costs.HasData,
+// pctOfRevenue returns amount as a percentage of gross revenue (0 when there is no revenue)
+func pctOfRevenue(amount, grossRevenue float64) float64 {
+	if grossRevenue == 0 {
+		return 0
 	}
+	return (amount / grossRevenue) * 100
 }
 
 // Helper function to calculate variance percentage
@@ -327,6 +599,15 @@ func calculateVariancePct(actual, budget float64) float64 {
 	return ((actual - budget) / budget) * 100
 }
 
+// marginPct is a margin (Production Based Margin or EBITDA) as a percentage of NSR, 0 when
+// there is no NSR
+func marginPct(margin, netSmelterReturn float64) float64 {
+	if netSmelterReturn == 0 {
+		return 0
+	}
+	return margin / netSmelterReturn * 100
+}
+
 // CalculateVarianceData calculates variance for a monthly comparison
 func (c *Calculator) CalculateVarianceData(actual, budget *DataSet) *VarianceData {
 	if actual == nil || budget == nil {
@@ -358,6 +639,9 @@ func (c *Calculator) CalculateVarianceData(actual, budget *DataSet) *VarianceDat
 			FullTimeEmployees: VarianceMetric{Actual: float64(actual.Mining.FullTimeEmployees), Budget: float64(budget.Mining.FullTimeEmployees), Variance: float64(actual.Mining.FullTimeEmployees - budget.Mining.FullTimeEmployees), VariancePct: calculateVariancePct(float64(actual.Mining.FullTimeEmployees), float64(budget.Mining.FullTimeEmployees))},
 			Contractors:       VarianceMetric{Actual: float64(actual.Mining.Contractors), Budget: float64(budget.Mining.Contractors), Variance: float64(actual.Mining.Contractors - budget.Mining.Contractors), VariancePct: calculateVariancePct(float64(actual.Mining.Contractors), float64(budget.Mining.Contractors))},
 			TotalHeadcount:    VarianceMetric{Actual: float64(actual.Mining.TotalHeadcount), Budget: float64(budget.Mining.TotalHeadcount), Variance: float64(actual.Mining.TotalHeadcount - budget.Mining.TotalHeadcount), VariancePct: calculateVariancePct(float64(actual.Mining.TotalHeadcount), float64(budget.Mining.TotalHeadcount))},
+			// Productivity
+			AverageHeadcount:  VarianceMetric{Actual: actual.Mining.AverageHeadcount, Budget: budget.Mining.AverageHeadcount, Variance: actual.Mining.AverageHeadcount - budget.Mining.AverageHeadcount, VariancePct: calculateVariancePct(actual.Mining.AverageHeadcount, budget.Mining.AverageHeadcount)},
+			TonnesPerEmployee: VarianceMetric{Actual: actual.Mining.TonnesPerEmployee, Budget: budget.Mining.TonnesPerEmployee, Variance: actual.Mining.TonnesPerEmployee - budget.Mining.TonnesPerEmployee, VariancePct: calculateVariancePct(actual.Mining.TonnesPerEmployee, budget.Mining.TonnesPerEmployee)},
 		},
 		Processing: ProcessingVariance{
 			TotalTonnesProcessed:  VarianceMetric{Actual: actual.Processing.TotalTonnesProcessed, Budget: budget.Processing.TotalTonnesProcessed, Variance: actual.Processing.TotalTonnesProcessed - budget.Processing.TotalTonnesProcessed, VariancePct: calculateVariancePct(actual.Processing.TotalTonnesProcessed, budget.Processing.TotalTonnesProcessed)},
@@ -372,6 +656,9 @@ func (c *Calculator) CalculateVarianceData(actual, budget *DataSet) *VarianceDat
 			PayableSilverOz:         VarianceMetric{Actual: actual.Production.PayableSilverOz, Budget: budget.Production.PayableSilverOz, Variance: actual.Production.PayableSilverOz - budget.Production.PayableSilverOz, VariancePct: calculateVariancePct(actual.Production.PayableSilverOz, budget.Production.PayableSilverOz)},
 			PayableGoldOz:           VarianceMetric{Actual: actual.Production.PayableGoldOz, Budget: budget.Production.PayableGoldOz, Variance: actual.Production.PayableGoldOz - budget.Production.PayableGoldOz, VariancePct: calculateVariancePct(actual.Production.PayableGoldOz, budget.Production.PayableGoldOz)},
 			DoreProductionOz:        VarianceMetric{Actual: actual.Production.DoreProductionOz, Budget: budget.Production.DoreProductionOz, Variance: actual.Production.DoreProductionOz - budget.Production.DoreProductionOz, VariancePct: calculateVariancePct(actual.Production.DoreProductionOz, budget.Production.DoreProductionOz)},
+			ContainedSilverOz:       VarianceMetric{Actual: actual.Production.ContainedSilverOz, Budget: budget.Production.ContainedSilverOz, Variance: actual.Production.ContainedSilverOz - budget.Production.ContainedSilverOz, VariancePct: calculateVariancePct(actual.Production.ContainedSilverOz, budget.Production.ContainedSilverOz)},
+			ContainedGoldOz:         VarianceMetric{Actual: actual.Production.ContainedGoldOz, Budget: budget.Production.ContainedGoldOz, Variance: actual.Production.ContainedGoldOz - budget.Production.ContainedGoldOz, VariancePct: calculateVariancePct(actual.Production.ContainedGoldOz, budget.Production.ContainedGoldOz)},
+			MetalLossOz:             VarianceMetric{Actual: actual.Production.MetalLossOz, Budget: budget.Production.MetalLossOz, Variance: actual.Production.MetalLossOz - budget.Production.MetalLossOz, VariancePct: calculateVariancePct(actual.Production.MetalLossOz, budget.Production.MetalLossOz)},
 		},
 		Costs: CostVariance{
 			Mine:                  VarianceMetric{Actual: actual.Costs.Mine, Budget: budget.Costs.Mine, Variance: actual.Costs.Mine - budget.Costs.Mine, VariancePct: calculateVariancePct(actual.Costs.Mine, budget.Costs.Mine)},
@@ -381,16 +668,22 @@ func (c *Calculator) CalculateVarianceData(actual, budget *DataSet) *VarianceDat
 			InventoryVariations:   VarianceMetric{Actual: actual.Costs.InventoryVariations, Budget: budget.Costs.InventoryVariations, Variance: actual.Costs.InventoryVariations - budget.Costs.InventoryVariations, VariancePct: calculateVariancePct(actual.Costs.InventoryVariations, budget.Costs.InventoryVariations)},
 			ProductionBasedCosts:  VarianceMetric{Actual: actual.Costs.ProductionBasedCosts, Budget: budget.Costs.ProductionBasedCosts, Variance: actual.Costs.ProductionBasedCosts - budget.Costs.ProductionBasedCosts, VariancePct: calculateVariancePct(actual.Costs.ProductionBasedCosts, budget.Costs.ProductionBasedCosts)},
 			ProductionBasedMargin: VarianceMetric{Actual: actual.Costs.ProductionBasedMargin, Budget: budget.Costs.ProductionBasedMargin, Variance: actual.Costs.ProductionBasedMargin - budget.Costs.ProductionBasedMargin, VariancePct: calculateVariancePct(actual.Costs.ProductionBasedMargin, budget.Costs.ProductionBasedMargin)},
+			OperatingMarginPct:    VarianceMetric{Actual: actual.Costs.OperatingMarginPct, Budget: budget.Costs.OperatingMarginPct, Variance: actual.Costs.OperatingMarginPct - budget.Costs.OperatingMarginPct, VariancePct: calculateVariancePct(actual.Costs.OperatingMarginPct, budget.Costs.OperatingMarginPct)},
+			ExcludedCosts:         VarianceMetric{Actual: actual.Costs.ExcludedCosts, Budget: budget.Costs.ExcludedCosts, Variance: actual.Costs.ExcludedCosts - budget.Costs.ExcludedCosts, VariancePct: calculateVariancePct(actual.Costs.ExcludedCosts, budget.Costs.ExcludedCosts)},
 		},
 		NSR: NSRVariance{
+			GrossRevenue:            VarianceMetric{Actual: actual.NSR.GrossRevenue, Budget: budget.NSR.GrossRevenue, Variance: actual.NSR.GrossRevenue - budget.NSR.GrossRevenue, VariancePct: calculateVariancePct(actual.NSR.GrossRevenue, budget.NSR.GrossRevenue)},
 			NSRDore:                 VarianceMetric{Actual: actual.NSR.NSRDore, Budget: budget.NSR.NSRDore, Variance: actual.NSR.NSRDore - budget.NSR.NSRDore, VariancePct: calculateVariancePct(actual.NSR.NSRDore, budget.NSR.NSRDore)},
 			Streaming:               VarianceMetric{Actual: actual.NSR.Streaming, Budget: budget.NSR.Streaming, Variance: actual.NSR.Streaming - budget.NSR.Streaming, VariancePct: calculateVariancePct(actual.NSR.Streaming, budget.NSR.Streaming)},
 			PBRRevenue:              VarianceMetric{Actual: actual.NSR.PBRRevenue, Budget: budget.NSR.PBRRevenue, Variance: actual.NSR.PBRRevenue - budget.NSR.PBRRevenue, VariancePct: calculateVariancePct(actual.NSR.PBRRevenue, budget.NSR.PBRRevenue)},
 			ShippingSelling:         VarianceMetric{Actual: actual.NSR.ShippingSelling, Budget: budget.NSR.ShippingSelling, Variance: actual.NSR.ShippingSelling - budget.NSR.ShippingSelling, VariancePct: calculateVariancePct(actual.NSR.ShippingSelling, budget.NSR.ShippingSelling)},
 			SalesTaxes:              VarianceMetric{Actual: actual.NSR.SalesTaxes, Budget: budget.NSR.SalesTaxes, Variance: actual.NSR.SalesTaxes - budget.NSR.SalesTaxes, VariancePct: calculateVariancePct(actual.NSR.SalesTaxes, budget.NSR.SalesTaxes)},
 			Royalties:               VarianceMetric{Actual: actual.NSR.Royalties, Budget: budget.NSR.Royalties, Variance: actual.NSR.Royalties - budget.NSR.Royalties, VariancePct: calculateVariancePct(actual.NSR.Royalties, budget.NSR.Royalties)},
+			EffectiveTaxRate:        VarianceMetric{Actual: actual.NSR.EffectiveTaxRate, Budget: budget.NSR.EffectiveTaxRate, Variance: actual.NSR.EffectiveTaxRate - budget.NSR.EffectiveTaxRate, VariancePct: calculateVariancePct(actual.NSR.EffectiveTaxRate, budget.NSR.EffectiveTaxRate)},
+			EffectiveRoyaltyRate:    VarianceMetric{Actual: actual.NSR.EffectiveRoyaltyRate, Budget: budget.NSR.EffectiveRoyaltyRate, Variance: actual.NSR.EffectiveRoyaltyRate - budget.NSR.EffectiveRoyaltyRate, VariancePct: calculateVariancePct(actual.NSR.EffectiveRoyaltyRate, budget.NSR.EffectiveRoyaltyRate)},
 			SalesTaxesRoyalties:     VarianceMetric{Actual: actual.NSR.SalesTaxesRoyalties, Budget: budget.NSR.SalesTaxesRoyalties, Variance: actual.NSR.SalesTaxesRoyalties - budget.NSR.SalesTaxesRoyalties, VariancePct: calculateVariancePct(actual.NSR.SalesTaxesRoyalties, budget.NSR.SalesTaxesRoyalties)},
 			OtherSalesDeductions:    VarianceMetric{Actual: actual.NSR.OtherSalesDeductions, Budget: budget.NSR.OtherSalesDeductions, Variance: actual.NSR.OtherSalesDeductions - budget.NSR.OtherSalesDeductions, VariancePct: calculateVariancePct(actual.NSR.OtherSalesDeductions, budget.NSR.OtherSalesDeductions)},
+			OtherAdjustments:        VarianceMetric{Actual: actual.NSR.OtherAdjustments, Budget: budget.NSR.OtherAdjustments, Variance: actual.NSR.OtherAdjustments - budget.NSR.OtherAdjustments, VariancePct: calculateVariancePct(actual.NSR.OtherAdjustments, budget.NSR.OtherAdjustments)},
 			SmeltingRefiningCharges: VarianceMetric{Actual: actual.NSR.SmeltingRefiningCharges, Budget: budget.NSR.SmeltingRefiningCharges, Variance: actual.NSR.SmeltingRefiningCharges - budget.NSR.SmeltingRefiningCharges, VariancePct: calculateVariancePct(actual.NSR.SmeltingRefiningCharges, budget.NSR.SmeltingRefiningCharges)},
 			NetSmelterReturn:        VarianceMetric{Actual: actual.NSR.NetSmelterReturn, Budget: budget.NSR.NetSmelterReturn, Variance: actual.NSR.NetSmelterReturn - budget.NSR.NetSmelterReturn, VariancePct: calculateVariancePct(actual.NSR.NetSmelterReturn, budget.NSR.NetSmelterReturn)},
 			GoldCredit:              VarianceMetric{Actual: actual.NSR.GoldCredit, Budget: budget.NSR.GoldCredit, Variance: actual.NSR.GoldCredit - budget.NSR.GoldCredit, VariancePct: calculateVariancePct(actual.NSR.GoldCredit, budget.NSR.GoldCredit)},
@@ -405,17 +698,30 @@ func (c *Calculator) CalculateVarianceData(actual, budget *DataSet) *VarianceDat
 			Project:                        VarianceMetric{Actual: actual.CAPEX.Project, Budget: budget.CAPEX.Project, Variance: actual.CAPEX.Project - budget.CAPEX.Project, VariancePct: calculateVariancePct(actual.CAPEX.Project, budget.CAPEX.Project)},
 			Leasing:                        VarianceMetric{Actual: actual.CAPEX.Leasing, Budget: budget.CAPEX.Leasing, Variance: actual.CAPEX.Leasing - budget.CAPEX.Leasing, VariancePct: calculateVariancePct(actual.CAPEX.Leasing, budget.CAPEX.Leasing)},
 			AccretionOfMineClosureLiability: VarianceMetric{Actual: actual.CAPEX.AccretionOfMineClosureLiability, Budget: budget.CAPEX.AccretionOfMineClosureLiability, Variance: actual.CAPEX.AccretionOfMineClosureLiability - budget.CAPEX.AccretionOfMineClosureLiability, VariancePct: calculateVariancePct(actual.CAPEX.AccretionOfMineClosureLiability, budget.CAPEX.AccretionOfMineClosureLiability)},
+			LeaseAdditions:                  VarianceMetric{Actual: actual.CAPEX.LeaseAdditions, Budget: budget.CAPEX.LeaseAdditions, Variance: actual.CAPEX.LeaseAdditions - budget.CAPEX.LeaseAdditions, VariancePct: calculateVariancePct(actual.CAPEX.LeaseAdditions, budget.CAPEX.LeaseAdditions)},
+			LeaseCashOutflows:               VarianceMetric{Actual: actual.CAPEX.LeaseCashOutflows, Budget: budget.CAPEX.LeaseCashOutflows, Variance: actual.CAPEX.LeaseCashOutflows - budget.CAPEX.LeaseCashOutflows, VariancePct: calculateVariancePct(actual.CAPEX.LeaseCashOutflows, budget.CAPEX.LeaseCashOutflows)},
 			Total:                          VarianceMetric{Actual: actual.CAPEX.Total, Budget: budget.CAPEX.Total, Variance: actual.CAPEX.Total - budget.CAPEX.Total, VariancePct: calculateVariancePct(actual.CAPEX.Total, budget.CAPEX.Total)},
 			ProductionBasedMargin:          VarianceMetric{Actual: actual.CAPEX.ProductionBasedMargin, Budget: budget.CAPEX.ProductionBasedMargin, Variance: actual.CAPEX.ProductionBasedMargin - budget.CAPEX.ProductionBasedMargin, VariancePct: calculateVariancePct(actual.CAPEX.ProductionBasedMargin, budget.CAPEX.ProductionBasedMargin)},
 			PBRNetCashFlow:                 VarianceMetric{Actual: actual.CAPEX.PBRNetCashFlow, Budget: budget.CAPEX.PBRNetCashFlow, Variance: actual.CAPEX.PBRNetCashFlow - budget.CAPEX.PBRNetCashFlow, VariancePct: calculateVariancePct(actual.CAPEX.PBRNetCashFlow, budget.CAPEX.PBRNetCashFlow)},
+			CapitalIntensity:               VarianceMetric{Actual: actual.CAPEX.CapitalIntensity, Budget: budget.CAPEX.CapitalIntensity, Variance: actual.CAPEX.CapitalIntensity - budget.CAPEX.CapitalIntensity, VariancePct: calculateVariancePct(actual.CAPEX.CapitalIntensity, budget.CAPEX.CapitalIntensity)},
 		},
 		CashCost: CashCostVariance{
 			CashCostPerOzSilver:    VarianceMetric{Actual: actual.CashCost.CashCostPerOzSilver, Budget: budget.CashCost.CashCostPerOzSilver, Variance: actual.CashCost.CashCostPerOzSilver - budget.CashCost.CashCostPerOzSilver, VariancePct: calculateVariancePct(actual.CashCost.CashCostPerOzSilver, budget.CashCost.CashCostPerOzSilver)},
 			AISCPerOzSilver:        VarianceMetric{Actual: actual.CashCost.AISCPerOzSilver, Budget: budget.CashCost.AISCPerOzSilver, Variance: actual.CashCost.AISCPerOzSilver - budget.CashCost.AISCPerOzSilver, VariancePct: calculateVariancePct(actual.CashCost.AISCPerOzSilver, budget.CashCost.AISCPerOzSilver)},
 			CashCostsSilver:        VarianceMetric{Actual: actual.CashCost.CashCostsSilver, Budget: budget.CashCost.CashCostsSilver, Variance: actual.CashCost.CashCostsSilver - budget.CashCost.CashCostsSilver, VariancePct: calculateVariancePct(actual.CashCost.CashCostsSilver, budget.CashCost.CashCostsSilver)},
 			AISCSilver:             VarianceMetric{Actual: actual.CashCost.AISCSilver, Budget: budget.CashCost.AISCSilver, Variance: actual.CashCost.AISCSilver - budget.CashCost.AISCSilver, VariancePct: calculateVariancePct(actual.CashCost.AISCSilver, budget.CashCost.AISCSilver)},
+			CashCostPerOzGold:      VarianceMetric{Actual: actual.CashCost.CashCostPerOzGold, Budget: budget.CashCost.CashCostPerOzGold, Variance: actual.CashCost.CashCostPerOzGold - budget.CashCost.CashCostPerOzGold, VariancePct: calculateVariancePct(actual.CashCost.CashCostPerOzGold, budget.CashCost.CashCostPerOzGold)},
+			AISCPerOzGold:          VarianceMetric{Actual: actual.CashCost.AISCPerOzGold, Budget: budget.CashCost.AISCPerOzGold, Variance: actual.CashCost.AISCPerOzGold - budget.CashCost.AISCPerOzGold, VariancePct: calculateVariancePct(actual.CashCost.AISCPerOzGold, budget.CashCost.AISCPerOzGold)},
+			CashCostsGold:          VarianceMetric{Actual: actual.CashCost.CashCostsGold, Budget: budget.CashCost.CashCostsGold, Variance: actual.CashCost.CashCostsGold - budget.CashCost.CashCostsGold, VariancePct: calculateVariancePct(actual.CashCost.CashCostsGold, budget.CashCost.CashCostsGold)},
+			AISCGold:               VarianceMetric{Actual: actual.CashCost.AISCGold, Budget: budget.CashCost.AISCGold, Variance: actual.CashCost.AISCGold - budget.CashCost.AISCGold, VariancePct: calculateVariancePct(actual.CashCost.AISCGold, budget.CashCost.AISCGold)},
 			GoldCredit:             VarianceMetric{Actual: actual.CashCost.GoldCredit, Budget: budget.CashCost.GoldCredit, Variance: actual.CashCost.GoldCredit - budget.CashCost.GoldCredit, VariancePct: calculateVariancePct(actual.CashCost.GoldCredit, budget.CashCost.GoldCredit)},
 			SustainingCapitalPerOz: VarianceMetric{Actual: actual.CashCost.SustainingCapitalPerOz, Budget: budget.CashCost.SustainingCapitalPerOz, Variance: actual.CashCost.SustainingCapitalPerOz - budget.CashCost.SustainingCapitalPerOz, VariancePct: calculateVariancePct(actual.CashCost.SustainingCapitalPerOz, budget.CashCost.SustainingCapitalPerOz)},
+			BreakevenSilverPrice:   VarianceMetric{Actual: actual.CashCost.BreakevenSilverPrice, Budget: budget.CashCost.BreakevenSilverPrice, Variance: actual.CashCost.BreakevenSilverPrice - budget.CashCost.BreakevenSilverPrice, VariancePct: calculateVariancePct(actual.CashCost.BreakevenSilverPrice, budget.CashCost.BreakevenSilverPrice)},
+			AISCMarginPerOz:        VarianceMetric{Actual: actual.CashCost.AISCMarginPerOz, Budget: budget.CashCost.AISCMarginPerOz, Variance: actual.CashCost.AISCMarginPerOz - budget.CashCost.AISCMarginPerOz, VariancePct: calculateVariancePct(actual.CashCost.AISCMarginPerOz, budget.CashCost.AISCMarginPerOz)},
+		},
+		Profitability: ProfitabilityVariance{
+			EBITDA:             VarianceMetric{Actual: actual.Profitability.EBITDA, Budget: budget.Profitability.EBITDA, Variance: actual.Profitability.EBITDA - budget.Profitability.EBITDA, VariancePct: calculateVariancePct(actual.Profitability.EBITDA, budget.Profitability.EBITDA)},
+			OperatingMarginPct: VarianceMetric{Actual: actual.Profitability.OperatingMarginPct, Budget: budget.Profitability.OperatingMarginPct, Variance: actual.Profitability.OperatingMarginPct - budget.Profitability.OperatingMarginPct, VariancePct: calculateVariancePct(actual.Profitability.OperatingMarginPct, budget.Profitability.OperatingMarginPct)},
 		},
 	}
 }
@@ -471,6 +777,14 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		strippingRatioYTD = wasteYTD / openPitOreYTD
 	}
 
+	// Average headcount YTD: mean of monthly headcount across accumulated months
+	headcountMonthsYTD := ytd.Mining.headcountMonths + month.Mining.headcountMonths
+	var averageHeadcountYTD float64
+	if headcountMonthsYTD > 0 {
+		averageHeadcountYTD = (ytd.Mining.AverageHeadcount*float64(ytd.Mining.headcountMonths) +
+			month.Mining.AverageHeadcount*float64(month.Mining.headcountMonths)) / float64(headcountMonthsYTD)
+	}
+
 	accumulated.Mining = MiningMetrics{
 		// Ore breakdown
 		OpenPitOreT:     openPitOreYTD,
@@ -500,6 +814,11 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		Contractors:       month.Mining.Contractors,
 		TotalHeadcount:    month.Mining.TotalHeadcount,
 
+		// Productivity: average headcount over the year, tonnes on accumulated ore
+		AverageHeadcount:  averageHeadcountYTD,
+		TonnesPerEmployee: tonnesPerEmployee(totalOreYTD, averageHeadcountYTD),
+		headcountMonths:   headcountMonthsYTD,
+
 		HasData: ytd.Mining.HasData || month.Mining.HasData,
 	}
 
@@ -513,11 +832,11 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 			month.Processing.FeedGradeGoldGpt*month.Processing.TotalTonnesProcessed) / totalTonnesYTD
 		
 		// Recovery YTD: sum(recovered metal) / sum(contained metal) * 100
-		// Contained metal = Feed Grade * Tonnes Processed / 31.1035 (grams per oz)
+		// Contained metal = Feed Grade * Tonnes Processed / grams per troy oz
 		containedSilverYTD := (ytd.Processing.FeedGradeSilverGpt*ytd.Processing.TotalTonnesProcessed +
-			month.Processing.FeedGradeSilverGpt*month.Processing.TotalTonnesProcessed) / 31.1035
+			month.Processing.FeedGradeSilverGpt*month.Processing.TotalTonnesProcessed) / c.gramsPerTroyOz
 		containedGoldYTD := (ytd.Processing.FeedGradeGoldGpt*ytd.Processing.TotalTonnesProcessed +
-			month.Processing.FeedGradeGoldGpt*month.Processing.TotalTonnesProcessed) / 31.1035
+			month.Processing.FeedGradeGoldGpt*month.Processing.TotalTonnesProcessed) / c.gramsPerTroyOz
 		
 		// Recovered metal = Production (already accumulated)
 		recoveredSilverYTD := accumulated.Production.TotalProductionSilverOz
@@ -558,6 +877,11 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		PayableSilverOz:         ytd.Production.PayableSilverOz + month.Production.PayableSilverOz,
 		PayableGoldOz:           ytd.Production.PayableGoldOz + month.Production.PayableGoldOz,
 		DoreProductionOz:        ytd.Production.DoreProductionOz + month.Production.DoreProductionOz,
+		ContainedSilverOz:       ytd.Production.ContainedSilverOz + month.Production.ContainedSilverOz,
+		ContainedGoldOz:         ytd.Production.ContainedGoldOz + month.Production.ContainedGoldOz,
+		MetalLossSilverOz:       ytd.Production.MetalLossSilverOz + month.Production.MetalLossSilverOz,
+		MetalLossGoldOz:         ytd.Production.MetalLossGoldOz + month.Production.MetalLossGoldOz,
+		MetalLossOz:             ytd.Production.MetalLossOz + month.Production.MetalLossOz,
 		HasData:                 ytd.Production.HasData || month.Production.HasData,
 	}
 
@@ -570,11 +894,16 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		InventoryVariations:   ytd.Costs.InventoryVariations + month.Costs.InventoryVariations,
 		ProductionBasedCosts:  ytd.Costs.ProductionBasedCosts + month.Costs.ProductionBasedCosts,
 		ProductionBasedMargin: ytd.Costs.ProductionBasedMargin + month.Costs.ProductionBasedMargin,
+		ExcludedCosts:         ytd.Costs.ExcludedCosts + month.Costs.ExcludedCosts,
+		ByCostCenter:          sumByKey(ytd.Costs.ByCostCenter, month.Costs.ByCostCenter),
 		HasData:               ytd.Costs.HasData || month.Costs.HasData,
 	}
 
 	// NSR: sum
 	accumulated.NSR = NSRMetrics{
+		GrossRevenue:            ytd.NSR.GrossRevenue + month.NSR.GrossRevenue,
+		GrossRevenueSilver:      ytd.NSR.GrossRevenueSilver + month.NSR.GrossRevenueSilver,
+		GrossRevenueGold:        ytd.NSR.GrossRevenueGold + month.NSR.GrossRevenueGold,
 		NSRDore:                 ytd.NSR.NSRDore + month.NSR.NSRDore,
 		Streaming:               ytd.NSR.Streaming + month.NSR.Streaming,
 		PBRRevenue:              ytd.NSR.PBRRevenue + month.NSR.PBRRevenue,
@@ -583,31 +912,26 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		Royalties:               ytd.NSR.Royalties + month.NSR.Royalties,
 		SalesTaxesRoyalties:     ytd.NSR.SalesTaxesRoyalties + month.NSR.SalesTaxesRoyalties,
 		OtherSalesDeductions:    ytd.NSR.OtherSalesDeductions + month.NSR.OtherSalesDeductions,
+		OtherAdjustments:        ytd.NSR.OtherAdjustments + month.NSR.OtherAdjustments,
 		SmeltingRefiningCharges: ytd.NSR.SmeltingRefiningCharges + month.NSR.SmeltingRefiningCharges,
 		NetSmelterReturn:        ytd.NSR.NetSmelterReturn + month.NSR.NetSmelterReturn,
 		GoldCredit:              ytd.NSR.GoldCredit + month.NSR.GoldCredit,
-		// Metal prices: weighted average by payable oz (not summed)
-		SilverPricePerOz: 0,
-		GoldPricePerOz:   0,
 		// Per tonne metrics: recalculate from accumulated totals (handle division by zero)
 		NSRPerTonne:       0,
 		TotalCostPerTonne: 0,
 		MarginPerTonne:    0,
 		HasData:           ytd.NSR.HasData || month.NSR.HasData,
+		payableSilverOz:   ytd.NSR.payableSilverOz + month.NSR.payableSilverOz,
+		payableGoldOz:     ytd.NSR.payableGoldOz + month.NSR.payableGoldOz,
 	}
-	// Metal prices YTD: weighted average by payable silver/gold oz
-	totalPayableSilverOz := accumulated.Production.PayableSilverOz
-	totalPayableGoldOz := accumulated.Production.PayableGoldOz
-	if totalPayableSilverOz > 0 {
-		prevSilverWeight := ytd.Production.PayableSilverOz * ytd.NSR.SilverPricePerOz
-		currSilverWeight := month.Production.PayableSilverOz * month.NSR.SilverPricePerOz
-		accumulated.NSR.SilverPricePerOz = (prevSilverWeight + currSilverWeight) / totalPayableSilverOz
-	}
-	if totalPayableGoldOz > 0 {
-		prevGoldWeight := ytd.Production.PayableGoldOz * ytd.NSR.GoldPricePerOz
-		currGoldWeight := month.Production.PayableGoldOz * month.NSR.GoldPricePerOz
-		accumulated.NSR.GoldPricePerOz = (prevGoldWeight + currGoldWeight) / totalPayableGoldOz
-	}
+	// Effective rates YTD: from accumulated totals (not averaged)
+	accumulated.NSR.EffectiveTaxRate = pctOfRevenue(accumulated.NSR.SalesTaxes, accumulated.NSR.GrossRevenue)
+	accumulated.NSR.EffectiveRoyaltyRate = pctOfRevenue(accumulated.NSR.Royalties, accumulated.NSR.GrossRevenue)
+	// Metal prices YTD: from accumulated revenue and payable oz (not summed or averaged)
+	accumulated.NSR.SilverPricePerOz = revenuePerOz(accumulated.NSR.GrossRevenueSilver, accumulated.NSR.payableSilverOz)
+	accumulated.NSR.GoldPricePerOz = revenuePerOz(accumulated.NSR.GrossRevenueGold, accumulated.NSR.payableGoldOz)
+	// Operating margin from accumulated totals, not an average of monthly percentages
+	accumulated.Costs.OperatingMarginPct = marginPct(accumulated.Costs.ProductionBasedMargin, accumulated.NSR.NetSmelterReturn)
 	if accumulated.Processing.TotalTonnesProcessed > 0 {
 		accumulated.NSR.NSRPerTonne = accumulated.NSR.NetSmelterReturn / accumulated.Processing.TotalTonnesProcessed
 		accumulated.NSR.TotalCostPerTonne = accumulated.Costs.ProductionBasedCosts / accumulated.Processing.TotalTonnesProcessed
@@ -620,11 +944,19 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		Project:                         ytd.CAPEX.Project + month.CAPEX.Project,
 		Leasing:                         ytd.CAPEX.Leasing + month.CAPEX.Leasing,
 		AccretionOfMineClosureLiability: ytd.CAPEX.AccretionOfMineClosureLiability + month.CAPEX.AccretionOfMineClosureLiability,
+		LeaseAdditions:                  ytd.CAPEX.LeaseAdditions + month.CAPEX.LeaseAdditions,
+		LeaseCashOutflows:               ytd.CAPEX.LeaseCashOutflows + month.CAPEX.LeaseCashOutflows,
 		Total:                           ytd.CAPEX.Total + month.CAPEX.Total,
 		ProductionBasedMargin:            accumulated.Costs.ProductionBasedMargin,
-		PBRNetCashFlow:                   accumulated.Costs.ProductionBasedMargin - accumulated.CAPEX.Sustaining,
 		HasData:                          ytd.CAPEX.HasData || month.CAPEX.HasData,
 	}
+	// Net cash flow from accumulated totals (same definition as the monthly figure)
+	accumulated.CAPEX.PBRNetCashFlow = accumulated.Costs.ProductionBasedMargin - c.netCashFlowCapex(accumulated.CAPEX)
+	accumulated.CAPEX.NetCashFlowBridge = c.netCashFlowBridge(accumulated.NSR.NetSmelterReturn, accumulated.CAPEX)
+
+	// Capital intensity over the production of the accumulated months, annualized
+	accumulated.months = ytd.months + month.months
+	setCapitalIntensity(accumulated)
 
 	// Cash Cost: recalculate from accumulated totals using CORRECTED formula
 	// CashCost = ProdCosts + Shipping + Smelting + SalesTaxes + Royalties + OtherDeductions - GoldCredit
@@ -632,7 +964,8 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		// Calculate gold credit for current month
 		var monthGoldCredit float64
 		if monthDore != nil && month.Production.PayableGoldOz > 0 {
-			monthGoldCredit = month.Production.PayableGoldOz * monthDore.RealizedPriceGold
+			_, priceGold := c.realizedPrices(monthDore)
+			monthGoldCredit = month.Production.PayableGoldOz * priceGold
 		}
 		
 		// Accumulate gold credit: YTD = previous YTD + current month
@@ -644,33 +977,23 @@ func (c *Calculator) AccumulateYTD(ytd *DataSet, month *DataSet, monthDore *data
 		}
 		
 		// CORRECTED Cash Cost formula: includes shipping, smelting, taxes, royalties, other deductions
-		cashCostsSilverYTD := accumulated.Costs.ProductionBasedCosts +
+		cashCostsYTD := accumulated.Costs.ProductionBasedCosts +
 			accumulated.NSR.ShippingSelling +
 			accumulated.NSR.SmeltingRefiningCharges +
 			accumulated.NSR.SalesTaxes +
 			accumulated.NSR.Royalties +
-			accumulated.NSR.OtherSalesDeductions -
-			goldCreditYTD
-		
-		aiscSilverYTD := cashCostsSilverYTD + accumulated.CAPEX.Sustaining + accumulated.CAPEX.AccretionOfMineClosureLiability
-		
-		var cashCostPerOzSilver, aiscPerOzSilver, sustainingCapitalPerOz float64
-		if accumulated.Production.PayableSilverOz > 0 {
-			cashCostPerOzSilver = cashCostsSilverYTD / accumulated.Production.PayableSilverOz
-			aiscPerOzSilver = aiscSilverYTD / accumulated.Production.PayableSilverOz
-			sustainingCapitalPerOz = accumulated.CAPEX.Sustaining / accumulated.Production.PayableSilverOz
-		}
-		
-		accumulated.CashCost = CashCostMetrics{
-			CashCostPerOzSilver:    cashCostPerOzSilver,
-			AISCPerOzSilver:        aiscPerOzSilver,
-			CashCostsSilver:        cashCostsSilverYTD,
-			AISCSilver:             aiscSilverYTD,
-			GoldCredit:             goldCreditYTD,
-			SustainingCapitalPerOz: sustainingCapitalPerOz,
-			HasData:                true,
-		}
+			accumulated.NSR.OtherSalesDeductions
+
+		accumulated.CashCost = c.allocateCashCosts(cashCostsYTD, goldCreditYTD, accumulated.CAPEX, accumulated.Production, accumulated.NSR)
+		accumulated.CashCost.HasData = true
+	}
+
+	// Profitability: EBITDA sums, the margin is recalculated from the accumulated totals
+	accumulated.Profitability = ProfitabilityMetrics{
+		EBITDA:  ytd.Profitability.EBITDA + month.Profitability.EBITDA,
+		HasData: ytd.Profitability.HasData || month.Profitability.HasData,
 	}
+	accumulated.Profitability.OperatingMarginPct = marginPct(accumulated.Profitability.EBITDA, accumulated.NSR.NetSmelterReturn)
 
 	return accumulated
 }
@@ -680,13 +1003,33 @@ func (c *Calculator) copyDataSet(ds *DataSet) *DataSet {
 	if ds == nil {
 		return nil
 	}
-	return &DataSet{
-		Mining:     ds.Mining,
-		Processing: ds.Processing,
-		Production: ds.Production,
-		Costs:      ds.Costs,
-		NSR:        ds.NSR,
-		CAPEX:      ds.CAPEX,
-		CashCost:   ds.CashCost,
+	copied := &DataSet{
+		Mining:        ds.Mining,
+		Processing:    ds.Processing,
+		Production:    ds.Production,
+		Costs:         ds.Costs,
+		NSR:           ds.NSR,
+		CAPEX:         ds.CAPEX,
+		CashCost:      ds.CashCost,
+		Profitability: ds.Profitability,
+		months:        ds.months,
+	}
+	copied.CAPEX.NetCashFlowBridge = slices.Clone(ds.CAPEX.NetCashFlowBridge)
+	copied.Costs.ByCostCenter = maps.Clone(ds.Costs.ByCostCenter)
+	return copied
+}
+
+// sumByKey adds two breakdowns key by key into a new map (nil when both are empty)
+func sumByKey(a, b map[string]float64) map[string]float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	sum := make(map[string]float64, max(len(a), len(b)))
+	for key, value := range a {
+		sum[key] += value
+	}
+	for key, value := range b {
+		sum[key] += value
 	}
+	return sum
 }
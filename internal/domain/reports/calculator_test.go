@@ -2,8 +2,10 @@ package reports
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/gmhafiz/go8/internal/domain/data"
 )
@@ -27,6 +29,195 @@ func TestCalculateProduction(t *testing.T) {
 	assert.True(t, production.HasData)
 }
 
+func TestCalculateProductionContainedMetal(t *testing.T) {
+	calc := NewCalculator()
+	pbr := newTestPBRData()
+
+	production := calc.calculateProduction(pbr)
+
+	// Contained = Feed Grade * Tonnes / 31.1035, before recovery
+	assert.InDelta(t, pbr.FeedGradeSilverGpt*pbr.TotalTonnesProcessed/31.1035, production.ContainedSilverOz, 0.001)
+	assert.InDelta(t, pbr.FeedGradeGoldGpt*pbr.TotalTonnesProcessed/31.1035, production.ContainedGoldOz, 0.001)
+
+	// Recovered + loss = contained
+	assert.InDelta(t, production.ContainedSilverOz, production.TotalProductionSilverOz+production.MetalLossSilverOz, 0.001)
+	assert.InDelta(t, production.ContainedGoldOz, production.TotalProductionGoldOz+production.MetalLossGoldOz, 0.001)
+	assert.InDelta(t, production.MetalLossSilverOz+production.MetalLossGoldOz, production.MetalLossOz, 0.001)
+
+	// YTD keeps the identity
+	ytd := calc.AccumulateYTD(nil, calc.CalculateDataSet(pbr, nil, nil, nil, nil), nil, nil)
+	ytd = calc.AccumulateYTD(ytd, calc.CalculateDataSet(newTestPBRData(), nil, nil, nil, nil), nil, nil)
+	assert.InDelta(t, 2*production.ContainedSilverOz, ytd.Production.ContainedSilverOz, 0.001)
+	assert.InDelta(t, ytd.Production.ContainedGoldOz, ytd.Production.TotalProductionGoldOz+ytd.Production.MetalLossGoldOz, 0.001)
+}
+
+func TestPayableOuncesFromDore(t *testing.T) {
+	calc := NewCalculator()
+	pbr, dore := newTestPBRData(), newTestDoreData()
+
+	// With Dore: metal in dore + adjustments - deductions, as in the Dore detail
+	ds := calc.CalculateDataSet(pbr, dore, nil, newTestOPEXList(), nil)
+	detail := (&detailUseCase{calculator: calc}).buildDoreDetail(calc, dore, pbr)
+	assert.InDelta(t, detail.PayableSilverOz, ds.Production.PayableSilverOz, 0.001)
+	assert.InDelta(t, detail.PayableGoldOz, ds.Production.PayableGoldOz, 0.001)
+	assert.NotEqual(t, ds.Production.TotalProductionSilverOz, ds.Production.PayableSilverOz)
+	assert.InDelta(t, ds.CashCost.CashCostsSilver/ds.Production.PayableSilverOz, ds.CashCost.CashCostPerOzSilver, 0.001)
+
+	// Without Dore: the PBR recovered ounces
+	ds = calc.CalculateDataSet(pbr, nil, nil, nil, nil)
+	assert.Equal(t, ds.Production.TotalProductionSilverOz, ds.Production.PayableSilverOz)
+	assert.Equal(t, ds.Production.TotalProductionGoldOz, ds.Production.PayableGoldOz)
+}
+
+func TestCalculatorGramsPerTroyOzOverride(t *testing.T) {
+	pbr := newTestPBRData()
+	standard := NewCalculator().calculateProduction(pbr)
+	reference := NewCalculator().WithGramsPerTroyOz(31.103477).calculateProduction(pbr)
+
+	assert.InDelta(t, standard.TotalProductionSilverOz*GramsPerTroyOz/31.103477, reference.TotalProductionSilverOz, 0.0001)
+	assert.InDelta(t, pbr.FeedGradeGoldGpt*pbr.TotalTonnesProcessed/31.103477, reference.ContainedGoldOz, 0.0001)
+
+	// The detail reports use the same constant as the summary
+	uc := &detailUseCase{calculator: NewCalculator().WithGramsPerTroyOz(31.103477)}
+	assert.InDelta(t, reference.TotalProductionSilverOz, uc.buildPBRDetail(pbr).TotalProductionSilverOz, 0.0001)
+
+	// Zero keeps the default
+	assert.Equal(t, standard, NewCalculator().WithGramsPerTroyOz(0).calculateProduction(pbr))
+}
+
+func TestBuildPBRProductionMonths(t *testing.T) {
+	uc := &detailUseCase{calculator: NewCalculator()}
+	pbr := newTestPBRData()
+
+	months := uc.buildPBRProductionMonths(pbr.Date.Year(), []*data.PBRData{pbr})
+	assert.Len(t, months, 12)
+
+	// Seeded month equals the calculator's production; other months are empty
+	expected := NewCalculator().calculateProduction(pbr)
+	for i, m := range months {
+		if i+1 == int(pbr.Date.Month()) {
+			assert.Equal(t, pbr.Date.Format("2006-01"), m.Month)
+			assert.Equal(t, &expected, m.Production)
+			continue
+		}
+		assert.Nil(t, m.Production, m.Month)
+	}
+}
+
+func TestBuildDailyMetricsSumToMonth(t *testing.T) {
+	uc := &detailUseCase{calculator: NewCalculator()}
+
+	day1 := newTestPBRData()
+	day2 := newTestPBRData()
+	day2.Date = time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+	day2.TotalTonnesProcessed = 12000
+	february := newTestPBRData()
+	february.Date = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	opexList := newTestOPEXList() // All on 2024-01-15
+	late := &data.OPEXData{Date: day2.Date, CostCenter: "Mine", Subcategory: "Drilling", ExpenseType: "Labour", Amount: 4000}
+	opexList = append(opexList, late)
+
+	days, totals := uc.buildDailyMetrics(1, []*data.PBRData{day1, day2, february}, []*data.DoreData{newTestDoreData()}, opexList)
+
+	assert.Len(t, days, 2)
+	assert.Equal(t, "2024-01-15", days[0].Date)
+	assert.Equal(t, "2024-01-20", days[1].Date)
+	assert.Len(t, days[0].Dore, 1)
+	assert.Len(t, days[1].OPEX, 1)
+
+	// Daily rows add up to the monthly totals
+	var silverOz, goldOz, opex float64
+	for _, d := range days {
+		silverOz += d.Production.TotalProductionSilverOz
+		goldOz += d.Production.TotalProductionGoldOz
+		opex += d.OPEXTotal
+	}
+	assert.InDelta(t, totals.TotalProductionSilverOz, silverOz, 0.0001)
+	assert.InDelta(t, totals.TotalProductionGoldOz, goldOz, 0.0001)
+	assert.InDelta(t, totals.OPEXTotal, opex, 0.0001)
+
+	// ... and to the monthly roll-up of the same rows
+	expectedSilverOz := uc.calculator.calculateProduction(day1).TotalProductionSilverOz + uc.calculator.calculateProduction(day2).TotalProductionSilverOz
+	assert.InDelta(t, expectedSilverOz, totals.TotalProductionSilverOz, 0.0001)
+	assert.InDelta(t, uc.calculator.calculateCosts(opexList).ProductionBasedCosts, totals.OPEXTotal, 0.0001)
+	assert.Equal(t, newTestDoreData().DoreProducedOz, totals.DoreProducedOz)
+}
+
+func TestBuildDoreDetailChargeAttribution(t *testing.T) {
+	uc := &detailUseCase{calculator: NewCalculator()}
+	dore := newTestDoreData()
+
+	detail := uc.buildDoreDetail(uc.calculator, dore, newTestPBRData())
+
+	// Attributed charges add back up to the total
+	assert.InDelta(t, detail.TotalCharges, detail.ChargesSilver+detail.ChargesGold, 0.0001)
+	assert.InDelta(t, dore.TreatmentCharge, detail.TreatmentChargeSilver+detail.TreatmentChargeGold, 0.0001)
+
+	// Treatment split by payable ounces, refining deductions on gold only
+	silverShare := detail.PayableSilverOz / (detail.PayableSilverOz + detail.PayableGoldOz)
+	assert.InDelta(t, dore.TreatmentCharge*silverShare, detail.TreatmentChargeSilver, 0.0001)
+	assert.Equal(t, detail.TreatmentChargeSilver, detail.ChargesSilver)
+	assert.InDelta(t, detail.TreatmentChargeGold+dore.RefiningDeductionsAu, detail.ChargesGold, 0.0001)
+}
+
+func TestBuildDoreDetailReferencesSourcePBR(t *testing.T) {
+	uc := &detailUseCase{calculator: NewCalculator()}
+	pbr := newTestPBRData()
+	pbr.Date = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	detail := uc.buildDoreDetail(uc.calculator, newTestDoreData(), pbr)
+
+	require.NotNil(t, detail.SourcePBR)
+	assert.Equal(t, "2024-01-31", detail.SourcePBR.Date)
+	assert.Equal(t, pbr.TotalTonnesProcessed, detail.SourcePBR.TotalTonnesProcessed)
+	assert.Equal(t, pbr.FeedGradeSilverGpt, detail.SourcePBR.FeedGradeSilverGpt)
+
+	// No PBR for the month: nothing to reference
+	assert.Nil(t, uc.buildDoreDetail(uc.calculator, newTestDoreData(), nil).SourcePBR)
+}
+
+func TestDetailAggregationOrderIsDeterministic(t *testing.T) {
+	uc := &detailUseCase{calculator: NewCalculator()}
+	opexList := newTestOPEXList()
+	mineralMap := map[int]struct{ Code, Name string }{
+		1: {Code: "AG", Name: "Silver"},
+		2: {Code: "AU", Name: "Gold"},
+		3: {Code: "ZN", Name: "Zinc"},
+		4: {Code: "CU", Name: "Copper"},
+	}
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	production := []*data.ProductionData{
+		{Date: date, MineralID: 4, Quantity: 10},
+		{Date: date, MineralID: 3, Quantity: 20},
+	}
+	revenue := []*data.RevenueData{
+		{Date: date, MineralID: 4, QuantitySold: 10, UnitPrice: 9},
+		{Date: date, MineralID: 1, QuantitySold: 20, UnitPrice: 24},
+	}
+
+	build := func() [][]string {
+		_, byCostCenter, _, byExpenseType := uc.buildOPEXMonthlyData(2024, opexList, opexList, nil, defaultCostCenters())
+		_, _, productionOrder := uc.buildProductionMonthlyData(2024, nil, nil, production, production, mineralMap, nil)
+		_, _, revenueOrder := uc.buildRevenueMonthlyData(2024, revenue, revenue, mineralMap, nil)
+		return [][]string{orderedKeys(byCostCenter, data.CostCenters), orderedKeys(byExpenseType, data.ExpenseTypes), productionOrder, revenueOrder}
+	}
+
+	// Same ordering across two calls
+	orders := build()
+	assert.Equal(t, orders, build())
+
+	// Canonical order for known values, alphabetical otherwise
+	assert.Equal(t, []string{"Mine", "Processing", "G&A", "Transport & Shipping"}, orders[0])
+	assert.Equal(t, []string{"Labour", "Third Party", "Other"}, orders[1])
+	assert.Equal(t, []string{"Mine", "A", "z"}, orderedKeys(map[string]int{"z": 1, "Mine": 2, "A": 3}, data.CostCenters))
+
+	// Minerals in catalog order, not alphabetical
+	assert.Equal(t, []string{"ZN", "CU"}, orders[2])
+	assert.Equal(t, []string{"AG", "CU"}, orders[3])
+	assert.Equal(t, []string{"AU", "ZN", "PB"}, mineralOrder(map[string]int{"PB": 1, "ZN": 2, "AU": 3}, mineralMap))
+}
+
 func TestCalculateCosts(t *testing.T) {
 	calc := NewCalculator()
 	opexList := newTestOPEXList()
@@ -44,6 +235,105 @@ func TestCalculateCosts(t *testing.T) {
 	assert.True(t, costs.HasData)
 }
 
+func TestCalculateCostsWithAdjustment(t *testing.T) {
+	calc := NewCalculator()
+	opexList := newTestOPEXList()
+	base := calc.calculateCosts(opexList)
+
+	adjustment := &data.OPEXData{
+		Date:         opexList[0].Date,
+		CostCenter:   "Mine",
+		Subcategory:  "Drilling",
+		ExpenseType:  "Labour",
+		Amount:       25000,
+		Currency:     "USD",
+		IsAdjustment: true,
+	}
+	costs := calc.calculateCosts(append(opexList, adjustment))
+
+	// The adjustment adds on top of the existing Mine total
+	assert.Equal(t, base.Mine+25000, costs.Mine)
+	assert.Equal(t, base.Processing, costs.Processing)
+	assert.Equal(t, base.ProductionBasedCosts+25000, costs.ProductionBasedCosts)
+}
+
+func TestCalculateCostsWithReversal(t *testing.T) {
+	calc := NewCalculator()
+	opexList := newTestOPEXList()
+	base := calc.calculateCosts(opexList)
+
+	reversal := &data.OPEXData{
+		Date:        opexList[0].Date,
+		CostCenter:  "Mine",
+		Subcategory: "Drilling",
+		ExpenseType: "Third Party",
+		Amount:      -50000,
+		Currency:    "USD",
+	}
+	opexList = append(opexList, reversal)
+	costs := calc.calculateCosts(opexList)
+
+	// A credit memo or accrual reversal reduces its cost center and the total
+	assert.Equal(t, base.Mine-50000, costs.Mine)
+	assert.Equal(t, base.ProductionBasedCosts-50000, costs.ProductionBasedCosts)
+
+	detail := (&detailUseCase{}).buildOPEXDetail(opexList, calc.costCenters)
+	assert.Equal(t, costs.Mine, detail.Mine)
+	assert.Equal(t, costs.ProductionBasedCosts, detail.Total)
+}
+
+func TestCalculateCostsExcludedExpenseTypes(t *testing.T) {
+	opexList := newTestOPEXList()
+	other := &data.OPEXData{
+		Date:        opexList[0].Date,
+		CostCenter:  "G&A",
+		Subcategory: "Community Relations",
+		ExpenseType: "Other",
+		Amount:      120000,
+		Currency:    "USD",
+	}
+	opexList = append(opexList, other)
+
+	// Default: "Other" counts towards Production Based Costs
+	included := NewCalculator().calculateCosts(opexList)
+	assert.Equal(t, expectedProductionBasedCosts+120000, included.ProductionBasedCosts)
+	assert.Equal(t, 0.0, included.ExcludedCosts)
+
+	// Excluded by company settings: surfaced separately
+	calc := NewCalculatorForCompany(&CompanyConfig{ExcludedExpenseTypes: []string{"Other"}})
+	costs := calc.calculateCosts(opexList)
+	assert.Equal(t, expectedProductionBasedCosts, costs.ProductionBasedCosts)
+	assert.Equal(t, 5471220.0, costs.GA)
+	assert.Equal(t, 120000.0, costs.ExcludedCosts)
+}
+
+func TestCalculateCostsConfiguredCostCenters(t *testing.T) {
+	opexList := newTestOPEXList()
+	exploration := &data.OPEXData{
+		Date:        opexList[0].Date,
+		CostCenter:  "Exploration",
+		Subcategory: "Brownfield Drilling",
+		ExpenseType: "Third Party",
+		Amount:      80000,
+		Currency:    "USD",
+	}
+	opexList = append(opexList, exploration)
+
+	// Default cost centers: Exploration is not one of them
+	assert.Equal(t, expectedProductionBasedCosts, NewCalculator().calculateCosts(opexList).ProductionBasedCosts)
+
+	calc := NewCalculatorForCompany(&CompanyConfig{CostCenters: []string{"Mine", "Processing", "G&A", "Transport & Shipping", "Exploration"}})
+	costs := calc.calculateCosts(opexList)
+	assert.Equal(t, expectedProductionBasedCosts+80000, costs.ProductionBasedCosts)
+	assert.Equal(t, 80000.0, costs.ByCostCenter["Exploration"])
+	assert.Equal(t, 8537997.0, costs.Mine)
+	assert.Equal(t, costs.Mine, costs.ByCostCenter["Mine"])
+
+	// YTD sums the breakdown too
+	ytd := calc.AccumulateYTD(&DataSet{Costs: costs}, &DataSet{Costs: costs}, nil, nil)
+	assert.Equal(t, 160000.0, ytd.Costs.ByCostCenter["Exploration"])
+}
+
 func TestCalculateNSR(t *testing.T) {
 	calc := NewCalculator()
 	dore := newTestDoreData()
@@ -83,9 +373,9 @@ func TestCalculateNSR(t *testing.T) {
 	expectedNSR := nsr.NSRDore + nsr.ShippingSelling + nsr.SalesTaxes + nsr.Royalties + nsr.OtherSalesDeductions
 	assert.Equal(t, expectedNSR, nsr.NetSmelterReturn)
 
-	// Metal prices should be propagated from dore data
-	assert.Equal(t, dore.RealizedPriceSilver, nsr.SilverPricePerOz)
-	assert.Equal(t, dore.RealizedPriceGold, nsr.GoldPricePerOz)
+	// Metal prices: realized revenue per payable ounce, the realized price for a single Dore
+	assert.InDelta(t, dore.RealizedPriceSilver, nsr.SilverPricePerOz, 1e-9)
+	assert.InDelta(t, dore.RealizedPriceGold, nsr.GoldPricePerOz, 1e-9)
 
 	// Per tonne calculations
 	assert.Greater(t, nsr.NSRPerTonne, 0.0)
@@ -95,6 +385,121 @@ func TestCalculateNSR(t *testing.T) {
 	assert.True(t, nsr.HasData)
 }
 
+func TestCalculateNSRRealizedPriceFallback(t *testing.T) {
+	dore := newTestDoreData()
+	dore.RealizedPriceSilver = 0
+	pbr := newTestPBRData()
+	payableSilverOz, payableGoldOz := dore.PayableOz()
+
+	// Disabled (default): silver without a realized price earns nothing
+	nsr := NewCalculator().calculateNSR(dore, nil, pbr, CostMetrics{})
+	assert.InDelta(t, payableGoldOz*dore.RealizedPriceGold, nsr.GrossRevenue, 0.01)
+	assert.Zero(t, nsr.SilverPricePerOz)
+
+	// Enabled: silver is valued at the PBR price, gold keeps its realized price
+	calc := NewCalculatorForCompany(&CompanyConfig{RealizedPriceFallback: true})
+	nsr = calc.calculateNSR(dore, nil, pbr, CostMetrics{})
+	expected := payableSilverOz*dore.PBRPriceSilver + payableGoldOz*dore.RealizedPriceGold
+	assert.InDelta(t, expected, nsr.GrossRevenue, 0.01)
+	assert.InDelta(t, dore.PBRPriceSilver, nsr.SilverPricePerOz, 1e-9)
+	assert.InDelta(t, dore.RealizedPriceGold, nsr.GoldPricePerOz, 1e-9)
+}
+
+func TestRealizedPriceFallbackInDetailAndMargins(t *testing.T) {
+	dore := newTestDoreData()
+	dore.RealizedPriceSilver = 0
+	pbr := newTestPBRData()
+	payableSilverOz, _ := dore.PayableOz()
+	calc := NewCalculatorForCompany(&CompanyConfig{RealizedPriceFallback: true})
+
+	// Detail: revenue and the reported price use the PBR price
+	detail := (&detailUseCase{calculator: NewCalculator()}).buildDoreDetail(calc, dore, pbr)
+	assert.InDelta(t, dore.PBRPriceSilver, detail.RealizedPriceSilver, 1e-9)
+	assert.InDelta(t, payableSilverOz*dore.PBRPriceSilver, detail.GrossRevenueSilver, 0.01)
+
+	// Mineral margin: silver revenue matches the summary NSR
+	mineralMap := map[int]struct{ Code, Name string }{1: {Code: "AG", Name: "Silver"}}
+	byMineral := mineralRevenue(calc, []*data.DoreData{dore}, nil, mineralMap)
+	assert.InDelta(t, payableSilverOz*dore.PBRPriceSilver, byMineral[1], 0.01)
+
+	// Waterfall: the price driver is the PBR price
+	drivers := newMarginDrivers(calc, monthData{pbr: pbr, dore: dore}, CostMetrics{})
+	assert.InDelta(t, dore.PBRPriceSilver, drivers.priceSilver, 1e-9)
+}
+
+func TestEffectiveTaxAndRoyaltyRates(t *testing.T) {
+	calc := NewCalculator()
+	dore := newTestDoreData()
+	financial := newTestFinancialData()
+	financial.Royalties = 120000
+	pbr := newTestPBRData()
+
+	nsr := calc.calculateNSR(dore, financial, pbr, CostMetrics{})
+
+	// Rate = amount / gross revenue (as %)
+	assert.Greater(t, nsr.GrossRevenue, 0.0)
+	assert.InDelta(t, financial.SalesTaxes/nsr.GrossRevenue*100, nsr.EffectiveTaxRate, 0.0001)
+	assert.InDelta(t, financial.Royalties/nsr.GrossRevenue*100, nsr.EffectiveRoyaltyRate, 0.0001)
+
+	// No gross revenue: rates are 0 instead of dividing by zero
+	dore.RealizedPriceSilver = 0
+	dore.RealizedPriceGold = 0
+	nsr = calc.calculateNSR(dore, financial, pbr, CostMetrics{})
+	assert.Equal(t, 0.0, nsr.EffectiveTaxRate)
+	assert.Equal(t, 0.0, nsr.EffectiveRoyaltyRate)
+
+	// YTD: from accumulated totals
+	dore = newTestDoreData()
+	ds := calc.CalculateDataSet(pbr, dore, financial, nil, nil)
+	ytd := calc.AccumulateYTD(nil, ds, nil, nil)
+	ytd = calc.AccumulateYTD(ytd, ds, nil, nil)
+	assert.InDelta(t, 2*financial.SalesTaxes/ytd.NSR.GrossRevenue*100, ytd.NSR.EffectiveTaxRate, 0.0001)
+	assert.InDelta(t, ds.NSR.EffectiveRoyaltyRate, ytd.NSR.EffectiveRoyaltyRate, 0.0001)
+}
+
+func TestOperatingMarginPct(t *testing.T) {
+	calc := NewCalculator()
+
+	ds := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), nil)
+	require.NotZero(t, ds.NSR.NetSmelterReturn)
+	assert.InDelta(t, ds.Costs.ProductionBasedMargin/ds.NSR.NetSmelterReturn*100, ds.Costs.OperatingMarginPct, 0.0001)
+
+	// YTD: from accumulated totals
+	costsOnly := calc.CalculateDataSet(nil, nil, nil, newTestOPEXList(), nil)
+	ytd := calc.AccumulateYTD(calc.AccumulateYTD(nil, ds, nil, nil), costsOnly, nil, nil)
+	assert.InDelta(t, ytd.Costs.ProductionBasedMargin/ytd.NSR.NetSmelterReturn*100, ytd.Costs.OperatingMarginPct, 0.0001)
+
+	// No NSR: 0 instead of dividing by zero
+	assert.Equal(t, 0.0, costsOnly.Costs.OperatingMarginPct)
+}
+
+func TestCalculateProfitability(t *testing.T) {
+	calc := NewCalculatorForCompany(&CompanyConfig{ExcludedExpenseTypes: []string{"Other"}})
+	opexList := append(newTestOPEXList(), &data.OPEXData{CostCenter: "G&A", ExpenseType: "Other", Amount: 50000})
+
+	ds := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), opexList, newTestCAPEXList())
+	require.Equal(t, 50000.0, ds.Costs.ExcludedCosts)
+
+	// EBITDA = NSR - Production Based Costs - Excluded Costs; CAPEX is not deducted
+	assert.InDelta(t, ds.Costs.ProductionBasedMargin-50000, ds.Profitability.EBITDA, 0.001)
+	assert.InDelta(t, ds.Profitability.EBITDA/ds.NSR.NetSmelterReturn*100, ds.Profitability.OperatingMarginPct, 0.0001)
+	assert.True(t, ds.Profitability.HasData)
+
+	// YTD: EBITDA sums, the margin comes from the accumulated totals
+	second := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), nil, opexList, nil)
+	ytd := calc.AccumulateYTD(calc.AccumulateYTD(nil, ds, nil, nil), second, nil, nil)
+	assert.InDelta(t, ds.Profitability.EBITDA+second.Profitability.EBITDA, ytd.Profitability.EBITDA, 0.001)
+	assert.InDelta(t, ytd.Profitability.EBITDA/ytd.NSR.NetSmelterReturn*100, ytd.Profitability.OperatingMarginPct, 0.0001)
+
+	variance := calc.CalculateVarianceData(ds, second)
+	assert.InDelta(t, ds.Profitability.EBITDA-second.Profitability.EBITDA, variance.Profitability.EBITDA.Variance, 0.001)
+
+	// Without Dore there is no revenue, so no EBITDA
+	costsOnly := calc.CalculateDataSet(nil, nil, nil, opexList, nil)
+	assert.False(t, costsOnly.Profitability.HasData)
+	assert.Zero(t, costsOnly.Profitability.EBITDA)
+}
+
 func TestCalculateCAPEX(t *testing.T) {
 	calc := NewCalculator()
 	capexList := newTestCAPEXList()
@@ -131,6 +536,268 @@ func TestCalculateCAPEX(t *testing.T) {
 	assert.True(t, capex.HasData)
 }
 
+func TestCalculateCAPEXLeaseSplit(t *testing.T) {
+	calc := NewCalculator()
+	capexList := []*data.CAPEXData{
+		{Category: "Leasing Addition - Sustaining Capital", Type: "leasing", Amount: 80000},
+		{Category: "Sustaining Capital Lease Cash Outflows", Type: "leasing", Amount: 25000},
+		{Category: "IFRS16", Type: "leasing", Amount: 5000},
+		{Category: "Mine Equipment", Type: "sustaining", Amount: 40000},
+	}
+
+	capex := calc.calculateCAPEX(capexList, NSRMetrics{}, CostMetrics{})
+
+	assert.Equal(t, 80000.0, capex.LeaseAdditions)
+	assert.Equal(t, 30000.0, capex.LeaseCashOutflows)
+	assert.Equal(t, capex.Leasing, capex.LeaseAdditions+capex.LeaseCashOutflows)
+
+	// YTD sums both parts
+	month := &DataSet{CAPEX: capex}
+	ytd := calc.AccumulateYTD(calc.AccumulateYTD(nil, month, nil, nil), month, nil, nil)
+	assert.Equal(t, 160000.0, ytd.CAPEX.LeaseAdditions)
+	assert.Equal(t, 60000.0, ytd.CAPEX.LeaseCashOutflows)
+}
+
+func TestBuildCAPEXDetailCompanyAllowList(t *testing.T) {
+	uc := &detailUseCase{calculator: NewCalculator()}
+	capexList := []*data.CAPEXData{
+		{Category: "Mine Equipment", CARNumber: "P-100", ProjectName: "Trucks", Type: "sustaining", Amount: 40000},
+	}
+
+	// Configured lists seed the breakdowns instead of the built-in ones
+	allowList := companyCAPEXAllowList(&CompanyConfig{
+		CAPEXCategories: []string{"Mine Equipment", "Plant Upgrades"},
+		CAPEXProjects:   []string{"P-100 - Trucks", "P-200"},
+	})
+	detail := uc.buildCAPEXDetail(capexList, allowList)
+	assert.Equal(t, map[string]float64{"Mine Equipment": 40000, "Plant Upgrades": 0}, detail.ByCategory)
+	assert.Equal(t, map[string]float64{"P-100 - Trucks": 40000, "P-200": 0}, detail.ByProject)
+
+	// Without configured lists the built-in ones apply
+	detail = uc.buildCAPEXDetail(capexList, companyCAPEXAllowList(&CompanyConfig{}))
+	assert.Len(t, detail.ByCategory, len(defaultCAPEXCategories))
+	assert.Contains(t, detail.ByProject, "C48703300")
+	assert.Equal(t, 40000.0, detail.ByProject["P-100 - Trucks"])
+}
+
+func TestPBRNetCashFlowDefinition(t *testing.T) {
+	nsr := NSRMetrics{NetSmelterReturn: expectedNetSmelterReturn}
+	costs := CostMetrics{ProductionBasedCosts: expectedProductionBasedCosts}
+	margin := expectedNetSmelterReturn - expectedProductionBasedCosts
+
+	tests := []struct {
+		name       string
+		config     *CompanyConfig
+		monthCAPEX float64
+	}{
+		{
+			name:       "sustaining only (default)",
+			config:     nil,
+			monthCAPEX: expectedSustainingCAPEX,
+		},
+		{
+			name:       "sustaining only",
+			config:     &CompanyConfig{NetCashFlowCapexTypes: []string{"sustaining"}},
+			monthCAPEX: expectedSustainingCAPEX,
+		},
+		{
+			name:       "all CAPEX",
+			config:     &CompanyConfig{NetCashFlowCapexTypes: []string{"sustaining", "project", "leasing"}},
+			monthCAPEX: expectedSustainingCAPEX + 350000.0 + 100000.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := NewCalculatorForCompany(tt.config)
+
+			capex := calc.calculateCAPEX(newTestCAPEXList(), nsr, costs)
+			assert.Equal(t, margin-tt.monthCAPEX, capex.PBRNetCashFlow)
+
+			// YTD uses the same definition over accumulated totals
+			month := &DataSet{
+				Costs: CostMetrics{ProductionBasedMargin: margin, HasData: true},
+				CAPEX: capex,
+			}
+			ytd := calc.AccumulateYTD(calc.AccumulateYTD(nil, month, nil, nil), month, nil, nil)
+			assert.Equal(t, 2*(margin-tt.monthCAPEX), ytd.CAPEX.PBRNetCashFlow)
+		})
+	}
+}
+
+func TestNetCashFlowBridgeReconciles(t *testing.T) {
+	nsr := NSRMetrics{NetSmelterReturn: expectedNetSmelterReturn}
+	costs := CostMetrics{ProductionBasedCosts: expectedProductionBasedCosts}
+	calc := NewCalculatorForCompany(&CompanyConfig{NetCashFlowCapexTypes: []string{"sustaining", "project", "leasing"}})
+
+	capex := calc.calculateCAPEX(newTestCAPEXList(), nsr, costs)
+	bridge := capex.NetCashFlowBridge
+
+	labels := make([]string, len(bridge))
+	for i, step := range bridge {
+		labels[i] = step.Label
+	}
+	assert.Equal(t, []string{
+		"Net Smelter Return", "Production Based Costs", "Production Based Margin",
+		"Sustaining CAPEX", "Project CAPEX", "Leasing CAPEX", "PBR Net Cash Flow",
+	}, labels)
+
+	// Each subtotal equals the running sum of the steps before it
+	var running float64
+	for i, step := range bridge {
+		if step.Subtotal && i > 0 {
+			assert.InDelta(t, running, step.Amount, 0.001, step.Label)
+			continue
+		}
+		running += step.Amount
+	}
+	assert.Equal(t, capex.ProductionBasedMargin, bridge[2].Amount)
+	assert.InDelta(t, capex.PBRNetCashFlow, bridge[len(bridge)-1].Amount, 0.001)
+}
+
+func TestAccumulateYTDAverageHeadcount(t *testing.T) {
+	calc := NewCalculator()
+
+	january := newTestPBRData()
+	january.TotalHeadcount = 400
+
+	february := newTestPBRData()
+	february.TotalHeadcount = 500
+
+	janDS := calc.CalculateDataSet(january, nil, nil, nil, nil)
+	febDS := calc.CalculateDataSet(february, nil, nil, nil, nil)
+
+	// Single month: average = month headcount
+	assert.Equal(t, 400.0, janDS.Mining.AverageHeadcount)
+	assert.InDelta(t, january.OreMinedT/400, janDS.Mining.TonnesPerEmployee, 0.001)
+
+	ytd := calc.AccumulateYTD(nil, janDS, nil, nil)
+	ytd = calc.AccumulateYTD(ytd, febDS, nil, nil)
+
+	// Latest headcount is kept, average is the mean across both months
+	assert.Equal(t, 500, ytd.Mining.TotalHeadcount)
+	assert.Equal(t, 450.0, ytd.Mining.AverageHeadcount)
+
+	// Tonnes per employee YTD = accumulated ore / average headcount
+	expectedTonnesPerEmployee := (january.OreMinedT + february.OreMinedT) / 450
+	assert.InDelta(t, expectedTonnesPerEmployee, ytd.Mining.TonnesPerEmployee, 0.001)
+}
+
+func TestAccumulateYTDRealizedPrices(t *testing.T) {
+	calc := NewCalculator()
+
+	january := newTestDoreData()
+	february := newTestDoreData()
+	february.DoreProducedOz /= 2
+	february.RealizedPriceSilver = 30
+	february.RealizedPriceGold = 2100
+
+	ytd := calc.AccumulateYTD(nil, calc.CalculateDataSet(newTestPBRData(), january, nil, nil, nil), nil, nil)
+	ytd = calc.AccumulateYTD(ytd, calc.CalculateDataSet(newTestPBRData(), february, nil, nil, nil), nil, nil)
+	// A month without Dore has payable ounces from PBR but no revenue: it must not dilute the price
+	ytd = calc.AccumulateYTD(ytd, calc.CalculateDataSet(newTestPBRData(), nil, nil, nil, nil), nil, nil)
+
+	janSilverOz, janGoldOz := january.PayableOz()
+	febSilverOz, febGoldOz := february.PayableOz()
+	expectedSilver := (janSilverOz*january.RealizedPriceSilver + febSilverOz*february.RealizedPriceSilver) / (janSilverOz + febSilverOz)
+	expectedGold := (janGoldOz*january.RealizedPriceGold + febGoldOz*february.RealizedPriceGold) / (janGoldOz + febGoldOz)
+	assert.InDelta(t, expectedSilver, ytd.NSR.SilverPricePerOz, 1e-9)
+	assert.InDelta(t, expectedGold, ytd.NSR.GoldPricePerOz, 1e-9)
+	assert.InDelta(t, ytd.NSR.GrossRevenueSilver/(janSilverOz+febSilverOz), ytd.NSR.SilverPricePerOz, 1e-9)
+}
+
+func TestSalesTaxRoyaltySplitThroughYTDAndVariance(t *testing.T) {
+	calc := NewCalculator()
+
+	financial := newTestFinancialData()
+	financial.SalesTaxes = -300000
+	financial.Royalties = -120000
+	financial.OtherSalesDeductions = -15000
+
+	month := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), financial, nil, nil)
+	assert.Equal(t, financial.SalesTaxes, month.NSR.SalesTaxes)
+	assert.Equal(t, financial.Royalties, month.NSR.Royalties)
+	assert.Equal(t, financial.OtherSalesDeductions, month.NSR.OtherSalesDeductions)
+	assert.Equal(t, financial.SalesTaxes+financial.Royalties, month.NSR.SalesTaxesRoyalties)
+
+	ytd := calc.AccumulateYTD(nil, month, nil, nil)
+	ytd = calc.AccumulateYTD(ytd, month, nil, nil)
+	assert.Equal(t, 2*financial.SalesTaxes, ytd.NSR.SalesTaxes)
+	assert.Equal(t, 2*financial.Royalties, ytd.NSR.Royalties)
+	assert.Equal(t, 2*financial.OtherSalesDeductions, ytd.NSR.OtherSalesDeductions)
+	assert.Equal(t, ytd.NSR.SalesTaxes+ytd.NSR.Royalties, ytd.NSR.SalesTaxesRoyalties)
+
+	budget := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), nil, nil)
+	variance := calc.CalculateVarianceData(month, budget)
+	assert.Equal(t, financial.SalesTaxes-budget.NSR.SalesTaxes, variance.NSR.SalesTaxes.Variance)
+	assert.Equal(t, financial.Royalties-budget.NSR.Royalties, variance.NSR.Royalties.Variance)
+	assert.Equal(t, financial.OtherSalesDeductions, variance.NSR.OtherSalesDeductions.Actual)
+}
+
+func TestOtherDeductionsAndAdjustmentsInNSR(t *testing.T) {
+	calc := NewCalculator()
+	base := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), nil)
+
+	financial := newTestFinancialData()
+	financial.OtherSalesDeductions = -15000
+	financial.OtherAdjustments = 4000
+	month := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), financial, newTestOPEXList(), nil)
+
+	assert.Equal(t, financial.OtherAdjustments, month.NSR.OtherAdjustments)
+	assert.InDelta(t, base.NSR.NetSmelterReturn-15000+4000, month.NSR.NetSmelterReturn, 0.001)
+	assert.InDelta(t, base.Costs.ProductionBasedMargin-11000, month.Costs.ProductionBasedMargin, 0.001)
+
+	ytd := calc.AccumulateYTD(nil, month, nil, nil)
+	ytd = calc.AccumulateYTD(ytd, month, nil, nil)
+	assert.Equal(t, 2*financial.OtherAdjustments, ytd.NSR.OtherAdjustments)
+	assert.Equal(t, 2*financial.OtherSalesDeductions, ytd.NSR.OtherSalesDeductions)
+
+	variance := calc.CalculateVarianceData(month, base)
+	assert.Equal(t, financial.OtherAdjustments, variance.NSR.OtherAdjustments.Variance)
+
+	// Both lines are reconciled against the reference workbook
+	assert.Equal(t, financial.OtherSalesDeductions, getValueFromDataSet(month, metricMapping["Other Sales Deductions"].Category, metricMapping["Other Sales Deductions"].Field))
+	assert.Equal(t, financial.OtherAdjustments, getValueFromDataSet(month, metricMapping["Other Adjustments"].Category, metricMapping["Other Adjustments"].Field))
+}
+
+func TestBuildTTMAcrossYearBoundary(t *testing.T) {
+	calc := NewCalculator()
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	december := newTestPBRData()
+	december.Date = time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)
+	november := newTestPBRData()
+	november.Date = time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC)
+	// November 2024 falls outside the Dec 2024 - Nov 2025 window
+	outside := newTestPBRData()
+	outside.Date = time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC)
+
+	years := map[int]*yearData{
+		2024: {
+			pbr:  map[int]*data.PBRData{11: outside, 12: december},
+			opex: map[int][]*data.OPEXData{12: newTestOPEXList()},
+		},
+		2025: {
+			pbr:  map[int]*data.PBRData{11: november},
+			opex: map[int][]*data.OPEXData{11: newTestOPEXList()},
+		},
+	}
+
+	months, ttm := buildTTM(calc, from, years)
+
+	assert.Equal(t, []string{"2024-12", "2025-11"}, months)
+	if assert.NotNil(t, ttm) {
+		monthDS := calc.CalculateDataSet(december, nil, nil, newTestOPEXList(), nil)
+		assert.InDelta(t, december.OreMinedT+november.OreMinedT, ttm.Mining.OreMinedT, 0.001)
+		assert.InDelta(t, 2*monthDS.Costs.ProductionBasedCosts, ttm.Costs.ProductionBasedCosts, 0.01)
+	}
+
+	// No data in the window
+	months, ttm = buildTTM(calc, from, map[int]*yearData{})
+	assert.Empty(t, months)
+	assert.Nil(t, ttm)
+}
+
 func TestCalculateCashCost(t *testing.T) {
 	calc := NewCalculator()
 
@@ -193,6 +860,79 @@ func TestCalculateCashCost(t *testing.T) {
 	assert.True(t, cashCost.HasData)
 }
 
+func TestCalculateCashCostCoProduct(t *testing.T) {
+	byProduct := NewCalculator()
+	coProduct := NewCalculatorForCompany(&CompanyConfig{PrimaryMetal: PrimaryMetalCoProduct})
+	pbr, dore, financial := newTestPBRData(), newTestDoreData(), newTestFinancialData()
+
+	ds := coProduct.CalculateDataSet(pbr, dore, financial, newTestOPEXList(), newTestCAPEXList())
+	reference := byProduct.CalculateDataSet(pbr, dore, financial, newTestOPEXList(), newTestCAPEXList())
+	cc := ds.CashCost
+
+	// The metals share the by-product cash costs before the gold credit, by revenue share
+	cashCosts := reference.CashCost.CashCostsSilver + reference.CashCost.GoldCredit
+	assert.InDelta(t, cashCosts, cc.CashCostsSilver+cc.CashCostsGold, 0.01)
+	silverShare := ds.NSR.GrossRevenueSilver / ds.NSR.GrossRevenue
+	assert.InDelta(t, cashCosts*silverShare, cc.CashCostsSilver, 0.01)
+	assert.InDelta(t, reference.CashCost.AISCSilver+reference.CashCost.GoldCredit, cc.AISCSilver+cc.AISCGold, 0.01)
+	assert.Zero(t, cc.GoldCredit)
+
+	assert.InDelta(t, cc.CashCostsGold/ds.Production.PayableGoldOz, cc.CashCostPerOzGold, 0.001)
+	assert.InDelta(t, cc.AISCGold/ds.Production.PayableGoldOz, cc.AISCPerOzGold, 0.001)
+	assert.InDelta(t, cc.CashCostsSilver/ds.Production.PayableSilverOz, cc.CashCostPerOzSilver, 0.001)
+
+	// By-product mode leaves the gold figures empty
+	assert.Zero(t, reference.CashCost.CashCostPerOzGold)
+	assert.Zero(t, reference.CashCost.AISCGold)
+
+	// YTD splits the accumulated totals the same way
+	ytd := coProduct.AccumulateYTD(nil, ds, dore, financial)
+	ytd = coProduct.AccumulateYTD(ytd, coProduct.CalculateDataSet(pbr, dore, financial, newTestOPEXList(), newTestCAPEXList()), dore, financial)
+	assert.InDelta(t, 2*cc.CashCostsGold, ytd.CashCost.CashCostsGold, 0.01)
+	assert.InDelta(t, cc.CashCostPerOzGold, ytd.CashCost.CashCostPerOzGold, 0.001)
+	assert.InDelta(t, 2*ds.NSR.GrossRevenueGold, ytd.NSR.GrossRevenueGold, 0.01)
+
+	variance := coProduct.CalculateVarianceData(ds, reference)
+	assert.Equal(t, cc.CashCostPerOzGold, variance.CashCost.CashCostPerOzGold.Actual)
+}
+
+func TestBreakevenSilverPrice(t *testing.T) {
+	calc := NewCalculator()
+	pbr, dore, financial := newTestPBRData(), newTestDoreData(), newTestFinancialData()
+
+	january := calc.CalculateDataSet(pbr, dore, financial, newTestOPEXList(), newTestCAPEXList())
+	cc := january.CashCost
+	require.Greater(t, january.Production.PayableSilverOz, 0.0)
+
+	// By-product AISC is already net of the gold credit
+	assert.InDelta(t, cc.AISCSilver/january.Production.PayableSilverOz, cc.BreakevenSilverPrice, 0.001)
+	assert.InDelta(t, cc.AISCPerOzSilver, cc.BreakevenSilverPrice, 0.001)
+	assert.InDelta(t, january.NSR.SilverPricePerOz-cc.AISCPerOzSilver, cc.AISCMarginPerOz, 0.001)
+
+	// Co-product mode nets gold revenue off the whole AISC, so the breakeven is the same
+	coProduct := NewCalculatorForCompany(&CompanyConfig{PrimaryMetal: PrimaryMetalCoProduct}).
+		CalculateDataSet(pbr, dore, financial, newTestOPEXList(), newTestCAPEXList())
+	assert.InDelta(t, cc.BreakevenSilverPrice, coProduct.CashCost.BreakevenSilverPrice, 0.001)
+
+	// YTD breakeven comes from the accumulated totals, not an average of the months
+	costlier := newTestOPEXList()
+	for _, opex := range costlier {
+		opex.Amount *= 3
+	}
+	february := calc.CalculateDataSet(pbr, dore, financial, costlier, newTestCAPEXList())
+	ytd := calc.AccumulateYTD(nil, january, dore, financial)
+	ytd = calc.AccumulateYTD(ytd, february, dore, financial)
+	expected := (cc.AISCSilver + february.CashCost.AISCSilver) / (2 * january.Production.PayableSilverOz)
+	assert.InDelta(t, expected, ytd.CashCost.BreakevenSilverPrice, 0.001)
+	assert.InDelta(t, ytd.NSR.SilverPricePerOz-ytd.CashCost.AISCPerOzSilver, ytd.CashCost.AISCMarginPerOz, 0.001)
+
+	variance := calc.CalculateVarianceData(february, january)
+	assert.Equal(t, february.CashCost.BreakevenSilverPrice, variance.CashCost.BreakevenSilverPrice.Actual)
+	assert.Equal(t, cc.BreakevenSilverPrice, variance.CashCost.BreakevenSilverPrice.Budget)
+	assert.Greater(t, variance.CashCost.BreakevenSilverPrice.Variance, 0.0)
+	assert.Less(t, variance.CashCost.AISCMarginPerOz.Variance, 0.0)
+}
+
 func TestCostCenterValidation(t *testing.T) {
 	validCenters := []string{"Mine", "Processing", "G&A", "Transport & Shipping"}
 
@@ -222,3 +962,284 @@ func TestExpenseTypeValidation(t *testing.T) {
 		assert.False(t, et.IsValid(), "Expense type %s should be invalid", expType)
 	}
 }
+
+func TestBuildBenchmarkMetrics(t *testing.T) {
+	calc := NewCalculator()
+	ds := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+
+	benchmarks := map[string]float64{
+		BenchmarkAISCPerOzSilver:    18.5,
+		BenchmarkRecoveryRateSilver: 88,
+		BenchmarkStrippingRatio:     4.2,
+	}
+
+	metrics := buildBenchmarkMetrics(ds, benchmarks)
+	assert.Len(t, metrics, len(benchmarkKPIs))
+
+	for _, m := range metrics {
+		value, ok := benchmarks[m.Metric]
+		assert.Equal(t, ok, m.HasBenchmark, m.Metric)
+		if !ok {
+			assert.Equal(t, 0.0, m.Gap, m.Metric)
+			continue
+		}
+		// Gap = company - benchmark
+		assert.Equal(t, value, m.Benchmark, m.Metric)
+		assert.Equal(t, m.Company-value, m.Gap, m.Metric)
+	}
+
+	assert.Equal(t, ds.CashCost.AISCPerOzSilver, metrics[0].Company)
+	assert.Equal(t, ds.CashCost.AISCPerOzSilver-18.5, metrics[0].Gap)
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	pbr := newTestPBRData()   // January
+	dore := newTestDoreData() // January
+	financial := newTestFinancialData()
+
+	// Orphan Financial month: March has no Dore
+	orphan := newTestFinancialData()
+	orphan.Date = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	mineralMap := map[int]struct{ Code, Name string }{1: {Code: "AG", Name: "Silver"}}
+	production := []*data.ProductionData{
+		{Date: pbr.Date, MineralID: 1, Quantity: 100},
+		{Date: pbr.Date, MineralID: 99, Quantity: 5},
+		{Date: pbr.Date, MineralID: 99, Quantity: 7},
+	}
+
+	violations := checkIntegrity(2024,
+		[]*data.PBRData{pbr},
+		[]*data.DoreData{dore},
+		[]*data.FinancialData{financial, orphan},
+		production,
+		nil,
+		mineralMap,
+	)
+
+	assert.Len(t, violations, 2)
+	assert.Equal(t, IntegrityUnknownMineral, violations[0].Rule)
+	assert.Equal(t, "2024-01", violations[0].Month)
+	assert.Equal(t, 99, violations[0].MineralID)
+	assert.Equal(t, IntegrityFinancialWithoutDore, violations[1].Rule)
+	assert.Equal(t, "2024-03", violations[1].Month)
+
+	// Complete year: no violations
+	violations = checkIntegrity(2024, []*data.PBRData{pbr}, []*data.DoreData{dore}, []*data.FinancialData{financial}, nil, nil, mineralMap)
+	assert.Empty(t, violations)
+}
+
+func TestMineralContributionsSumToTotalMargin(t *testing.T) {
+	mineralMap := map[int]struct{ Code, Name string }{
+		1: {Code: "AG", Name: "Silver"},
+		2: {Code: "AU", Name: "Gold"},
+		3: {Code: "ZN", Name: "Zinc"},
+	}
+	dore := newTestDoreData() // January
+	revenue := []*data.RevenueData{
+		{Date: dore.Date, MineralID: 3, QuantitySold: 1000, UnitPrice: 2.5},
+		// Silver already comes from January Dore: not counted twice
+		{Date: dore.Date, MineralID: 1, QuantitySold: 1000, UnitPrice: 24},
+	}
+
+	byMineral := mineralRevenue(NewCalculator(), []*data.DoreData{dore}, revenue, mineralMap)
+	payableSilverOz, payableGoldOz := dore.PayableOz()
+	assert.InDelta(t, payableSilverOz*dore.RealizedPriceSilver, byMineral[1], 0.01)
+	assert.InDelta(t, payableGoldOz*dore.RealizedPriceGold, byMineral[2], 0.01)
+	assert.Equal(t, 2500.0, byMineral[3])
+
+	totalRevenue := byMineral[1] + byMineral[2] + byMineral[3]
+	totalCosts := 19363057.0
+
+	for _, basis := range []string{AllocationBasisRevenue, AllocationBasisEqual} {
+		minerals := buildMineralContributions(byMineral, totalCosts, basis, mineralMap)
+		assert.Len(t, minerals, 3)
+
+		var contributions, allocated, marginShare float64
+		for _, m := range minerals {
+			contributions += m.Contribution
+			allocated += m.AllocatedCosts
+			marginShare += m.MarginShare
+		}
+		assert.InDelta(t, totalRevenue-totalCosts, contributions, 0.01, basis)
+		assert.InDelta(t, totalCosts, allocated, 0.01, basis)
+		assert.InDelta(t, 100, marginShare, 0.0001, basis)
+	}
+
+	// Revenue-weighted: every mineral keeps the same margin percentage
+	minerals := buildMineralContributions(byMineral, totalCosts, AllocationBasisRevenue, mineralMap)
+	assert.InDelta(t, minerals[0].RevenueShare, minerals[0].MarginShare, 0.0001)
+	assert.Equal(t, "ZN", minerals[2].MineralCode)
+}
+
+func TestMarginWaterfallBucketsSumToVariance(t *testing.T) {
+	calc := NewCalculator()
+
+	budget := monthData{
+		pbr:       newTestPBRData(),
+		dore:      newTestDoreData(),
+		financial: newTestFinancialData(),
+		opex:      newTestOPEXList(),
+	}
+
+	actualPBR := newTestPBRData()
+	actualPBR.TotalTonnesProcessed *= 1.1
+	actualPBR.FeedGradeSilverGpt *= 0.95
+	actualPBR.RecoveryRateGoldPct -= 2
+	actualDore := newTestDoreData()
+	actualDore.RealizedPriceSilver += 1.5
+	actualDore.DoreProducedOz *= 1.05
+	actualOPEX := newTestOPEXList()
+	actualOPEX[0].Amount += 250000
+	actual := monthData{pbr: actualPBR, dore: actualDore, financial: newTestFinancialData(), opex: actualOPEX}
+
+	report := buildMarginWaterfall(calc, actual, budget)
+
+	var sum float64
+	for _, bucket := range report.Buckets {
+		sum += bucket.Amount
+	}
+	assert.InDelta(t, report.TotalVariance, sum, 0.001)
+	assert.InDelta(t, report.ActualMargin-report.BudgetMargin, report.TotalVariance, 0.001)
+	assert.Len(t, report.Buckets, 6)
+
+	// Higher tonnes and silver price help; lower silver grade, gold recovery and higher costs hurt
+	assert.Greater(t, report.Buckets[0].Amount, 0.0) // volume
+	assert.Less(t, report.Buckets[1].Amount, 0.0)    // grade
+	assert.Less(t, report.Buckets[2].Amount, 0.0)    // recovery
+	assert.Greater(t, report.Buckets[3].Amount, 0.0) // price
+	assert.InDelta(t, -250000, report.Buckets[4].Amount, 0.001)
+
+	// Identical datasets: no variance in any bucket
+	report = buildMarginWaterfall(calc, budget, budget)
+	for _, bucket := range report.Buckets {
+		assert.InDelta(t, 0, bucket.Amount, 0.001, bucket.Name)
+	}
+}
+
+func TestBuildSensitivity(t *testing.T) {
+	calc := NewCalculator()
+	yd := &yearData{
+		pbr:       map[int]*data.PBRData{1: newTestPBRData(), 2: newTestPBRData(), 3: newTestPBRData()},
+		dore:      map[int]*data.DoreData{1: newTestDoreData(), 2: newTestDoreData(), 3: newTestDoreData()},
+		financial: map[int]*data.FinancialData{1: newTestFinancialData(), 2: newTestFinancialData(), 3: newTestFinancialData()},
+		opex:      map[int][]*data.OPEXData{1: newTestOPEXList(), 2: newTestOPEXList(), 3: newTestOPEXList()},
+		capex:     map[int][]*data.CAPEXData{1: newTestCAPEXList(), 2: newTestCAPEXList(), 3: newTestCAPEXList()},
+	}
+	month := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+
+	deltas := []float64{-10, 0, 10}
+	report := buildSensitivity(calc, yd, map[int]bool{1: true, 2: true}, deltas, deltas)
+	assert.True(t, report.HasData)
+	assert.Equal(t, []int{1, 2}, report.Months)
+	require.Len(t, report.Matrix, 3)
+	require.Len(t, report.Matrix[0], 3)
+
+	// No price change: the figures of the summary
+	base := report.Matrix[1][1]
+	assert.InDelta(t, 2*month.NSR.NetSmelterReturn, base.NetSmelterReturn, 0.01)
+	assert.InDelta(t, 2*month.Costs.ProductionBasedMargin, base.ProductionBasedMargin, 0.01)
+	assert.InDelta(t, 2*month.CAPEX.PBRNetCashFlow, base.PBRNetCashFlow, 0.01)
+
+	// A price change moves the margin by that share of the metal's gross revenue
+	silverUp := report.Matrix[2][1]
+	assert.Equal(t, 10.0, silverUp.SilverPriceDeltaPct)
+	assert.Equal(t, 0.0, silverUp.GoldPriceDeltaPct)
+	assert.InDelta(t, 2*0.1*month.NSR.GrossRevenueSilver, silverUp.ProductionBasedMargin-base.ProductionBasedMargin, 0.01)
+	assert.InDelta(t, silverUp.ProductionBasedMargin-base.ProductionBasedMargin, silverUp.PBRNetCashFlow-base.PBRNetCashFlow, 0.01)
+	bothDown := report.Matrix[0][0]
+	assert.InDelta(t, -2*0.1*month.NSR.GrossRevenue, bothDown.NetSmelterReturn-base.NetSmelterReturn, 0.01)
+
+	// Without a filter every month with data counts
+	report = buildSensitivity(calc, yd, nil, deltas, deltas)
+	assert.Equal(t, []int{1, 2, 3}, report.Months)
+	assert.InDelta(t, 3*month.NSR.NetSmelterReturn, report.Matrix[1][1].NetSmelterReturn, 0.01)
+
+	report = buildSensitivity(calc, yd, map[int]bool{6: true}, deltas, deltas)
+	assert.False(t, report.HasData)
+	assert.Empty(t, report.Months)
+	assert.Zero(t, report.Matrix[2][2].NetSmelterReturn)
+}
+
+func TestBuildVarianceReportMatchesSummaryMonth(t *testing.T) {
+	uc := &useCase{}
+
+	// Actual and budget for January and February (budget with a lower grade)
+	pbrFor := func(month time.Month, grade float64) *data.PBRData {
+		pbr := newTestPBRData()
+		pbr.Date = time.Date(2024, month, 15, 0, 0, 0, 0, time.UTC)
+		pbr.FeedGradeSilverGpt = grade
+		return pbr
+	}
+	pbrActual := []*data.PBRData{pbrFor(time.January, 209.79), pbrFor(time.February, 215)}
+	pbrBudget := []*data.PBRData{pbrFor(time.January, 200), pbrFor(time.February, 200)}
+
+	months := uc.buildMonthlyData(NewCalculator(), 2024,
+		pbrActual, pbrBudget,
+		nil, nil,
+		nil, nil,
+		newTestOPEXList(), newTestOPEXList(),
+		nil, nil,
+		nil,
+	)
+
+	report := buildVarianceReport(months, 2024, 2)
+
+	assert.Equal(t, "2024-02", report.Month)
+	require.NotNil(t, report.Variance)
+	require.NotNil(t, report.YTDVariance)
+	assert.Equal(t, months[1].Variance, report.Variance)
+	assert.Equal(t, months[1].YTD.Variance, report.YTDVariance)
+
+	// YTD covers both months, so it differs from the month alone
+	assert.NotEqual(t, report.Variance.Production.TotalProductionSilverOz, report.YTDVariance.Production.TotalProductionSilverOz)
+
+	// A month without data has no variance
+	assert.Nil(t, buildVarianceReport(months, 2024, 5).Variance)
+}
+
+func TestCapitalIntensityIsTotalCapexOverAnnualizedProduction(t *testing.T) {
+	calc := NewCalculator()
+	capex := newTestCAPEXList()
+
+	month := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), nil, nil, capex)
+
+	require.Greater(t, month.Production.PayableSilverOz, 0.0)
+	assert.InDelta(t, month.Production.PayableSilverOz*12, month.CAPEX.AnnualizedPayableSilverOz, 0.0001)
+	assert.InDelta(t, month.CAPEX.Total/(month.Production.PayableSilverOz*12), month.CAPEX.CapitalIntensity, 0.0001)
+
+	// YTD annualizes the production of the months accumulated so far
+	ytd := calc.AccumulateYTD(nil, month, nil, nil)
+	ytd = calc.AccumulateYTD(ytd, month, nil, nil)
+	assert.InDelta(t, ytd.Production.PayableSilverOz*12/2, ytd.CAPEX.AnnualizedPayableSilverOz, 0.0001)
+	assert.InDelta(t, ytd.CAPEX.Total/ytd.CAPEX.AnnualizedPayableSilverOz, ytd.CAPEX.CapitalIntensity, 0.0001)
+	assert.InDelta(t, 2*month.CAPEX.CapitalIntensity, ytd.CAPEX.CapitalIntensity, 0.0001)
+}
+
+func TestBuildWeightedGradeOverSelectedMonths(t *testing.T) {
+	pbrFor := func(month time.Month, tonnes, silverGpt, goldGpt float64) *data.PBRData {
+		return &data.PBRData{
+			Date:                 time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC),
+			TotalTonnesProcessed: tonnes,
+			FeedGradeSilverGpt:   silverGpt,
+			FeedGradeGoldGpt:     goldGpt,
+		}
+	}
+	pbrList := []*data.PBRData{
+		pbrFor(time.January, 30000, 100, 1.0),
+		pbrFor(time.February, 10000, 300, 3.0),
+		pbrFor(time.March, 30000, 200, 2.0),
+	}
+
+	report := buildWeightedGrade(pbrList, []int{3, 2})
+
+	// (10,000 t x 300 g/t + 30,000 t x 200 g/t) / 40,000 t = 225 g/t
+	assert.Equal(t, []int{2, 3}, report.Months)
+	assert.Equal(t, []int{2, 3}, report.MonthsWithData)
+	assert.Equal(t, 40000.0, report.TotalTonnesProcessed)
+	assert.InDelta(t, 225.0, report.FeedGradeSilverGpt, 1e-9)
+	assert.InDelta(t, 2.25, report.FeedGradeGoldGpt, 1e-9)
+
+	// A simple average of the two months would overstate the grade
+	assert.NotEqual(t, (300.0+200.0)/2, report.FeedGradeSilverGpt)
+}
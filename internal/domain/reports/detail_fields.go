@@ -0,0 +1,163 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// detailFieldGroup is a metric group a detail report can be projected to with ?fields=.
+// Metrics are the keys of the group in the monthly actual/budget/forecast/variance
+// objects; ReportKeys are the report-level aggregations and orders of the group.
+type detailFieldGroup struct {
+	Name       string
+	Metrics    []string
+	ReportKeys []string
+}
+
+// Metric groups of each detail report, in the order of their detail struct
+var (
+	pbrFieldGroups = []detailFieldGroup{
+		{Name: "mining", Metrics: []string{
+			"open_pit_ore_t", "underground_ore_t", "ore_mined_t", "waste_mined_t", "stripping_ratio", "waste_ore_ratio", "total_moved",
+			"mining_grade_silver_gpt", "mining_grade_gold_gpt", "open_pit_grade_silver_gpt", "underground_grade_silver_gpt",
+			"open_pit_grade_gold_gpt", "underground_grade_gold_gpt",
+		}},
+		{Name: "developments", Metrics: []string{"primary_development_m", "secondary_development_opex_m", "expansionary_development_m", "developments_m"}},
+		{Name: "processing", Metrics: []string{"total_tonnes_processed", "feed_grade_silver_gpt", "feed_grade_gold_gpt", "recovery_rate_silver_pct", "recovery_rate_gold_pct"}},
+		{Name: "production", Metrics: []string{"total_production_silver_oz", "total_production_gold_oz"}},
+		{Name: "headcount", Metrics: []string{"full_time_employees", "contractors", "total_headcount"}},
+	}
+	doreFieldGroups = []detailFieldGroup{
+		{Name: "production", Metrics: []string{
+			"dore_produced_oz", "silver_grade_pct", "gold_grade_pct", "metal_in_dore_silver_oz", "metal_in_dore_gold_oz",
+			"silver_adjustment_oz", "gold_adjustment_oz", "metal_adjusted_silver_oz", "metal_adjusted_gold_oz",
+		}},
+		{Name: "deductions", Metrics: []string{"ag_deductions_pct", "au_deductions_pct", "deductions_silver_oz", "deductions_gold_oz", "payable_silver_oz", "payable_gold_oz"}},
+		{Name: "prices", Metrics: []string{"pbr_price_silver", "pbr_price_gold", "realized_price_silver", "realized_price_gold"}},
+		{Name: "revenue", Metrics: []string{"gross_revenue_silver", "gross_revenue_gold", "gross_revenue_total"}},
+		{Name: "charges", Metrics: []string{
+			"treatment_charge", "refining_deductions_au", "total_charges",
+			"treatment_charge_silver", "treatment_charge_gold", "charges_silver", "charges_gold",
+		}},
+		{Name: "nsr", Metrics: []string{"nsr_dore"}},
+		{Name: "source_pbr", Metrics: []string{"source_pbr"}},
+	}
+	opexFieldGroups = []detailFieldGroup{
+		{
+			Name:       "cost_centers",
+			Metrics:    []string{"mine", "processing", "ga", "transport_shipping", "by_cost_center", "inventory_variations", "total"},
+			ReportKeys: []string{"by_cost_center", "cost_center_order"},
+		},
+		{Name: "subcategories", Metrics: []string{"by_subcategory"}, ReportKeys: []string{"by_subcategory"}},
+		{Name: "expense_types", Metrics: []string{"by_expense_type"}, ReportKeys: []string{"by_expense_type", "expense_type_order"}},
+	}
+	capexFieldGroups = []detailFieldGroup{
+		{
+			Name:       "types",
+			Metrics:    []string{"sustaining", "project", "leasing", "accretion_of_mine_closure_liability", "lease_additions", "lease_cash_outflows", "total"},
+			ReportKeys: []string{"by_type", "type_order"},
+		},
+		{Name: "categories", Metrics: []string{"by_category"}, ReportKeys: []string{"by_category"}},
+		{Name: "projects", Metrics: []string{"by_project"}, ReportKeys: []string{"project_order"}},
+	}
+	financialFieldGroups = []detailFieldGroup{
+		{Name: "selling", Metrics: []string{"shipping_selling"}},
+		{Name: "taxes", Metrics: []string{"sales_taxes", "royalties", "sales_taxes_royalties"}},
+		{Name: "other", Metrics: []string{"other_sales_deductions", "other_adjustments"}},
+		{Name: "total", Metrics: []string{"total"}},
+	}
+)
+
+// parseDetailFields parses a comma-separated ?fields= list of metric groups. Empty means
+// every group (no projection).
+func parseDetailFields(raw string, groups []detailFieldGroup) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !slices.Contains(names, field) {
+			return nil, fmt.Errorf("invalid fields %q (comma-separated, each one of %s)", field, strings.Join(names, ", "))
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// projectDetailFields returns the JSON of a detail report keeping only the metric groups
+// in fields: the groups' keys in each month's actual, budget, forecast and variance, and
+// their report-level aggregations. has_data and the keys of no group are always kept.
+// Without fields the report is returned as is.
+func projectDetailFields(report any, fields []string, groups []detailFieldGroup) (any, error) {
+	if len(fields) == 0 {
+		return report, nil
+	}
+
+	// A key of a selected group is kept even when another group has it too
+	keepMetrics, keepReportKeys := make(map[string]bool), make(map[string]bool)
+	for _, group := range groups {
+		if slices.Contains(fields, group.Name) {
+			for _, key := range group.Metrics {
+				keepMetrics[key] = true
+			}
+			for _, key := range group.ReportKeys {
+				keepReportKeys[key] = true
+			}
+		}
+	}
+	var dropMetrics, dropReportKeys []string
+	for _, group := range groups {
+		for _, key := range group.Metrics {
+			if !keepMetrics[key] {
+				dropMetrics = append(dropMetrics, key)
+			}
+		}
+		for _, key := range group.ReportKeys {
+			if !keepReportKeys[key] {
+				dropReportKeys = append(dropReportKeys, key)
+			}
+		}
+	}
+
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers are kept as written, so the projection does not round large values
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var projected map[string]any
+	if err := decoder.Decode(&projected); err != nil {
+		return nil, err
+	}
+
+	for _, key := range dropReportKeys {
+		delete(projected, key)
+	}
+	months, _ := projected["months"].([]any)
+	for _, month := range months {
+		month, ok := month.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, scenario := range []string{"actual", "budget", "forecast", "variance"} {
+			if detail, ok := month[scenario].(map[string]any); ok {
+				for _, key := range dropMetrics {
+					delete(detail, key)
+				}
+			}
+		}
+	}
+	return projected, nil
+}
@@ -1,5 +1,7 @@
 package reports
 
+import "github.com/gmhafiz/go8/internal/domain/data"
+
 // PBRDetailReport represents detailed PBR report
 type PBRDetailReport struct {
 	CompanyID   int64            `json:"company_id"`
@@ -7,6 +9,7 @@ type PBRDetailReport struct {
 	Year        int              `json:"year"`
 	Config      *CompanyConfig   `json:"config,omitempty"`
 	Months      []PBRMonthlyData `json:"months"`
+	Coverage    *DataCoverage    `json:"coverage"` // Months with actual/budget PBR data, whatever the months filter
 }
 
 // PBRMonthlyData represents PBR data for a single month
@@ -15,6 +18,7 @@ type PBRMonthlyData struct {
 	Actual   *PBRDetail   `json:"actual"`
 	Budget   *PBRDetail   `json:"budget"`
 	Variance *PBRVariance `json:"variance,omitempty"`
+	Forecast *PBRDetail   `json:"forecast,omitempty"` // Only with include_forecast
 }
 
 // PBRDetail contains detailed PBR metrics
@@ -114,6 +118,7 @@ type DoreDetailReport struct {
 	Year        int               `json:"year"`
 	Config      *CompanyConfig    `json:"config,omitempty"`
 	Months      []DoreMonthlyData `json:"months"`
+	Coverage    *DataCoverage     `json:"coverage"` // Months with actual/budget Dore data, whatever the months filter
 }
 
 // DoreMonthlyData represents Dore data for a single month
@@ -122,6 +127,7 @@ type DoreMonthlyData struct {
 	Actual   *DoreDetail   `json:"actual"`
 	Budget   *DoreDetail   `json:"budget"`
 	Variance *DoreVariance `json:"variance,omitempty"`
+	Forecast *DoreDetail   `json:"forecast,omitempty"` // Only with include_forecast
 }
 
 // DoreDetail contains detailed Dore metrics
@@ -169,12 +175,32 @@ type DoreDetail struct {
 	RefiningDeductionsAu float64 `json:"refining_deductions_au"`
 	TotalCharges         float64 `json:"total_charges"`
 
+	// Charges attributed per metal (ChargesSilver + ChargesGold = TotalCharges)
+	// Treatment charge is split by payable ounces; refining deductions are gold only
+	TreatmentChargeSilver float64 `json:"treatment_charge_silver"`
+	TreatmentChargeGold   float64 `json:"treatment_charge_gold"`
+	ChargesSilver         float64 `json:"charges_silver"`
+	ChargesGold           float64 `json:"charges_gold"`
+
 	// NSR
 	NSRDore float64 `json:"nsr_dore"`
 
+	// PBR row of the same month the Dore figures were derived against (nil when missing)
+	SourcePBR *DoreSourcePBR `json:"source_pbr,omitempty"`
+
 	HasData bool `json:"has_data"`
 }
 
+// DoreSourcePBR identifies the PBR row and processing values behind a Dore month
+type DoreSourcePBR struct {
+	Date                  string  `json:"date"` // "2025-01-31"
+	TotalTonnesProcessed  float64 `json:"total_tonnes_processed"`
+	FeedGradeSilverGpt    float64 `json:"feed_grade_silver_gpt"`
+	FeedGradeGoldGpt      float64 `json:"feed_grade_gold_gpt"`
+	RecoveryRateSilverPct float64 `json:"recovery_rate_silver_pct"`
+	RecoveryRateGoldPct   float64 `json:"recovery_rate_gold_pct"`
+}
+
 // DoreVariance contains variance for Dore metrics
 type DoreVariance struct {
 	DoreProducedOz        VarianceMetric `json:"dore_produced_oz"`
@@ -209,6 +235,14 @@ type OPEXDetailReport struct {
 	ByCostCenter  map[string]OPEXCostCenterData  `json:"by_cost_center"`
 	BySubcategory map[string]OPEXSubcategoryData `json:"by_subcategory"`
 	ByExpenseType map[string]OPEXExpenseTypeData `json:"by_expense_type"`
+
+	// Display order of the cost center and expense type maps above (and the monthly
+	// breakdowns): known values in their canonical order, other keys alphabetical. JSON
+	// object keys are already alphabetical, so subcategories have no order of their own.
+	CostCenterOrder  []string `json:"cost_center_order"`
+	ExpenseTypeOrder []string `json:"expense_type_order"`
+
+	Coverage *DataCoverage `json:"coverage"` // Months with actual/budget OPEX data, whatever the months filter
 }
 
 // OPEXMonthlyData represents OPEX data for a single month
@@ -217,6 +251,7 @@ type OPEXMonthlyData struct {
 	Actual   *OPEXDetail   `json:"actual"`
 	Budget   *OPEXDetail   `json:"budget"`
 	Variance *OPEXVariance `json:"variance,omitempty"`
+	Forecast *OPEXDetail   `json:"forecast,omitempty"` // Only with include_forecast
 }
 
 // OPEXDetail contains detailed OPEX metrics
@@ -227,6 +262,9 @@ type OPEXDetail struct {
 	GA                float64 `json:"ga"`
 	TransportShipping float64 `json:"transport_shipping"`
 
+	// Every configured cost center, including the four above
+	ByCostCenter map[string]float64 `json:"by_cost_center,omitempty"`
+
 	// Inventory
 	InventoryVariations float64 `json:"inventory_variations"`
 
@@ -286,6 +324,14 @@ type CAPEXDetailReport struct {
 	Months      []CAPEXMonthlyData           `json:"months"`
 	ByType      map[string]CAPEXTypeData     `json:"by_type"`
 	ByCategory  map[string]CAPEXCategoryData `json:"by_category"`
+
+	// Display order of by_type (CAPEX types in their canonical order, other keys
+	// alphabetical) and of the monthly by_project breakdowns (largest amount first; with
+	// ?top=N, the N projects kept). Categories are alphabetical, as JSON object keys are.
+	TypeOrder    []string `json:"type_order"`
+	ProjectOrder []string `json:"project_order"`
+
+	Coverage *DataCoverage `json:"coverage"` // Months with actual/budget CAPEX data, whatever the months filter
 }
 
 // CAPEXMonthlyData represents CAPEX data for a single month
@@ -294,6 +340,7 @@ type CAPEXMonthlyData struct {
 	Actual   *CAPEXDetail         `json:"actual"`
 	Budget   *CAPEXDetail         `json:"budget"`
 	Variance *CAPEXVarianceDetail `json:"variance,omitempty"`
+	Forecast *CAPEXDetail         `json:"forecast,omitempty"` // Only with include_forecast
 }
 
 // CAPEXDetail contains detailed CAPEX metrics
@@ -302,6 +349,8 @@ type CAPEXDetail struct {
 	Project                         float64 `json:"project"`
 	Leasing                         float64 `json:"leasing"`
 	AccretionOfMineClosureLiability float64 `json:"accretion_of_mine_closure_liability"`
+	LeaseAdditions                  float64 `json:"lease_additions"`     // Leasing split: right-of-use assets recognized
+	LeaseCashOutflows               float64 `json:"lease_cash_outflows"` // Leasing split: lease payments
 	Total                           float64 `json:"total"`
 
 	// Breakdown by category (e.g., "Mine Equipment", "Plant Upgrades", "Exploration/Mine Geology")
@@ -319,6 +368,8 @@ type CAPEXVarianceDetail struct {
 	Project                         VarianceMetric `json:"project"`
 	Leasing                         VarianceMetric `json:"leasing"`
 	AccretionOfMineClosureLiability VarianceMetric `json:"accretion_of_mine_closure_liability"`
+	LeaseAdditions                  VarianceMetric `json:"lease_additions"`
+	LeaseCashOutflows               VarianceMetric `json:"lease_cash_outflows"`
 	Total                           VarianceMetric `json:"total"`
 }
 
@@ -344,6 +395,7 @@ type FinancialDetailReport struct {
 	CompanyName string                 `json:"company_name"`
 	Year        int                    `json:"year"`
 	Months      []FinancialMonthlyData `json:"months"`
+	Coverage    *DataCoverage          `json:"coverage"` // Months with actual/budget financial data, whatever the months filter
 }
 
 // FinancialMonthlyData represents Financial data for a single month
@@ -380,11 +432,12 @@ type FinancialVariance struct {
 
 // ProductionDetailReport represents detailed Production report
 type ProductionDetailReport struct {
-	CompanyID   int64                            `json:"company_id"`
-	CompanyName string                           `json:"company_name"`
-	Year        int                              `json:"year"`
-	Months      []ProductionMonthlyData          `json:"months"`
-	ByMineral   map[string]ProductionMineralData `json:"by_mineral"`
+	CompanyID    int64                            `json:"company_id"`
+	CompanyName  string                           `json:"company_name"`
+	Year         int                              `json:"year"`
+	Months       []ProductionMonthlyData          `json:"months"`
+	ByMineral    map[string]ProductionMineralData `json:"by_mineral"`
+	MineralOrder []string                         `json:"mineral_order"` // Display order of by_mineral: mineral catalog order, other codes alphabetical
 }
 
 // ProductionMonthlyData represents Production data for a single month
@@ -425,11 +478,12 @@ type ProductionMineralData struct {
 
 // RevenueDetailReport represents detailed Revenue report
 type RevenueDetailReport struct {
-	CompanyID   int64                         `json:"company_id"`
-	CompanyName string                        `json:"company_name"`
-	Year        int                           `json:"year"`
-	Months      []RevenueMonthlyData          `json:"months"`
-	ByMineral   map[string]RevenueMineralData `json:"by_mineral"`
+	CompanyID    int64                         `json:"company_id"`
+	CompanyName  string                        `json:"company_name"`
+	Year         int                           `json:"year"`
+	Months       []RevenueMonthlyData          `json:"months"`
+	ByMineral    map[string]RevenueMineralData `json:"by_mineral"`
+	MineralOrder []string                      `json:"mineral_order"` // Display order of by_mineral: mineral catalog order, other codes alphabetical
 }
 
 // RevenueMonthlyData represents Revenue data for a single month
@@ -479,3 +533,48 @@ type RevenueMineralData struct {
 	Budget      float64        `json:"budget"`
 	Variance    VarianceMetric `json:"variance"`
 }
+
+// PBRProductionReport represents production derived from PBR only (independent of Dore)
+type PBRProductionReport struct {
+	CompanyID   int64                `json:"company_id"`
+	CompanyName string               `json:"company_name"`
+	Year        int                  `json:"year"`
+	DataType    string               `json:"data_type"`
+	Version     int                  `json:"version"`
+	Months      []PBRProductionMonth `json:"months"`
+}
+
+// PBRProductionMonth represents PBR-derived production for a single month
+type PBRProductionMonth struct {
+	Month      string             `json:"month"`      // "2025-01"
+	Production *ProductionMetrics `json:"production"` // nil when the month has no PBR data
+}
+
+// DailyReport represents the unaggregated (per-day) rows of a single month
+type DailyReport struct {
+	CompanyID   int64          `json:"company_id"`
+	CompanyName string         `json:"company_name"`
+	Year        int            `json:"year"`
+	Month       int            `json:"month"`
+	DataType    string         `json:"data_type"`
+	Version     int            `json:"version"`
+	Days        []DailyMetrics `json:"days"`   // Only days with data, in date order
+	Totals      DailyTotals    `json:"totals"` // Sum of the daily rows
+}
+
+// DailyMetrics represents the data recorded for a single day
+type DailyMetrics struct {
+	Date       string             `json:"date"`       // "2025-01-15"
+	Production *ProductionMetrics `json:"production"` // PBR-derived, nil when the day has no PBR row
+	Dore       []*data.DoreData   `json:"dore"`
+	OPEX       []*data.OPEXData   `json:"opex"`
+	OPEXTotal  float64            `json:"opex_total"`
+}
+
+// DailyTotals sums the daily rows of a month
+type DailyTotals struct {
+	TotalProductionSilverOz float64 `json:"total_production_silver_oz"`
+	TotalProductionGoldOz   float64 `json:"total_production_gold_oz"`
+	DoreProducedOz          float64 `json:"dore_produced_oz"`
+	OPEXTotal               float64 `json:"opex_total"`
+}
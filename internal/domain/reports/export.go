@@ -0,0 +1,352 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media types a summary can be served as, picked from the Accept header
+const (
+	MediaTypeJSON  = "application/json"
+	MediaTypeCSV   = "text/csv"
+	MediaTypeExcel = "application/vnd.ms-excel"
+	MediaTypePDF   = "application/pdf"
+)
+
+// exportMediaTypes are the supported media types, in order of preference on a q tie
+var exportMediaTypes = []string{MediaTypeJSON, MediaTypeCSV, MediaTypeExcel, MediaTypePDF}
+
+// negotiateMediaType picks the media type to serve for an Accept header: the supported
+// type with the highest q value (earliest listed on a tie). An empty header, */* and
+// application/* get JSON, text/* gets CSV. Returns false when nothing acceptable is supported.
+func negotiateMediaType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypeJSON, true
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "*/*", "application/*":
+			mediaType = MediaTypeJSON
+		case "text/*":
+			mediaType = MediaTypeCSV
+		}
+		if q > bestQ && slices.Contains(exportMediaTypes, mediaType) {
+			best, bestQ = mediaType, q
+		}
+	}
+
+	return best, best != ""
+}
+
+// exportFileExtension is the download file extension of an export media type
+func exportFileExtension(mediaType string) string {
+	switch mediaType {
+	case MediaTypeCSV:
+		return "csv"
+	case MediaTypeExcel:
+		return "xls"
+	case MediaTypePDF:
+		return "pdf"
+	}
+	return "json"
+}
+
+// summaryTableHeader are the columns of the tabular summary export
+var summaryTableHeader = []string{
+	"Month", "Scenario",
+	"Ore Processed (t)", "Feed Grade Ag (g/t)", "Feed Grade Au (g/t)",
+	"Payable Ag (oz)", "Payable Au (oz)",
+	"Net Smelter Return", "Production Based Costs", "Production Based Margin", "PBR Net Cash Flow",
+	"Cash Cost Ag ($/oz)", "AISC Ag ($/oz)",
+}
+
+// summaryTable flattens a summary into one row per month and scenario (actual, budget).
+// Columns after the first two are numbers.
+func summaryTable(report *SummaryReport) [][]string {
+	var rows [][]string
+	for _, month := range report.Months {
+		for _, scenario := range []struct {
+			name string
+			ds   *DataSet
+		}{{"actual", month.Actual}, {"budget", month.Budget}} {
+			if scenario.ds == nil {
+				continue
+			}
+			ds := scenario.ds
+			rows = append(rows, append([]string{month.Month, scenario.name}, formatAmounts(
+				ds.Processing.TotalTonnesProcessed, ds.Processing.FeedGradeSilverGpt, ds.Processing.FeedGradeGoldGpt,
+				ds.Production.PayableSilverOz, ds.Production.PayableGoldOz,
+				ds.NSR.NetSmelterReturn, ds.Costs.ProductionBasedCosts, ds.Costs.ProductionBasedMargin, ds.CAPEX.PBRNetCashFlow,
+				ds.CashCost.CashCostPerOzSilver, ds.CashCost.AISCPerOzSilver,
+			)...))
+		}
+	}
+	return rows
+}
+
+func formatAmounts(values ...float64) []string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return formatted
+}
+
+// writeSummary serializes a summary as CSV, Excel (SpreadsheetML) or PDF
+func writeSummary(w io.Writer, mediaType string, report *SummaryReport) error {
+	rows := summaryTable(report)
+	switch mediaType {
+	case MediaTypeCSV:
+		return writeCSVTable(w, summaryTableHeader, rows)
+	case MediaTypeExcel:
+		return writeSpreadsheetML(w, "Summary", summaryTableHeader, rows)
+	case MediaTypePDF:
+		title := fmt.Sprintf("%s - Summary %d", report.CompanyName, report.Year)
+		return writePDFTable(w, title, summaryTableHeader, rows)
+	}
+	return fmt.Errorf("unsupported media type %q", mediaType)
+}
+
+func writeCSVTable(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// writeSpreadsheetML writes an Excel 2003 XML workbook with one sheet, which Excel opens
+// as application/vnd.ms-excel. Columns after the first two are written as numbers.
+func writeSpreadsheetML(w io.Writer, sheet string, header []string, rows [][]string) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<?mso-application progid="Excel.Sheet"?>` + "\n")
+	buf.WriteString(`<Workbook xmlns="urn:schemas-microsoft-com:office:spreadsheet" xmlns:ss="urn:schemas-microsoft-com:office:spreadsheet">` + "\n")
+	buf.WriteString(`<Worksheet ss:Name="`)
+	_ = xml.EscapeText(&buf, []byte(sheet))
+	buf.WriteString(`"><Table>` + "\n")
+
+	writeRow := func(cells []string, numbersFrom int) {
+		buf.WriteString("<Row>")
+		for i, cell := range cells {
+			cellType := "String"
+			if i >= numbersFrom {
+				cellType = "Number"
+			}
+			buf.WriteString(`<Cell><Data ss:Type="` + cellType + `">`)
+			_ = xml.EscapeText(&buf, []byte(cell))
+			buf.WriteString("</Data></Cell>")
+		}
+		buf.WriteString("</Row>\n")
+	}
+	writeRow(header, len(header))
+	for _, row := range rows {
+		writeRow(row, 2)
+	}
+
+	buf.WriteString("</Table></Worksheet>\n</Workbook>\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// summaryMonthColumns are the columns each month takes in the reference Summary layout:
+// month Actual, Budget, Fav (Unf), % Variance, then the same four year to date
+const summaryMonthColumns = 8
+
+// writeSummaryReferenceCSV writes a summary laid out like the reference Summary.csv the
+// reconciliation test reads: a title row, two header rows, then one row per metric of
+// summaryMetrics with a block of summaryMonthColumns per month. Months without actual or
+// budget data leave their cells empty.
+func writeSummaryReferenceCSV(w io.Writer, report *SummaryReport) error {
+	scenarios, periods := summaryReferenceHeader(report)
+	// Every row has the same number of fields, as a spreadsheet saves it
+	title := make([]string, len(periods))
+	title[0] = fmt.Sprintf("%s - Summary %d", report.CompanyName, report.Year)
+
+	rows := [][]string{title, scenarios, periods}
+	for _, metric := range summaryMetrics {
+		row := []string{metric.Label}
+		for _, values := range summaryMetricValues(report, metric) {
+			if values == nil {
+				row = append(row, make([]string, summaryMonthColumns/2)...)
+			} else {
+				row = append(row, formatAmounts(values...)...)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// summaryReferenceHeader are the two header rows of the reference layout, scenarios
+// ("Actual", "2025 Budget") over periods ("Jan", "Fav (Unf)", "Jan_YTD"...)
+func summaryReferenceHeader(report *SummaryReport) (scenarios, periods []string) {
+	scenarios, periods = []string{""}, []string{""}
+	budget := fmt.Sprintf("%d Budget", report.Year)
+	for _, month := range report.Months {
+		name := month.Month
+		if t, err := time.Parse("2006-01", month.Month); err == nil {
+			name = t.Format("Jan")
+		}
+		scenarios = append(scenarios, "Actual", budget, "", "", "Actual", budget, "", "")
+		periods = append(periods, name, name, "Fav (Unf)", "% Variance", name+"_YTD", name+"_YTD", "Fav (Unf)", "% Variance")
+	}
+	return scenarios, periods
+}
+
+// summaryMetricValues are the Actual, Budget, Fav (Unf) and % Variance of a metric for
+// each month, then year to date: two groups per month, nil when either side is missing
+func summaryMetricValues(report *SummaryReport, metric summaryMetric) [][]float64 {
+	values := func(actual, budget *DataSet) []float64 {
+		if actual == nil || budget == nil {
+			return nil
+		}
+		a := getValueFromDataSet(actual, metric.Category, metric.Field)
+		b := getValueFromDataSet(budget, metric.Category, metric.Field)
+		return []float64{a, b, a - b, calculateVariancePct(a, b)}
+	}
+
+	groups := make([][]float64, 0, 2*len(report.Months))
+	for _, month := range report.Months {
+		groups = append(groups, values(month.Actual, month.Budget))
+		if month.YTD != nil {
+			groups = append(groups, values(month.YTD.Actual, month.YTD.Budget))
+		} else {
+			groups = append(groups, nil)
+		}
+	}
+	return groups
+}
+
+// PDF page layout: A4 landscape, monospaced text so columns line up
+const (
+	pdfPageWidth    = 842
+	pdfPageHeight   = 595
+	pdfMargin       = 36
+	pdfFontSize     = 6
+	pdfLineHeight   = 9
+	pdfColumnWidth  = 16 // Characters per column (13 columns fit the landscape width)
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// writePDFTable writes a minimal PDF (built-in Courier font, no dependencies) laying the
+// table out as fixed-width text, paginated with the title and header on every page
+func writePDFTable(w io.Writer, title string, header []string, rows [][]string) error {
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = pdfTableLine(row)
+	}
+	headerLines := []string{title, "", pdfTableLine(header)}
+	perPage := pdfLinesPerPage - len(headerLines)
+
+	var pages [][]string
+	for start := 0; ; start += perPage {
+		end := min(start+perPage, len(lines))
+		pages = append(pages, append(slices.Clone(headerLines), lines[start:end]...))
+		if end == len(lines) {
+			break
+		}
+	}
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content stream per page
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	)
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			content.WriteString("(" + pdfEscape(line) + ") Tj T*\n")
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfTableLine pads (or truncates) each cell to the column width
+func pdfTableLine(cells []string) string {
+	var line strings.Builder
+	for _, cell := range cells {
+		if len(cell) >= pdfColumnWidth {
+			cell = cell[:pdfColumnWidth-1]
+		}
+		line.WriteString(cell + strings.Repeat(" ", pdfColumnWidth-len(cell)))
+	}
+	return strings.TrimRight(line.String(), " ")
+}
+
+// pdfEscape escapes a PDF string literal; characters outside printable ASCII become "?"
+// since the built-in font encoding only covers those reliably
+func pdfEscape(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 126:
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
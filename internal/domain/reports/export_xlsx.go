@@ -0,0 +1,198 @@
+package reports
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MediaTypeXLSX is the content type of an Excel workbook (Office Open XML)
+const MediaTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// summarySections are the workbook sheets of the XLSX summary export, one per DataSet section
+var summarySections = []struct {
+	Sheet    string
+	Category string
+}{
+	{"Mining", "mining"},
+	{"Processing", "processing"},
+	{"Production", "production"},
+	{"NSR", "nsr"},
+	{"Costs", "costs"},
+	{"CAPEX", "capex"},
+	{"CashCost", "cash_cost"},
+}
+
+// Cell styles of the XLSX export, indexes into cellXfs of xlsxStyles
+const (
+	xlsxStyleText    = 0
+	xlsxStyleHeader  = 1
+	xlsxStyleAmount  = 2
+	xlsxStylePercent = 3
+)
+
+// xlsxStyles formats amounts and percentages with negatives in parentheses, as the
+// reference workbook does
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="#,##0.00;(#,##0.00)"/><numFmt numFmtId="165" formatCode="0.00%;(0.00%)"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// xlsxCell is a cell of the export: text, or a number with a style
+type xlsxCell struct {
+	Text   string
+	Number float64
+	Style  int
+	IsText bool
+}
+
+// writeSummaryXLSX writes a summary as an Excel workbook with a sheet per section of
+// summarySections. Each sheet has the header rows and columns of the reference layout
+// (see writeSummaryReferenceCSV), with % Variance as a percentage cell.
+func writeSummaryXLSX(w io.Writer, report *SummaryReport) error {
+	scenarios, periods := summaryReferenceHeader(report)
+	headerRow := func(cells []string) []xlsxCell {
+		row := make([]xlsxCell, len(cells))
+		for i, cell := range cells {
+			row[i] = xlsxCell{Text: cell, Style: xlsxStyleHeader, IsText: true}
+		}
+		return row
+	}
+
+	sheets := make([][][]xlsxCell, len(summarySections))
+	for i, section := range summarySections {
+		title := fmt.Sprintf("%s - %s %d", report.CompanyName, section.Sheet, report.Year)
+		rows := [][]xlsxCell{{{Text: title, Style: xlsxStyleHeader, IsText: true}}, headerRow(scenarios), headerRow(periods)}
+		for _, metric := range summaryMetrics {
+			if metric.Category != section.Category {
+				continue
+			}
+			row := []xlsxCell{{Text: metric.Label, IsText: true}}
+			for _, values := range summaryMetricValues(report, metric) {
+				if values == nil {
+					row = append(row, make([]xlsxCell, summaryMonthColumns/2)...)
+					continue
+				}
+				for j, v := range values {
+					if j == len(values)-1 {
+						row = append(row, xlsxCell{Number: v / 100, Style: xlsxStylePercent})
+					} else {
+						row = append(row, xlsxCell{Number: v, Style: xlsxStyleAmount})
+					}
+				}
+			}
+			rows = append(rows, row)
+		}
+		sheets[i] = rows
+	}
+
+	archive := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xlsxWorkbook()},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, rows := range sheets {
+		parts = append(parts, struct {
+			name    string
+			content string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheet(rows)})
+	}
+
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func xlsxContentTypes(sheets int) string {
+	var b strings.Builder
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func xlsxWorkbook() string {
+	var b strings.Builder
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, section := range summarySections {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, section.Sheet, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+// xlsxWorkbookRels relates the sheets as rId1..rIdN and the styles after them
+func xlsxWorkbookRels(sheets int) string {
+	var b strings.Builder
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// xlsxSheet writes text as inline strings, so the workbook needs no shared strings part.
+// Empty cells are left out.
+func xlsxSheet(rows [][]xlsxCell) string {
+	var b strings.Builder
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := xlsxColumnName(c) + strconv.Itoa(r+1)
+			switch {
+			case cell.IsText && cell.Text != "":
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t>`, ref, cell.Style)
+				_ = xml.EscapeText(&b, []byte(cell.Text))
+				b.WriteString(`</t></is></c>`)
+			case !cell.IsText && cell.Style != xlsxStyleText:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.Style, strconv.FormatFloat(cell.Number, 'f', -1, 64))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxColumnName is the letter reference of a 0-based column: A, B, ..., Z, AA, AB...
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
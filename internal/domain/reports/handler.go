@@ -1,9 +1,14 @@
 package reports
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
@@ -37,68 +42,148 @@ func RegisterHTTPEndPoints(router *chi.Mux, validator *validator.Validate, uc Us
 
 	router.Route("/api/v1/reports", func(r chi.Router) {
 		r.Get("/summary", h.GetSummary)
+		r.Get("/summary/export.csv", h.ExportSummaryCSV)
+		r.Get("/summary/export.xlsx", h.ExportSummaryXLSX)
+		r.Post("/summaries", h.GetSummaries)
 		r.Post("/save", h.SaveReport)
 		r.Get("/saved", h.ListSavedReports)
 		r.Post("/compare", h.CompareReports)
+		r.Get("/compare", h.CompareScenarios)
+		r.Get("/benchmark", h.GetBenchmark)
+		r.Get("/integrity", h.GetIntegrity)
+		r.Get("/ttm", h.GetTTM)
+		r.Get("/mineral-margin", h.GetMineralMargin)
+		r.Get("/margin-waterfall", h.GetMarginWaterfall)
+		r.Get("/sensitivity", h.GetSensitivity)
+		r.Get("/variance", h.GetVariance)
+		r.Get("/weighted-grade", h.GetWeightedGrade)
+		r.Get("/reconcile", h.GetReconciliation)
 
 		// Detailed reports
 		r.Get("/pbr", detailH.GetPBRDetail)
 		r.Get("/dore", detailH.GetDoreDetail)
 		r.Get("/opex", detailH.GetOPEXDetail)
 		r.Get("/capex", detailH.GetCAPEXDetail)
+		r.Get("/financial", detailH.GetFinancialDetail)
+		r.Get("/production", detailH.GetPBRProduction)
+		r.Get("/daily", detailH.GetDaily)
 	})
 }
 
 // GetSummary returns the summary report for a company
 // @Summary Get summary report
-// @Description Get complete summary report with actual and budget data
+// @Description Get complete summary report with actual and budget data. The Accept header picks the format:
+// @Description JSON (default), text/csv, application/vnd.ms-excel or application/pdf (one row per month and scenario)
 // @Tags reports
-// @Produce json
+// @Produce json,text/csv,application/vnd.ms-excel,application/pdf
 // @Param company_id query integer true "Company ID"
 // @Param year query integer true "Year"
 // @Param budget_version query integer true "Budget version to compare against"
 // @Param months query string false "Comma-separated months (1-12)" example:"1,2,3"
+// @Param include_forecast query boolean false "Also return forecast data and variance against it (default: false)"
 // @Success 200 {object} SummaryReport
 // @Failure 400 {object} respond.Error
 // @Failure 404 {object} respond.Error
+// @Failure 406 {object} respond.Error
 // @Failure 500 {object} respond.Error
 // @Router /api/v1/reports/summary [get]
 func (h *Handler) GetSummary(w http.ResponseWriter, r *http.Request) {
-	// Parse company_id
-	companyIDStr := r.URL.Query().Get("company_id")
-	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
-	if err != nil || companyID <= 0 {
-		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+	req, err := h.parseSummaryRequest(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// Parse year
-	yearStr := r.URL.Query().Get("year")
-	year, err := strconv.Atoi(yearStr)
-	if err != nil || year < 2000 {
-		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+	// Serializer from the Accept header (JSON by default)
+	mediaType, ok := negotiateMediaType(r.Header.Get("Accept"))
+	if !ok {
+		respond.Error(w, http.StatusNotAcceptable, fmt.Errorf("unsupported Accept header, use one of: %s", strings.Join(exportMediaTypes, ", ")))
 		return
 	}
 
-	// Parse budget_version (required)
-	budgetVersionStr := r.URL.Query().Get("budget_version")
-	budgetVersion, err := strconv.Atoi(budgetVersionStr)
-	if err != nil || budgetVersion < 1 {
-		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing budget_version (must be >= 1)"))
+	report, err := h.useCase.GetSummary(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	// Parse months (optional - if empty, returns all 12 months)
-	months := r.URL.Query().Get("months")
+	if mediaType == MediaTypeJSON {
+		respond.JSON(w, http.StatusOK, report)
+		return
+	}
 
-	req := &SummaryRequest{
-		CompanyID:     companyID,
-		Year:          year,
-		Months:        months,
-		BudgetVersion: budgetVersion,
+	var body bytes.Buffer
+	if err := writeSummary(&body, mediaType, report); err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="summary_%d_%d.%s"`, report.CompanyID, report.Year, exportFileExtension(mediaType)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body.Bytes())
+}
+
+// parseSummaryRequest reads and validates the summary query parameters
+func (h *Handler) parseSummaryRequest(r *http.Request) (*SummaryRequest, error) {
+	companyID, err := strconv.ParseInt(r.URL.Query().Get("company_id"), 10, 64)
+	if err != nil || companyID <= 0 {
+		return nil, errors.New("invalid or missing company_id")
+	}
+
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil || year < 2000 {
+		return nil, errors.New("invalid or missing year")
+	}
+
+	// budget_version is required
+	budgetVersion, err := strconv.Atoi(r.URL.Query().Get("budget_version"))
+	if err != nil || budgetVersion < 1 {
+		return nil, errors.New("invalid or missing budget_version (must be >= 1)")
+	}
+
+	includeForecast := false
+	if raw := r.URL.Query().Get("include_forecast"); raw != "" {
+		includeForecast, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("invalid include_forecast")
+		}
 	}
 
+	req := &SummaryRequest{
+		CompanyID:       companyID,
+		Year:            year,
+		Months:          r.URL.Query().Get("months"), // Optional - if empty, returns all 12 months
+		BudgetVersion:   budgetVersion,
+		IncludeForecast: includeForecast,
+	}
 	if err := h.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ExportSummaryCSV returns the summary report as CSV in the reference Summary.csv layout
+// @Summary Export summary report as CSV
+// @Description Summary in the layout of the reference Summary workbook: one row per reconciled metric and,
+// @Description per month, Actual, Budget, Fav (Unf), % Variance and the same four year to date
+// @Tags reports
+// @Produce text/csv
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param budget_version query integer true "Budget version to compare against"
+// @Param months query string false "Comma-separated months (1-12)" example:"1,2,3"
+// @Success 200 {file} file
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/summary/export.csv [get]
+func (h *Handler) ExportSummaryCSV(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseSummaryRequest(r)
+	if err != nil {
 		respond.Error(w, http.StatusBadRequest, err)
 		return
 	}
@@ -113,11 +198,130 @@ func (h *Handler) GetSummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond.JSON(w, http.StatusOK, report)
+	w.Header().Set("Content-Type", MediaTypeCSV)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="summary_%d_%d.csv"`, report.CompanyID, report.Year))
+	w.WriteHeader(http.StatusOK)
+	_ = writeSummaryReferenceCSV(w, report)
+}
+
+// ExportSummaryXLSX returns the summary report as an Excel workbook
+// @Summary Export summary report as XLSX
+// @Description Workbook with a sheet per section (Mining, Processing, Production, NSR, Costs, CAPEX, CashCost) in the
+// @Description layout of the CSV export, with number formats and negative variances in parentheses
+// @Tags reports
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param budget_version query integer true "Budget version to compare against"
+// @Param months query string false "Comma-separated months (1-12)" example:"1,2,3"
+// @Success 200 {file} file
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/summary/export.xlsx [get]
+func (h *Handler) ExportSummaryXLSX(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseSummaryRequest(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetSummary(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := writeSummaryXLSX(&body, report); err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", MediaTypeXLSX)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="summary_%d_%d.xlsx"`, report.CompanyID, report.Year))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body.Bytes())
+}
+
+// GetSummaries returns the summaries of several companies in one request, computed concurrently
+// @Summary Get summaries for several companies
+// @Description Summary reports keyed by company ID. Companies the user cannot view are left out and listed as inaccessible.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param request body SummariesRequest true "Companies, years, budget versions and months"
+// @Success 200 {object} SummariesReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/summaries [post]
+func (h *Handler) GetSummaries(w http.ResponseWriter, r *http.Request) {
+	var req SummariesRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Viewer role is enough for a summary (from session cache - no DB query)
+	response := &SummariesReport{Summaries: map[int64]*SummaryReport{}}
+	var summaryReqs []*SummaryRequest
+	for _, item := range req.Summaries {
+		if err := middleware.CheckCompanyRole(r.Context(), item.CompanyID, middleware.RoleViewer); err != nil {
+			if errors.Is(err, middleware.ErrCompanyAccessDenied) || errors.Is(err, middleware.ErrInsufficientRole) {
+				response.Inaccessible = append(response.Inaccessible, item.CompanyID)
+				continue
+			}
+			respond.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		summaryReqs = append(summaryReqs, &SummaryRequest{
+			CompanyID:     item.CompanyID,
+			Year:          item.Year,
+			Months:        item.Months,
+			BudgetVersion: item.Version,
+		})
+	}
+
+	if len(summaryReqs) > 0 {
+		summaries, err := h.useCase.GetSummaries(r.Context(), summaryReqs)
+		if err != nil {
+			if errors.Is(err, ErrCompanyNotFound) {
+				respond.Error(w, http.StatusNotFound, err)
+				return
+			}
+			respond.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		response.Summaries = summaries
+	}
+
+	respond.JSON(w, http.StatusOK, response)
 }
 
 // SaveReport saves a report snapshot
 // Requires: editor role (can create/modify data)
+// @Summary Save the current summary as a scenario
+// @Description Computes the full-year summary for the budget version and stores it as a saved report,
+// @Description e.g. to snapshot a month-end state before re-importing corrected data
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param request body SaveReportRequest true "Company, year, budget version and scenario name"
+// @Success 201 {object} SavedReport
+// @Failure 400 {object} respond.Error
+// @Failure 403 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/save [post]
 func (h *Handler) SaveReport(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
@@ -148,6 +352,10 @@ func (h *Handler) SaveReport(w http.ResponseWriter, r *http.Request) {
 
 	savedReport, err := h.useCase.SaveReport(r.Context(), &req, userID)
 	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
 		respond.Error(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -225,3 +433,756 @@ func (h *Handler) CompareReports(w http.ResponseWriter, r *http.Request) {
 
 	respond.JSON(w, http.StatusOK, comparison)
 }
+
+// CompareScenarios compares saved scenarios of one company side by side
+// Requires: viewer role in the reports' company, checked here as the company comes from the reports
+// @Summary Compare saved scenarios
+// @Description Year to date actual and budget of each saved report, with the variance of each against the first (baseline)
+// @Tags reports
+// @Produce json
+// @Param ids query string true "Comma-separated saved report IDs (2 to 5), baseline first" example:"1,2,3"
+// @Success 200 {object} ScenarioComparison
+// @Failure 400 {object} respond.Error
+// @Failure 403 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/compare [get]
+func (h *Handler) CompareScenarios(w http.ResponseWriter, r *http.Request) {
+	var req CompareReportsRequest
+	for _, raw := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			respond.Error(w, http.StatusBadRequest, fmt.Errorf("invalid report id %q", raw))
+			return
+		}
+		if slices.Contains(req.ReportIDs, id) {
+			respond.Error(w, http.StatusBadRequest, fmt.Errorf("report %d is listed twice", id))
+			return
+		}
+		req.ReportIDs = append(req.ReportIDs, id)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var companyID int64
+	for i, reportID := range req.ReportIDs {
+		reportCompanyID, err := h.useCase.GetReportCompanyID(r.Context(), reportID)
+		if err != nil {
+			if errors.Is(err, ErrReportNotFound) {
+				respond.Error(w, http.StatusNotFound, err)
+				return
+			}
+			respond.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if i == 0 {
+			companyID = reportCompanyID
+		} else if reportCompanyID != companyID {
+			respond.Error(w, http.StatusBadRequest, ErrMixedCompanies)
+			return
+		}
+	}
+
+	if err := middleware.CheckCompanyRole(r.Context(), companyID, middleware.RoleViewer); err != nil {
+		if errors.Is(err, middleware.ErrCompanyAccessDenied) || errors.Is(err, middleware.ErrInsufficientRole) {
+			respond.Error(w, http.StatusForbidden, errors.New("you don't have access to the selected reports"))
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	comparison, err := h.useCase.CompareScenarios(r.Context(), req.ReportIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrReportNotFound):
+			respond.Error(w, http.StatusNotFound, err)
+		case errors.Is(err, ErrMixedCompanies):
+			respond.Error(w, http.StatusBadRequest, err)
+		default:
+			respond.Error(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, comparison)
+}
+
+// GetBenchmark compares a company's monthly KPIs against a peer group benchmark set
+// @Summary Get benchmark comparison
+// @Description Company KPIs (AISC/oz, recovery, strip ratio) for a month next to peer group benchmarks and the gap
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param month query integer true "Month (1-12)"
+// @Param peer_group query string false "Peer group (default: default)"
+// @Success 200 {object} BenchmarkReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/benchmark [get]
+func (h *Handler) GetBenchmark(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	monthStr := r.URL.Query().Get("month")
+	month, err := strconv.Atoi(monthStr)
+	if err != nil || month < 1 || month > 12 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing month (must be 1-12)"))
+		return
+	}
+
+	req := &BenchmarkRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Month:     month,
+		PeerGroup: r.URL.Query().Get("peer_group"),
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetBenchmark(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetIntegrity checks referential integrity of a company year before year-close
+// @Summary Check referential integrity
+// @Description Lists Dore months without PBR, Financial months without Dore and references to unknown minerals
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param version query integer false "Data version (default: 1)"
+// @Param data_type query string false "actual, budget or forecast (default: actual)"
+// @Success 200 {object} IntegrityReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/integrity [get]
+func (h *Handler) GetIntegrity(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	req := &IntegrityRequest{
+		CompanyID: companyID,
+		Year:      year,
+		DataType:  dataType,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.CheckIntegrity(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetTTM returns trailing-twelve-month figures ending at a month, across year boundaries
+// @Summary Get trailing twelve months
+// @Description Accumulates the twelve months ending at through (YYYY-MM), which may span two calendar years
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param through query string true "Last month of the window (YYYY-MM)"
+// @Param version query integer false "Data version (default: 1)"
+// @Param data_type query string false "actual, budget or forecast (default: actual)"
+// @Success 200 {object} TTMReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/ttm [get]
+func (h *Handler) GetTTM(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	through := r.URL.Query().Get("through")
+	if _, err := time.Parse("2006-01", through); err != nil {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing through (must be YYYY-MM)"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	req := &TTMRequest{
+		CompanyID: companyID,
+		Through:   through,
+		DataType:  dataType,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetTTM(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetMineralMargin returns each mineral's revenue and contribution to the margin
+// @Summary Get contribution margin by mineral
+// @Description Splits revenue by mineral (Dore silver/gold plus Revenue rows) and allocates Production Based Costs on the chosen basis
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param version query integer false "Data version (default: 1)"
+// @Param data_type query string false "actual, budget or forecast (default: actual)"
+// @Param allocation_basis query string false "revenue or equal (default: revenue)"
+// @Success 200 {object} MineralMarginReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/mineral-margin [get]
+func (h *Handler) GetMineralMargin(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	allocationBasis := r.URL.Query().Get("allocation_basis")
+	if allocationBasis == "" {
+		allocationBasis = AllocationBasisRevenue
+	}
+
+	req := &MineralMarginRequest{
+		CompanyID:       companyID,
+		Year:            year,
+		DataType:        dataType,
+		Version:         version,
+		AllocationBasis: allocationBasis,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetMineralMargin(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetMarginWaterfall returns the budget to actual margin waterfall of a month
+// @Summary Get margin variance waterfall
+// @Description Decomposes actual vs budget margin variance into volume, grade, recovery, price, cost and other
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param month query integer true "Month (1-12)"
+// @Param version query integer false "Budget version (default: 1)"
+// @Success 200 {object} MarginWaterfallReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/margin-waterfall [get]
+func (h *Handler) GetMarginWaterfall(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	monthStr := r.URL.Query().Get("month")
+	month, err := strconv.Atoi(monthStr)
+	if err != nil || month < 1 || month > 12 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing month (must be 1-12)"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	req := &MarginWaterfallRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Month:     month,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetMarginWaterfall(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetSensitivity returns the margin of a company year under a grid of metal price scenarios
+// @Summary Get metal price sensitivity
+// @Description Recomputes Net Smelter Return, Production Based Margin and PBR Net Cash Flow with silver and gold
+// @Description realized prices changed by -20%, -10%, 0, +10% and +20%, as a silver x gold matrix
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param version query integer false "Data version (default: 1)"
+// @Param data_type query string false "actual, budget or forecast (default: actual)"
+// @Param months query string false "Comma-separated months (1-12), all months when empty" example:"1,2,3"
+// @Success 200 {object} SensitivityReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/sensitivity [get]
+func (h *Handler) GetSensitivity(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	months := r.URL.Query().Get("months")
+	if months != "" {
+		for _, part := range strings.Split(months, ",") {
+			month, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || month < 1 || month > 12 {
+				respond.Error(w, http.StatusBadRequest, errors.New("invalid months (comma-separated, each 1-12)"))
+				return
+			}
+		}
+	}
+
+	req := &SensitivityRequest{
+		CompanyID: companyID,
+		Year:      year,
+		DataType:  dataType,
+		Version:   version,
+		Months:    months,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetSensitivity(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetVariance returns the month and YTD variance of a month
+// @Summary Get month variance
+// @Description Returns the actual vs budget variance of a month and its YTD, for dashboard widgets
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param month query integer true "Month (1-12)"
+// @Param version query integer false "Budget version (default: 1)"
+// @Success 200 {object} VarianceReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/variance [get]
+func (h *Handler) GetVariance(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	monthStr := r.URL.Query().Get("month")
+	month, err := strconv.Atoi(monthStr)
+	if err != nil || month < 1 || month > 12 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing month (must be 1-12)"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	req := &VarianceRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Month:     month,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetVariance(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetWeightedGrade returns tonnage-weighted feed grades across a set of months
+// @Summary Get weighted feed grade
+// @Description Weights the PBR feed grades of the selected months by tonnes processed, e.g. for a high-grade quarter
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param months query string true "Comma-separated months (1-12)" example:"4,5,6"
+// @Param version query integer false "Data version (default: 1)"
+// @Param data_type query string false "actual, budget or forecast (default: actual)"
+// @Success 200 {object} WeightedGradeReport
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/weighted-grade [get]
+func (h *Handler) GetWeightedGrade(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	var months []int
+	for _, part := range strings.Split(r.URL.Query().Get("months"), ",") {
+		month, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || month < 1 || month > 12 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing months (comma-separated, each 1-12)"))
+			return
+		}
+		months = append(months, month)
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	req := &WeightedGradeRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Months:    months,
+		DataType:  dataType,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetWeightedGrade(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
+
+// GetReconciliation reconciles a month of live data against a saved report
+// @Summary Reconcile a month
+// @Description Compares the actual and budget Summary metrics of a month with the same month of a saved report.
+// @Description A metric matches when the difference is within tolerance, either absolute or in percent.
+// @Tags reports
+// @Produce json
+// @Param company_id query integer true "Company ID"
+// @Param year query integer true "Year"
+// @Param month query integer true "Month (1-12)"
+// @Param report_id query integer true "Saved report of the company and year to compare against"
+// @Param version query integer false "Budget version (default: the saved report's)"
+// @Param tolerance query number false "Difference accepted as a match (default: 0.01)"
+// @Param decimals query integer false "Round both sides to this many decimals before comparing (default: no rounding)"
+// @Success 200 {object} ReconciliationResult
+// @Failure 400 {object} respond.Error
+// @Failure 404 {object} respond.Error
+// @Failure 500 {object} respond.Error
+// @Router /api/v1/reports/reconcile [get]
+func (h *Handler) GetReconciliation(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	companyID, err := strconv.ParseInt(query.Get("company_id"), 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	year, err := strconv.Atoi(query.Get("year"))
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	month, err := strconv.Atoi(query.Get("month"))
+	if err != nil || month < 1 || month > 12 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing month (must be 1-12)"))
+		return
+	}
+
+	reportID, err := strconv.ParseInt(query.Get("report_id"), 10, 64)
+	if err != nil || reportID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing report_id"))
+		return
+	}
+
+	version := 0
+	if versionStr := query.Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	tolerance := DefaultReconcileTolerance
+	if toleranceStr := query.Get("tolerance"); toleranceStr != "" {
+		tolerance, err = strconv.ParseFloat(toleranceStr, 64)
+		if err != nil || tolerance < 0 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid tolerance (must be >= 0)"))
+			return
+		}
+	}
+
+	decimals := NoRounding
+	if decimalsStr := query.Get("decimals"); decimalsStr != "" {
+		decimals, err = strconv.Atoi(decimalsStr)
+		if err != nil || decimals < 0 || decimals > 10 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid decimals (must be 0-10)"))
+			return
+		}
+	}
+
+	req := &ReconcileRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Month:     month,
+		Version:   version,
+		ReportID:  reportID,
+		Tolerance: tolerance,
+		Decimals:  decimals,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := h.useCase.GetReconciliation(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) || errors.Is(err, ErrReportNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, result)
+}
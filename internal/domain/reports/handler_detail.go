@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 
@@ -37,10 +38,13 @@ func NewDetailHandler(useCase DetailUseCase, validator *validator.Validate, auth
 // @Param company_id query int true "Company ID"
 // @Param year query int true "Year"
 // @Param months query string false "Months filter (e.g., '1,2,3')"
+// @Param budget_version query int false "Budget version to compare against (default: 1)"
+// @Param include_forecast query bool false "Also return forecast data (default: false)"
+// @Param fields query string false "Metric groups to return, all when empty (comma-separated: mining, developments, processing, production, headcount)"
 // @Success 200 {object} PBRDetailReport
 // @Router /api/v1/reports/pbr [get]
 func (h *DetailHandler) GetPBRDetail(w http.ResponseWriter, r *http.Request) {
-	req, err := h.parseDetailRequest(r)
+	req, err := h.parseDetailRequest(r, pbrFieldGroups)
 	if err != nil {
 		respond.Error(w, http.StatusBadRequest, err)
 		return
@@ -61,7 +65,13 @@ func (h *DetailHandler) GetPBRDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond.JSON(w, http.StatusOK, report)
+	projected, err := projectDetailFields(report, req.Fields, pbrFieldGroups)
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, projected)
 }
 
 // GetDoreDetail returns detailed Dore report
@@ -73,10 +83,13 @@ func (h *DetailHandler) GetPBRDetail(w http.ResponseWriter, r *http.Request) {
 // @Param company_id query int true "Company ID"
 // @Param year query int true "Year"
 // @Param months query string false "Months filter (e.g., '1,2,3')"
+// @Param budget_version query int false "Budget version to compare against (default: 1)"
+// @Param include_forecast query bool false "Also return forecast data (default: false)"
+// @Param fields query string false "Metric groups to return, all when empty (comma-separated: production, deductions, prices, revenue, charges, nsr, source_pbr)"
 // @Success 200 {object} DoreDetailReport
 // @Router /api/v1/reports/dore [get]
 func (h *DetailHandler) GetDoreDetail(w http.ResponseWriter, r *http.Request) {
-	req, err := h.parseDetailRequest(r)
+	req, err := h.parseDetailRequest(r, doreFieldGroups)
 	if err != nil {
 		respond.Error(w, http.StatusBadRequest, err)
 		return
@@ -97,7 +110,13 @@ func (h *DetailHandler) GetDoreDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond.JSON(w, http.StatusOK, report)
+	projected, err := projectDetailFields(report, req.Fields, doreFieldGroups)
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, projected)
 }
 
 // GetOPEXDetail returns detailed OPEX report
@@ -109,10 +128,13 @@ func (h *DetailHandler) GetDoreDetail(w http.ResponseWriter, r *http.Request) {
 // @Param company_id query int true "Company ID"
 // @Param year query int true "Year"
 // @Param months query string false "Months filter (e.g., '1,2,3')"
+// @Param budget_version query int false "Budget version to compare against (default: 1)"
+// @Param include_forecast query bool false "Also return forecast data (default: false)"
+// @Param fields query string false "Metric groups to return, all when empty (comma-separated: cost_centers, subcategories, expense_types)"
 // @Success 200 {object} OPEXDetailReport
 // @Router /api/v1/reports/opex [get]
 func (h *DetailHandler) GetOPEXDetail(w http.ResponseWriter, r *http.Request) {
-	req, err := h.parseDetailRequest(r)
+	req, err := h.parseDetailRequest(r, opexFieldGroups)
 	if err != nil {
 		respond.Error(w, http.StatusBadRequest, err)
 		return
@@ -133,7 +155,13 @@ func (h *DetailHandler) GetOPEXDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond.JSON(w, http.StatusOK, report)
+	projected, err := projectDetailFields(report, req.Fields, opexFieldGroups)
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, projected)
 }
 
 // GetCAPEXDetail returns detailed CAPEX report
@@ -145,10 +173,14 @@ func (h *DetailHandler) GetOPEXDetail(w http.ResponseWriter, r *http.Request) {
 // @Param company_id query int true "Company ID"
 // @Param year query int true "Year"
 // @Param months query string false "Months filter (e.g., '1,2,3')"
+// @Param budget_version query int false "Budget version to compare against (default: 1)"
+// @Param include_forecast query bool false "Also return forecast data (default: false)"
+// @Param fields query string false "Metric groups to return, all when empty (comma-separated: types, categories, projects)"
+// @Param top query int false "Keep only the N largest projects in by_project"
 // @Success 200 {object} CAPEXDetailReport
 // @Router /api/v1/reports/capex [get]
 func (h *DetailHandler) GetCAPEXDetail(w http.ResponseWriter, r *http.Request) {
-	req, err := h.parseDetailRequest(r)
+	req, err := h.parseDetailRequest(r, capexFieldGroups)
 	if err != nil {
 		respond.Error(w, http.StatusBadRequest, err)
 		return
@@ -169,6 +201,122 @@ func (h *DetailHandler) GetCAPEXDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	projected, err := projectDetailFields(report, req.Fields, capexFieldGroups)
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, projected)
+}
+
+// GetFinancialDetail returns detailed Financial report
+// @Summary Get detailed Financial report
+// @Description Returns detailed Financial report with monthly shipping & selling, sales taxes, royalties and other deductions, and variances
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param company_id query int true "Company ID"
+// @Param year query int true "Year"
+// @Param months query string false "Months filter (e.g., '1,2,3')"
+// @Param budget_version query int false "Budget version to compare against (default: 1)"
+// @Param fields query string false "Metric groups to return, all when empty (comma-separated: selling, taxes, other, total)"
+// @Success 200 {object} FinancialDetailReport
+// @Router /api/v1/reports/financial [get]
+func (h *DetailHandler) GetFinancialDetail(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseDetailRequest(r, financialFieldGroups)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetFinancialDetail(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	projected, err := projectDetailFields(report, req.Fields, financialFieldGroups)
+	if err != nil {
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, projected)
+}
+
+// GetPBRProduction returns monthly production derived from PBR only
+// @Summary Get production derived from PBR
+// @Description Returns ounces produced per month calculated from PBR feed grade, tonnes and recovery, independent of Dore
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param company_id query int true "Company ID"
+// @Param year query int true "Year"
+// @Param data_type query string false "Data type (default: actual)" Enums(actual, budget, forecast)
+// @Param version query int false "Data version (default: 1)"
+// @Success 200 {object} PBRProductionReport
+// @Router /api/v1/reports/production [get]
+func (h *DetailHandler) GetPBRProduction(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	req := &ProductionRequest{
+		CompanyID: companyID,
+		Year:      year,
+		DataType:  dataType,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetPBRProduction(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	respond.JSON(w, http.StatusOK, report)
 }
 
@@ -178,7 +326,8 @@ func (h *DetailHandler) GetCAPEXDetail(w http.ResponseWriter, r *http.Request) {
 // - Revenue data is now in Dore and Summary/NSR
 
 // parseDetailRequest parses detail request from query parameters
-func (h *DetailHandler) parseDetailRequest(r *http.Request) (*DetailRequest, error) {
+// parseDetailRequest parses the query of a detail report, whose ?fields= are among fieldGroups
+func (h *DetailHandler) parseDetailRequest(r *http.Request, fieldGroups []detailFieldGroup) (*DetailRequest, error) {
 	companyIDStr := r.URL.Query().Get("company_id")
 	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
 	if err != nil || companyID <= 0 {
@@ -191,18 +340,128 @@ func (h *DetailHandler) parseDetailRequest(r *http.Request) (*DetailRequest, err
 		return nil, errors.New("invalid or missing year")
 	}
 
-	budgetVersionStr := r.URL.Query().Get("budget_version")
-	budgetVersion, err := strconv.Atoi(budgetVersionStr)
-	if err != nil || budgetVersion < 1 {
-		return nil, errors.New("invalid or missing budget_version (must be >= 1)")
+	// Budget version defaults to 1, the version a budget gets on its first import
+	budgetVersion := 1
+	if raw := r.URL.Query().Get("budget_version"); raw != "" {
+		budgetVersion, err = strconv.Atoi(raw)
+		if err != nil || budgetVersion < 1 {
+			return nil, errors.New("invalid budget_version (must be >= 1)")
+		}
+	}
+
+	includeForecast := false
+	if raw := r.URL.Query().Get("include_forecast"); raw != "" {
+		includeForecast, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("invalid include_forecast")
+		}
 	}
 
 	months := r.URL.Query().Get("months")
+	if months != "" {
+		for _, part := range strings.Split(months, ",") {
+			month, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || month < 1 || month > 12 {
+				return nil, errors.New("invalid months (comma-separated, each 1-12)")
+			}
+		}
+	}
+
+	fields, err := parseDetailFields(r.URL.Query().Get("fields"), fieldGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	top := 0
+	if raw := r.URL.Query().Get("top"); raw != "" {
+		top, err = strconv.Atoi(raw)
+		if err != nil || top < 1 {
+			return nil, errors.New("invalid top (must be >= 1)")
+		}
+	}
 
 	return &DetailRequest{
-		CompanyID:     companyID,
-		Year:          year,
-		Months:        months,
-		BudgetVersion: budgetVersion,
+		CompanyID:       companyID,
+		Year:            year,
+		Months:          months,
+		BudgetVersion:   budgetVersion,
+		IncludeForecast: includeForecast,
+		Fields:          fields,
+		Top:             top,
 	}, nil
 }
+
+// GetDaily returns the per-day (unaggregated) rows of a month
+// @Summary Get daily metrics for a month
+// @Description Returns each day's PBR-derived production and the Dore/OPEX rows recorded on that day
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param company_id query int true "Company ID"
+// @Param year query int true "Year"
+// @Param month query int true "Month (1-12)"
+// @Param data_type query string false "Data type (default: actual)" Enums(actual, budget, forecast)
+// @Param version query int false "Data version (default: 1)"
+// @Success 200 {object} DailyReport
+// @Router /api/v1/reports/daily [get]
+func (h *DetailHandler) GetDaily(w http.ResponseWriter, r *http.Request) {
+	companyIDStr := r.URL.Query().Get("company_id")
+	companyID, err := strconv.ParseInt(companyIDStr, 10, 64)
+	if err != nil || companyID <= 0 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing company_id"))
+		return
+	}
+
+	yearStr := r.URL.Query().Get("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || year < 2000 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing year"))
+		return
+	}
+
+	monthStr := r.URL.Query().Get("month")
+	month, err := strconv.Atoi(monthStr)
+	if err != nil || month < 1 || month > 12 {
+		respond.Error(w, http.StatusBadRequest, errors.New("invalid or missing month (must be 1-12)"))
+		return
+	}
+
+	version := 1
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			respond.Error(w, http.StatusBadRequest, errors.New("invalid version (must be >= 1)"))
+			return
+		}
+	}
+
+	dataType := r.URL.Query().Get("data_type")
+	if dataType == "" {
+		dataType = "actual"
+	}
+
+	req := &DailyRequest{
+		CompanyID: companyID,
+		Year:      year,
+		Month:     month,
+		DataType:  dataType,
+		Version:   version,
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		respond.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.useCase.GetDaily(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			respond.Error(w, http.StatusNotFound, err)
+			return
+		}
+		respond.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, report)
+}
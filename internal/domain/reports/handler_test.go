@@ -0,0 +1,599 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gmhafiz/go8/internal/domain/auth"
+	"github.com/gmhafiz/go8/internal/domain/data"
+	"github.com/gmhafiz/go8/internal/middleware"
+)
+
+// summaryUseCase serves a fixed summary; other UseCase methods are not used by these tests
+type summaryUseCase struct {
+	UseCase
+	report *SummaryReport
+}
+
+func (uc *summaryUseCase) GetSummary(ctx context.Context, req *SummaryRequest) (*SummaryReport, error) {
+	return uc.report, nil
+}
+
+func TestGetSummaryAcceptNegotiation(t *testing.T) {
+	calc := NewCalculator()
+	actual := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+	report := &SummaryReport{
+		CompanyID:   testCompanyID,
+		CompanyName: "Test (Mining) Co",
+		Year:        2024,
+		Months:      []MonthlyData{{Month: "2024-01", Actual: actual}},
+	}
+	h := NewHandler(&summaryUseCase{report: report}, validator.New(), nil)
+
+	tests := []struct {
+		accept      string
+		contentType string
+		checkBody   func(t *testing.T, body string)
+	}{
+		{"", MediaTypeJSON, func(t *testing.T, body string) {
+			var decoded SummaryReport
+			require.NoError(t, json.Unmarshal([]byte(body), &decoded))
+			assert.Equal(t, report.CompanyName, decoded.CompanyName)
+		}},
+		{"*/*", MediaTypeJSON, func(t *testing.T, body string) {
+			assert.True(t, strings.HasPrefix(body, "{"))
+		}},
+		{"text/csv", MediaTypeCSV, func(t *testing.T, body string) {
+			lines := strings.Split(strings.TrimSpace(body), "\n")
+			require.Len(t, lines, 2)
+			assert.True(t, strings.HasPrefix(lines[0], "Month,Scenario,Ore Processed (t)"))
+			assert.True(t, strings.HasPrefix(lines[1], "2024-01,actual,35951.00"))
+		}},
+		{"application/vnd.ms-excel", MediaTypeExcel, func(t *testing.T, body string) {
+			assert.Contains(t, body, `<Workbook xmlns="urn:schemas-microsoft-com:office:spreadsheet"`)
+			assert.Contains(t, body, `<Data ss:Type="Number">35951.00</Data>`)
+		}},
+		{"application/pdf", MediaTypePDF, func(t *testing.T, body string) {
+			assert.True(t, strings.HasPrefix(body, "%PDF-1.4"))
+			assert.Contains(t, body, `(Test \(Mining\) Co - Summary 2024) Tj`)
+			assert.True(t, strings.HasSuffix(body, "%%EOF\n"))
+		}},
+		{"application/pdf;q=0.5, text/csv;q=0.9", MediaTypeCSV, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/summary?company_id=1&year=2024&budget_version=1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			h.GetSummary(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+			if tt.checkBody != nil {
+				tt.checkBody(t, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/summary?company_id=1&year=2024&budget_version=1", nil)
+		req.Header.Set("Accept", "image/png")
+		rec := httptest.NewRecorder()
+
+		h.GetSummary(rec, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	})
+}
+
+func TestExportSummaryCSVReferenceLayout(t *testing.T) {
+	calc := NewCalculator()
+	actual := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+	budgetPBR := newTestPBRData()
+	budgetPBR.OreMinedT *= 1.1
+	budget := calc.CalculateDataSet(budgetPBR, newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+	report := &SummaryReport{
+		CompanyID:   testCompanyID,
+		CompanyName: "Test Co",
+		Year:        2024,
+		Months: []MonthlyData{{
+			Month: "2024-01", Actual: actual, Budget: budget,
+			YTD: &YTDData{Actual: actual, Budget: budget},
+		}},
+	}
+	h := NewHandler(&summaryUseCase{report: report}, validator.New(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/summary/export.csv?company_id=1&year=2024&budget_version=1", nil)
+	rec := httptest.NewRecorder()
+	h.ExportSummaryCSV(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, MediaTypeCSV, rec.Header().Get("Content-Type"))
+	lines := strings.Split(rec.Body.String(), "\n")
+	assert.Equal(t, ",Actual,2024 Budget,,,Actual,2024 Budget,,", lines[1])
+	assert.Equal(t, ",Jan,Jan,Fav (Unf),% Variance,Jan_YTD,Jan_YTD,Fav (Unf),% Variance", lines[2])
+
+	// The reconciliation parser reads the export back as the reference it is modelled on
+	reference, err := parseReferenceSummary(bytes.NewReader(rec.Body.Bytes()), 1)
+	require.NoError(t, err)
+	require.Len(t, reference.Values, len(summaryMetrics))
+
+	ore := reference.Values["Ore Mined (t)"]
+	assert.InDelta(t, actual.Mining.OreMinedT, ore.Actual, 0.01)
+	assert.InDelta(t, budget.Mining.OreMinedT, ore.Budget, 0.01)
+	assert.InDelta(t, actual.Mining.OreMinedT-budget.Mining.OreMinedT, ore.Variance, 0.01)
+	assert.InDelta(t, calculateVariancePct(actual.Mining.OreMinedT, budget.Mining.OreMinedT), ore.VariancePct, 0.01)
+	assert.InDelta(t, ore.Variance, ore.YTDVariance, 0.01)
+
+	result := Reconcile(actual, budget, reference, 0.01)
+	assert.Empty(t, result.Mismatches)
+}
+
+// companiesRepository serves the same PBR data for each known company; other data is empty
+type companiesRepository struct {
+	Repository
+	names map[int64]string
+}
+
+func (r *companiesRepository) GetCompanyName(ctx context.Context, companyID int64) (string, error) {
+	name, ok := r.names[companyID]
+	if !ok {
+		return "", ErrCompanyNotFound
+	}
+	return name, nil
+}
+
+func (r *companiesRepository) GetCompanyConfig(ctx context.Context, companyID int64) (*CompanyConfig, error) {
+	return &CompanyConfig{}, nil
+}
+
+func (r *companiesRepository) GetPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.PBRData, error) {
+	pbr := newTestPBRData()
+	pbr.CompanyID = companyID
+	return []*data.PBRData{pbr}, nil
+}
+
+func (r *companiesRepository) GetDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.DoreData, error) {
+	return nil, nil
+}
+
+func (r *companiesRepository) GetOPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.OPEXData, error) {
+	return nil, nil
+}
+
+func (r *companiesRepository) GetCAPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.CAPEXData, error) {
+	return nil, nil
+}
+
+func (r *companiesRepository) GetFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.FinancialData, error) {
+	return nil, nil
+}
+
+func TestGetSummariesForSeveralCompanies(t *testing.T) {
+	repo := &companiesRepository{names: map[int64]string{1: "Cerro Moro", 2: "San José", 3: "Mina Martha"}}
+	h := NewHandler(NewUseCase(repo, 0), validator.New(), nil)
+
+	body := `{"summaries": [
+		{"company_id": 1, "year": 2024, "version": 1, "months": "1"},
+		{"company_id": 2, "year": 2024, "version": 2},
+		{"company_id": 3, "year": 2024, "version": 1}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reports/summaries", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	// The user can view companies 1 and 2 only
+	ctx := context.WithValue(req.Context(), middleware.CompanyRolesKey, auth.CompanyRoles{"1": "viewer", "2": "admin"})
+	rec := httptest.NewRecorder()
+
+	h.GetSummaries(rec, req.WithContext(ctx))
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response SummariesReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	require.Len(t, response.Summaries, 2)
+	assert.Equal(t, "Cerro Moro", response.Summaries[1].CompanyName)
+	assert.Equal(t, "San José", response.Summaries[2].CompanyName)
+	assert.Equal(t, 2024, response.Summaries[2].Year)
+	assert.Equal(t, []int64{3}, response.Inaccessible)
+}
+
+// forecastRepository serves forecast PBR data with 10% more ore mined than actual and budget
+type forecastRepository struct {
+	companiesRepository
+}
+
+func (r *forecastRepository) GetPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.PBRData, error) {
+	pbr := newTestPBRData()
+	if dataType == string(data.DataTypeForecast) {
+		pbr.OreMinedT *= 1.1
+	}
+	return []*data.PBRData{pbr}, nil
+}
+
+func TestGetSummaryIncludeForecast(t *testing.T) {
+	repo := &forecastRepository{companiesRepository{names: map[int64]string{1: "Cerro Moro"}}}
+	uc := NewUseCase(repo, 0)
+
+	report, err := uc.GetSummary(context.Background(), &SummaryRequest{CompanyID: 1, Year: 2024, Months: "1", BudgetVersion: 1})
+	require.NoError(t, err)
+	require.Len(t, report.Months, 1)
+	assert.Nil(t, report.Months[0].Forecast)
+	assert.Nil(t, report.Months[0].ForecastVariance)
+
+	report, err = uc.GetSummary(context.Background(), &SummaryRequest{CompanyID: 1, Year: 2024, Months: "1", BudgetVersion: 1, IncludeForecast: true})
+	require.NoError(t, err)
+	month := report.Months[0]
+	require.NotNil(t, month.Forecast)
+	require.NotNil(t, month.ForecastVariance)
+	assert.InDelta(t, month.Actual.Mining.OreMinedT*1.1, month.Forecast.Mining.OreMinedT, 0.01)
+	assert.InDelta(t, month.Actual.Mining.OreMinedT-month.Forecast.Mining.OreMinedT, month.ForecastVariance.Mining.OreMinedT.Variance, 0.01)
+	assert.InDelta(t, 0, month.Variance.Mining.OreMinedT.Variance, 0.01)
+}
+
+func TestGetPBRDetailCoverage(t *testing.T) {
+	repo := &companiesRepository{names: map[int64]string{1: "Cerro Moro"}}
+	uc := NewDetailUseCase(repo, 0)
+
+	// Coverage describes the loaded months, not the requested ones
+	report, err := uc.GetPBRDetail(context.Background(), &DetailRequest{CompanyID: 1, Year: 2024, Months: "2,3", BudgetVersion: 1})
+	require.NoError(t, err)
+	require.NotNil(t, report.Coverage)
+	assert.Equal(t, []int{1}, report.Coverage.ActualMonths)
+	assert.Equal(t, []int{1}, report.Coverage.BudgetMonths)
+	assert.Equal(t, 1, report.Coverage.ActualLastMonth)
+	assert.True(t, report.Coverage.ActualIsPartial)
+	assert.False(t, report.Coverage.HasCompleteActual)
+
+	// No OPEX loaded at all
+	opex, err := uc.GetOPEXDetail(context.Background(), &DetailRequest{CompanyID: 1, Year: 2024, BudgetVersion: 1})
+	require.NoError(t, err)
+	assert.Empty(t, opex.Coverage.ActualMonths)
+	assert.False(t, opex.Coverage.HasAnyActual)
+	assert.False(t, opex.Coverage.ActualIsPartial)
+}
+
+// financialRepository serves January financial data, with budget royalties half the actual
+type financialRepository struct {
+	companiesRepository
+}
+
+func (r *financialRepository) GetFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.FinancialData, error) {
+	financial := newTestFinancialData()
+	financial.Royalties = -120000
+	if dataType == "budget" {
+		financial.Royalties /= 2
+	}
+	return []*data.FinancialData{financial}, nil
+}
+
+func TestGetFinancialDetail(t *testing.T) {
+	repo := &financialRepository{companiesRepository{names: map[int64]string{1: "Cerro Moro"}}}
+	h := NewDetailHandler(NewDetailUseCase(repo, 0), validator.New(), nil)
+
+	rec := httptest.NewRecorder()
+	h.GetFinancialDetail(rec, httptest.NewRequest(http.MethodGet, "/api/v1/reports/financial?company_id=1&year=2024&months=1,2", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var report FinancialDetailReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "Cerro Moro", report.CompanyName)
+	assert.Equal(t, []int{1}, report.Coverage.ActualMonths)
+	require.Len(t, report.Months, 2)
+
+	january := report.Months[0]
+	assert.Equal(t, "2024-01", january.Month)
+	require.NotNil(t, january.Actual)
+	require.NotNil(t, january.Variance)
+	assert.Equal(t, -120000.0, january.Actual.Royalties)
+	assert.Equal(t, january.Actual.SalesTaxes+january.Actual.Royalties, january.Actual.SalesTaxesRoyalties)
+	assert.Equal(t, -60000.0, january.Variance.Royalties.Variance)
+	assert.Nil(t, report.Months[1].Actual)
+
+	rec = httptest.NewRecorder()
+	h.GetFinancialDetail(rec, httptest.NewRequest(http.MethodGet, "/api/v1/reports/financial?company_id=9&year=2024", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestParseDetailRequestBudgetVersion(t *testing.T) {
+	h := &DetailHandler{}
+	tests := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{query: "", want: 1},
+		{query: "&budget_version=3", want: 3},
+		{query: "&budget_version=0", wantErr: true},
+		{query: "&budget_version=v2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		req, err := h.parseDetailRequest(httptest.NewRequest(http.MethodGet, "/api/v1/reports/pbr?company_id=1&year=2024"+tt.query, nil), pbrFieldGroups)
+		if tt.wantErr {
+			assert.Error(t, err, tt.query)
+			continue
+		}
+		require.NoError(t, err, tt.query)
+		assert.Equal(t, tt.want, req.BudgetVersion, tt.query)
+	}
+}
+
+func TestParseDetailRequestShaping(t *testing.T) {
+	h := &DetailHandler{}
+	parse := func(query string) (*DetailRequest, error) {
+		return h.parseDetailRequest(httptest.NewRequest(http.MethodGet, "/api/v1/reports/capex?company_id=1&year=2024"+query, nil), capexFieldGroups)
+	}
+
+	req, err := parse("&months=3,1&fields=Projects,types,projects&top=5")
+	require.NoError(t, err)
+	assert.Equal(t, "3,1", req.Months)
+	assert.Equal(t, []string{"projects", "types"}, req.Fields)
+	assert.Equal(t, 5, req.Top)
+
+	for _, query := range []string{"&months=13", "&months=1,x", "&fields=mining", "&top=0", "&top=many"} {
+		_, err := parse(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestProjectDetailFields(t *testing.T) {
+	report := &PBRDetailReport{
+		CompanyID: testCompanyID,
+		Year:      2024,
+		Months: []PBRMonthlyData{{
+			Month:    "2024-01",
+			Actual:   &PBRDetail{OreMinedT: 24859, TotalTonnesProcessed: 35951, FullTimeEmployees: 420, HasData: true},
+			Variance: &PBRVariance{OreMinedT: VarianceMetric{Actual: 24859}},
+		}},
+	}
+
+	unchanged, err := projectDetailFields(report, nil, pbrFieldGroups)
+	require.NoError(t, err)
+	assert.Same(t, report, unchanged)
+
+	projected, err := projectDetailFields(report, []string{"processing"}, pbrFieldGroups)
+	require.NoError(t, err)
+	raw, err := json.Marshal(projected)
+	require.NoError(t, err)
+
+	var decoded struct {
+		CompanyID int64 `json:"company_id"`
+		Months    []struct {
+			Month    string                     `json:"month"`
+			Actual   map[string]json.RawMessage `json:"actual"`
+			Budget   map[string]json.RawMessage `json:"budget"`
+			Variance map[string]json.RawMessage `json:"variance"`
+		} `json:"months"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, testCompanyID, decoded.CompanyID)
+	require.Len(t, decoded.Months, 1)
+	january := decoded.Months[0]
+	assert.Equal(t, "2024-01", january.Month)
+	assert.Equal(t, json.RawMessage("35951"), january.Actual["total_tonnes_processed"])
+	assert.Contains(t, january.Actual, "has_data")
+	assert.NotContains(t, january.Actual, "ore_mined_t")
+	assert.NotContains(t, january.Actual, "full_time_employees")
+	assert.Contains(t, january.Variance, "feed_grade_silver_gpt")
+	assert.NotContains(t, january.Variance, "ore_mined_t")
+	assert.Nil(t, january.Budget)
+}
+
+func TestTopCAPEXProjects(t *testing.T) {
+	months := []CAPEXMonthlyData{
+		{
+			Actual: &CAPEXDetail{ByProject: map[string]float64{"Tailings": 500, "Exploration": 100, "Camp": 50}},
+			Budget: &CAPEXDetail{ByProject: map[string]float64{"Exploration": 450, "Ventilation": 20}},
+		},
+		{
+			Actual:   &CAPEXDetail{ByProject: map[string]float64{"Camp": -700}},
+			Forecast: &CAPEXDetail{ByProject: map[string]float64{"Camp": 10, "Ventilation": 30}},
+		},
+	}
+
+	top := topCAPEXProjects(months, 2)
+	assert.Equal(t, []string{"Camp", "Exploration"}, top)
+	assert.Equal(t, []string{"Camp", "Exploration", "Tailings", "Ventilation"}, topCAPEXProjects(months, 10))
+	assert.Equal(t, []string{"Camp", "Exploration", "Tailings", "Ventilation"}, topCAPEXProjects(months, 0), "without top, every project, largest first")
+
+	keepCAPEXProjects(months, top)
+	assert.Equal(t, map[string]float64{"Exploration": 100, "Camp": 50}, months[0].Actual.ByProject)
+	assert.Equal(t, map[string]float64{"Exploration": 450}, months[0].Budget.ByProject)
+	assert.Equal(t, map[string]float64{"Camp": 10}, months[1].Forecast.ByProject)
+}
+
+func TestExportSummaryXLSX(t *testing.T) {
+	calc := NewCalculator()
+	actual := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+	budgetPBR := newTestPBRData()
+	budgetPBR.OreMinedT *= 1.1
+	budget := calc.CalculateDataSet(budgetPBR, newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+	report := &SummaryReport{
+		CompanyID:   testCompanyID,
+		CompanyName: "Test & Co",
+		Year:        2024,
+		Months:      []MonthlyData{{Month: "2024-01", Actual: actual, Budget: budget}},
+	}
+	h := NewHandler(&summaryUseCase{report: report}, validator.New(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/summary/export.xlsx?company_id=1&year=2024&budget_version=1", nil)
+	rec := httptest.NewRecorder()
+	h.ExportSummaryXLSX(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, MediaTypeXLSX, rec.Header().Get("Content-Type"))
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		parts[file.Name] = string(content)
+	}
+
+	for _, sheet := range []string{"Mining", "Processing", "Production", "NSR", "Costs", "CAPEX", "CashCost"} {
+		assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="`+sheet+`"`)
+	}
+	assert.Contains(t, parts["xl/styles.xml"], `formatCode="#,##0.00;(#,##0.00)"`)
+
+	// Mining sheet: title, the two header rows, then Ore Mined with a negative variance
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Style  int    `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet))
+	require.Len(t, sheet.Rows, 3+3)
+	assert.Equal(t, "Test & Co - Mining 2024", sheet.Rows[0].Cells[0].Inline)
+	ore := sheet.Rows[3].Cells
+	require.Len(t, ore, 5, "YTD columns are empty without YTD data")
+	assert.Equal(t, "Ore Mined (t)", ore[0].Inline)
+	assert.Equal(t, "D4", ore[3].Ref)
+	variance, err := strconv.ParseFloat(ore[3].Value, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, actual.Mining.OreMinedT-budget.Mining.OreMinedT, variance, 0.01)
+	assert.Less(t, variance, 0.0)
+	assert.Equal(t, xlsxStyleAmount, ore[3].Style)
+	assert.Equal(t, xlsxStylePercent, ore[4].Style)
+}
+
+// savedReportsRepository serves saved reports by ID; other Repository methods are not used
+type savedReportsRepository struct {
+	Repository
+	reports map[int64]*SavedReport
+}
+
+func (r *savedReportsRepository) GetSavedReportsByIDs(ctx context.Context, ids []int64) ([]*SavedReport, error) {
+	var reports []*SavedReport
+	for _, id := range ids {
+		if report, ok := r.reports[id]; ok {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+func (r *savedReportsRepository) GetReportCompanyID(ctx context.Context, reportID int64) (int64, error) {
+	report, ok := r.reports[reportID]
+	if !ok {
+		return 0, ErrReportNotFound
+	}
+	return report.CompanyID, nil
+}
+
+func TestCompareScenarios(t *testing.T) {
+	calc := NewCalculator()
+	savedReport := func(id, companyID int64, budgetVersion int, oreFactor float64) *SavedReport {
+		budgetPBR := newTestPBRData()
+		budgetPBR.OreMinedT *= oreFactor
+		actual := calc.CalculateDataSet(newTestPBRData(), newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+		budget := calc.CalculateDataSet(budgetPBR, newTestDoreData(), newTestFinancialData(), newTestOPEXList(), newTestCAPEXList())
+		return &SavedReport{
+			ID: id, CompanyID: companyID, Name: "Budget v" + strconv.Itoa(budgetVersion), Year: 2024, BudgetVersion: budgetVersion,
+			ReportData: SummaryReport{Months: []MonthlyData{
+				{Month: "2024-01", Actual: actual, Budget: budget, YTD: &YTDData{Actual: actual, Budget: budget}},
+				{Month: "2024-02", Budget: budget},
+			}},
+		}
+	}
+	repo := &savedReportsRepository{reports: map[int64]*SavedReport{
+		1: savedReport(1, 1, 1, 1),
+		2: savedReport(2, 1, 2, 1.2),
+		3: savedReport(3, 2, 1, 1),
+	}}
+	h := NewHandler(NewUseCase(repo, 0), validator.New(), nil)
+
+	compare := func(ids string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/compare?ids="+ids, nil)
+		ctx := context.WithValue(req.Context(), middleware.CompanyRolesKey, auth.CompanyRoles{"1": "viewer"})
+		rec := httptest.NewRecorder()
+		h.CompareScenarios(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	rec := compare("2,1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var comparison ScenarioComparison
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &comparison))
+	assert.Equal(t, int64(1), comparison.CompanyID)
+	assert.Equal(t, int64(2), comparison.BaselineID)
+	require.Len(t, comparison.Scenarios, 2)
+	assert.Equal(t, "2024-01", comparison.Scenarios[0].Through)
+	assert.Nil(t, comparison.Scenarios[0].BudgetVariance)
+	require.NotNil(t, comparison.Scenarios[1].BudgetVariance)
+	ore := comparison.Scenarios[1].BudgetVariance.Mining.OreMinedT
+	assert.InDelta(t, comparison.Scenarios[1].Budget.Mining.OreMinedT-comparison.Scenarios[0].Budget.Mining.OreMinedT, ore.Variance, 0.01)
+	assert.Less(t, ore.Variance, 0.0)
+	assert.InDelta(t, 0, comparison.Scenarios[1].ActualVariance.Mining.OreMinedT.Variance, 0.01)
+
+	assert.Equal(t, http.StatusBadRequest, compare("1,3").Code, "mixed companies")
+	assert.Equal(t, http.StatusBadRequest, compare("1").Code, "a single report")
+	assert.Equal(t, http.StatusBadRequest, compare("1,1").Code, "a report twice")
+	assert.Equal(t, http.StatusNotFound, compare("1,9").Code)
+}
+
+// savingRepository records saved reports on top of companiesRepository
+type savingRepository struct {
+	companiesRepository
+	saved []*SavedReport
+}
+
+func (r *savingRepository) SaveReport(ctx context.Context, report *SavedReport) error {
+	report.ID = int64(len(r.saved) + 1)
+	r.saved = append(r.saved, report)
+	return nil
+}
+
+func TestSaveReport(t *testing.T) {
+	repo := &savingRepository{companiesRepository: companiesRepository{names: map[int64]string{1: "Cerro Moro"}}}
+	h := NewHandler(NewUseCase(repo, 0), validator.New(), nil)
+
+	save := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reports/save", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := context.WithValue(req.Context(), middleware.UserIDKey, int64(7))
+		ctx = context.WithValue(ctx, middleware.CompanyRolesKey, auth.CompanyRoles{"1": "editor", "2": "editor"})
+		rec := httptest.NewRecorder()
+		h.SaveReport(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	rec := save(`{"name": "Before March re-import", "company_id": 1, "year": 2024, "budget_version": 2}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var saved SavedReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	assert.Equal(t, int64(1), saved.ID)
+
+	require.Len(t, repo.saved, 1)
+	assert.Equal(t, int64(7), repo.saved[0].CreatedBy)
+	assert.Equal(t, 2, repo.saved[0].BudgetVersion)
+	assert.Len(t, repo.saved[0].ReportData.Months, 12)
+
+	assert.Equal(t, http.StatusNotFound, save(`{"name": "Unknown", "company_id": 2, "year": 2024, "budget_version": 1}`).Code)
+	assert.Equal(t, http.StatusBadRequest, save(`{"name": "No budget version", "company_id": 1, "year": 2024}`).Code)
+}
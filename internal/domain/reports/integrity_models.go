@@ -0,0 +1,28 @@
+package reports
+
+// Integrity rules checked before a year is closed
+const (
+	IntegrityDoreWithoutPBR       = "dore_without_pbr"       // Dore month with no PBR for the same month
+	IntegrityFinancialWithoutDore = "financial_without_dore" // Financial month with no Dore for the same month
+	IntegrityUnknownMineral       = "unknown_mineral"        // Production/Revenue row referencing a missing or inactive mineral
+)
+
+// IntegrityViolation is a single referential integrity problem in a company year
+type IntegrityViolation struct {
+	Rule      string `json:"rule"`
+	Month     string `json:"month"` // "2025-01"
+	Dataset   string `json:"dataset"`
+	MineralID int    `json:"mineral_id,omitempty"`
+	Message   string `json:"message"`
+}
+
+// IntegrityReport lists the integrity violations found for a company year and version
+type IntegrityReport struct {
+	CompanyID   int64                `json:"company_id"`
+	CompanyName string               `json:"company_name"`
+	Year        int                  `json:"year"`
+	DataType    string               `json:"data_type"`
+	Version     int                  `json:"version"`
+	Valid       bool                 `json:"valid"` // True when no violations were found
+	Violations  []IntegrityViolation `json:"violations"`
+}
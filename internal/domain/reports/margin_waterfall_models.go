@@ -0,0 +1,32 @@
+package reports
+
+// Margin waterfall buckets, in the order they are applied from budget to actual
+const (
+	WaterfallVolume   = "volume"   // Tonnes processed
+	WaterfallGrade    = "grade"    // Feed grades
+	WaterfallRecovery = "recovery" // Recovery rates
+	WaterfallPrice    = "price"    // Realized prices
+	WaterfallCost     = "cost"     // Production Based Costs
+	WaterfallOther    = "other"    // Deductions, charges, streaming and financial items not explained above
+)
+
+// WaterfallBucket is the part of the margin variance explained by one driver
+type WaterfallBucket struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"` // Positive: increases actual margin vs budget
+}
+
+// MarginWaterfallReport walks from budget margin to actual margin for a month
+type MarginWaterfallReport struct {
+	CompanyID     int64             `json:"company_id"`
+	CompanyName   string            `json:"company_name"`
+	Year          int               `json:"year"`
+	Month         int               `json:"month"`
+	BudgetVersion int               `json:"budget_version"`
+	BudgetMargin  float64           `json:"budget_margin"` // Net Smelter Return - Production Based Costs
+	ActualMargin  float64           `json:"actual_margin"`
+	TotalVariance float64           `json:"total_variance"` // ActualMargin - BudgetMargin = sum of buckets
+	Buckets       []WaterfallBucket `json:"buckets"`
+	HasActualData bool              `json:"has_actual_data"`
+	HasBudgetData bool              `json:"has_budget_data"`
+}
@@ -0,0 +1,33 @@
+package reports
+
+// Allocation bases used to spread costs across minerals
+const (
+	AllocationBasisRevenue = "revenue" // Costs split by each mineral's share of revenue
+	AllocationBasisEqual   = "equal"   // Costs split evenly across minerals with revenue
+)
+
+// MineralContribution is the revenue, allocated costs and contribution of one mineral
+type MineralContribution struct {
+	MineralID      int     `json:"mineral_id"`
+	MineralCode    string  `json:"mineral_code"`
+	MineralName    string  `json:"mineral_name"`
+	Revenue        float64 `json:"revenue"`
+	RevenueShare   float64 `json:"revenue_share"` // % of total revenue
+	AllocatedCosts float64 `json:"allocated_costs"`
+	Contribution   float64 `json:"contribution"` // Revenue - AllocatedCosts
+	MarginShare    float64 `json:"margin_share"` // % of total margin
+}
+
+// MineralMarginReport shows how much each mineral contributes to the company margin
+type MineralMarginReport struct {
+	CompanyID       int64                 `json:"company_id"`
+	CompanyName     string                `json:"company_name"`
+	Year            int                   `json:"year"`
+	DataType        string                `json:"data_type"`
+	Version         int                   `json:"version"`
+	AllocationBasis string                `json:"allocation_basis"`
+	Minerals        []MineralContribution `json:"minerals"`
+	TotalRevenue    float64               `json:"total_revenue"`
+	TotalCosts      float64               `json:"total_costs"` // Production Based Costs of the year
+	TotalMargin     float64               `json:"total_margin"`
+}
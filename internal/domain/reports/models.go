@@ -4,6 +4,28 @@ package reports
 type CompanyConfig struct {
 	MiningType string   `json:"mining_type"` // "open_pit", "underground", "both"
 	Minerals   []string `json:"minerals"`    // List of mineral codes: ["AU", "AG", "CU", etc.]
+
+	// CAPEX types subtracted from Production Based Margin in PBR Net Cash Flow
+	// ["sustaining"] (default) or e.g. ["sustaining", "project", "leasing"] for free cash flow after all CAPEX
+	NetCashFlowCapexTypes []string `json:"net_cash_flow_capex_types"`
+
+	// OPEX expense types (e.g. ["Other"]) left out of Production Based Costs and reported as excluded costs
+	ExcludedExpenseTypes []string `json:"excluded_expense_types"`
+
+	// Value Dore metal at the PBR price when the realized price is zero or absent
+	RealizedPriceFallback bool `json:"realized_price_fallback"`
+
+	// Cash cost basis: "silver" (gold as by-product credit, default) or "co_product"
+	PrimaryMetal string `json:"primary_metal"`
+
+	// OPEX cost centers summed into Production Based Costs, in display order
+	// (default Mine, Processing, G&A, Transport & Shipping)
+	CostCenters []string `json:"cost_centers"`
+
+	// CAPEX categories and projects the CAPEX detail always reports, zero when a month has
+	// no data for them (default the built-in lists, see defaultCAPEXCategories)
+	CAPEXCategories []string `json:"capex_categories"`
+	CAPEXProjects   []string `json:"capex_projects"`
 }
 
 // SummaryReport represents the complete summary report for a company
@@ -17,6 +39,12 @@ type SummaryReport struct {
 	Coverage    *DataCoverage  `json:"coverage,omitempty"`
 }
 
+// SummariesReport holds the summaries of a batch request, keyed by company ID
+type SummariesReport struct {
+	Summaries    map[int64]*SummaryReport `json:"summaries"`
+	Inaccessible []int64                  `json:"inaccessible,omitempty"` // Requested companies left out: no access
+}
+
 // DataCoverage indicates which months have data loaded
 type DataCoverage struct {
 	ActualMonths      []int `json:"actual_months"`
@@ -38,6 +66,10 @@ type MonthlyData struct {
 	Budget   *DataSet      `json:"budget"`
 	Variance *VarianceData `json:"variance,omitempty"` // Variance calculations (Actual - Budget)
 	YTD      *YTDData      `json:"ytd,omitempty"`      // Year-to-date calculations
+
+	// Only with include_forecast
+	Forecast         *DataSet      `json:"forecast,omitempty"`
+	ForecastVariance *VarianceData `json:"forecast_variance,omitempty"` // Actual - Forecast
 }
 
 // YTDData represents year-to-date aggregated data
@@ -47,6 +79,43 @@ type YTDData struct {
 	Variance *VarianceData `json:"variance,omitempty"`
 }
 
+// TTMReport represents trailing-twelve-month figures, which may span a year boundary
+type TTMReport struct {
+	CompanyID   int64    `json:"company_id"`
+	CompanyName string   `json:"company_name"`
+	From        string   `json:"from"`    // First month of the window, "2024-12"
+	Through     string   `json:"through"` // Last month of the window, "2025-11"
+	DataType    string   `json:"data_type"`
+	Version     int      `json:"version"`
+	Months      []string `json:"months"` // Months of the window with data (accumulated)
+	TTM         *DataSet `json:"ttm"`    // nil when the window has no data
+}
+
+// VarianceReport is the variance view of a single month: the month and its YTD
+type VarianceReport struct {
+	CompanyID     int64         `json:"company_id"`
+	CompanyName   string        `json:"company_name"`
+	Year          int           `json:"year"`
+	Month         string        `json:"month"` // "2025-01"
+	BudgetVersion int           `json:"budget_version"`
+	Variance      *VarianceData `json:"variance"`     // nil unless both actual and budget exist for the month
+	YTDVariance   *VarianceData `json:"ytd_variance"` // nil unless both YTD actual and budget exist
+}
+
+// WeightedGradeReport holds the feed grades across a set of months, weighted by tonnes processed
+type WeightedGradeReport struct {
+	CompanyID            int64   `json:"company_id"`
+	CompanyName          string  `json:"company_name"`
+	Year                 int     `json:"year"`
+	DataType             string  `json:"data_type"`
+	Version              int     `json:"version"`
+	Months               []int   `json:"months"`                 // Requested months
+	MonthsWithData       []int   `json:"months_with_data"`       // Requested months with PBR data
+	TotalTonnesProcessed float64 `json:"total_tonnes_processed"` // Weight of the grades
+	FeedGradeSilverGpt   float64 `json:"feed_grade_silver_gpt"`
+	FeedGradeGoldGpt     float64 `json:"feed_grade_gold_gpt"`
+}
+
 // DataSet contains all metrics for actual or budget
 type DataSet struct {
 	Mining     MiningMetrics     `json:"mining"`
@@ -55,7 +124,10 @@ type DataSet struct {
 	Costs      CostMetrics       `json:"costs"`
 	NSR        NSRMetrics        `json:"nsr"`
 	CAPEX      CAPEXMetrics      `json:"capex"`
-	CashCost   CashCostMetrics   `json:"cash_cost"`
+	CashCost      CashCostMetrics      `json:"cash_cost"`
+	Profitability ProfitabilityMetrics `json:"profitability"`
+
+	months int // Months accumulated into this dataset, used to annualize production
 }
 
 // MiningMetrics represents mining data
@@ -88,7 +160,13 @@ type MiningMetrics struct {
 	Contractors       int `json:"contractors"`
 	TotalHeadcount    int `json:"total_headcount"`
 
+	// Productivity KPIs
+	AverageHeadcount  float64 `json:"average_headcount"`   // Mean total headcount across months (YTD)
+	TonnesPerEmployee float64 `json:"tonnes_per_employee"` // Ore mined / average headcount
+
 	HasData bool `json:"has_data"`
+
+	headcountMonths int // Months included in AverageHeadcount (for YTD accumulation)
 }
 
 // ProcessingMetrics represents processing data
@@ -108,7 +186,15 @@ type ProductionMetrics struct {
 	PayableSilverOz         float64 `json:"payable_silver_oz"`
 	PayableGoldOz           float64 `json:"payable_gold_oz"`
 	DoreProductionOz        float64 `json:"dore_production_oz"` // Total dore (Silver + Gold)
-	HasData                 bool    `json:"has_data"`
+
+	// Contained metal in feed (grade x tonnes) and what the plant did not recover
+	ContainedSilverOz float64 `json:"contained_silver_oz"`
+	ContainedGoldOz   float64 `json:"contained_gold_oz"`
+	MetalLossSilverOz float64 `json:"metal_loss_silver_oz"` // Contained - recovered
+	MetalLossGoldOz   float64 `json:"metal_loss_gold_oz"`
+	MetalLossOz       float64 `json:"metal_loss_oz"` // Silver + Gold
+
+	HasData bool `json:"has_data"`
 }
 
 // CostMetrics represents cost breakdown
@@ -120,28 +206,43 @@ type CostMetrics struct {
 	InventoryVariations   float64 `json:"inventory_variations"`
 	ProductionBasedCosts  float64 `json:"production_based_costs"`
 	ProductionBasedMargin float64 `json:"production_based_margin"`
-	HasData               bool    `json:"has_data"`
+	OperatingMarginPct    float64 `json:"operating_margin_pct"` // Production Based Margin / NSR × 100 (0 without NSR)
+	ExcludedCosts         float64 `json:"excluded_costs"`       // OPEX of company-excluded expense types, not in Production Based Costs
+	// Costs of every configured cost center, including the four above
+	ByCostCenter map[string]float64 `json:"by_cost_center,omitempty"`
+	HasData      bool               `json:"has_data"`
 }
 
 // NSRMetrics represents Net Smelter Return metrics
 type NSRMetrics struct {
+	GrossRevenue            float64 `json:"gross_revenue"`              // Payable silver + gold at realized prices
+	GrossRevenueSilver      float64 `json:"gross_revenue_silver"`       // Payable silver at the realized price
+	GrossRevenueGold        float64 `json:"gross_revenue_gold"`         // Payable gold at the realized price
 	NSRDore                 float64 `json:"nsr_dore"`
 	Streaming               float64 `json:"streaming"`                  // Streaming agreement value (usually negative)
 	PBRRevenue              float64 `json:"pbr_revenue"`                // NSR Dore + Streaming
 	ShippingSelling         float64 `json:"shipping_selling"`
 	SalesTaxes              float64 `json:"sales_taxes"`                // Sales taxes (split from combined)
 	Royalties               float64 `json:"royalties"`                  // Royalties (split from combined)
+	EffectiveTaxRate        float64 `json:"effective_tax_rate"`         // Sales taxes as % of gross revenue
+	EffectiveRoyaltyRate    float64 `json:"effective_royalty_rate"`     // Royalties as % of gross revenue
 	SalesTaxesRoyalties     float64 `json:"sales_taxes_royalties"`      // Calculated: SalesTaxes + Royalties
 	OtherSalesDeductions    float64 `json:"other_sales_deductions"`     // Other sales deductions
+	OtherAdjustments        float64 `json:"other_adjustments"`          // Other financial adjustments
 	SmeltingRefiningCharges float64 `json:"smelting_refining_charges"`  // Treatment + Refining charges
 	NetSmelterReturn        float64 `json:"net_smelter_return"`
 	GoldCredit              float64 `json:"gold_credit"`                // Gold by-product credit (negative)
-	SilverPricePerOz        float64 `json:"silver_price_per_oz"`        // Realized silver price $/oz
-	GoldPricePerOz          float64 `json:"gold_price_per_oz"`          // Realized gold price $/oz
+	SilverPricePerOz        float64 `json:"silver_price_per_oz"`        // Blended realized silver price $/oz: GrossRevenueSilver / payable oz
+	GoldPricePerOz          float64 `json:"gold_price_per_oz"`          // Blended realized gold price $/oz: GrossRevenueGold / payable oz
 	NSRPerTonne             float64 `json:"nsr_per_tonne"`
 	TotalCostPerTonne       float64 `json:"total_cost_per_tonne"`
 	MarginPerTonne          float64 `json:"margin_per_tonne"`
 	HasData                 bool    `json:"has_data"`
+
+	// Payable ounces behind the gross revenue (for the YTD prices): unlike
+	// ProductionMetrics, they leave out months without Dore data, which have no revenue
+	payableSilverOz float64
+	payableGoldOz   float64
 }
 
 // CAPEXMetrics represents capital expenditure metrics
@@ -150,10 +251,28 @@ type CAPEXMetrics struct {
 	Project                         float64 `json:"project"`
 	Leasing                         float64 `json:"leasing"`
 	AccretionOfMineClosureLiability float64 `json:"accretion_of_mine_closure_liability"` // New field
+	LeaseAdditions                  float64 `json:"lease_additions"`     // Leasing split for IFRS16: right-of-use assets recognized
+	LeaseCashOutflows               float64 `json:"lease_cash_outflows"` // Lease payments (data.LeaseCashOutflowCategories); Leasing = LeaseAdditions + LeaseCashOutflows
 	Total                           float64 `json:"total"`
 	ProductionBasedMargin           float64 `json:"production_based_margin"`
 	PBRNetCashFlow                  float64 `json:"pbr_net_cash_flow"`
-	HasData                         bool    `json:"has_data"`
+
+	// Ordered steps from Net Smelter Return to PBR Net Cash Flow
+	NetCashFlowBridge []BridgeStep `json:"net_cash_flow_bridge"`
+
+	// Capital intensity: Total CAPEX / annualized payable silver oz ($ per oz of annual capacity)
+	AnnualizedPayableSilverOz float64 `json:"annualized_payable_silver_oz"`
+	CapitalIntensity          float64 `json:"capital_intensity"`
+
+	HasData bool `json:"has_data"`
+}
+
+// BridgeStep is one line of a cash flow bridge: a signed change, or a subtotal carrying
+// the running total
+type BridgeStep struct {
+	Label    string  `json:"label"`
+	Amount   float64 `json:"amount"`
+	Subtotal bool    `json:"subtotal"`
 }
 
 // CashCostMetrics represents cash cost and AISC metrics
@@ -162,11 +281,33 @@ type CashCostMetrics struct {
 	AISCPerOzSilver        float64 `json:"aisc_per_oz_silver"`
 	CashCostsSilver        float64 `json:"cash_costs_silver"`         // Total cash costs before dividing by ounces
 	AISCSilver             float64 `json:"aisc_silver"`               // Total AISC before dividing by ounces
-	GoldCredit             float64 `json:"gold_credit"`
+	// Gold per ounce figures are only set in co-product mode (primary_metal "co_product"),
+	// where costs are split between the metals by revenue share
+	CashCostPerOzGold      float64 `json:"cash_cost_per_oz_gold"`
+	AISCPerOzGold          float64 `json:"aisc_per_oz_gold"`
+	CashCostsGold          float64 `json:"cash_costs_gold"`
+	AISCGold               float64 `json:"aisc_gold"`
+	GoldCredit             float64 `json:"gold_credit"`               // By-product mode only
 	SustainingCapitalPerOz float64 `json:"sustaining_capital_per_oz"` // Sustaining CAPEX / payable silver oz
+	// Silver price at which payable silver covers AISC: AISC net of gold revenue / payable silver oz
+	BreakevenSilverPrice float64 `json:"breakeven_silver_price"`
+	AISCMarginPerOz      float64 `json:"aisc_margin_per_oz"` // Realized silver price - AISC per oz
 	HasData                bool    `json:"has_data"`
 }
 
+// ProfitabilityMetrics represents earnings before interest, taxes, depreciation and amortization.
+// EBITDA = Net Smelter Return - Production Based Costs - Excluded Costs, where excluded costs
+// are the OPEX of company-excluded expense types (corporate G&A and similar) that Production
+// Based Costs leaves out. CAPEX is not deducted.
+type ProfitabilityMetrics struct {
+	EBITDA float64 `json:"ebitda"`
+	// (NSR − ProductionBasedCosts − ExcludedCosts) / NSR × 100, i.e. EBITDA / NSR × 100 (0
+	// without NSR). No separate "G&A adjustments" term is applied: G&A only counts through
+	// the excluded expense types or Production Based Costs.
+	OperatingMarginPct float64 `json:"operating_margin_pct"`
+	HasData            bool    `json:"has_data"`
+}
+
 // ComparisonData for YTD comparisons
 type ComparisonData struct {
 	Actual   *DataSet      `json:"actual"`
@@ -182,7 +323,8 @@ type VarianceData struct {
 	Costs      CostVariance       `json:"costs"`
 	NSR        NSRVariance        `json:"nsr"`
 	CAPEX      CAPEXVariance      `json:"capex"`
-	CashCost   CashCostVariance   `json:"cash_cost"`
+	CashCost      CashCostVariance      `json:"cash_cost"`
+	Profitability ProfitabilityVariance `json:"profitability"`
 }
 
 // Variance helper structs for each metric type
@@ -214,6 +356,10 @@ type MiningVariance struct {
 	FullTimeEmployees VarianceMetric `json:"full_time_employees"`
 	Contractors       VarianceMetric `json:"contractors"`
 	TotalHeadcount    VarianceMetric `json:"total_headcount"`
+
+	// Productivity KPIs
+	AverageHeadcount  VarianceMetric `json:"average_headcount"`
+	TonnesPerEmployee VarianceMetric `json:"tonnes_per_employee"`
 }
 
 type ProcessingVariance struct {
@@ -230,6 +376,9 @@ type ProductionVariance struct {
 	PayableSilverOz         VarianceMetric `json:"payable_silver_oz"`
 	PayableGoldOz           VarianceMetric `json:"payable_gold_oz"`
 	DoreProductionOz        VarianceMetric `json:"dore_production_oz"`
+	ContainedSilverOz       VarianceMetric `json:"contained_silver_oz"`
+	ContainedGoldOz         VarianceMetric `json:"contained_gold_oz"`
+	MetalLossOz             VarianceMetric `json:"metal_loss_oz"`
 }
 
 type CostVariance struct {
@@ -240,17 +389,23 @@ type CostVariance struct {
 	InventoryVariations   VarianceMetric `json:"inventory_variations"`
 	ProductionBasedCosts  VarianceMetric `json:"production_based_costs"`
 	ProductionBasedMargin VarianceMetric `json:"production_based_margin"`
+	OperatingMarginPct    VarianceMetric `json:"operating_margin_pct"`
+	ExcludedCosts         VarianceMetric `json:"excluded_costs"`
 }
 
 type NSRVariance struct {
+	GrossRevenue            VarianceMetric `json:"gross_revenue"`
 	NSRDore                 VarianceMetric `json:"nsr_dore"`
 	Streaming               VarianceMetric `json:"streaming"`
 	PBRRevenue              VarianceMetric `json:"pbr_revenue"`
 	ShippingSelling         VarianceMetric `json:"shipping_selling"`
 	SalesTaxes              VarianceMetric `json:"sales_taxes"`
 	Royalties               VarianceMetric `json:"royalties"`
+	EffectiveTaxRate        VarianceMetric `json:"effective_tax_rate"`
+	EffectiveRoyaltyRate    VarianceMetric `json:"effective_royalty_rate"`
 	SalesTaxesRoyalties     VarianceMetric `json:"sales_taxes_royalties"`
 	OtherSalesDeductions    VarianceMetric `json:"other_sales_deductions"`
+	OtherAdjustments        VarianceMetric `json:"other_adjustments"`
 	SmeltingRefiningCharges VarianceMetric `json:"smelting_refining_charges"`
 	NetSmelterReturn        VarianceMetric `json:"net_smelter_return"`
 	GoldCredit              VarianceMetric `json:"gold_credit"`
@@ -266,9 +421,12 @@ type CAPEXVariance struct {
 	Project                         VarianceMetric `json:"project"`
 	Leasing                         VarianceMetric `json:"leasing"`
 	AccretionOfMineClosureLiability VarianceMetric `json:"accretion_of_mine_closure_liability"`
+	LeaseAdditions                  VarianceMetric `json:"lease_additions"`
+	LeaseCashOutflows               VarianceMetric `json:"lease_cash_outflows"`
 	Total                           VarianceMetric `json:"total"`
 	ProductionBasedMargin           VarianceMetric `json:"production_based_margin"`
 	PBRNetCashFlow                  VarianceMetric `json:"pbr_net_cash_flow"`
+	CapitalIntensity                VarianceMetric `json:"capital_intensity"`
 }
 
 type CashCostVariance struct {
@@ -276,8 +434,19 @@ type CashCostVariance struct {
 	AISCPerOzSilver        VarianceMetric `json:"aisc_per_oz_silver"`
 	CashCostsSilver        VarianceMetric `json:"cash_costs_silver"`
 	AISCSilver             VarianceMetric `json:"aisc_silver"`
+	CashCostPerOzGold      VarianceMetric `json:"cash_cost_per_oz_gold"`
+	AISCPerOzGold          VarianceMetric `json:"aisc_per_oz_gold"`
+	CashCostsGold          VarianceMetric `json:"cash_costs_gold"`
+	AISCGold               VarianceMetric `json:"aisc_gold"`
 	GoldCredit             VarianceMetric `json:"gold_credit"`
 	SustainingCapitalPerOz VarianceMetric `json:"sustaining_capital_per_oz"`
+	BreakevenSilverPrice   VarianceMetric `json:"breakeven_silver_price"`
+	AISCMarginPerOz        VarianceMetric `json:"aisc_margin_per_oz"`
+}
+
+type ProfitabilityVariance struct {
+	EBITDA             VarianceMetric `json:"ebitda"`
+	OperatingMarginPct VarianceMetric `json:"operating_margin_pct"`
 }
 
 // VarianceMetric represents variance calculation for a single metric
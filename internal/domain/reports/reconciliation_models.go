@@ -0,0 +1,70 @@
+package reports
+
+// ReferenceSummary holds the month values a reconciliation compares the API against, keyed
+// by Summary.csv row label (see summaryMetrics)
+type ReferenceSummary struct {
+	Month  int // 1-12
+	Values map[string]ReferenceValue
+}
+
+// ReferenceValue represents a single metric value from reference Summary
+type ReferenceValue struct {
+	Actual         float64
+	Budget         float64
+	Variance       float64
+	VariancePct    float64
+	YTDActual      float64
+	YTDBudget      float64
+	YTDVariance    float64
+	YTDVariancePct float64
+}
+
+// ReconciliationResult represents the result of comparing API vs Reference
+type ReconciliationResult struct {
+	Matches    []MetricMatch         `json:"matches"`
+	Mismatches []MetricMismatch      `json:"mismatches"`
+	Summary    ReconciliationSummary `json:"summary"`
+}
+
+// MetricMatch represents a metric that matches within tolerance
+type MetricMatch struct {
+	Category      string  `json:"category"`
+	MetricName    string  `json:"metric_name"`
+	ActualValue   float64 `json:"actual_value"`
+	ExpectedValue float64 `json:"expected_value"`
+	Difference    float64 `json:"difference"`
+	DifferencePct float64 `json:"difference_pct"`
+}
+
+// MetricMismatch represents a metric that doesn't match
+type MetricMismatch struct {
+	Category        string   `json:"category"`
+	MetricName      string   `json:"metric_name"`
+	ActualValue     float64  `json:"actual_value"`
+	ExpectedValue   float64  `json:"expected_value"`
+	Difference      float64  `json:"difference"`
+	DifferencePct   float64  `json:"difference_pct"`
+	DependencyChain []string `json:"dependency_chain"`
+}
+
+// ReconciliationSummary provides overall statistics
+type ReconciliationSummary struct {
+	TotalMetrics     int     `json:"total_metrics"`
+	Matches          int     `json:"matches"`
+	Mismatches       int     `json:"mismatches"`
+	MatchRate        float64 `json:"match_rate"`
+	MaxDifference    float64 `json:"max_difference"`
+	MaxDifferencePct float64 `json:"max_difference_pct"`
+}
+
+// NoRounding disables rounding in ReconcileOptions
+const NoRounding = -1
+
+// DefaultReconcileTolerance accepts differences of up to 0.01, absolute or in percent
+const DefaultReconcileTolerance = 0.01
+
+// ReconcileOptions controls how API and reference values are compared
+type ReconcileOptions struct {
+	Tolerance float64 // Absolute or percentage difference accepted as a match
+	Decimals  int     // Round both sides to this many decimals before comparing (NoRounding to disable)
+}
@@ -1,404 +1,25 @@
 package reports
 
 import (
-	"encoding/csv"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/gmhafiz/go8/internal/domain/data"
 )
 
-// ReferenceSummary represents parsed values from the reference Summary.csv
-type ReferenceSummary struct {
-	Month  int // 1-12
-	Values map[string]ReferenceValue
-}
-
-// ReferenceValue represents a single metric value from reference Summary
-type ReferenceValue struct {
-	Actual      float64
-	Budget      float64
-	Variance    float64
-	VariancePct float64
-	YTDActual   float64
-	YTDBudget   float64
-	YTDVariance float64
-	YTDVariancePct float64
-}
-
-// MetricMapping maps Summary.csv row labels to DataSet field paths
-var metricMapping = map[string]struct {
-	Category string
-	Field    string
-}{
-	"Ore Mined (t)":                          {"mining", "ore_mined_t"},
-	"Waste Mined (t)":                        {"mining", "waste_mined_t"},
-	"Developments (m)":                       {"mining", "developments_m"},
-	"Total Tonnes Processed":                  {"processing", "total_tonnes_processed"},
-	"Feed Grade - Silver (g/t)":              {"processing", "feed_grade_silver_gpt"},
-	"Feed Grade - Gold (g/t)":                {"processing", "feed_grade_gold_gpt"},
-	"Recovery Rate - Silver (%)":             {"processing", "recovery_rate_silver_pct"},
-	"Recovery Rate - Gold (%)":               {"processing", "recovery_rate_gold_pct"},
-	"Total Production - Silver (oz)":         {"production", "total_production_silver_oz"},
-	"Total Production - Gold (oz)":           {"production", "total_production_gold_oz"},
-	"Payable Metal in Dore - Silver (oz)":   {"production", "payable_silver_oz"},
-	"Payable Metal in Dore - Gold (oz)":       {"production", "payable_gold_oz"},
-	"NSR per tonne":                           {"nsr", "nsr_per_tonne"},
-	"Total cost per tonne":                    {"nsr", "total_cost_per_tonne"},
-	"Margin per Tonne":                        {"nsr", "margin_per_tonne"},
-	"Net Smelter Return - Dore":               {"nsr", "nsr_dore"},
-	"Shipping & Selling":                     {"nsr", "shipping_selling"},
-	"Sales Taxes & Royalties":                 {"nsr", "sales_taxes_royalties"},
-	"Net Smelter Return":                      {"nsr", "net_smelter_return"},
-	"Costs - Mine":                            {"costs", "mine"},
-	"Costs - Processing":                      {"costs", "processing"},
-	"Costs - G&A":                             {"costs", "ga"},
-	"Transport & Shipping":                    {"costs", "transport_shipping"},
-	"Inventory Variations":                    {"costs", "inventory_variations"},
-	"Production based Costs":                   {"costs", "production_based_costs"},
-	"Production based Margin":                 {"costs", "production_based_margin"},
-	"AISC Sustaining Capital":                 {"capex", "sustaining"},
-	"PBR Net Cash flow":                       {"capex", "pbr_net_cash_flow"},
-	"Cash Cost per Payable Ounce - Silver":   {"cash_cost", "cash_cost_per_oz_silver"},
-	"AISC per Payable Ounce - Silver":        {"cash_cost", "aisc_per_oz_silver"},
-}
-
-// parseReferenceSummary parses the reference Summary.csv file
-func parseReferenceSummary(filePath string, month int) (*ReferenceSummary, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open reference file: %w", err)
-	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
-	}
-
-	if len(records) < 4 {
-		return nil, fmt.Errorf("invalid CSV format: expected at least 4 rows")
-	}
-
-	// Row 0: Header metadata (ignore)
-	// Row 1: "Actual,2025 Budget,,,Actual,2025 Budget,,"
-	// Row 2: "Jan,Jan,Fav (Unf),% Variance,Jan_YTD,Jan_YTD,Fav (Unf),% Variance"
-	// Row 3+: Data rows
-
-	summary := &ReferenceSummary{
-		Month:  month,
-		Values: make(map[string]ReferenceValue),
-	}
-
-	// Find the column indices for the requested month
-	// Columns: [Label, Actual, Budget, Variance, VariancePct, YTD Actual, YTD Budget, YTD Variance, YTD VariancePct]
-	actualCol := 1
-	budgetCol := 2
-	varianceCol := 3
-	variancePctCol := 4
-	ytdActualCol := 5
-	ytdBudgetCol := 6
-	ytdVarianceCol := 7
-	ytdVariancePctCol := 8
-
-	// Parse data rows (starting from row 4, index 3)
-	for i := 3; i < len(records); i++ {
-		row := records[i]
-		if len(row) < 9 {
-			continue
-		}
-
-		label := strings.TrimSpace(row[0])
-		if label == "" {
-			continue
-		}
-
-		// Check if this metric is in our mapping
-		if _, exists := metricMapping[label]; !exists {
-			continue
-		}
-
-		// Parse values using robust parsing
-		actual, _ := parseReferenceValue(row[actualCol])
-		budget, _ := parseReferenceValue(row[budgetCol])
-		variance, _ := parseReferenceValue(row[varianceCol])
-		variancePct, _ := parseReferenceValue(row[variancePctCol])
-		ytdActual, _ := parseReferenceValue(row[ytdActualCol])
-		ytdBudget, _ := parseReferenceValue(row[ytdBudgetCol])
-		ytdVariance, _ := parseReferenceValue(row[ytdVarianceCol])
-		ytdVariancePct, _ := parseReferenceValue(row[ytdVariancePctCol])
-
-		summary.Values[label] = ReferenceValue{
-			Actual:         actual,
-			Budget:         budget,
-			Variance:       variance,
-			VariancePct:    variancePct,
-			YTDActual:      ytdActual,
-			YTDBudget:      ytdBudget,
-			YTDVariance:    ytdVariance,
-			YTDVariancePct: ytdVariancePct,
-		}
-	}
-
-	return summary, nil
-}
-
-// parseReferenceValue parses a value from Summary.csv (handles formatting)
-func parseReferenceValue(value string) (float64, error) {
-	value = strings.TrimSpace(value)
-	if value == "" || value == "-" || value == "$ -" || value == "$ -   " {
-		return 0, nil
-	}
-
-	// Remove currency symbols
-	value = strings.TrimPrefix(value, "$")
-	value = strings.TrimSpace(value)
-
-	// Remove percentage sign
-	value = strings.TrimSuffix(value, "%")
-	value = strings.TrimSpace(value)
-
-	// Handle parentheses for negatives
-	isNegative := false
-	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
-		isNegative = true
-		value = strings.TrimPrefix(value, "(")
-		value = strings.TrimSuffix(value, ")")
-		value = strings.TrimSpace(value)
-	}
-
-	// Remove commas
-	value = strings.ReplaceAll(value, ",", "")
-
-	f, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid number: %s", value)
-	}
-
-	if isNegative {
-		f = -f
-	}
-
-	return f, nil
-}
-
-// getValueFromDataSet extracts a value from DataSet using category and field name
-func getValueFromDataSet(ds *DataSet, category, field string) float64 {
-	switch category {
-	case "mining":
-		switch field {
-		case "ore_mined_t":
-			return ds.Mining.OreMinedT
-		case "waste_mined_t":
-			return ds.Mining.WasteMinedT
-		case "developments_m":
-			return ds.Mining.DevelopmentsM
-		}
-	case "processing":
-		switch field {
-		case "total_tonnes_processed":
-			return ds.Processing.TotalTonnesProcessed
-		case "feed_grade_silver_gpt":
-			return ds.Processing.FeedGradeSilverGpt
-		case "feed_grade_gold_gpt":
-			return ds.Processing.FeedGradeGoldGpt
-		case "recovery_rate_silver_pct":
-			return ds.Processing.RecoveryRateSilverPct
-		case "recovery_rate_gold_pct":
-			return ds.Processing.RecoveryRateGoldPct
-		}
-	case "production":
-		switch field {
-		case "total_production_silver_oz":
-			return ds.Production.TotalProductionSilverOz
-		case "total_production_gold_oz":
-			return ds.Production.TotalProductionGoldOz
-		case "payable_silver_oz":
-			return ds.Production.PayableSilverOz
-		case "payable_gold_oz":
-			return ds.Production.PayableGoldOz
-		}
-	case "nsr":
-		switch field {
-		case "nsr_per_tonne":
-			return ds.NSR.NSRPerTonne
-		case "total_cost_per_tonne":
-			return ds.NSR.TotalCostPerTonne
-		case "margin_per_tonne":
-			return ds.NSR.MarginPerTonne
-		case "nsr_dore":
-			return ds.NSR.NSRDore
-		case "shipping_selling":
-			return ds.NSR.ShippingSelling
-		case "sales_taxes_royalties":
-			return ds.NSR.SalesTaxesRoyalties
-		case "net_smelter_return":
-			return ds.NSR.NetSmelterReturn
-		}
-	case "costs":
-		switch field {
-		case "mine":
-			return ds.Costs.Mine
-		case "processing":
-			return ds.Costs.Processing
-		case "ga":
-			return ds.Costs.GA
-		case "transport_shipping":
-			return ds.Costs.TransportShipping
-		case "inventory_variations":
-			return ds.Costs.InventoryVariations
-		case "production_based_costs":
-			return ds.Costs.ProductionBasedCosts
-		case "production_based_margin":
-			return ds.Costs.ProductionBasedMargin
-		}
-	case "capex":
-		switch field {
-		case "sustaining":
-			return ds.CAPEX.Sustaining
-		case "pbr_net_cash_flow":
-			return ds.CAPEX.PBRNetCashFlow
-		}
-	case "cash_cost":
-		switch field {
-		case "cash_cost_per_oz_silver":
-			return ds.CashCost.CashCostPerOzSilver
-		case "aisc_per_oz_silver":
-			return ds.CashCost.AISCPerOzSilver
-		}
-	}
-	return 0
-}
-
-// ReconciliationResult represents the result of comparing API vs Reference
-type ReconciliationResult struct {
-	Matches    []MetricMatch
-	Mismatches []MetricMismatch
-	Summary    ReconciliationSummary
-}
-
-// MetricMatch represents a metric that matches within tolerance
-type MetricMatch struct {
-	Category      string
-	MetricName    string
-	ActualValue   float64
-	ExpectedValue float64
-	Difference    float64
-	DifferencePct float64
-}
-
-// MetricMismatch represents a metric that doesn't match
-type MetricMismatch struct {
-	Category       string
-	MetricName     string
-	ActualValue    float64
-	ExpectedValue  float64
-	Difference     float64
-	DifferencePct  float64
-	DependencyChain []string
-}
-
-// ReconciliationSummary provides overall statistics
-type ReconciliationSummary struct {
-	TotalMetrics   int
-	Matches       int
-	Mismatches    int
-	MatchRate     float64
-	MaxDifference float64
-	MaxDifferencePct float64
-}
-
-// Reconcile compares API-calculated Summary with reference Summary.csv
-func Reconcile(apiActual, apiBudget *DataSet, reference *ReferenceSummary, tolerance float64) *ReconciliationResult {
-	result := &ReconciliationResult{
-		Matches:    []MetricMatch{},
-		Mismatches: []MetricMismatch{},
-	}
-
-	for label, refValue := range reference.Values {
-		mapping, exists := metricMapping[label]
-		if !exists {
-			continue
-		}
-
-		// Get API values
-		apiActualVal := getValueFromDataSet(apiActual, mapping.Category, mapping.Field)
-		apiBudgetVal := getValueFromDataSet(apiBudget, mapping.Category, mapping.Field)
-
-		// Compare Actual
-		actualDiff := apiActualVal - refValue.Actual
-		actualDiffPct := 0.0
-		if refValue.Actual != 0 {
-			actualDiffPct = (actualDiff / refValue.Actual) * 100
-		}
-
-		// Compare Budget
-		budgetDiff := apiBudgetVal - refValue.Budget
-		budgetDiffPct := 0.0
-		if refValue.Budget != 0 {
-			budgetDiffPct = (budgetDiff / refValue.Budget) * 100
-		}
-
-		// Check if within tolerance (use absolute difference or percentage, whichever is larger)
-		actualWithinTolerance := abs(actualDiff) <= tolerance || abs(actualDiffPct) <= tolerance
-		budgetWithinTolerance := abs(budgetDiff) <= tolerance || abs(budgetDiffPct) <= tolerance
-
-		if actualWithinTolerance && budgetWithinTolerance {
-			result.Matches = append(result.Matches, MetricMatch{
-				Category:      mapping.Category,
-				MetricName:    label,
-				ActualValue:   apiActualVal,
-				ExpectedValue: refValue.Actual,
-				Difference:    actualDiff,
-				DifferencePct: actualDiffPct,
-			})
-		} else {
-			result.Mismatches = append(result.Mismatches, MetricMismatch{
-				Category:       mapping.Category,
-				MetricName:     label,
-				ActualValue:    apiActualVal,
-				ExpectedValue:  refValue.Actual,
-				Difference:     actualDiff,
-				DifferencePct:  actualDiffPct,
-				DependencyChain: []string{mapping.Category, mapping.Field},
-			})
-		}
-	}
-
-	// Calculate summary
-	result.Summary.TotalMetrics = len(result.Matches) + len(result.Mismatches)
-	result.Summary.Matches = len(result.Matches)
-	result.Summary.Mismatches = len(result.Mismatches)
-	if result.Summary.TotalMetrics > 0 {
-		result.Summary.MatchRate = float64(result.Summary.Matches) / float64(result.Summary.TotalMetrics) * 100
-	}
-
-	// Find max differences
-	for _, m := range result.Mismatches {
-		if abs(m.Difference) > result.Summary.MaxDifference {
-			result.Summary.MaxDifference = abs(m.Difference)
-		}
-		if abs(m.DifferencePct) > result.Summary.MaxDifferencePct {
-			result.Summary.MaxDifferencePct = abs(m.DifferencePct)
-		}
-	}
-
-	return result
-}
-
-// abs returns absolute value
-func abs(x float64) float64 {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
-
 // GenerateDiffReport generates a human-readable diff report
 func GenerateDiffReport(result *ReconciliationResult) string {
 	var report strings.Builder
@@ -433,9 +54,33 @@ func GenerateDiffReport(result *ReconciliationResult) string {
 	return report.String()
 }
 
+func TestReconcileRoundingTurnsNearMissIntoMatch(t *testing.T) {
+	apiActual := &DataSet{Processing: ProcessingMetrics{FeedGradeSilverGpt: 412.3449}}
+	apiBudget := &DataSet{Processing: ProcessingMetrics{FeedGradeSilverGpt: 398.0049}}
+	reference := &ReferenceSummary{
+		Month: 1,
+		Values: map[string]ReferenceValue{
+			"Feed Grade - Silver (g/t)": {Actual: 412.34, Budget: 398.00},
+		},
+	}
+
+	raw := ReconcileWithOptions(apiActual, apiBudget, reference, ReconcileOptions{Decimals: NoRounding})
+	if len(raw.Mismatches) != 1 {
+		t.Fatalf("expected a mismatch without rounding, got %d", len(raw.Mismatches))
+	}
+
+	rounded := ReconcileWithOptions(apiActual, apiBudget, reference, ReconcileOptions{Decimals: 2})
+	if len(rounded.Mismatches) != 0 || len(rounded.Matches) != 1 {
+		t.Fatalf("expected a match after rounding to 2 decimals, got %d mismatches", len(rounded.Mismatches))
+	}
+}
+
 // TestReconciliation is the main test function
 // It imports sample CSVs, calculates Summary, and compares with reference
 // Set RECONCILIATION_REF_PATH environment variable to specify custom path
+// Set RECONCILIATION_DECIMALS to round both sides before comparing
+// Set RECONCILIATION_MONTH (1-12, default 1) and RECONCILIATION_TOLERANCE (default
+// DefaultReconcileTolerance) to reconcile another month or with another tolerance
 func TestReconciliation(t *testing.T) {
 	// Get reference path from environment or use default
 	referencePath := os.Getenv("RECONCILIATION_REF_PATH")
@@ -452,8 +97,28 @@ func TestReconciliation(t *testing.T) {
 	
 	t.Logf("Using reference file: %s", referencePath)
 
-	// Parse reference Summary for January
-	reference, err := parseReferenceSummary(referencePath, 1)
+	month := 1
+	if v := os.Getenv("RECONCILIATION_MONTH"); v != "" {
+		var err error
+		if month, err = strconv.Atoi(v); err != nil || month < 1 || month > 12 {
+			t.Fatalf("Invalid RECONCILIATION_MONTH %q (expected 1-12)", v)
+		}
+	}
+	tolerance := DefaultReconcileTolerance
+	if v := os.Getenv("RECONCILIATION_TOLERANCE"); v != "" {
+		var err error
+		if tolerance, err = strconv.ParseFloat(v, 64); err != nil || tolerance < 0 {
+			t.Fatalf("Invalid RECONCILIATION_TOLERANCE %q", v)
+		}
+	}
+
+	// Parse reference Summary of the month
+	file, err := os.Open(referencePath)
+	if err != nil {
+		t.Fatalf("Failed to open reference file: %v", err)
+	}
+	defer file.Close()
+	reference, err := parseReferenceSummary(file, month)
 	if err != nil {
 		t.Fatalf("Failed to parse reference Summary: %v", err)
 	}
@@ -586,9 +251,17 @@ func TestReconciliation(t *testing.T) {
 		t.Fatalf("Invalid month in reference: %d (expected 1-12)", reference.Month)
 	}
 	
-	// Reconcile with tolerance of 1% or $1, whichever is larger
-	tolerance := 0.01 // 1%
-	result := Reconcile(apiActual, apiBudget, reference, tolerance)
+	decimals := NoRounding
+	if v := os.Getenv("RECONCILIATION_DECIMALS"); v != "" {
+		decimals, err = strconv.Atoi(v)
+		if err != nil {
+			t.Fatalf("Invalid RECONCILIATION_DECIMALS %q: %v", v, err)
+		}
+	}
+	result := ReconcileWithOptions(apiActual, apiBudget, reference, ReconcileOptions{
+		Tolerance: tolerance,
+		Decimals:  decimals,
+	})
 
 	// Generate and print diff report
 	report := GenerateDiffReport(result)
@@ -611,3 +284,79 @@ func TestReconciliation(t *testing.T) {
 		t.Logf("Max difference %%: %.2f%%", result.Summary.MaxDifferencePct)
 	}
 }
+
+// reconcileRepository serves the reports saved on top of savingRepository
+type reconcileRepository struct {
+	savingRepository
+}
+
+func (r *reconcileRepository) GetSavedReportsByIDs(ctx context.Context, ids []int64) ([]*SavedReport, error) {
+	var reports []*SavedReport
+	for _, report := range r.saved {
+		if slices.Contains(ids, report.ID) {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+func TestGetReconciliationAgainstSavedReport(t *testing.T) {
+	repo := &reconcileRepository{savingRepository{companiesRepository: companiesRepository{names: map[int64]string{1: "Cerro Moro", 2: "San José"}}}}
+	uc := NewUseCase(repo, 0)
+	h := NewHandler(uc, validator.New(), nil)
+
+	saved, err := uc.SaveReport(context.Background(), &SaveReportRequest{Name: "January close", CompanyID: 1, Year: 2024, BudgetVersion: 1}, 1)
+	require.NoError(t, err)
+	other, err := uc.SaveReport(context.Background(), &SaveReportRequest{Name: "Other company", CompanyID: 2, Year: 2024, BudgetVersion: 1}, 1)
+	require.NoError(t, err)
+
+	reconcile := func(query string) (*httptest.ResponseRecorder, ReconciliationResult) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/reconcile?company_id=1&year=2024&month=1&"+query, nil)
+		rec := httptest.NewRecorder()
+		h.GetReconciliation(rec, req)
+		var result ReconciliationResult
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		}
+		return rec, result
+	}
+
+	// Live data unchanged since the snapshot: every metric matches
+	rec, result := reconcile("report_id=" + strconv.FormatInt(saved.ID, 10))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, result.Mismatches)
+	assert.Len(t, result.Matches, len(summaryMetrics))
+	assert.Equal(t, "Ore Mined (t)", result.Matches[0].MetricName, "metrics come in workbook order")
+
+	// Data reloaded after the snapshot: ore mined differs by 1%, a mismatch at the default
+	// tolerance and a match at a 2% tolerance
+	saved.ReportData.Months[0].Actual.Mining.OreMinedT *= 1.01
+	rec, result = reconcile("report_id=" + strconv.FormatInt(saved.ID, 10))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, result.Mismatches, 1)
+	assert.Equal(t, "Ore Mined (t)", result.Mismatches[0].MetricName)
+	assert.Equal(t, 1, result.Summary.Mismatches)
+
+	rec, result = reconcile("tolerance=2&report_id=" + strconv.FormatInt(saved.ID, 10))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, result.Mismatches)
+
+	rec, _ = reconcile("report_id=" + strconv.FormatInt(other.ID, 10))
+	assert.Equal(t, http.StatusNotFound, rec.Code, "a report of another company")
+	rec, _ = reconcile("report_id=" + strconv.FormatInt(saved.ID, 10) + "&tolerance=-1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = reconcile("")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "report_id is required")
+}
+
+func TestParseReferenceValueBlankPlaceholders(t *testing.T) {
+	for _, placeholder := range []string{"", "-", "$ -", "$ -   ", "$-", "\u2014", "$ \u2014", "\u2013"} {
+		value, err := parseReferenceValue(placeholder)
+		assert.NoError(t, err, placeholder)
+		assert.Zero(t, value, placeholder)
+	}
+
+	value, err := parseReferenceValue("$ (8,537,997)")
+	assert.NoError(t, err)
+	assert.Equal(t, -8537997.0, value)
+}
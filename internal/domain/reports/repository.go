@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -15,6 +17,7 @@ import (
 var (
 	ErrCompanyNotFound = errors.New("company not found")
 	ErrReportNotFound  = errors.New("report not found")
+	ErrMixedCompanies  = errors.New("reports belong to different companies")
 )
 
 type Repository interface {
@@ -34,6 +37,9 @@ type Repository interface {
 	ListSavedReports(ctx context.Context, companyID int64, year int) ([]*SavedReport, error)
 	GetSavedReportsByIDs(ctx context.Context, ids []int64) ([]*SavedReport, error)
 	GetReportCompanyID(ctx context.Context, reportID int64) (int64, error)
+
+	// Peer group benchmarks
+	GetBenchmarks(ctx context.Context, peerGroup string, year int) (map[string]float64, error) // metric -> value
 }
 
 type repository struct {
@@ -61,20 +67,51 @@ func (r *repository) GetCompanyName(ctx context.Context, companyID int64) (strin
 
 func (r *repository) GetCompanyConfig(ctx context.Context, companyID int64) (*CompanyConfig, error) {
 	config := &CompanyConfig{
-		MiningType: "both",    // Default
-		Minerals:   []string{}, // Empty list by default
+		MiningType:            "both",                 // Default
+		Minerals:              []string{},             // Empty list by default
+		NetCashFlowCapexTypes: []string{"sustaining"}, // Default: sustaining CAPEX only
+		ExcludedExpenseTypes:  []string{},             // Default: every expense type counts
+		CostCenters:           defaultCostCenters(),
+		CAPEXCategories:       slices.Clone(defaultCAPEXCategories),
+		CAPEXProjects:         slices.Clone(defaultCAPEXProjects),
 	}
 
-	// Get mining type from company_settings
-	var miningType sql.NullString
-	settingsQuery := `SELECT mining_type FROM company_settings WHERE company_id = $1`
-	err := r.db.GetContext(ctx, &miningType, settingsQuery, companyID)
+	// Get mining type and net cash flow definition from company_settings
+	var settings struct {
+		MiningType            sql.NullString `db:"mining_type"`
+		NetCashFlowCapexTypes sql.NullString `db:"net_cash_flow_capex_types"`
+		ExcludedExpenseTypes  sql.NullString `db:"excluded_expense_types"`
+		RealizedPriceFallback sql.NullBool   `db:"realized_price_fallback"`
+		PrimaryMetal          sql.NullString `db:"primary_metal"`
+		CostCenters           sql.NullString `db:"cost_centers"`
+		CAPEXCategories       sql.NullString `db:"capex_categories"`
+		CAPEXProjects         sql.NullString `db:"capex_projects"`
+	}
+	settingsQuery := `SELECT mining_type, net_cash_flow_capex_types, excluded_expense_types, realized_price_fallback, primary_metal, cost_centers, capex_categories, capex_projects FROM company_settings WHERE company_id = $1`
+	err := r.db.GetContext(ctx, &settings, settingsQuery, companyID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, err
 	}
-	if miningType.Valid && miningType.String != "" {
-		config.MiningType = miningType.String
+	if settings.MiningType.Valid && settings.MiningType.String != "" {
+		config.MiningType = settings.MiningType.String
+	}
+	if capexTypes := parseList(settings.NetCashFlowCapexTypes.String); len(capexTypes) > 0 {
+		config.NetCashFlowCapexTypes = capexTypes
+	}
+	if expenseTypes := parseList(settings.ExcludedExpenseTypes.String); len(expenseTypes) > 0 {
+		config.ExcludedExpenseTypes = expenseTypes
+	}
+	if costCenters := parseList(settings.CostCenters.String); len(costCenters) > 0 {
+		config.CostCenters = costCenters
+	}
+	if categories := parseList(settings.CAPEXCategories.String); len(categories) > 0 {
+		config.CAPEXCategories = categories
 	}
+	if projects := parseList(settings.CAPEXProjects.String); len(projects) > 0 {
+		config.CAPEXProjects = projects
+	}
+	config.RealizedPriceFallback = settings.RealizedPriceFallback.Bool
+	config.PrimaryMetal = settings.PrimaryMetal.String
 
 	// Get minerals assigned to company
 	var mineralCodes []string
@@ -96,6 +133,18 @@ func (r *repository) GetCompanyConfig(ctx context.Context, companyID int64) (*Co
 	return config, nil
 }
 
+// parseList splits a comma-separated settings list (CAPEX or expense types), ignoring blanks
+func parseList(value string) []string {
+	var items []string
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			items = append(items, t)
+		}
+	}
+	return items
+}
+
 func (r *repository) GetPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.PBRData, error) {
 	var records []*data.PBRData
 
@@ -133,7 +182,7 @@ func (r *repository) GetDoreData(ctx context.Context, companyID int64, year int,
 		SELECT id, company_id, date, dore_produced_oz, silver_grade_pct, gold_grade_pct,
 		       pbr_price_silver, pbr_price_gold, realized_price_silver, realized_price_gold,
 		       silver_adjustment_oz, gold_adjustment_oz, ag_deductions_pct, au_deductions_pct,
-		       treatment_charge, refining_deductions_au, streaming,
+		       treatment_charge, refining_deductions_au, streaming, grade_basis,
 		       data_type, version, created_by, created_at
 		FROM dore_data
 		WHERE company_id = $1 AND date >= $2 AND date <= $3 AND data_type = $4 AND version = $5 AND deleted_at IS NULL
@@ -152,7 +201,7 @@ func (r *repository) GetOPEXData(ctx context.Context, companyID int64, year int,
 
 	query := `
 		SELECT id, company_id, date, cost_center, subcategory, expense_type,
-		       amount, currency, data_type, version, created_by, created_at
+		       amount, currency, data_type, version, is_adjustment, created_by, created_at
 		FROM opex_data
 		WHERE company_id = $1 AND date >= $2 AND date <= $3 AND data_type = $4 AND version = $5 AND deleted_at IS NULL
 		ORDER BY date
@@ -170,7 +219,7 @@ func (r *repository) GetCAPEXData(ctx context.Context, companyID int64, year int
 
 	query := `
 		SELECT id, company_id, date, category, car_number, project_name, type,
-		       amount, accretion_of_mine_closure_liability, currency, data_type, version, created_by, created_at
+		       amount, accretion_of_mine_closure_liability, currency, data_type, version, is_adjustment, created_by, created_at
 		FROM capex_data
 		WHERE company_id = $1 AND date >= $2 AND date <= $3 AND data_type = $4 AND version = $5 AND deleted_at IS NULL
 		ORDER BY date
@@ -352,3 +401,26 @@ func (r *repository) GetReportCompanyID(ctx context.Context, reportID int64) (in
 
 	return companyID, nil
 }
+
+// GetBenchmarks retrieves the benchmark values of a peer group for a year (metric -> value)
+func (r *repository) GetBenchmarks(ctx context.Context, peerGroup string, year int) (map[string]float64, error) {
+	var records []*Benchmark
+
+	query := `
+		SELECT id, peer_group, year, metric, value, created_at, updated_at
+		FROM benchmarks
+		WHERE peer_group = $1 AND year = $2
+	`
+
+	err := r.db.SelectContext(ctx, &records, query, peerGroup, year)
+	if err != nil {
+		return nil, err
+	}
+
+	benchmarks := make(map[string]float64, len(records))
+	for _, b := range records {
+		benchmarks[b.Metric] = b.Value
+	}
+
+	return benchmarks, nil
+}
@@ -2,8 +2,103 @@ package reports
 
 // SummaryRequest represents a request for summary report
 type SummaryRequest struct {
-	CompanyID     int64  `form:"company_id" validate:"required,gt=0"`
-	Year          int    `form:"year" validate:"required,gt=2000"`
-	Months        string `form:"months"`                                  // Optional: "1,2,3" or empty for all months
-	BudgetVersion int    `form:"budget_version" validate:"required,gte=1"` // Required: budget data version to compare against
+	CompanyID       int64  `form:"company_id" validate:"required,gt=0"`
+	Year            int    `form:"year" validate:"required,gt=2000"`
+	Months          string `form:"months"`                                   // Optional: "1,2,3" or empty for all months
+	BudgetVersion   int    `form:"budget_version" validate:"required,gte=1"` // Required: budget data version to compare against
+	IncludeForecast bool   `form:"include_forecast"`                         // Optional: also return forecast data (version 1) and variance against it
+}
+
+// SummariesRequest asks for the summaries of several companies at once (group dashboards)
+type SummariesRequest struct {
+	Summaries []SummariesRequestItem `json:"summaries" validate:"required,min=1,max=20,unique=CompanyID,dive"`
+}
+
+// SummariesRequestItem is one company's summary in a SummariesRequest
+type SummariesRequestItem struct {
+	CompanyID int64  `json:"company_id" validate:"required,gt=0"`
+	Year      int    `json:"year" validate:"required,gt=2000"`
+	Version   int    `json:"version" validate:"required,gte=1"` // Budget version to compare against
+	Months    string `json:"months"`                            // Optional: "1,2,3" or empty for all months
+}
+
+// BenchmarkRequest represents a request to compare a company month against a peer group
+type BenchmarkRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Year      int    `form:"year" validate:"required,gt=2000"`
+	Month     int    `form:"month" validate:"required,gte=1,lte=12"`
+	PeerGroup string `form:"peer_group" validate:"max=100"` // Optional: defaults to "default"
+}
+
+// IntegrityRequest represents a request to check referential integrity of a company year
+type IntegrityRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Year      int    `form:"year" validate:"required,gt=2000"`
+	DataType  string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version   int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
+}
+
+// TTMRequest represents a request for trailing-twelve-month figures ending at Through
+type TTMRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Through   string `form:"through" validate:"required,datetime=2006-01"`               // Last month of the window: "YYYY-MM"
+	DataType  string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version   int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
+}
+
+// MineralMarginRequest represents a request for contribution margin by mineral
+type MineralMarginRequest struct {
+	CompanyID       int64  `form:"company_id" validate:"required,gt=0"`
+	Year            int    `form:"year" validate:"required,gt=2000"`
+	DataType        string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version         int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
+	AllocationBasis string `form:"allocation_basis" validate:"required,oneof=revenue equal"`   // Optional in query, defaults to revenue
+}
+
+// MarginWaterfallRequest represents a request for the budget to actual margin waterfall of a month
+type MarginWaterfallRequest struct {
+	CompanyID int64 `form:"company_id" validate:"required,gt=0"`
+	Year      int   `form:"year" validate:"required,gt=2000"`
+	Month     int   `form:"month" validate:"required,gte=1,lte=12"`
+	Version   int   `form:"version" validate:"required,gte=1"` // Budget version, optional in query, defaults to 1
+}
+
+// SensitivityRequest represents a request for the margin of a company year under metal price scenarios
+type SensitivityRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Year      int    `form:"year" validate:"required,gt=2000"`
+	DataType  string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version   int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
+	Months    string `form:"months"`                                                     // Optional: "1,2,3" or empty for all months
+}
+
+// VarianceRequest represents a request for the month and YTD variance of a month
+type VarianceRequest struct {
+	CompanyID int64 `form:"company_id" validate:"required,gt=0"`
+	Year      int   `form:"year" validate:"required,gt=2000"`
+	Month     int   `form:"month" validate:"required,gte=1,lte=12"`
+	Version   int   `form:"version" validate:"required,gte=1"` // Budget version, optional in query, defaults to 1
+}
+
+// ReconcileRequest reconciles a month of live data against a saved report, or against
+// Reference when set (e.g. a parsed reference Summary.csv)
+type ReconcileRequest struct {
+	CompanyID int64   `form:"company_id" validate:"required,gt=0"`
+	Year      int     `form:"year" validate:"required,gt=2000"`
+	Month     int     `form:"month" validate:"required,gte=1,lte=12"`
+	Version   int     `form:"version" validate:"gte=0"`          // Budget version; 0 for the saved report's
+	ReportID  int64   `form:"report_id" validate:"gte=0"`        // Saved report to compare against, unless Reference is set
+	Tolerance float64 `form:"tolerance" validate:"gte=0"`        // Absolute or percentage difference accepted as a match
+	Decimals  int     `form:"decimals" validate:"gte=-1,lte=10"` // Round both sides before comparing, NoRounding (-1) to disable
+
+	Reference *ReferenceSummary `form:"-"`
+}
+
+// WeightedGradeRequest represents a request for tonnage-weighted feed grades across selected months
+type WeightedGradeRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Year      int    `form:"year" validate:"required,gt=2000"`
+	Months    []int  `form:"months" validate:"required,min=1,dive,gte=1,lte=12"`         // "1,2,3" in query
+	DataType  string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version   int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
 }
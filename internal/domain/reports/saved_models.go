@@ -38,3 +38,25 @@ type CompareReportsResponse struct {
 	Reports    []SavedReport `json:"reports"`
 	Comparison interface{}   `json:"comparison"` // Side-by-side comparison data
 }
+
+// ScenarioComparison is the side-by-side view of saved scenarios of one company, each
+// compared with the first scenario requested (the baseline)
+type ScenarioComparison struct {
+	CompanyID  int64             `json:"company_id"`
+	BaselineID int64             `json:"baseline_id"`
+	Scenarios  []ScenarioMetrics `json:"scenarios"` // In request order, baseline first
+}
+
+// ScenarioMetrics holds a scenario's year to date figures as of its last month with YTD data.
+// In the variances, "actual" is this scenario and "budget" the baseline.
+type ScenarioMetrics struct {
+	ReportID       int64         `json:"report_id"`
+	Name           string        `json:"name"`
+	Year           int           `json:"year"`
+	BudgetVersion  int           `json:"budget_version"`
+	Through        string        `json:"through,omitempty"` // "2025-06"; empty when the scenario has no YTD data
+	Actual         *DataSet      `json:"actual"`
+	Budget         *DataSet      `json:"budget"`
+	ActualVariance *VarianceData `json:"actual_variance,omitempty"` // nil for the baseline
+	BudgetVariance *VarianceData `json:"budget_variance,omitempty"` // nil for the baseline
+}
@@ -0,0 +1,31 @@
+package reports
+
+// SensitivityPriceDeltasPct are the silver and gold price changes of the sensitivity grid,
+// in % of the realized prices
+var SensitivityPriceDeltasPct = []float64{-20, -10, 0, 10, 20}
+
+// SensitivityScenario is the result of the selected months with silver and gold sold at
+// their realized prices changed by the given percentages
+type SensitivityScenario struct {
+	SilverPriceDeltaPct   float64 `json:"silver_price_delta_pct"`
+	GoldPriceDeltaPct     float64 `json:"gold_price_delta_pct"`
+	NetSmelterReturn      float64 `json:"net_smelter_return"`
+	ProductionBasedMargin float64 `json:"production_based_margin"` // Net Smelter Return - Production Based Costs
+	PBRNetCashFlow        float64 `json:"pbr_net_cash_flow"`       // Margin - configured CAPEX (sustaining by default)
+}
+
+// SensitivityReport is the margin of a company year under a grid of metal price scenarios.
+// Matrix[i][j] is the scenario with silver at SilverPriceDeltasPct[i] and gold at
+// GoldPriceDeltasPct[j]; the scenario with both at 0 is the reported figure.
+type SensitivityReport struct {
+	CompanyID            int64                   `json:"company_id"`
+	CompanyName          string                  `json:"company_name"`
+	Year                 int                     `json:"year"`
+	DataType             string                  `json:"data_type"`
+	Version              int                     `json:"version"`
+	Months               []int                   `json:"months"` // Months with data included in the figures
+	SilverPriceDeltasPct []float64               `json:"silver_price_deltas_pct"`
+	GoldPriceDeltasPct   []float64               `json:"gold_price_deltas_pct"`
+	Matrix               [][]SensitivityScenario `json:"matrix"`
+	HasData              bool                    `json:"has_data"`
+}
@@ -0,0 +1,145 @@
+package reports
+
+// summaryMetric is a row of the reference Summary workbook and the DataSet field it holds
+type summaryMetric struct {
+	Label    string
+	Category string
+	Field    string
+}
+
+// summaryMetrics are the Summary.csv rows we reconcile against, in workbook order
+var summaryMetrics = []summaryMetric{
+	{"Ore Mined (t)", "mining", "ore_mined_t"},
+	{"Waste Mined (t)", "mining", "waste_mined_t"},
+	{"Developments (m)", "mining", "developments_m"},
+	{"Total Tonnes Processed", "processing", "total_tonnes_processed"},
+	{"Feed Grade - Silver (g/t)", "processing", "feed_grade_silver_gpt"},
+	{"Feed Grade - Gold (g/t)", "processing", "feed_grade_gold_gpt"},
+	{"Recovery Rate - Silver (%)", "processing", "recovery_rate_silver_pct"},
+	{"Recovery Rate - Gold (%)", "processing", "recovery_rate_gold_pct"},
+	{"Total Production - Silver (oz)", "production", "total_production_silver_oz"},
+	{"Total Production - Gold (oz)", "production", "total_production_gold_oz"},
+	{"Payable Metal in Dore - Silver (oz)", "production", "payable_silver_oz"},
+	{"Payable Metal in Dore - Gold (oz)", "production", "payable_gold_oz"},
+	{"NSR per tonne", "nsr", "nsr_per_tonne"},
+	{"Total cost per tonne", "nsr", "total_cost_per_tonne"},
+	{"Margin per Tonne", "nsr", "margin_per_tonne"},
+	{"Net Smelter Return - Dore", "nsr", "nsr_dore"},
+	{"Shipping & Selling", "nsr", "shipping_selling"},
+	{"Sales Taxes & Royalties", "nsr", "sales_taxes_royalties"},
+	{"Other Sales Deductions", "nsr", "other_sales_deductions"},
+	{"Other Adjustments", "nsr", "other_adjustments"},
+	{"Net Smelter Return", "nsr", "net_smelter_return"},
+	{"Costs - Mine", "costs", "mine"},
+	{"Costs - Processing", "costs", "processing"},
+	{"Costs - G&A", "costs", "ga"},
+	{"Transport & Shipping", "costs", "transport_shipping"},
+	{"Inventory Variations", "costs", "inventory_variations"},
+	{"Production based Costs", "costs", "production_based_costs"},
+	{"Production based Margin", "costs", "production_based_margin"},
+	{"AISC Sustaining Capital", "capex", "sustaining"},
+	{"PBR Net Cash flow", "capex", "pbr_net_cash_flow"},
+	{"Cash Cost per Payable Ounce - Silver", "cash_cost", "cash_cost_per_oz_silver"},
+	{"AISC per Payable Ounce - Silver", "cash_cost", "aisc_per_oz_silver"},
+}
+
+// metricMapping maps Summary.csv row labels to DataSet field paths
+var metricMapping = func() map[string]summaryMetric {
+	mapping := make(map[string]summaryMetric, len(summaryMetrics))
+	for _, metric := range summaryMetrics {
+		mapping[metric.Label] = metric
+	}
+	return mapping
+}()
+
+// getValueFromDataSet extracts a value from DataSet using category and field name
+func getValueFromDataSet(ds *DataSet, category, field string) float64 {
+	switch category {
+	case "mining":
+		switch field {
+		case "ore_mined_t":
+			return ds.Mining.OreMinedT
+		case "waste_mined_t":
+			return ds.Mining.WasteMinedT
+		case "developments_m":
+			return ds.Mining.DevelopmentsM
+		}
+	case "processing":
+		switch field {
+		case "total_tonnes_processed":
+			return ds.Processing.TotalTonnesProcessed
+		case "feed_grade_silver_gpt":
+			return ds.Processing.FeedGradeSilverGpt
+		case "feed_grade_gold_gpt":
+			return ds.Processing.FeedGradeGoldGpt
+		case "recovery_rate_silver_pct":
+			return ds.Processing.RecoveryRateSilverPct
+		case "recovery_rate_gold_pct":
+			return ds.Processing.RecoveryRateGoldPct
+		}
+	case "production":
+		switch field {
+		case "total_production_silver_oz":
+			return ds.Production.TotalProductionSilverOz
+		case "total_production_gold_oz":
+			return ds.Production.TotalProductionGoldOz
+		case "payable_silver_oz":
+			return ds.Production.PayableSilverOz
+		case "payable_gold_oz":
+			return ds.Production.PayableGoldOz
+		}
+	case "nsr":
+		switch field {
+		case "nsr_per_tonne":
+			return ds.NSR.NSRPerTonne
+		case "total_cost_per_tonne":
+			return ds.NSR.TotalCostPerTonne
+		case "margin_per_tonne":
+			return ds.NSR.MarginPerTonne
+		case "nsr_dore":
+			return ds.NSR.NSRDore
+		case "shipping_selling":
+			return ds.NSR.ShippingSelling
+		case "sales_taxes_royalties":
+			return ds.NSR.SalesTaxesRoyalties
+		case "other_sales_deductions":
+			return ds.NSR.OtherSalesDeductions
+		case "other_adjustments":
+			return ds.NSR.OtherAdjustments
+		case "net_smelter_return":
+			return ds.NSR.NetSmelterReturn
+		}
+	case "costs":
+		switch field {
+		case "mine":
+			return ds.Costs.Mine
+		case "processing":
+			return ds.Costs.Processing
+		case "ga":
+			return ds.Costs.GA
+		case "transport_shipping":
+			return ds.Costs.TransportShipping
+		case "inventory_variations":
+			return ds.Costs.InventoryVariations
+		case "production_based_costs":
+			return ds.Costs.ProductionBasedCosts
+		case "production_based_margin":
+			return ds.Costs.ProductionBasedMargin
+		}
+	case "capex":
+		switch field {
+		case "sustaining":
+			return ds.CAPEX.Sustaining
+		case "pbr_net_cash_flow":
+			return ds.CAPEX.PBRNetCashFlow
+		}
+	case "cash_cost":
+		switch field {
+		case "cash_cost_per_oz_silver":
+			return ds.CashCost.CashCostPerOzSilver
+		case "aisc_per_oz_silver":
+			return ds.CashCost.AISCPerOzSilver
+		}
+	}
+	return 0
+}
@@ -12,24 +12,41 @@ import (
 
 type UseCase interface {
 	GetSummary(ctx context.Context, req *SummaryRequest) (*SummaryReport, error)
+	GetSummaries(ctx context.Context, reqs []*SummaryRequest) (map[int64]*SummaryReport, error)
 	SaveReport(ctx context.Context, req *SaveReportRequest, userID int64) (*SavedReport, error)
 	ListSavedReports(ctx context.Context, companyID int64, year int) ([]*SavedReport, error)
 	CompareReports(ctx context.Context, reportIDs []int64) (*CompareReportsResponse, error)
+	CompareScenarios(ctx context.Context, reportIDs []int64) (*ScenarioComparison, error)
 	GetReportCompanyID(ctx context.Context, reportID int64) (int64, error)
+	GetBenchmark(ctx context.Context, req *BenchmarkRequest) (*BenchmarkReport, error)
+	CheckIntegrity(ctx context.Context, req *IntegrityRequest) (*IntegrityReport, error)
+	GetTTM(ctx context.Context, req *TTMRequest) (*TTMReport, error)
+	GetMineralMargin(ctx context.Context, req *MineralMarginRequest) (*MineralMarginReport, error)
+	GetMarginWaterfall(ctx context.Context, req *MarginWaterfallRequest) (*MarginWaterfallReport, error)
+	GetSensitivity(ctx context.Context, req *SensitivityRequest) (*SensitivityReport, error)
+	GetVariance(ctx context.Context, req *VarianceRequest) (*VarianceReport, error)
+	GetWeightedGrade(ctx context.Context, req *WeightedGradeRequest) (*WeightedGradeReport, error)
+	GetReconciliation(ctx context.Context, req *ReconcileRequest) (*ReconciliationResult, error)
 }
 
 type useCase struct {
-	repo       Repository
-	calculator *Calculator
+	repo           Repository
+	gramsPerTroyOz float64 // Deployment override of GramsPerTroyOz, 0 for the default
 }
 
-func NewUseCase(repo Repository) UseCase {
+// NewUseCase creates a reports use case; gramsPerTroyOz <= 0 uses GramsPerTroyOz
+func NewUseCase(repo Repository, gramsPerTroyOz float64) UseCase {
 	return &useCase{
-		repo:       repo,
-		calculator: NewCalculator(),
+		repo:           repo,
+		gramsPerTroyOz: gramsPerTroyOz,
 	}
 }
 
+// newCalculator returns the company's calculator with the deployment's grams per troy ounce
+func (uc *useCase) newCalculator(config *CompanyConfig) *Calculator {
+	return NewCalculatorForCompany(config).WithGramsPerTroyOz(uc.gramsPerTroyOz)
+}
+
 func (uc *useCase) GetSummary(ctx context.Context, req *SummaryRequest) (*SummaryReport, error) {
 	// Get company name
 	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
@@ -116,8 +133,10 @@ func (uc *useCase) GetSummary(ctx context.Context, req *SummaryRequest) (*Summar
 		capexActual, capexBudget,
 	)
 
-	// Group data by month and calculate metrics
+	// Group data by month and calculate metrics using the company's net cash flow definition
+	calculator := uc.newCalculator(companyConfig)
 	months := uc.buildMonthlyData(
+		calculator,
 		req.Year,
 		pbrActual, pbrBudget,
 		doreActual, doreBudget,
@@ -127,6 +146,12 @@ func (uc *useCase) GetSummary(ctx context.Context, req *SummaryRequest) (*Summar
 		monthsFilter,
 	)
 
+	if req.IncludeForecast {
+		if err := uc.addForecast(ctx, calculator, req, months); err != nil {
+			return nil, err
+		}
+	}
+
 	return &SummaryReport{
 		CompanyID:   req.CompanyID,
 		CompanyName: companyName,
@@ -155,6 +180,7 @@ func (uc *useCase) parseMonthsFilter(monthsStr string) map[int]bool {
 }
 
 func (uc *useCase) buildMonthlyData(
+	calculator *Calculator,
 	year int,
 	pbrActual, pbrBudget []*data.PBRData,
 	doreActual, doreBudget []*data.DoreData,
@@ -202,7 +228,7 @@ func (uc *useCase) buildMonthlyData(
 
 		var actualDataSet *DataSet
 		if actualHasData {
-			actualDataSet = uc.calculator.CalculateDataSet(
+			actualDataSet = calculator.CalculateDataSet(
 				pbrActualByMonth[month],
 				doreActualByMonth[month],
 				financialActualByMonth[month],
@@ -213,7 +239,7 @@ func (uc *useCase) buildMonthlyData(
 
 		var budgetDataSet *DataSet
 		if budgetHasData {
-			budgetDataSet = uc.calculator.CalculateDataSet(
+			budgetDataSet = calculator.CalculateDataSet(
 				pbrBudgetByMonth[month],
 				doreBudgetByMonth[month],
 				financialBudgetByMonth[month],
@@ -225,7 +251,7 @@ func (uc *useCase) buildMonthlyData(
 		// Calculate variance if both actual and budget exist
 		var variance *VarianceData
 		if actualDataSet != nil && budgetDataSet != nil {
-			variance = uc.calculator.CalculateVarianceData(actualDataSet, budgetDataSet)
+			variance = calculator.CalculateVarianceData(actualDataSet, budgetDataSet)
 		}
 
 		// Calculate YTD only up to the last loaded actual month
@@ -237,14 +263,14 @@ func (uc *useCase) buildMonthlyData(
 			actualFinancial := financialActualByMonth[month]
 			budgetFinancial := financialBudgetByMonth[month]
 
-			ytdActual = uc.calculator.AccumulateYTD(ytdActual, actualDataSet, actualDore, actualFinancial)
+			ytdActual = calculator.AccumulateYTD(ytdActual, actualDataSet, actualDore, actualFinancial)
 			if budgetHasData {
-				ytdBudget = uc.calculator.AccumulateYTD(ytdBudget, budgetDataSet, budgetDore, budgetFinancial)
+				ytdBudget = calculator.AccumulateYTD(ytdBudget, budgetDataSet, budgetDore, budgetFinancial)
 			}
 
 			var ytdVariance *VarianceData
 			if ytdActual != nil && ytdBudget != nil {
-				ytdVariance = uc.calculator.CalculateVarianceData(ytdActual, ytdBudget)
+				ytdVariance = calculator.CalculateVarianceData(ytdActual, ytdBudget)
 			}
 
 			ytd = &YTDData{
@@ -266,6 +292,65 @@ func (uc *useCase) buildMonthlyData(
 	return months
 }
 
+// addForecast fills the forecast of each month (forecast data is versioned like actual, so
+// version 1) and the variance of actual against it
+func (uc *useCase) addForecast(ctx context.Context, calculator *Calculator, req *SummaryRequest, months []MonthlyData) error {
+	const forecastVersion = 1
+	forecastType := string(data.DataTypeForecast)
+
+	pbrForecast, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, forecastType, forecastVersion)
+	if err != nil {
+		return err
+	}
+	doreForecast, err := uc.repo.GetDoreData(ctx, req.CompanyID, req.Year, forecastType, forecastVersion)
+	if err != nil {
+		return err
+	}
+	financialForecast, err := uc.repo.GetFinancialData(ctx, req.CompanyID, req.Year, forecastType, forecastVersion)
+	if err != nil {
+		return err
+	}
+	opexForecast, err := uc.repo.GetOPEXData(ctx, req.CompanyID, req.Year, forecastType, forecastVersion)
+	if err != nil {
+		return err
+	}
+	capexForecast, err := uc.repo.GetCAPEXData(ctx, req.CompanyID, req.Year, forecastType, forecastVersion)
+	if err != nil {
+		return err
+	}
+
+	pbrByMonth := groupPBRByMonth(pbrForecast)
+	doreByMonth := groupDoreByMonth(doreForecast)
+	financialByMonth := groupFinancialByMonth(financialForecast)
+	opexByMonth := groupOPEXByMonth(opexForecast)
+	capexByMonth := groupCAPEXByMonth(capexForecast)
+
+	for i := range months {
+		monthDate, err := time.Parse("2006-01", months[i].Month)
+		if err != nil {
+			return err
+		}
+		month := int(monthDate.Month())
+
+		if pbrByMonth[month] == nil && doreByMonth[month] == nil && financialByMonth[month] == nil &&
+			len(opexByMonth[month]) == 0 && len(capexByMonth[month]) == 0 {
+			continue
+		}
+
+		months[i].Forecast = calculator.CalculateDataSet(
+			pbrByMonth[month],
+			doreByMonth[month],
+			financialByMonth[month],
+			opexByMonth[month],
+			capexByMonth[month],
+		)
+		if months[i].Actual != nil {
+			months[i].ForecastVariance = calculator.CalculateVarianceData(months[i].Actual, months[i].Forecast)
+		}
+	}
+	return nil
+}
+
 func (uc *useCase) buildCoverage(
 	pbrActual, pbrBudget []*data.PBRData,
 	doreActual, doreBudget []*data.DoreData,
@@ -294,7 +379,13 @@ func (uc *useCase) buildCoverage(
 		opexBudgetByMonth, capexBudgetByMonth,
 	)
 
-	coverage := &DataCoverage{
+	return newDataCoverage(actualMonths, budgetMonths)
+}
+
+// newDataCoverage describes the months (1-12, in order) with actual and budget data.
+// The summary and the detail reports share it so they agree on what is loaded.
+func newDataCoverage(actualMonths, budgetMonths []int) *DataCoverage {
+	return &DataCoverage{
 		ActualMonths:      actualMonths,
 		BudgetMonths:      budgetMonths,
 		ActualLastMonth:   lastMonth(actualMonths),
@@ -306,8 +397,17 @@ func (uc *useCase) buildCoverage(
 		HasCompleteActual: len(actualMonths) == 12,
 		HasCompleteBudget: len(budgetMonths) == 12,
 	}
+}
 
-	return coverage
+// monthsWithData returns the months of records grouped by month (see groupPBRByMonth), in order
+func monthsWithData[V any](byMonth map[int]V) []int {
+	months := make([]int, 0, 12)
+	for month := 1; month <= 12; month++ {
+		if _, ok := byMonth[month]; ok {
+			months = append(months, month)
+		}
+	}
+	return months
 }
 
 func collectMonthsWithData(
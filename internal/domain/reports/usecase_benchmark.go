@@ -0,0 +1,91 @@
+package reports
+
+import (
+	"context"
+)
+
+// GetBenchmark compares the company's actual KPIs for a month against a peer group benchmark set
+func (uc *useCase) GetBenchmark(ctx context.Context, req *BenchmarkRequest) (*BenchmarkReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	companyConfig, err := uc.repo.GetCompanyConfig(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	peerGroup := req.PeerGroup
+	if peerGroup == "" {
+		peerGroup = DefaultPeerGroup
+	}
+
+	benchmarks, err := uc.repo.GetBenchmarks(ctx, peerGroup, req.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	// Actual data always uses version 1
+	const actualVersion = 1
+
+	pbr, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, "actual", actualVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	dore, err := uc.repo.GetDoreData(ctx, req.CompanyID, req.Year, "actual", actualVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	financial, err := uc.repo.GetFinancialData(ctx, req.CompanyID, req.Year, "actual", actualVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	opex, err := uc.repo.GetOPEXData(ctx, req.CompanyID, req.Year, "actual", actualVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	capex, err := uc.repo.GetCAPEXData(ctx, req.CompanyID, req.Year, "actual", actualVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	pbrByMonth := groupPBRByMonth(pbr)
+	doreByMonth := groupDoreByMonth(dore)
+	financialByMonth := groupFinancialByMonth(financial)
+	opexByMonth := groupOPEXByMonth(opex)
+	capexByMonth := groupCAPEXByMonth(capex)
+
+	month := req.Month
+	hasData := pbrByMonth[month] != nil ||
+		doreByMonth[month] != nil ||
+		financialByMonth[month] != nil ||
+		len(opexByMonth[month]) > 0 ||
+		len(capexByMonth[month]) > 0
+
+	var ds *DataSet
+	if hasData {
+		calculator := uc.newCalculator(companyConfig)
+		ds = calculator.CalculateDataSet(
+			pbrByMonth[month],
+			doreByMonth[month],
+			financialByMonth[month],
+			opexByMonth[month],
+			capexByMonth[month],
+		)
+	}
+
+	return &BenchmarkReport{
+		CompanyID:   req.CompanyID,
+		CompanyName: companyName,
+		Year:        req.Year,
+		Month:       month,
+		PeerGroup:   peerGroup,
+		HasData:     hasData,
+		Metrics:     buildBenchmarkMetrics(ds, benchmarks),
+	}, nil
+}
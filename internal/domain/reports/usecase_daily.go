@@ -0,0 +1,108 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// DailyRequest represents a request for the per-day rows of a month
+type DailyRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Year      int    `form:"year" validate:"required,gt=2000"`
+	Month     int    `form:"month" validate:"required,gte=1,lte=12"`
+	DataType  string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version   int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
+}
+
+// GetDaily returns each day's PBR-derived production and Dore/OPEX rows for a month
+func (uc *detailUseCase) GetDaily(ctx context.Context, req *DailyRequest) (*DailyReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pbrList, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	doreList, err := uc.repo.GetDoreData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	opexList, err := uc.repo.GetOPEXData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	days, totals := uc.buildDailyMetrics(req.Month, pbrList, doreList, opexList)
+
+	return &DailyReport{
+		CompanyID:   req.CompanyID,
+		CompanyName: companyName,
+		Year:        req.Year,
+		Month:       req.Month,
+		DataType:    req.DataType,
+		Version:     req.Version,
+		Days:        days,
+		Totals:      totals,
+	}, nil
+}
+
+// buildDailyMetrics groups a year's rows by day for the given month (no monthly roll-up)
+func (uc *detailUseCase) buildDailyMetrics(
+	month int,
+	pbrList []*data.PBRData,
+	doreList []*data.DoreData,
+	opexList []*data.OPEXData,
+) ([]DailyMetrics, DailyTotals) {
+	byDay := make(map[string]*DailyMetrics)
+	day := func(date time.Time) *DailyMetrics {
+		key := date.Format("2006-01-02")
+		if byDay[key] == nil {
+			byDay[key] = &DailyMetrics{Date: key, Dore: []*data.DoreData{}, OPEX: []*data.OPEXData{}}
+		}
+		return byDay[key]
+	}
+
+	var totals DailyTotals
+
+	for _, pbr := range pbrList {
+		if int(pbr.Date.Month()) != month {
+			continue
+		}
+		// PBR dates are unique per version, so a day has at most one PBR row
+		production := uc.calculator.calculateProduction(pbr)
+		day(pbr.Date).Production = &production
+		totals.TotalProductionSilverOz += production.TotalProductionSilverOz
+		totals.TotalProductionGoldOz += production.TotalProductionGoldOz
+	}
+
+	for _, dore := range doreList {
+		if int(dore.Date.Month()) != month {
+			continue
+		}
+		metrics := day(dore.Date)
+		metrics.Dore = append(metrics.Dore, dore)
+		totals.DoreProducedOz += dore.DoreProducedOz
+	}
+
+	for _, opex := range opexList {
+		if int(opex.Date.Month()) != month {
+			continue
+		}
+		metrics := day(opex.Date)
+		metrics.OPEX = append(metrics.OPEX, opex)
+		metrics.OPEXTotal += opex.Amount
+		totals.OPEXTotal += opex.Amount
+	}
+
+	days := make([]DailyMetrics, 0, len(byDay))
+	for _, key := range sortedKeys(byDay) {
+		days = append(days, *byDay[key])
+	}
+	return days, totals
+}
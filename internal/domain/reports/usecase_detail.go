@@ -2,6 +2,9 @@ package reports
 
 import (
 	"context"
+	"math"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,18 +18,34 @@ type DetailUseCase interface {
 	GetDoreDetail(ctx context.Context, req *DetailRequest) (*DoreDetailReport, error)
 	GetOPEXDetail(ctx context.Context, req *DetailRequest) (*OPEXDetailReport, error)
 	GetCAPEXDetail(ctx context.Context, req *DetailRequest) (*CAPEXDetailReport, error)
-	// NOTE: GetFinancialDetail, GetProductionDetail, GetRevenueDetail removed
-	// - Financial data is now in Summary/NSR and Summary/Costs
+	GetPBRProduction(ctx context.Context, req *ProductionRequest) (*PBRProductionReport, error)
+	GetDaily(ctx context.Context, req *DailyRequest) (*DailyReport, error)
+	GetFinancialDetail(ctx context.Context, req *DetailRequest) (*FinancialDetailReport, error)
+	// NOTE: GetProductionDetail, GetRevenueDetail removed
 	// - Production data is now in PBR and Summary/Production
 	// - Revenue data is now in Dore and Summary/NSR
 }
 
 // DetailRequest represents a request for detailed report
 type DetailRequest struct {
-	CompanyID     int64  `form:"company_id" validate:"required,gt=0"`
-	Year          int    `form:"year" validate:"required,gt=2000"`
-	Months        string `form:"months"`                                  // Optional: "1,2,3" or empty for all months
-	BudgetVersion int    `form:"budget_version" validate:"required,gte=1"` // Required: budget data version to compare against
+	CompanyID       int64  `form:"company_id" validate:"required,gt=0"`
+	Year            int    `form:"year" validate:"required,gt=2000"`
+	Months          string `form:"months"`                                   // Optional: "1,2,3" or empty for all months
+	BudgetVersion   int    `form:"budget_version" validate:"required,gte=1"` // Budget data version to compare against (query defaults to 1)
+	IncludeForecast bool   `form:"include_forecast"`                         // Optional: also return forecast data (version 1)
+
+	// Response shaping: the metric groups to return (all when empty, see projectDetailFields)
+	// and, for CAPEX, the number of largest projects kept in by_project (all when 0)
+	Fields []string `form:"fields"`
+	Top    int      `form:"top" validate:"gte=0"`
+}
+
+// ProductionRequest represents a request for production derived from PBR only
+type ProductionRequest struct {
+	CompanyID int64  `form:"company_id" validate:"required,gt=0"`
+	Year      int    `form:"year" validate:"required,gt=2000"`
+	DataType  string `form:"data_type" validate:"required,oneof=actual budget forecast"` // Optional in query, defaults to actual
+	Version   int    `form:"version" validate:"required,gte=1"`                          // Optional in query, defaults to 1
 }
 
 type detailUseCase struct {
@@ -34,10 +53,10 @@ type detailUseCase struct {
 	calculator *Calculator
 }
 
-func NewDetailUseCase(repo Repository) DetailUseCase {
+func NewDetailUseCase(repo Repository, gramsPerTroyOz float64) DetailUseCase {
 	return &detailUseCase{
 		repo:       repo,
-		calculator: NewCalculator(),
+		calculator: NewCalculator().WithGramsPerTroyOz(gramsPerTroyOz),
 	}
 }
 
@@ -66,15 +85,35 @@ func (uc *detailUseCase) GetPBRDetail(ctx context.Context, req *DetailRequest) (
 	monthsFilter := uc.parseMonthsFilter(req.Months)
 	months := uc.buildPBRMonthlyData(req.Year, pbrActual, pbrBudget, monthsFilter)
 
+	if req.IncludeForecast {
+		pbrForecast, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, string(data.DataTypeForecast), 1)
+		if err != nil {
+			return nil, err
+		}
+		// Built as the actual side: same months, same filter, so indexes line up
+		for i, forecast := range uc.buildPBRMonthlyData(req.Year, pbrForecast, nil, monthsFilter) {
+			months[i].Forecast = forecast.Actual
+		}
+	}
+
+	coverage := newDataCoverage(monthsWithData(groupPBRByMonth(pbrActual)), monthsWithData(groupPBRByMonth(pbrBudget)))
+
 	return &PBRDetailReport{
 		CompanyID:   req.CompanyID,
 		CompanyName: companyName,
 		Year:        req.Year,
 		Config:      companyConfig,
 		Months:      months,
+		Coverage:    coverage,
 	}, nil
 }
 
+// companyCalculator returns the detail calculator with a company's settings, such as its
+// realized price fallback
+func (uc *detailUseCase) companyCalculator(config *CompanyConfig) *Calculator {
+	return NewCalculatorForCompany(config).WithGramsPerTroyOz(uc.calculator.gramsPerTroyOz)
+}
+
 // GetDoreDetail returns detailed Dore report
 func (uc *detailUseCase) GetDoreDetail(ctx context.Context, req *DetailRequest) (*DoreDetailReport, error) {
 	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
@@ -107,8 +146,26 @@ func (uc *detailUseCase) GetDoreDetail(ctx context.Context, req *DetailRequest)
 		return nil, err
 	}
 
+	// Dore revenue is valued like the summary NSR, with the company's realized price fallback
+	calculator := uc.companyCalculator(companyConfig)
 	monthsFilter := uc.parseMonthsFilter(req.Months)
-	months := uc.buildDoreMonthlyData(req.Year, doreActual, doreBudget, pbrActual, pbrBudget, monthsFilter)
+	months := uc.buildDoreMonthlyData(calculator, req.Year, doreActual, doreBudget, pbrActual, pbrBudget, monthsFilter)
+
+	if req.IncludeForecast {
+		doreForecast, err := uc.repo.GetDoreData(ctx, req.CompanyID, req.Year, string(data.DataTypeForecast), 1)
+		if err != nil {
+			return nil, err
+		}
+		pbrForecast, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, string(data.DataTypeForecast), 1)
+		if err != nil {
+			return nil, err
+		}
+		for i, forecast := range uc.buildDoreMonthlyData(calculator, req.Year, doreForecast, nil, pbrForecast, nil, monthsFilter) {
+			months[i].Forecast = forecast.Actual
+		}
+	}
+
+	coverage := newDataCoverage(monthsWithData(groupDoreByMonth(doreActual)), monthsWithData(groupDoreByMonth(doreBudget)))
 
 	return &DoreDetailReport{
 		CompanyID:   req.CompanyID,
@@ -116,6 +173,7 @@ func (uc *detailUseCase) GetDoreDetail(ctx context.Context, req *DetailRequest)
 		Year:        req.Year,
 		Config:      companyConfig,
 		Months:      months,
+		Coverage:    coverage,
 	}, nil
 }
 
@@ -142,17 +200,35 @@ func (uc *detailUseCase) GetOPEXDetail(ctx context.Context, req *DetailRequest)
 	}
 
 	monthsFilter := uc.parseMonthsFilter(req.Months)
-	months, byCostCenter, bySubcategory, byExpenseType := uc.buildOPEXMonthlyData(req.Year, opexActual, opexBudget, monthsFilter)
+	costCenters := companyCostCenters(companyConfig)
+	months, byCostCenter, bySubcategory, byExpenseType := uc.buildOPEXMonthlyData(req.Year, opexActual, opexBudget, monthsFilter, costCenters)
+
+	if req.IncludeForecast {
+		opexForecast, err := uc.repo.GetOPEXData(ctx, req.CompanyID, req.Year, string(data.DataTypeForecast), 1)
+		if err != nil {
+			return nil, err
+		}
+		// The breakdowns stay actual vs budget; only the monthly detail gets the forecast
+		forecastMonths, _, _, _ := uc.buildOPEXMonthlyData(req.Year, opexForecast, nil, monthsFilter, costCenters)
+		for i, forecast := range forecastMonths {
+			months[i].Forecast = forecast.Actual
+		}
+	}
+
+	coverage := newDataCoverage(monthsWithData(groupOPEXByMonth(opexActual)), monthsWithData(groupOPEXByMonth(opexBudget)))
 
 	return &OPEXDetailReport{
-		CompanyID:     req.CompanyID,
-		CompanyName:   companyName,
-		Year:          req.Year,
-		Config:        companyConfig,
-		Months:        months,
-		ByCostCenter:  byCostCenter,
-		BySubcategory: bySubcategory,
-		ByExpenseType: byExpenseType,
+		CompanyID:        req.CompanyID,
+		CompanyName:      companyName,
+		Year:             req.Year,
+		Config:           companyConfig,
+		Months:           months,
+		ByCostCenter:     byCostCenter,
+		BySubcategory:    bySubcategory,
+		ByExpenseType:    byExpenseType,
+		CostCenterOrder:  orderedKeys(byCostCenter, costCenters),
+		ExpenseTypeOrder: orderedKeys(byExpenseType, data.ExpenseTypes),
+		Coverage:         coverage,
 	}, nil
 }
 
@@ -179,26 +255,180 @@ func (uc *detailUseCase) GetCAPEXDetail(ctx context.Context, req *DetailRequest)
 	}
 
 	monthsFilter := uc.parseMonthsFilter(req.Months)
-	months, byType, byCategory := uc.buildCAPEXMonthlyData(req.Year, capexActual, capexBudget, monthsFilter)
+	allowList := companyCAPEXAllowList(companyConfig)
+	months, byType, byCategory := uc.buildCAPEXMonthlyData(req.Year, capexActual, capexBudget, monthsFilter, allowList)
+
+	if req.IncludeForecast {
+		capexForecast, err := uc.repo.GetCAPEXData(ctx, req.CompanyID, req.Year, string(data.DataTypeForecast), 1)
+		if err != nil {
+			return nil, err
+		}
+		forecastMonths, _, _ := uc.buildCAPEXMonthlyData(req.Year, capexForecast, nil, monthsFilter, allowList)
+		for i, forecast := range forecastMonths {
+			months[i].Forecast = forecast.Actual
+		}
+	}
+
+	coverage := newDataCoverage(monthsWithData(groupCAPEXByMonth(capexActual)), monthsWithData(groupCAPEXByMonth(capexBudget)))
+
+	projectOrder := topCAPEXProjects(months, req.Top)
+	if req.Top > 0 {
+		keepCAPEXProjects(months, projectOrder)
+	}
 
 	return &CAPEXDetailReport{
-		CompanyID:   req.CompanyID,
-		CompanyName: companyName,
-		Year:        req.Year,
-		Config:      companyConfig,
-		Months:      months,
-		ByType:      byType,
-		ByCategory:  byCategory,
+		CompanyID:    req.CompanyID,
+		CompanyName:  companyName,
+		Year:         req.Year,
+		Config:       companyConfig,
+		Months:       months,
+		ByType:       byType,
+		ByCategory:   byCategory,
+		TypeOrder:    orderedKeys(byType, data.CapexTypes),
+		ProjectOrder: projectOrder,
+		Coverage:     coverage,
 	}, nil
 }
 
+// topCAPEXProjects returns the n projects (all when n <= 0) with the largest amount over
+// the months, actual plus budget as absolute values, largest first (ties alphabetical)
+func topCAPEXProjects(months []CAPEXMonthlyData, n int) []string {
+	amounts := make(map[string]float64)
+	for _, month := range months {
+		for _, detail := range []*CAPEXDetail{month.Actual, month.Budget} {
+			if detail == nil {
+				continue
+			}
+			for project, amount := range detail.ByProject {
+				amounts[project] += math.Abs(amount)
+			}
+		}
+	}
+	projects := sortedKeys(amounts)
+	sort.SliceStable(projects, func(i, j int) bool {
+		return amounts[projects[i]] > amounts[projects[j]]
+	})
+	if n > 0 && len(projects) > n {
+		projects = projects[:n]
+	}
+	return projects
+}
+
+// keepCAPEXProjects drops the projects not in keep from each month's by_project breakdowns
+func keepCAPEXProjects(months []CAPEXMonthlyData, keep []string) {
+	for _, month := range months {
+		for _, detail := range []*CAPEXDetail{month.Actual, month.Budget, month.Forecast} {
+			if detail == nil {
+				continue
+			}
+			for project := range detail.ByProject {
+				if !slices.Contains(keep, project) {
+					delete(detail.ByProject, project)
+				}
+			}
+		}
+	}
+}
+
+// sortedKeys returns the keys of m sorted alphabetically, so map-based
+// aggregations can be rendered in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orderedKeys returns the keys of m with the canonical values first (in their
+// declared order), followed by any other keys sorted alphabetically
+func orderedKeys[V any, T ~string](m map[string]V, canonical []T) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(canonical))
+	for _, value := range canonical {
+		key := string(value)
+		seen[key] = true
+		if _, ok := m[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range sortedKeys(m) {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// mineralOrder returns the codes of byMineral in mineral catalog order (by mineral ID),
+// followed by any codes missing from the catalog sorted alphabetically
+func mineralOrder[V any](byMineral map[string]V, mineralMap map[int]struct{ Code, Name string }) []string {
+	ids := make([]int, 0, len(mineralMap))
+	for id := range mineralMap {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	codes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		codes = append(codes, mineralMap[id].Code)
+	}
+	return orderedKeys(byMineral, codes)
+}
+
 // NOTE: GetFinancialDetail, GetProductionDetail, GetRevenueDetail removed
 // - Financial data is now in Summary/NSR and Summary/Costs
 // - Production data is now in PBR and Summary/Production
 // - Revenue data is now in Dore and Summary/NSR
 
+// GetPBRProduction returns monthly production derived purely from PBR (no Dore needed),
+// so ounces produced are available before Dore prices are loaded
+func (uc *detailUseCase) GetPBRProduction(ctx context.Context, req *ProductionRequest) (*PBRProductionReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pbrList, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PBRProductionReport{
+		CompanyID:   req.CompanyID,
+		CompanyName: companyName,
+		Year:        req.Year,
+		DataType:    req.DataType,
+		Version:     req.Version,
+		Months:      uc.buildPBRProductionMonths(req.Year, pbrList),
+	}, nil
+}
+
 // Helper methods
 
+// buildPBRProductionMonths calculates production for each month with PBR data
+func (uc *detailUseCase) buildPBRProductionMonths(year int, pbrList []*data.PBRData) []PBRProductionMonth {
+	pbrByMonth := groupPBRByMonth(pbrList)
+
+	months := make([]PBRProductionMonth, 0, 12)
+	for month := 1; month <= 12; month++ {
+		monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+
+		var production *ProductionMetrics
+		if pbr := pbrByMonth[month]; pbr != nil {
+			metrics := uc.calculator.calculateProduction(pbr)
+			production = &metrics
+		}
+
+		months = append(months, PBRProductionMonth{
+			Month:      monthKey,
+			Production: production,
+		})
+	}
+
+	return months
+}
+
 func (uc *detailUseCase) parseMonthsFilter(monthsStr string) map[int]bool {
 	if monthsStr == "" {
 		return nil // No filter, return all months
@@ -266,8 +496,8 @@ func (uc *detailUseCase) buildPBRDetail(pbr *data.PBRData) *PBRDetail {
 	totalMoved := pbr.OreMinedT + pbr.WasteMinedT
 
 	// Calculate production
-	silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / 31.1035
-	goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / 31.1035
+	silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / uc.calculator.gramsPerTroyOz
+	goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / uc.calculator.gramsPerTroyOz
 
 	return &PBRDetail{
 		// Mining - Ore breakdown
@@ -362,6 +592,7 @@ func (uc *detailUseCase) calculatePBRVariance(actual, budget *PBRDetail) *PBRVar
 
 // buildDoreMonthlyData builds Dore monthly data with variances
 func (uc *detailUseCase) buildDoreMonthlyData(
+	calculator *Calculator,
 	year int,
 	doreActual, doreBudget []*data.DoreData,
 	pbrActual, pbrBudget []*data.PBRData,
@@ -381,8 +612,8 @@ func (uc *detailUseCase) buildDoreMonthlyData(
 
 		monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
 
-		actual := uc.buildDoreDetail(doreActualByMonth[month], pbrActualByMonth[month])
-		budget := uc.buildDoreDetail(doreBudgetByMonth[month], pbrBudgetByMonth[month])
+		actual := uc.buildDoreDetail(calculator, doreActualByMonth[month], pbrActualByMonth[month])
+		budget := uc.buildDoreDetail(calculator, doreBudgetByMonth[month], pbrBudgetByMonth[month])
 
 		var variance *DoreVariance
 		if actual != nil && budget != nil {
@@ -400,14 +631,15 @@ func (uc *detailUseCase) buildDoreMonthlyData(
 	return months
 }
 
-func (uc *detailUseCase) buildDoreDetail(dore *data.DoreData, pbr *data.PBRData) *DoreDetail {
+// buildDoreDetail derives a Dore month, valuing payable metal at the calculator's realized
+// prices (RealizedPrice* are the prices used, after any fallback to the PBR price)
+func (uc *detailUseCase) buildDoreDetail(calculator *Calculator, dore *data.DoreData, pbr *data.PBRData) *DoreDetail {
 	if dore == nil {
 		return nil
 	}
 
 	// Metal in dore (before adjustments)
-	metalInDoreSilverOz := dore.DoreProducedOz * (dore.SilverGradePct / 100)
-	metalInDoreGoldOz := dore.DoreProducedOz * (dore.GoldGradePct / 100)
+	metalInDoreSilverOz, metalInDoreGoldOz := dore.MetalOz()
 
 	// Metal adjusted (after adjustments)
 	metalAdjustedSilverOz := metalInDoreSilverOz + dore.SilverAdjustmentOz
@@ -422,13 +654,24 @@ func (uc *detailUseCase) buildDoreDetail(dore *data.DoreData, pbr *data.PBRData)
 	payableGoldOz := metalAdjustedGoldOz - deductionsGoldOz
 
 	// Gross revenue
-	grossRevenueSilver := payableSilverOz * dore.RealizedPriceSilver
-	grossRevenueGold := payableGoldOz * dore.RealizedPriceGold
+	realizedPriceSilver, realizedPriceGold := calculator.realizedPrices(dore)
+	grossRevenueSilver := payableSilverOz * realizedPriceSilver
+	grossRevenueGold := payableGoldOz * realizedPriceGold
 	grossRevenueTotal := grossRevenueSilver + grossRevenueGold
 
 	// Charges
 	totalCharges := dore.TreatmentCharge + dore.RefiningDeductionsAu
 
+	// Per-metal attribution: treatment is charged on the whole doré, so it is split
+	// by payable ounces (all silver when nothing is payable); refining is gold only
+	treatmentChargeSilver := dore.TreatmentCharge
+	if payableOz := payableSilverOz + payableGoldOz; payableOz != 0 {
+		treatmentChargeSilver = dore.TreatmentCharge * payableSilverOz / payableOz
+	}
+	treatmentChargeGold := dore.TreatmentCharge - treatmentChargeSilver
+	chargesSilver := treatmentChargeSilver
+	chargesGold := treatmentChargeGold + dore.RefiningDeductionsAu
+
 	// NSR Dore
 	nsrDore := grossRevenueTotal - totalCharges
 
@@ -450,19 +693,40 @@ func (uc *detailUseCase) buildDoreDetail(dore *data.DoreData, pbr *data.PBRData)
 		PayableGoldOz:         payableGoldOz,
 		PBRPriceSilver:        dore.PBRPriceSilver,
 		PBRPriceGold:          dore.PBRPriceGold,
-		RealizedPriceSilver:   dore.RealizedPriceSilver,
-		RealizedPriceGold:     dore.RealizedPriceGold,
+		RealizedPriceSilver:   realizedPriceSilver,
+		RealizedPriceGold:     realizedPriceGold,
 		GrossRevenueSilver:    grossRevenueSilver,
 		GrossRevenueGold:      grossRevenueGold,
 		GrossRevenueTotal:     grossRevenueTotal,
 		TreatmentCharge:       dore.TreatmentCharge,
 		RefiningDeductionsAu:  dore.RefiningDeductionsAu,
 		TotalCharges:          totalCharges,
+		TreatmentChargeSilver: treatmentChargeSilver,
+		TreatmentChargeGold:   treatmentChargeGold,
+		ChargesSilver:         chargesSilver,
+		ChargesGold:           chargesGold,
 		NSRDore:               nsrDore,
+		SourcePBR:             doreSourcePBR(pbr),
 		HasData:               true,
 	}
 }
 
+// doreSourcePBR exposes the PBR row a Dore month was matched with, so analysts
+// can trace which processing values fed it
+func doreSourcePBR(pbr *data.PBRData) *DoreSourcePBR {
+	if pbr == nil {
+		return nil
+	}
+	return &DoreSourcePBR{
+		Date:                  pbr.Date.Format("2006-01-02"),
+		TotalTonnesProcessed:  pbr.TotalTonnesProcessed,
+		FeedGradeSilverGpt:    pbr.FeedGradeSilverGpt,
+		FeedGradeGoldGpt:      pbr.FeedGradeGoldGpt,
+		RecoveryRateSilverPct: pbr.RecoveryRateSilverPct,
+		RecoveryRateGoldPct:   pbr.RecoveryRateGoldPct,
+	}
+}
+
 func (uc *detailUseCase) calculateDoreVariance(actual, budget *DoreDetail) *DoreVariance {
 	return &DoreVariance{
 		DoreProducedOz:        VarianceMetric{Actual: actual.DoreProducedOz, Budget: budget.DoreProducedOz, Variance: actual.DoreProducedOz - budget.DoreProducedOz, VariancePct: calculateVariancePct(actual.DoreProducedOz, budget.DoreProducedOz)},
@@ -488,11 +752,12 @@ func (uc *detailUseCase) calculateDoreVariance(actual, budget *DoreDetail) *Dore
 	}
 }
 
-// buildOPEXMonthlyData builds OPEX monthly data with variances and aggregations
+// buildOPEXMonthlyData builds OPEX monthly data with variances and aggregations of the cost centers
 func (uc *detailUseCase) buildOPEXMonthlyData(
 	year int,
 	opexActual, opexBudget []*data.OPEXData,
 	monthsFilter map[int]bool,
+	costCenters []string,
 ) ([]OPEXMonthlyData, map[string]OPEXCostCenterData, map[string]OPEXSubcategoryData, map[string]OPEXExpenseTypeData) {
 	opexActualByMonth := groupOPEXByMonth(opexActual)
 	opexBudgetByMonth := groupOPEXByMonth(opexBudget)
@@ -517,21 +782,23 @@ func (uc *detailUseCase) buildOPEXMonthlyData(
 
 		monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
 
-		actual := uc.buildOPEXDetail(opexActualByMonth[month])
-		budget := uc.buildOPEXDetail(opexBudgetByMonth[month])
+		actual := uc.buildOPEXDetail(opexActualByMonth[month], costCenters)
+		budget := uc.buildOPEXDetail(opexBudgetByMonth[month], costCenters)
 
 		// Aggregate by cost center
 		if actual != nil {
-			costCenterTotals["Mine"] = struct{ Actual, Budget float64 }{costCenterTotals["Mine"].Actual + actual.Mine, costCenterTotals["Mine"].Budget}
-			costCenterTotals["Processing"] = struct{ Actual, Budget float64 }{costCenterTotals["Processing"].Actual + actual.Processing, costCenterTotals["Processing"].Budget}
-			costCenterTotals["G&A"] = struct{ Actual, Budget float64 }{costCenterTotals["G&A"].Actual + actual.GA, costCenterTotals["G&A"].Budget}
-			costCenterTotals["Transport & Shipping"] = struct{ Actual, Budget float64 }{costCenterTotals["Transport & Shipping"].Actual + actual.TransportShipping, costCenterTotals["Transport & Shipping"].Budget}
+			for center, amount := range actual.ByCostCenter {
+				totals := costCenterTotals[center]
+				totals.Actual += amount
+				costCenterTotals[center] = totals
+			}
 		}
 		if budget != nil {
-			costCenterTotals["Mine"] = struct{ Actual, Budget float64 }{costCenterTotals["Mine"].Actual, costCenterTotals["Mine"].Budget + budget.Mine}
-			costCenterTotals["Processing"] = struct{ Actual, Budget float64 }{costCenterTotals["Processing"].Actual, costCenterTotals["Processing"].Budget + budget.Processing}
-			costCenterTotals["G&A"] = struct{ Actual, Budget float64 }{costCenterTotals["G&A"].Actual, costCenterTotals["G&A"].Budget + budget.GA}
-			costCenterTotals["Transport & Shipping"] = struct{ Actual, Budget float64 }{costCenterTotals["Transport & Shipping"].Actual, costCenterTotals["Transport & Shipping"].Budget + budget.TransportShipping}
+			for center, amount := range budget.ByCostCenter {
+				totals := costCenterTotals[center]
+				totals.Budget += amount
+				costCenterTotals[center] = totals
+			}
 		}
 
 		// Aggregate by subcategory from raw data (for actual)
@@ -612,12 +879,18 @@ func (uc *detailUseCase) buildOPEXMonthlyData(
 	return months, byCostCenter, bySubcategory, byExpenseType
 }
 
-func (uc *detailUseCase) buildOPEXDetail(opexList []*data.OPEXData) *OPEXDetail {
+// buildOPEXDetail sums the OPEX of a month by cost center; the total includes every
+// configured cost center and inventory variations
+func (uc *detailUseCase) buildOPEXDetail(opexList []*data.OPEXData, costCenters []string) *OPEXDetail {
 	if len(opexList) == 0 {
 		return nil
 	}
 
-	var mine, processing, ga, transport, inventory float64
+	var inventory float64
+	byCostCenter := make(map[string]float64, len(costCenters))
+	for _, center := range costCenters {
+		byCostCenter[center] = 0
+	}
 	bySubcategory := make(map[string]float64)
 	byExpenseType := make(map[string]float64)
 
@@ -626,7 +899,7 @@ func (uc *detailUseCase) buildOPEXDetail(opexList []*data.OPEXData) *OPEXDetail
 		byExpenseType[opex.ExpenseType] += opex.Amount
 
 		// Inventory variations handling
-		if opex.Subcategory == "Inventory Variation" || opex.Subcategory == "Stockpile/WIP" || opex.Subcategory == "Inventory Variations" {
+		if opex.Subcategory == data.SubcategoryInventoryVariations {
 			inventory += opex.Amount
 			bySubcategory[opex.Subcategory] += opex.Amount
 			continue
@@ -634,25 +907,22 @@ func (uc *detailUseCase) buildOPEXDetail(opexList []*data.OPEXData) *OPEXDetail
 
 		bySubcategory[opex.Subcategory] += opex.Amount
 
-		switch opex.CostCenter {
-		case "Mine":
-			mine += opex.Amount
-		case "Processing":
-			processing += opex.Amount
-		case "G&A":
-			ga += opex.Amount
-		case "Transport & Shipping":
-			transport += opex.Amount
+		if _, ok := byCostCenter[opex.CostCenter]; ok {
+			byCostCenter[opex.CostCenter] += opex.Amount
 		}
 	}
 
-	total := mine + processing + ga + transport + inventory
+	total := inventory
+	for _, amount := range byCostCenter {
+		total += amount
+	}
 
 	return &OPEXDetail{
-		Mine:                mine,
-		Processing:          processing,
-		GA:                  ga,
-		TransportShipping:   transport,
+		Mine:                byCostCenter[string(data.CostCenterMine)],
+		Processing:          byCostCenter[string(data.CostCenterProcessing)],
+		GA:                  byCostCenter[string(data.CostCenterGA)],
+		TransportShipping:   byCostCenter[string(data.CostCenterTransport)],
+		ByCostCenter:        byCostCenter,
 		InventoryVariations: inventory,
 		Total:               total,
 		BySubcategory:       bySubcategory,
@@ -677,6 +947,7 @@ func (uc *detailUseCase) buildCAPEXMonthlyData(
 	year int,
 	capexActual, capexBudget []*data.CAPEXData,
 	monthsFilter map[int]bool,
+	allowList capexAllowList,
 ) ([]CAPEXMonthlyData, map[string]CAPEXTypeData, map[string]CAPEXCategoryData) {
 	capexActualByMonth := groupCAPEXByMonth(capexActual)
 	capexBudgetByMonth := groupCAPEXByMonth(capexBudget)
@@ -692,8 +963,8 @@ func (uc *detailUseCase) buildCAPEXMonthlyData(
 
 		monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
 
-		actual := uc.buildCAPEXDetail(capexActualByMonth[month])
-		budget := uc.buildCAPEXDetail(capexBudgetByMonth[month])
+		actual := uc.buildCAPEXDetail(capexActualByMonth[month], allowList)
+		budget := uc.buildCAPEXDetail(capexBudgetByMonth[month], allowList)
 
 		// Aggregate by type
 		if actual != nil {
@@ -753,8 +1024,9 @@ func (uc *detailUseCase) buildCAPEXMonthlyData(
 	return months, byType, byCategory
 }
 
-// Required CAPEX categories - all must be present in response
-var requiredCAPEXCategories = []string{
+// defaultCAPEXCategories are the CAPEX categories reported for companies that have not
+// configured their own (capex_categories in company settings)
+var defaultCAPEXCategories = []string{
 	// Sustaining Capital (PBR)
 	"Pre-Stripping and Capital Developments",
 	"Exploration/Mine Geology",
@@ -778,8 +1050,9 @@ var requiredCAPEXCategories = []string{
 	"IFRS16",
 }
 
-// Required CAPEX projects - all must be present in response
-var requiredCAPEXProjects = []string{
+// defaultCAPEXProjects are the CAPEX projects reported for companies that have not
+// configured their own (capex_projects in company settings)
+var defaultCAPEXProjects = []string{
 	"C487EY21001 - CAPEX EXPLORACIONES",
 	"C487MY25001",
 	"C487MY25002",
@@ -801,21 +1074,41 @@ var requiredCAPEXProjects = []string{
 	"C48703300",
 }
 
-func (uc *detailUseCase) buildCAPEXDetail(capexList []*data.CAPEXData) *CAPEXDetail {
+// capexAllowList are the categories and projects every CAPEX detail reports, zero without data
+type capexAllowList struct {
+	Categories []string
+	Projects   []string
+}
+
+// companyCAPEXAllowList returns the company's configured CAPEX categories and projects,
+// or the default ones
+func companyCAPEXAllowList(config *CompanyConfig) capexAllowList {
+	allowList := capexAllowList{Categories: defaultCAPEXCategories, Projects: defaultCAPEXProjects}
+	if config != nil && len(config.CAPEXCategories) > 0 {
+		allowList.Categories = config.CAPEXCategories
+	}
+	if config != nil && len(config.CAPEXProjects) > 0 {
+		allowList.Projects = config.CAPEXProjects
+	}
+	return allowList
+}
+
+func (uc *detailUseCase) buildCAPEXDetail(capexList []*data.CAPEXData, allowList capexAllowList) *CAPEXDetail {
 	if len(capexList) == 0 {
 		return nil
 	}
 
 	var sustaining, project, leasing, accretion float64
+	var leaseAdditions, leaseCashOutflows float64
 
 	// Initialize maps with all required keys set to 0
 	byCategory := make(map[string]float64)
-	for _, cat := range requiredCAPEXCategories {
+	for _, cat := range allowList.Categories {
 		byCategory[cat] = 0
 	}
 
 	byProject := make(map[string]float64)
-	for _, proj := range requiredCAPEXProjects {
+	for _, proj := range allowList.Projects {
 		byProject[proj] = 0
 	}
 
@@ -827,6 +1120,11 @@ func (uc *detailUseCase) buildCAPEXDetail(capexList []*data.CAPEXData) *CAPEXDet
 			project += capex.Amount
 		case "leasing":
 			leasing += capex.Amount
+			if data.IsLeaseCashOutflow(capex.Category) {
+				leaseCashOutflows += capex.Amount
+			} else {
+				leaseAdditions += capex.Amount
+			}
 		}
 		// Accretion of Mine Closure Liability - now comes from the field
 		accretion += capex.AccretionOfMineClosureLiability
@@ -850,6 +1148,8 @@ func (uc *detailUseCase) buildCAPEXDetail(capexList []*data.CAPEXData) *CAPEXDet
 		Project:                         project,
 		Leasing:                         leasing,
 		AccretionOfMineClosureLiability: accretion,
+		LeaseAdditions:                  leaseAdditions,
+		LeaseCashOutflows:               leaseCashOutflows,
 		Total:                           total,
 		ByCategory:                      byCategory,
 		ByProject:                       byProject,
@@ -874,11 +1174,45 @@ func (uc *detailUseCase) calculateCAPEXVariance(actual, budget *CAPEXDetail) *CA
 		Project:                         VarianceMetric{Actual: actual.Project, Budget: budget.Project, Variance: actual.Project - budget.Project, VariancePct: calculateVariancePct(actual.Project, budget.Project)},
 		Leasing:                         VarianceMetric{Actual: actual.Leasing, Budget: budget.Leasing, Variance: actual.Leasing - budget.Leasing, VariancePct: calculateVariancePct(actual.Leasing, budget.Leasing)},
 		AccretionOfMineClosureLiability: VarianceMetric{Actual: actual.AccretionOfMineClosureLiability, Budget: budget.AccretionOfMineClosureLiability, Variance: actual.AccretionOfMineClosureLiability - budget.AccretionOfMineClosureLiability, VariancePct: calculateVariancePct(actual.AccretionOfMineClosureLiability, budget.AccretionOfMineClosureLiability)},
+		LeaseAdditions:                  VarianceMetric{Actual: actual.LeaseAdditions, Budget: budget.LeaseAdditions, Variance: actual.LeaseAdditions - budget.LeaseAdditions, VariancePct: calculateVariancePct(actual.LeaseAdditions, budget.LeaseAdditions)},
+		LeaseCashOutflows:               VarianceMetric{Actual: actual.LeaseCashOutflows, Budget: budget.LeaseCashOutflows, Variance: actual.LeaseCashOutflows - budget.LeaseCashOutflows, VariancePct: calculateVariancePct(actual.LeaseCashOutflows, budget.LeaseCashOutflows)},
 		Total:                           VarianceMetric{Actual: actual.Total, Budget: budget.Total, Variance: actual.Total - budget.Total, VariancePct: calculateVariancePct(actual.Total, budget.Total)},
 	}
 }
 
 // buildFinancialMonthlyData builds Financial monthly data with variances
+// GetFinancialDetail returns detailed Financial report: shipping & selling, sales taxes,
+// royalties and other deductions per month against budget
+func (uc *detailUseCase) GetFinancialDetail(ctx context.Context, req *DetailRequest) (*FinancialDetailReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	financialActual, err := uc.repo.GetFinancialData(ctx, req.CompanyID, req.Year, "actual", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	financialBudget, err := uc.repo.GetFinancialData(ctx, req.CompanyID, req.Year, "budget", req.BudgetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	monthsFilter := uc.parseMonthsFilter(req.Months)
+	months := uc.buildFinancialMonthlyData(req.Year, financialActual, financialBudget, monthsFilter)
+
+	coverage := newDataCoverage(monthsWithData(groupFinancialByMonth(financialActual)), monthsWithData(groupFinancialByMonth(financialBudget)))
+
+	return &FinancialDetailReport{
+		CompanyID:   req.CompanyID,
+		CompanyName: companyName,
+		Year:        req.Year,
+		Months:      months,
+		Coverage:    coverage,
+	}, nil
+}
+
 func (uc *detailUseCase) buildFinancialMonthlyData(
 	year int,
 	financialActual, financialBudget []*data.FinancialData,
@@ -954,7 +1288,7 @@ func (uc *detailUseCase) buildProductionMonthlyData(
 	productionActual, productionBudget []*data.ProductionData,
 	mineralMap map[int]struct{ Code, Name string },
 	monthsFilter map[int]bool,
-) ([]ProductionMonthlyData, map[string]ProductionMineralData) {
+) ([]ProductionMonthlyData, map[string]ProductionMineralData, []string) {
 	pbrActualByMonth := groupPBRByMonth(pbrActual)
 	pbrBudgetByMonth := groupPBRByMonth(pbrBudget)
 	productionActualByMonth := groupProductionByMonth(productionActual)
@@ -1058,7 +1392,7 @@ func (uc *detailUseCase) buildProductionMonthlyData(
 		}
 	}
 
-	return months, byMineral
+	return months, byMineral, mineralOrder(byMineral, mineralMap)
 }
 
 func (uc *detailUseCase) buildProductionDetail(pbr *data.PBRData, productionList []*data.ProductionData, mineralMap map[int]struct{ Code, Name string }) *ProductionDetail {
@@ -1066,8 +1400,8 @@ func (uc *detailUseCase) buildProductionDetail(pbr *data.PBRData, productionList
 
 	// Add Silver and Gold from PBR
 	if pbr != nil {
-		silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / 31.1035
-		goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / 31.1035
+		silverOz := pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / uc.calculator.gramsPerTroyOz
+		goldOz := pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / uc.calculator.gramsPerTroyOz
 		byMineral["AG"] = silverOz
 		byMineral["AU"] = goldOz
 	}
@@ -1083,8 +1417,8 @@ func (uc *detailUseCase) buildProductionDetail(pbr *data.PBRData, productionList
 
 	var silverOz, goldOz float64
 	if pbr != nil {
-		silverOz = pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / 31.1035
-		goldOz = pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / 31.1035
+		silverOz = pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateSilverPct / 100) / uc.calculator.gramsPerTroyOz
+		goldOz = pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed * (pbr.RecoveryRateGoldPct / 100) / uc.calculator.gramsPerTroyOz
 	}
 
 	return &ProductionDetail{
@@ -1108,7 +1442,7 @@ func (uc *detailUseCase) buildRevenueMonthlyData(
 	revenueActual, revenueBudget []*data.RevenueData,
 	mineralMap map[int]struct{ Code, Name string },
 	monthsFilter map[int]bool,
-) ([]RevenueMonthlyData, map[string]RevenueMineralData) {
+) ([]RevenueMonthlyData, map[string]RevenueMineralData, []string) {
 	revenueActualByMonth := groupRevenueByMonth(revenueActual)
 	revenueBudgetByMonth := groupRevenueByMonth(revenueBudget)
 
@@ -1188,7 +1522,7 @@ func (uc *detailUseCase) buildRevenueMonthlyData(
 		}
 	}
 
-	return months, byMineral
+	return months, byMineral, mineralOrder(byMineral, mineralMap)
 }
 
 func (uc *detailUseCase) buildRevenueDetail(revenueList []*data.RevenueData, mineralMap map[int]struct{ Code, Name string }) *RevenueDetail {
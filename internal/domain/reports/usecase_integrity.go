@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// CheckIntegrity validates referential integrity of a company year before it is closed:
+// every Dore month has a PBR, every Financial month has a Dore, and mineral references exist
+func (uc *useCase) CheckIntegrity(ctx context.Context, req *IntegrityRequest) (*IntegrityReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pbr, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	dore, err := uc.repo.GetDoreData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	financial, err := uc.repo.GetFinancialData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	production, err := uc.repo.GetProductionData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	revenue, err := uc.repo.GetRevenueData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	mineralMap, err := uc.repo.GetMineralMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := checkIntegrity(req.Year, pbr, dore, financial, production, revenue, mineralMap)
+
+	return &IntegrityReport{
+		CompanyID:   req.CompanyID,
+		CompanyName: companyName,
+		Year:        req.Year,
+		DataType:    req.DataType,
+		Version:     req.Version,
+		Valid:       len(violations) == 0,
+		Violations:  violations,
+	}, nil
+}
+
+// checkIntegrity returns the integrity violations of a year's datasets, ordered by month
+func checkIntegrity(
+	year int,
+	pbrList []*data.PBRData,
+	doreList []*data.DoreData,
+	financialList []*data.FinancialData,
+	productionList []*data.ProductionData,
+	revenueList []*data.RevenueData,
+	mineralMap map[int]struct{ Code, Name string },
+) []IntegrityViolation {
+	pbrByMonth := groupPBRByMonth(pbrList)
+	doreByMonth := groupDoreByMonth(doreList)
+	financialByMonth := groupFinancialByMonth(financialList)
+	productionByMonth := groupProductionByMonth(productionList)
+	revenueByMonth := groupRevenueByMonth(revenueList)
+
+	violations := []IntegrityViolation{}
+
+	for month := 1; month <= 12; month++ {
+		monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+
+		if doreByMonth[month] != nil && pbrByMonth[month] == nil {
+			violations = append(violations, IntegrityViolation{
+				Rule:    IntegrityDoreWithoutPBR,
+				Month:   monthKey,
+				Dataset: string(data.ImportDore),
+				Message: fmt.Sprintf("Dore data for %s has no matching PBR data", monthKey),
+			})
+		}
+
+		if financialByMonth[month] != nil && doreByMonth[month] == nil {
+			violations = append(violations, IntegrityViolation{
+				Rule:    IntegrityFinancialWithoutDore,
+				Month:   monthKey,
+				Dataset: string(data.ImportFinancial),
+				Message: fmt.Sprintf("Financial data for %s has no matching Dore data", monthKey),
+			})
+		}
+
+		// One violation per month, dataset and mineral
+		reported := make(map[string]bool)
+		checkMineral := func(dataset data.DataImportType, mineralID int) {
+			if _, ok := mineralMap[mineralID]; ok {
+				return
+			}
+			key := fmt.Sprintf("%s:%d", dataset, mineralID)
+			if reported[key] {
+				return
+			}
+			reported[key] = true
+			violations = append(violations, IntegrityViolation{
+				Rule:      IntegrityUnknownMineral,
+				Month:     monthKey,
+				Dataset:   string(dataset),
+				MineralID: mineralID,
+				Message:   fmt.Sprintf("%s data for %s references unknown or inactive mineral %d", dataset, monthKey, mineralID),
+			})
+		}
+		for _, production := range productionByMonth[month] {
+			checkMineral(data.ImportProduction, production.MineralID)
+		}
+		for _, revenue := range revenueByMonth[month] {
+			checkMineral(data.ImportRevenue, revenue.MineralID)
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"context"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// monthData holds the inputs of one month of a dataset (actual or budget)
+type monthData struct {
+	pbr       *data.PBRData
+	dore      *data.DoreData
+	financial *data.FinancialData
+	opex      []*data.OPEXData
+	capex     []*data.CAPEXData
+}
+
+// month returns the inputs of a month of the year
+func (yd *yearData) month(month int) monthData {
+	return monthData{
+		pbr:       yd.pbr[month],
+		dore:      yd.dore[month],
+		financial: yd.financial[month],
+		opex:      yd.opex[month],
+		capex:     yd.capex[month],
+	}
+}
+
+// hasData reports whether any input is present
+func (md monthData) hasData() bool {
+	return md.pbr != nil || md.dore != nil || md.financial != nil || len(md.opex) > 0 || len(md.capex) > 0
+}
+
+// GetMarginWaterfall decomposes the actual vs budget margin variance of a month into drivers
+func (uc *useCase) GetMarginWaterfall(ctx context.Context, req *MarginWaterfallRequest) (*MarginWaterfallReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	companyConfig, err := uc.repo.GetCompanyConfig(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Actual data always uses version 1; budget uses the requested version
+	const actualVersion = 1
+
+	actual, err := uc.loadYearData(ctx, req.CompanyID, req.Year, "actual", actualVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := uc.loadYearData(ctx, req.CompanyID, req.Year, "budget", req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildMarginWaterfall(uc.newCalculator(companyConfig), actual.month(req.Month), budget.month(req.Month))
+	report.CompanyID = req.CompanyID
+	report.CompanyName = companyName
+	report.Year = req.Year
+	report.Month = req.Month
+	report.BudgetVersion = req.Version
+
+	return report, nil
+}
+
+// marginDrivers are the calculator inputs the waterfall substitutes one at a time
+type marginDrivers struct {
+	tonnes              float64
+	gradeSilver         float64 // g/t
+	gradeGold           float64 // g/t
+	recoverySilver      float64 // %
+	recoveryGold        float64 // %
+	priceSilver         float64 // $/oz
+	priceGold           float64 // $/oz
+	productionBasedCost float64
+	gramsPerTroyOz      float64
+}
+
+func newMarginDrivers(calculator *Calculator, md monthData, costs CostMetrics) marginDrivers {
+	drivers := marginDrivers{productionBasedCost: costs.ProductionBasedCosts, gramsPerTroyOz: calculator.gramsPerTroyOz}
+	if md.pbr != nil {
+		drivers.tonnes = md.pbr.TotalTonnesProcessed
+		drivers.gradeSilver = md.pbr.FeedGradeSilverGpt
+		drivers.gradeGold = md.pbr.FeedGradeGoldGpt
+		drivers.recoverySilver = md.pbr.RecoveryRateSilverPct
+		drivers.recoveryGold = md.pbr.RecoveryRateGoldPct
+	}
+	if md.dore != nil {
+		drivers.priceSilver, drivers.priceGold = calculator.realizedPrices(md.dore)
+	}
+	return drivers
+}
+
+// revenue is the recovered metal value: tonnes x grade x recovery / grams per troy oz x price
+func (d marginDrivers) revenue() float64 {
+	silverOz := d.tonnes * d.gradeSilver * (d.recoverySilver / 100) / d.gramsPerTroyOz
+	goldOz := d.tonnes * d.gradeGold * (d.recoveryGold / 100) / d.gramsPerTroyOz
+	return silverOz*d.priceSilver + goldOz*d.priceGold
+}
+
+// buildMarginWaterfall walks from budget to actual margin replacing one driver at a time
+// (volume, grade, recovery, price, then cost). What the drivers do not explain
+// (payable deductions, charges, streaming, financial items) goes to "other", so the
+// buckets always sum to the total variance.
+func buildMarginWaterfall(calculator *Calculator, actual, budget monthData) *MarginWaterfallReport {
+	actualDS := calculator.CalculateDataSet(actual.pbr, actual.dore, actual.financial, actual.opex, actual.capex)
+	budgetDS := calculator.CalculateDataSet(budget.pbr, budget.dore, budget.financial, budget.opex, budget.capex)
+
+	actualMargin := actualDS.NSR.NetSmelterReturn - actualDS.Costs.ProductionBasedCosts
+	budgetMargin := budgetDS.NSR.NetSmelterReturn - budgetDS.Costs.ProductionBasedCosts
+	totalVariance := actualMargin - budgetMargin
+
+	a := newMarginDrivers(calculator, actual, actualDS.Costs)
+	step := newMarginDrivers(calculator, budget, budgetDS.Costs)
+
+	previous := step.revenue()
+	substitute := func(apply func()) float64 {
+		apply()
+		current := step.revenue()
+		delta := current - previous
+		previous = current
+		return delta
+	}
+
+	volume := substitute(func() { step.tonnes = a.tonnes })
+	grade := substitute(func() { step.gradeSilver, step.gradeGold = a.gradeSilver, a.gradeGold })
+	recovery := substitute(func() { step.recoverySilver, step.recoveryGold = a.recoverySilver, a.recoveryGold })
+	price := substitute(func() { step.priceSilver, step.priceGold = a.priceSilver, a.priceGold })
+	cost := -(a.productionBasedCost - step.productionBasedCost)
+	other := totalVariance - (volume + grade + recovery + price + cost)
+
+	return &MarginWaterfallReport{
+		BudgetMargin:  budgetMargin,
+		ActualMargin:  actualMargin,
+		TotalVariance: totalVariance,
+		Buckets: []WaterfallBucket{
+			{Name: WaterfallVolume, Amount: volume},
+			{Name: WaterfallGrade, Amount: grade},
+			{Name: WaterfallRecovery, Amount: recovery},
+			{Name: WaterfallPrice, Amount: price},
+			{Name: WaterfallCost, Amount: cost},
+			{Name: WaterfallOther, Amount: other},
+		},
+		HasActualData: actual.hasData(),
+		HasBudgetData: budget.hasData(),
+	}
+}
@@ -0,0 +1,151 @@
+package reports
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// GetMineralMargin computes each mineral's revenue and contribution to the margin of a company year
+func (uc *useCase) GetMineralMargin(ctx context.Context, req *MineralMarginRequest) (*MineralMarginReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	companyConfig, err := uc.repo.GetCompanyConfig(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	dore, err := uc.repo.GetDoreData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	revenue, err := uc.repo.GetRevenueData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	opex, err := uc.repo.GetOPEXData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	mineralMap, err := uc.repo.GetMineralMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Production Based Costs of the year, computed month by month like the summary
+	calculator := uc.newCalculator(companyConfig)
+	var totalCosts float64
+	for _, monthOPEX := range groupOPEXByMonth(opex) {
+		totalCosts += calculator.calculateCosts(monthOPEX).ProductionBasedCosts
+	}
+
+	minerals := buildMineralContributions(mineralRevenue(calculator, dore, revenue, mineralMap), totalCosts, req.AllocationBasis, mineralMap)
+
+	var totalRevenue float64
+	for _, m := range minerals {
+		totalRevenue += m.Revenue
+	}
+
+	return &MineralMarginReport{
+		CompanyID:       req.CompanyID,
+		CompanyName:     companyName,
+		Year:            req.Year,
+		DataType:        req.DataType,
+		Version:         req.Version,
+		AllocationBasis: req.AllocationBasis,
+		Minerals:        minerals,
+		TotalRevenue:    totalRevenue,
+		TotalCosts:      totalCosts,
+		TotalMargin:     totalRevenue - totalCosts,
+	}, nil
+}
+
+// mineralRevenue sums revenue by mineral ID. Silver and gold come from Dore gross revenue
+// (payable oz x the calculator's realized price, as in the summary NSR); Revenue rows add
+// the other minerals. In months with Dore, Revenue rows for silver and gold are skipped so
+// doré metal is not counted twice.
+func mineralRevenue(calculator *Calculator, dore []*data.DoreData, revenue []*data.RevenueData, mineralMap map[int]struct{ Code, Name string }) map[int]float64 {
+	mineralIDs := make(map[string]int, len(mineralMap))
+	for id, mineral := range mineralMap {
+		mineralIDs[mineral.Code] = id
+	}
+	silverID, hasSilver := mineralIDs["AG"]
+	goldID, hasGold := mineralIDs["AU"]
+
+	byMineral := make(map[int]float64)
+	doreMonths := make(map[int]bool)
+	for _, d := range dore {
+		doreMonths[int(d.Date.Month())] = true
+		payableSilverOz, payableGoldOz := d.PayableOz()
+		priceSilver, priceGold := calculator.realizedPrices(d)
+		if hasSilver {
+			byMineral[silverID] += payableSilverOz * priceSilver
+		}
+		if hasGold {
+			byMineral[goldID] += payableGoldOz * priceGold
+		}
+	}
+
+	for _, r := range revenue {
+		isDoreMetal := (hasSilver && r.MineralID == silverID) || (hasGold && r.MineralID == goldID)
+		if isDoreMetal && doreMonths[int(r.Date.Month())] {
+			continue
+		}
+		byMineral[r.MineralID] += r.QuantitySold * r.UnitPrice
+	}
+
+	return byMineral
+}
+
+// buildMineralContributions allocates totalCosts across minerals on the given basis.
+// Contributions always sum to total revenue - totalCosts.
+func buildMineralContributions(revenueByMineral map[int]float64, totalCosts float64, basis string, mineralMap map[int]struct{ Code, Name string }) []MineralContribution {
+	ids := make([]int, 0, len(revenueByMineral))
+	var totalRevenue float64
+	for id, revenue := range revenueByMineral {
+		if revenue == 0 {
+			continue
+		}
+		ids = append(ids, id)
+		totalRevenue += revenue
+	}
+	sort.Ints(ids)
+
+	totalMargin := totalRevenue - totalCosts
+	minerals := make([]MineralContribution, 0, len(ids))
+	for _, id := range ids {
+		revenue := revenueByMineral[id]
+
+		var allocated float64
+		switch basis {
+		case AllocationBasisEqual:
+			allocated = totalCosts / float64(len(ids))
+		default:
+			if totalRevenue != 0 {
+				allocated = totalCosts * revenue / totalRevenue
+			}
+		}
+
+		contribution := revenue - allocated
+		mineral := mineralMap[id]
+		minerals = append(minerals, MineralContribution{
+			MineralID:      id,
+			MineralCode:    mineral.Code,
+			MineralName:    mineral.Name,
+			Revenue:        revenue,
+			RevenueShare:   pctOfRevenue(revenue, totalRevenue),
+			AllocatedCosts: allocated,
+			Contribution:   contribution,
+			MarginShare:    pctOfRevenue(contribution, totalMargin),
+		})
+	}
+
+	return minerals
+}
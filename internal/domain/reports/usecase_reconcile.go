@@ -0,0 +1,314 @@
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// GetReconciliation reconciles a month of the company's live data against a comparison
+// source: the request's reference summary when given (e.g. a parsed Summary.csv),
+// otherwise the same month of a saved report of the company and year
+func (uc *useCase) GetReconciliation(ctx context.Context, req *ReconcileRequest) (*ReconciliationResult, error) {
+	reference, version := req.Reference, req.Version
+	if reference == nil {
+		saved, err := uc.repo.GetSavedReportsByIDs(ctx, []int64{req.ReportID})
+		if err != nil {
+			return nil, err
+		}
+		if len(saved) == 0 || saved[0].CompanyID != req.CompanyID || saved[0].Year != req.Year {
+			return nil, fmt.Errorf("%w: %d", ErrReportNotFound, req.ReportID)
+		}
+		reference = referenceFromSummary(&saved[0].ReportData, req.Year, req.Month)
+		if version == 0 {
+			version = saved[0].BudgetVersion
+		}
+	}
+	if version == 0 {
+		version = 1
+	}
+
+	summary, err := uc.GetSummary(ctx, &SummaryRequest{
+		CompanyID:     req.CompanyID,
+		Year:          req.Year,
+		BudgetVersion: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	actual, budget := &DataSet{}, &DataSet{}
+	if month := summaryMonth(summary.Months, req.Year, req.Month); month != nil {
+		if month.Actual != nil {
+			actual = month.Actual
+		}
+		if month.Budget != nil {
+			budget = month.Budget
+		}
+	}
+
+	return ReconcileWithOptions(actual, budget, reference, ReconcileOptions{
+		Tolerance: req.Tolerance,
+		Decimals:  req.Decimals,
+	}), nil
+}
+
+// summaryMonth returns a month of summary months, or nil when it is not there
+func summaryMonth(months []MonthlyData, year, month int) *MonthlyData {
+	monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	for i := range months {
+		if months[i].Month == monthKey {
+			return &months[i]
+		}
+	}
+	return nil
+}
+
+// referenceFromSummary takes the reference values of a month out of a summary report,
+// e.g. a saved snapshot. A month the summary does not have compares as all zero.
+func referenceFromSummary(report *SummaryReport, year, month int) *ReferenceSummary {
+	reference := &ReferenceSummary{
+		Month:  month,
+		Values: make(map[string]ReferenceValue, len(summaryMetrics)),
+	}
+	data := summaryMonth(report.Months, year, month)
+	for _, metric := range summaryMetrics {
+		var value ReferenceValue
+		if data != nil && data.Actual != nil {
+			value.Actual = getValueFromDataSet(data.Actual, metric.Category, metric.Field)
+		}
+		if data != nil && data.Budget != nil {
+			value.Budget = getValueFromDataSet(data.Budget, metric.Category, metric.Field)
+		}
+		reference.Values[metric.Label] = value
+	}
+	return reference
+}
+
+// parseReferenceSummary parses a reference Summary.csv of a month
+func parseReferenceSummary(r io.Reader, month int) (*ReferenceSummary, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	if len(records) < 4 {
+		return nil, fmt.Errorf("invalid CSV format: expected at least 4 rows")
+	}
+
+	// Row 0: Header metadata (ignore)
+	// Row 1: "Actual,2025 Budget,,,Actual,2025 Budget,,"
+	// Row 2: "Jan,Jan,Fav (Unf),% Variance,Jan_YTD,Jan_YTD,Fav (Unf),% Variance"
+	// Row 3+: Data rows
+
+	summary := &ReferenceSummary{
+		Month:  month,
+		Values: make(map[string]ReferenceValue),
+	}
+
+	// Columns: [Label, Actual, Budget, Variance, VariancePct, YTD Actual, YTD Budget, YTD Variance, YTD VariancePct]
+	actualCol := 1
+	budgetCol := 2
+	varianceCol := 3
+	variancePctCol := 4
+	ytdActualCol := 5
+	ytdBudgetCol := 6
+	ytdVarianceCol := 7
+	ytdVariancePctCol := 8
+
+	// Parse data rows (starting from row 4, index 3)
+	for i := 3; i < len(records); i++ {
+		row := records[i]
+		if len(row) < 9 {
+			continue
+		}
+
+		label := strings.TrimSpace(row[0])
+		if label == "" {
+			continue
+		}
+
+		// Check if this metric is in our mapping
+		if _, exists := metricMapping[label]; !exists {
+			continue
+		}
+
+		// Parse values using robust parsing
+		actual, _ := parseReferenceValue(row[actualCol])
+		budget, _ := parseReferenceValue(row[budgetCol])
+		variance, _ := parseReferenceValue(row[varianceCol])
+		variancePct, _ := parseReferenceValue(row[variancePctCol])
+		ytdActual, _ := parseReferenceValue(row[ytdActualCol])
+		ytdBudget, _ := parseReferenceValue(row[ytdBudgetCol])
+		ytdVariance, _ := parseReferenceValue(row[ytdVarianceCol])
+		ytdVariancePct, _ := parseReferenceValue(row[ytdVariancePctCol])
+
+		summary.Values[label] = ReferenceValue{
+			Actual:         actual,
+			Budget:         budget,
+			Variance:       variance,
+			VariancePct:    variancePct,
+			YTDActual:      ytdActual,
+			YTDBudget:      ytdBudget,
+			YTDVariance:    ytdVariance,
+			YTDVariancePct: ytdVariancePct,
+		}
+	}
+
+	return summary, nil
+}
+
+// parseReferenceValue parses a value from Summary.csv (handles formatting)
+func parseReferenceValue(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if data.IsBlankNumber(value) {
+		return 0, nil
+	}
+
+	// Remove currency symbols
+	value = strings.TrimPrefix(value, "$")
+	value = strings.TrimSpace(value)
+
+	// Remove percentage sign
+	value = strings.TrimSuffix(value, "%")
+	value = strings.TrimSpace(value)
+
+	// Handle parentheses for negatives
+	isNegative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		isNegative = true
+		value = strings.TrimPrefix(value, "(")
+		value = strings.TrimSuffix(value, ")")
+		value = strings.TrimSpace(value)
+	}
+
+	// Remove commas
+	value = strings.ReplaceAll(value, ",", "")
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+
+	if isNegative {
+		f = -f
+	}
+
+	return f, nil
+}
+
+// Reconcile compares API-calculated Summary with reference Summary.csv
+func Reconcile(apiActual, apiBudget *DataSet, reference *ReferenceSummary, tolerance float64) *ReconciliationResult {
+	return ReconcileWithOptions(apiActual, apiBudget, reference, ReconcileOptions{
+		Tolerance: tolerance,
+		Decimals:  NoRounding,
+	})
+}
+
+// ReconcileWithOptions compares API-calculated Summary with reference Summary.csv, in
+// summaryMetrics order. Rounding both sides avoids spurious mismatches when the reference
+// spreadsheet only carries a few decimals
+func ReconcileWithOptions(apiActual, apiBudget *DataSet, reference *ReferenceSummary, opts ReconcileOptions) *ReconciliationResult {
+	tolerance := opts.Tolerance
+	result := &ReconciliationResult{
+		Matches:    []MetricMatch{},
+		Mismatches: []MetricMismatch{},
+	}
+
+	for _, mapping := range summaryMetrics {
+		label := mapping.Label
+		refValue, exists := reference.Values[label]
+		if !exists {
+			continue
+		}
+
+		// Get API values
+		apiActualVal := roundTo(getValueFromDataSet(apiActual, mapping.Category, mapping.Field), opts.Decimals)
+		apiBudgetVal := roundTo(getValueFromDataSet(apiBudget, mapping.Category, mapping.Field), opts.Decimals)
+		refValue.Actual = roundTo(refValue.Actual, opts.Decimals)
+		refValue.Budget = roundTo(refValue.Budget, opts.Decimals)
+
+		// Compare Actual
+		actualDiff := apiActualVal - refValue.Actual
+		actualDiffPct := 0.0
+		if refValue.Actual != 0 {
+			actualDiffPct = (actualDiff / refValue.Actual) * 100
+		}
+
+		// Compare Budget
+		budgetDiff := apiBudgetVal - refValue.Budget
+		budgetDiffPct := 0.0
+		if refValue.Budget != 0 {
+			budgetDiffPct = (budgetDiff / refValue.Budget) * 100
+		}
+
+		// Check if within tolerance (use absolute difference or percentage, whichever is larger)
+		actualWithinTolerance := abs(actualDiff) <= tolerance || abs(actualDiffPct) <= tolerance
+		budgetWithinTolerance := abs(budgetDiff) <= tolerance || abs(budgetDiffPct) <= tolerance
+
+		if actualWithinTolerance && budgetWithinTolerance {
+			result.Matches = append(result.Matches, MetricMatch{
+				Category:      mapping.Category,
+				MetricName:    label,
+				ActualValue:   apiActualVal,
+				ExpectedValue: refValue.Actual,
+				Difference:    actualDiff,
+				DifferencePct: actualDiffPct,
+			})
+		} else {
+			result.Mismatches = append(result.Mismatches, MetricMismatch{
+				Category:        mapping.Category,
+				MetricName:      label,
+				ActualValue:     apiActualVal,
+				ExpectedValue:   refValue.Actual,
+				Difference:      actualDiff,
+				DifferencePct:   actualDiffPct,
+				DependencyChain: []string{mapping.Category, mapping.Field},
+			})
+		}
+	}
+
+	// Calculate summary
+	result.Summary.TotalMetrics = len(result.Matches) + len(result.Mismatches)
+	result.Summary.Matches = len(result.Matches)
+	result.Summary.Mismatches = len(result.Mismatches)
+	if result.Summary.TotalMetrics > 0 {
+		result.Summary.MatchRate = float64(result.Summary.Matches) / float64(result.Summary.TotalMetrics) * 100
+	}
+
+	// Find max differences
+	for _, m := range result.Mismatches {
+		if abs(m.Difference) > result.Summary.MaxDifference {
+			result.Summary.MaxDifference = abs(m.Difference)
+		}
+		if abs(m.DifferencePct) > result.Summary.MaxDifferencePct {
+			result.Summary.MaxDifferencePct = abs(m.DifferencePct)
+		}
+	}
+
+	return result
+}
+
+// roundTo rounds x to the given number of decimals; NoRounding returns x unchanged
+func roundTo(x float64, decimals int) float64 {
+	if decimals < 0 {
+		return x
+	}
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(x*factor) / factor
+}
+
+// abs returns absolute value
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
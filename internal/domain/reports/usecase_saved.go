@@ -76,6 +76,65 @@ func (uc *useCase) CompareReports(ctx context.Context, reportIDs []int64) (*Comp
 	}, nil
 }
 
+// CompareScenarios compares saved scenarios of one company with the first of reportIDs
+func (uc *useCase) CompareScenarios(ctx context.Context, reportIDs []int64) (*ScenarioComparison, error) {
+	reports, err := uc.repo.GetSavedReportsByIDs(ctx, reportIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*SavedReport, len(reports))
+	for _, report := range reports {
+		byID[report.ID] = report
+	}
+
+	// Reports come back ordered by ID; the baseline is the first requested
+	scenarios := make([]ScenarioMetrics, 0, len(reportIDs))
+	for _, id := range reportIDs {
+		report, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrReportNotFound, id)
+		}
+		if report.CompanyID != byID[reportIDs[0]].CompanyID {
+			return nil, ErrMixedCompanies
+		}
+		scenarios = append(scenarios, scenarioMetrics(report))
+	}
+
+	baseline := scenarios[0]
+	calculator := uc.newCalculator(byID[baseline.ReportID].ReportData.Config)
+	for i := 1; i < len(scenarios); i++ {
+		scenarios[i].ActualVariance = calculator.CalculateVarianceData(scenarios[i].Actual, baseline.Actual)
+		scenarios[i].BudgetVariance = calculator.CalculateVarianceData(scenarios[i].Budget, baseline.Budget)
+	}
+
+	return &ScenarioComparison{
+		CompanyID:  byID[baseline.ReportID].CompanyID,
+		BaselineID: baseline.ReportID,
+		Scenarios:  scenarios,
+	}, nil
+}
+
+// scenarioMetrics takes the YTD figures of the last month of a saved report that has them
+func scenarioMetrics(report *SavedReport) ScenarioMetrics {
+	metrics := ScenarioMetrics{
+		ReportID:      report.ID,
+		Name:          report.Name,
+		Year:          report.Year,
+		BudgetVersion: report.BudgetVersion,
+	}
+	months := report.ReportData.Months
+	for i := len(months) - 1; i >= 0; i-- {
+		if months[i].YTD != nil {
+			metrics.Through = months[i].Month
+			metrics.Actual = months[i].YTD.Actual
+			metrics.Budget = months[i].YTD.Budget
+			break
+		}
+	}
+	return metrics
+}
+
 // buildComparison creates side-by-side comparison data
 func buildComparison(reports []*SavedReport) map[string]interface{} {
 	// Extract key metrics from each report for easy comparison
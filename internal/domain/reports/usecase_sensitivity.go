@@ -0,0 +1,82 @@
+package reports
+
+import "context"
+
+// GetSensitivity recomputes the margin of a company year (or of the selected months) with
+// silver and gold at each price change of SensitivityPriceDeltasPct
+func (uc *useCase) GetSensitivity(ctx context.Context, req *SensitivityRequest) (*SensitivityReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	companyConfig, err := uc.repo.GetCompanyConfig(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	yd, err := uc.loadYearData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildSensitivity(uc.newCalculator(companyConfig), yd, uc.parseMonthsFilter(req.Months), SensitivityPriceDeltasPct, SensitivityPriceDeltasPct)
+	report.CompanyID = req.CompanyID
+	report.CompanyName = companyName
+	report.Year = req.Year
+	report.DataType = req.DataType
+	report.Version = req.Version
+
+	return report, nil
+}
+
+// buildSensitivity computes the DataSet of each month with data (all months without a
+// filter) and sums its Net Smelter Return, margin and PBR Net Cash Flow under every pair of
+// silver and gold price changes. Only Dore revenue moves with the prices: costs, CAPEX,
+// charges and the recorded financial adjustments stay as they are.
+func buildSensitivity(calculator *Calculator, yd *yearData, monthsFilter map[int]bool, silverDeltasPct, goldDeltasPct []float64) *SensitivityReport {
+	report := &SensitivityReport{
+		Months:               []int{},
+		SilverPriceDeltasPct: silverDeltasPct,
+		GoldPriceDeltasPct:   goldDeltasPct,
+		Matrix:               make([][]SensitivityScenario, len(silverDeltasPct)),
+	}
+	for i, silverDelta := range silverDeltasPct {
+		report.Matrix[i] = make([]SensitivityScenario, len(goldDeltasPct))
+		for j, goldDelta := range goldDeltasPct {
+			report.Matrix[i][j] = SensitivityScenario{SilverPriceDeltaPct: silverDelta, GoldPriceDeltaPct: goldDelta}
+		}
+	}
+
+	for month := 1; month <= 12; month++ {
+		if monthsFilter != nil && !monthsFilter[month] {
+			continue
+		}
+		md := yd.month(month)
+		if !md.hasData() {
+			continue
+		}
+		report.Months = append(report.Months, month)
+		report.HasData = true
+
+		ds := calculator.CalculateDataSet(md.pbr, md.dore, md.financial, md.opex, md.capex)
+		netCashFlowCapex := calculator.netCashFlowCapex(ds.CAPEX)
+		for i := range report.Matrix {
+			for j := range report.Matrix[i] {
+				scenario := &report.Matrix[i][j]
+				nsr := ds.NSR.NetSmelterReturn
+				if md.dore != nil {
+					priceSilver, priceGold := calculator.realizedPrices(md.dore)
+					nsr = calculator.calculateNSRAtPrices(md.dore, md.financial, md.pbr, ds.Costs,
+						priceSilver*(1+scenario.SilverPriceDeltaPct/100), priceGold*(1+scenario.GoldPriceDeltaPct/100)).NetSmelterReturn
+				}
+				margin := nsr - ds.Costs.ProductionBasedCosts
+				scenario.NetSmelterReturn += nsr
+				scenario.ProductionBasedMargin += margin
+				scenario.PBRNetCashFlow += margin - netCashFlowCapex
+			}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,38 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// GetSummaries computes the summaries of several companies concurrently, keyed by company ID.
+// The first failure cancels the remaining ones and is returned.
+func (uc *useCase) GetSummaries(ctx context.Context, reqs []*SummaryRequest) (map[int64]*SummaryReport, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		summaries = make(map[int64]*SummaryReport, len(reqs))
+	)
+	for _, req := range reqs {
+		wg.Go(func() {
+			report, err := uc.GetSummary(ctx, req)
+			if err != nil {
+				cancel(fmt.Errorf("company %d: %w", req.CompanyID, err))
+				return
+			}
+			mu.Lock()
+			summaries[req.CompanyID] = report
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
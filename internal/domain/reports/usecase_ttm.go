@@ -0,0 +1,131 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// yearData holds one calendar year of data grouped by month
+type yearData struct {
+	pbr       map[int]*data.PBRData
+	dore      map[int]*data.DoreData
+	financial map[int]*data.FinancialData
+	opex      map[int][]*data.OPEXData
+	capex     map[int][]*data.CAPEXData
+}
+
+// GetTTM accumulates the twelve months ending at req.Through, pulling both calendar
+// years when the window crosses a year boundary
+func (uc *useCase) GetTTM(ctx context.Context, req *TTMRequest) (*TTMReport, error) {
+	through, err := time.Parse("2006-01", req.Through)
+	if err != nil {
+		return nil, err
+	}
+	from := through.AddDate(0, -11, 0)
+
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	companyConfig, err := uc.repo.GetCompanyConfig(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	years := make(map[int]*yearData)
+	for year := from.Year(); year <= through.Year(); year++ {
+		years[year], err = uc.loadYearData(ctx, req.CompanyID, year, req.DataType, req.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	months, ttm := buildTTM(uc.newCalculator(companyConfig), from, years)
+
+	return &TTMReport{
+		CompanyID:   req.CompanyID,
+		CompanyName: companyName,
+		From:        from.Format("2006-01"),
+		Through:     through.Format("2006-01"),
+		DataType:    req.DataType,
+		Version:     req.Version,
+		Months:      months,
+		TTM:         ttm,
+	}, nil
+}
+
+// loadYearData fetches and groups by month the data of a calendar year
+func (uc *useCase) loadYearData(ctx context.Context, companyID int64, year int, dataType string, version int) (*yearData, error) {
+	pbr, err := uc.repo.GetPBRData(ctx, companyID, year, dataType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	dore, err := uc.repo.GetDoreData(ctx, companyID, year, dataType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	financial, err := uc.repo.GetFinancialData(ctx, companyID, year, dataType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	opex, err := uc.repo.GetOPEXData(ctx, companyID, year, dataType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	capex, err := uc.repo.GetCAPEXData(ctx, companyID, year, dataType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &yearData{
+		pbr:       groupPBRByMonth(pbr),
+		dore:      groupDoreByMonth(dore),
+		financial: groupFinancialByMonth(financial),
+		opex:      groupOPEXByMonth(opex),
+		capex:     groupCAPEXByMonth(capex),
+	}, nil
+}
+
+// buildTTM accumulates the twelve months starting at from with AccumulateYTD.
+// It returns the months that had data and the accumulated DataSet (nil when none had data).
+func buildTTM(calculator *Calculator, from time.Time, years map[int]*yearData) ([]string, *DataSet) {
+	months := []string{}
+	var ttm *DataSet
+
+	for i := 0; i < 12; i++ {
+		date := from.AddDate(0, i, 0)
+		yd := years[date.Year()]
+		if yd == nil {
+			continue
+		}
+		month := int(date.Month())
+
+		hasData := yd.pbr[month] != nil ||
+			yd.dore[month] != nil ||
+			yd.financial[month] != nil ||
+			len(yd.opex[month]) > 0 ||
+			len(yd.capex[month]) > 0
+		if !hasData {
+			continue
+		}
+
+		monthDataSet := calculator.CalculateDataSet(
+			yd.pbr[month],
+			yd.dore[month],
+			yd.financial[month],
+			yd.opex[month],
+			yd.capex[month],
+		)
+		ttm = calculator.AccumulateYTD(ttm, monthDataSet, yd.dore[month], yd.financial[month])
+		months = append(months, date.Format("2006-01"))
+	}
+
+	return months, ttm
+}
@@ -0,0 +1,48 @@
+package reports
+
+import (
+	"context"
+	"time"
+)
+
+// GetVariance returns the month and YTD variance of a month. It builds the full-year
+// summary so YTD accumulates every month up to the requested one.
+func (uc *useCase) GetVariance(ctx context.Context, req *VarianceRequest) (*VarianceReport, error) {
+	summary, err := uc.GetSummary(ctx, &SummaryRequest{
+		CompanyID:     req.CompanyID,
+		Year:          req.Year,
+		BudgetVersion: req.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildVarianceReport(summary.Months, req.Year, req.Month)
+	report.CompanyID = req.CompanyID
+	report.CompanyName = summary.CompanyName
+	report.BudgetVersion = req.Version
+
+	return report, nil
+}
+
+// buildVarianceReport picks the variance view of a month out of the summary months
+func buildVarianceReport(months []MonthlyData, year, month int) *VarianceReport {
+	monthKey := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+
+	report := &VarianceReport{
+		Year:  year,
+		Month: monthKey,
+	}
+	for _, m := range months {
+		if m.Month != monthKey {
+			continue
+		}
+		report.Variance = m.Variance
+		if m.YTD != nil {
+			report.YTDVariance = m.YTD.Variance
+		}
+		break
+	}
+
+	return report
+}
@@ -0,0 +1,66 @@
+package reports
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gmhafiz/go8/internal/domain/data"
+)
+
+// GetWeightedGrade computes the feed grades of a company across the requested months,
+// weighted by tonnes processed (the same weighting YTD uses)
+func (uc *useCase) GetWeightedGrade(ctx context.Context, req *WeightedGradeRequest) (*WeightedGradeReport, error) {
+	companyName, err := uc.repo.GetCompanyName(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pbrList, err := uc.repo.GetPBRData(ctx, req.CompanyID, req.Year, req.DataType, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildWeightedGrade(pbrList, req.Months)
+	report.CompanyID = req.CompanyID
+	report.CompanyName = companyName
+	report.Year = req.Year
+	report.DataType = req.DataType
+	report.Version = req.Version
+
+	return report, nil
+}
+
+// buildWeightedGrade weights each PBR row's feed grades by its tonnes processed,
+// over the rows dated in one of the months
+func buildWeightedGrade(pbrList []*data.PBRData, months []int) *WeightedGradeReport {
+	months = slices.Clone(months)
+	slices.Sort(months)
+	months = slices.Compact(months)
+
+	report := &WeightedGradeReport{
+		Months:         months,
+		MonthsWithData: []int{},
+	}
+
+	var silverGramTonnes, goldGramTonnes float64
+	for _, pbr := range pbrList {
+		month := int(pbr.Date.Month())
+		if !slices.Contains(months, month) {
+			continue
+		}
+		if !slices.Contains(report.MonthsWithData, month) {
+			report.MonthsWithData = append(report.MonthsWithData, month)
+		}
+		report.TotalTonnesProcessed += pbr.TotalTonnesProcessed
+		silverGramTonnes += pbr.FeedGradeSilverGpt * pbr.TotalTonnesProcessed
+		goldGramTonnes += pbr.FeedGradeGoldGpt * pbr.TotalTonnesProcessed
+	}
+	slices.Sort(report.MonthsWithData)
+
+	if report.TotalTonnesProcessed > 0 {
+		report.FeedGradeSilverGpt = silverGramTonnes / report.TotalTonnesProcessed
+		report.FeedGradeGoldGpt = goldGramTonnes / report.TotalTonnesProcessed
+	}
+
+	return report
+}
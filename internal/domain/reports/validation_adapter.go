@@ -45,37 +45,142 @@ func (a *reportsRepositoryAdapter) GetMineralCodeMap(ctx context.Context) (map[s
 	return make(map[string]int), nil
 }
 
-func (a *reportsRepositoryAdapter) GetPBRByDate(ctx context.Context, companyID int64, date time.Time, dataType string, version int) (*data.PBRData, error) {
-	// Not needed for validation, but required by interface
-	// Could be implemented if needed
+func (a *reportsRepositoryAdapter) ListPBRDates(ctx context.Context, companyID int64, from, to time.Time, dataType string, version int) ([]time.Time, error) {
+	// Not needed for validation (only used when importing PBR)
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (a *reportsRepositoryAdapter) InsertProductionBulk(ctx context.Context, records []*data.ProductionData) error {
+func (a *reportsRepositoryAdapter) GetDoreGradeBasis(ctx context.Context, companyID int64) (data.DoreGradeBasis, error) {
+	// Not needed for validation (only used when importing Dore)
+	return "", fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) GetZeroRowChecks(ctx context.Context, companyID int64) (map[data.DataImportType]data.ZeroRowCheck, error) {
+	// Not needed for validation (only used when importing)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) GetCostCenters(ctx context.Context, companyID int64) ([]data.CostCenter, error) {
+	// Not needed for validation (only used when importing OPEX)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) GetOPEXSubcategories(ctx context.Context, companyID int64) (data.OPEXSubcategories, error) {
+	// Not needed for validation (only used when importing OPEX)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) GetCurrencyRates(ctx context.Context, currencies []data.Currency) (data.CurrencyRates, error) {
+	// Not needed for validation (only used when importing OPEX and CAPEX)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) GetFormatProfile(ctx context.Context, companyID int64) (*data.FormatProfile, error) {
+	// Not needed for validation (only used when importing)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) SaveFormatProfile(ctx context.Context, companyID int64, profile data.FormatProfile) error {
+	// Not needed for validation (only used when importing)
+	return fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListImportLog(ctx context.Context, companyID int64, year int) ([]*data.ImportLog, error) {
+	// Not needed for validation (only used for the import history)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) SoftDeleteByScope(ctx context.Context, table string, companyID int64, year, month int, dataType string, version int) (int64, error) {
+	// Not needed for validation (only used when deleting data)
+	return 0, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListBudgetVersions(ctx context.Context, companyID int64, year int) ([]int, error) {
+	// Not needed for validation (only used when importing)
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ArchiveBudgetVersions(ctx context.Context, companyID int64, year int, versions []int) (int64, error) {
+	// Not needed for validation (only used when pruning versions)
+	return 0, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) GetRetentionYears(ctx context.Context, companyID int64) (int, error) {
+	// Not needed for validation (only used by the retention policy)
+	return 0, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ArchiveExpiredData(ctx context.Context, companyID int64, cutoff *time.Time) (int64, error) {
+	// Not needed for validation (only used by the retention policy)
+	return 0, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListArchivedPBRData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.PBRData, error) {
+	// Validation only looks at active rows
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListArchivedDoreData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.DoreData, error) {
+	// Validation only looks at active rows
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListArchivedOPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.OPEXData, error) {
+	// Validation only looks at active rows
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListArchivedCAPEXData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.CAPEXData, error) {
+	// Validation only looks at active rows
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) ListArchivedFinancialData(ctx context.Context, companyID int64, year int, dataType string, version int) ([]*data.FinancialData, error) {
+	// Validation only looks at active rows
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *reportsRepositoryAdapter) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fmt.Errorf("not implemented - read-only adapter")
+}
+
+func (a *reportsRepositoryAdapter) ClaimIdempotencyKey(ctx context.Context, userID int64, key, fingerprint string, ttl time.Duration) (*data.ImportResponse, bool, error) {
+	return nil, false, fmt.Errorf("not implemented - read-only adapter")
+}
+
+func (a *reportsRepositoryAdapter) SaveIdempotencyKeyResponse(ctx context.Context, userID int64, key string, response *data.ImportResponse) error {
+	return fmt.Errorf("not implemented - read-only adapter")
+}
+
+func (a *reportsRepositoryAdapter) InsertProductionBulk(ctx context.Context, records []*data.ProductionData, replace bool, log *data.ImportLog) error {
+	return fmt.Errorf("not implemented - read-only adapter")
+}
+
+func (a *reportsRepositoryAdapter) InsertDoreBulk(ctx context.Context, records []*data.DoreData, replace bool, log *data.ImportLog) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
-func (a *reportsRepositoryAdapter) InsertDoreBulk(ctx context.Context, records []*data.DoreData) error {
+func (a *reportsRepositoryAdapter) InsertPBRBulk(ctx context.Context, records []*data.PBRData, replace bool, log *data.ImportLog) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
-func (a *reportsRepositoryAdapter) InsertPBRBulk(ctx context.Context, records []*data.PBRData) error {
+func (a *reportsRepositoryAdapter) InsertPBRStream(ctx context.Context, replace bool, log *data.ImportLog, next func() ([]*data.PBRData, error)) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
-func (a *reportsRepositoryAdapter) InsertOPEXBulk(ctx context.Context, records []*data.OPEXData) error {
+func (a *reportsRepositoryAdapter) InsertOPEXBulk(ctx context.Context, records []*data.OPEXData, replace bool, log *data.ImportLog) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
-func (a *reportsRepositoryAdapter) InsertCAPEXBulk(ctx context.Context, records []*data.CAPEXData) error {
+func (a *reportsRepositoryAdapter) InsertCAPEXBulk(ctx context.Context, records []*data.CAPEXData, replace bool, log *data.ImportLog) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
-func (a *reportsRepositoryAdapter) InsertRevenueBulk(ctx context.Context, records []*data.RevenueData) error {
+func (a *reportsRepositoryAdapter) InsertRevenueBulk(ctx context.Context, records []*data.RevenueData, replace bool, log *data.ImportLog) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
-func (a *reportsRepositoryAdapter) InsertFinancialBulk(ctx context.Context, records []*data.FinancialData) error {
+func (a *reportsRepositoryAdapter) InsertFinancialBulk(ctx context.Context, records []*data.FinancialData, replace bool, log *data.ImportLog) error {
 	return fmt.Errorf("not implemented - read-only adapter")
 }
 
@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/gmhafiz/go8/internal/domain/auth"
 	"github.com/gmhafiz/go8/internal/domain/auth/repository"
 	"github.com/gmhafiz/go8/internal/domain/auth/usecase"
@@ -25,6 +28,8 @@ const (
 	CompanyIDKey contextKey = "company_id"
 	// CompanyRoleKey is the context key for user's role in the current company
 	CompanyRoleKey contextKey = "company_role"
+	// PermissionsKey is the context key for the user's global permissions, loaded once per request
+	PermissionsKey contextKey = "permissions"
 )
 
 // =============================================================================
@@ -71,11 +76,29 @@ func (r CompanyRole) IsValid() bool {
 	return r.Level() > 0
 }
 
+// PermissionSuperAdmin is the global permission that manages users and companies system-wide.
+// Super admins have the admin role in every company.
+const PermissionSuperAdmin = "super_admin"
+
+// Action permissions of the company user management routes, granted per user on top of
+// the company admin role. Super admins hold all of them.
+const (
+	PermissionUsersCreate     = "users.create"
+	PermissionUsersUpdate     = "users.update"
+	PermissionUsersDeactivate = "users.deactivate"
+	PermissionUsersRoles      = "users.roles" // Change or remove a user's company role
+)
+
+// actionPermissions are the permissions super admins hold without being granted them
+var actionPermissions = []string{PermissionUsersCreate, PermissionUsersUpdate, PermissionUsersDeactivate, PermissionUsersRoles}
+
 var (
 	ErrCompanyAccessDenied    = errors.New("you don't have access to this company")
 	ErrInsufficientRole       = errors.New("insufficient role for this action")
 	ErrMissingCompanyID       = errors.New("missing or invalid company_id")
 	ErrInvalidRole            = errors.New("invalid company role")
+	ErrCompanyIDMismatch      = errors.New("company_id does not match the company in the path")
+	ErrUserNotInCompany       = errors.New("user not found in this company")
 )
 
 // RequireAuth is a middleware that validates the session token
@@ -108,6 +131,10 @@ func RequireAuth(authUseCase usecase.UseCase) func(http.Handler) http.Handler {
 }
 
 // RequirePermission is a middleware that validates if user has a specific permission
+// (super admins have every action permission, such as users.create)
+// The permissions are loaded once per request and cached in context, so stacked
+// RequirePermission middlewares and handlers (see GetPermissions) reuse them
+// MUST be used AFTER RequireAuth middleware
 func RequirePermission(authRepo repository.Repository, permission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -117,34 +144,75 @@ func RequirePermission(authRepo repository.Repository, permission string) func(h
 				return
 			}
 
-			permissions, err := authRepo.GetUserPermissions(r.Context(), userID)
+			ctx, permissions, err := loadPermissions(r.Context(), authRepo, userID)
 			if err != nil {
 				respond.Error(w, http.StatusInternalServerError, err)
 				return
 			}
 
-			hasPermission := false
-			for _, p := range permissions {
-				if p == permission {
-					hasPermission = true
-					break
-				}
+			superAdminAction := slices.Contains(actionPermissions, permission) && HasPermission(permissions, PermissionSuperAdmin)
+			if !HasPermission(permissions, permission) && !superAdminAction {
+				respond.Error(w, http.StatusForbidden, fmt.Errorf("insufficient permissions: requires '%s'", permission))
+				return
 			}
 
-			if !hasPermission {
-				respond.Error(w, http.StatusForbidden, fmt.Errorf("insufficient permissions: requires '%s'", permission))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// LoadPermissions is a middleware that caches the user's permissions in context, for
+// handlers that check company access themselves (CheckCompanyAccess) so super admins pass
+// MUST be used AFTER RequireAuth middleware
+func LoadPermissions(authRepo repository.Repository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserID(r.Context())
+			if !ok {
+				respond.Error(w, http.StatusUnauthorized, errors.New("user not authenticated"))
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			ctx, _, err := loadPermissions(r.Context(), authRepo, userID)
+			if err != nil {
+				respond.Error(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// loadPermissions returns the permissions cached in context, or loads and caches them
+func loadPermissions(ctx context.Context, authRepo repository.Repository, userID int64) (context.Context, []string, error) {
+	if permissions, ok := GetPermissions(ctx); ok {
+		return ctx, permissions, nil
+	}
+	permissions, err := authRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, PermissionsKey, permissions), permissions, nil
+}
+
+// HasPermission reports whether permission is in the user's permissions
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateCompanyAccess is a middleware that validates if user has access to the requested company
-// It reads the role from session cache (context) - NO database query needed
+// The company is the {company_id} path parameter on routes that have one (a company_id in
+// the query or form must then match it), otherwise the company_id query or form value.
+// It reads the role from session cache (context) - NO database query needed, except for
+// users without a role in the company: super admins pass with the admin role
 // MUST be used AFTER RequireAuth middleware
-func ValidateCompanyAccess(_ repository.Repository) func(http.Handler) http.Handler {
+func ValidateCompanyAccess(authRepo repository.Repository) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get company roles from context (set by RequireAuth)
@@ -161,6 +229,15 @@ func ValidateCompanyAccess(_ repository.Repository) func(http.Handler) http.Hand
 				companyIDStr = r.FormValue("company_id")
 			}
 
+			// Handlers of routes with a {company_id} act on it: that is the company to check
+			if pathCompanyID := chi.URLParam(r, "company_id"); pathCompanyID != "" {
+				if companyIDStr != "" && companyIDStr != pathCompanyID {
+					respond.Error(w, http.StatusBadRequest, ErrCompanyIDMismatch)
+					return
+				}
+				companyIDStr = pathCompanyID
+			}
+
 			if companyIDStr == "" {
 				respond.Error(w, http.StatusBadRequest, ErrMissingCompanyID)
 				return
@@ -173,10 +250,21 @@ func ValidateCompanyAccess(_ repository.Repository) func(http.Handler) http.Hand
 			}
 
 			// Get user's role in this company from session cache (no DB query!)
+			ctx := r.Context()
 			roleStr := companyRoles.GetRole(companyID)
 			if roleStr == "" {
-				respond.Error(w, http.StatusForbidden, ErrCompanyAccessDenied)
-				return
+				userID, _ := GetUserID(ctx)
+				var permissions []string
+				ctx, permissions, err = loadPermissions(ctx, authRepo, userID)
+				if err != nil {
+					respond.Error(w, http.StatusInternalServerError, err)
+					return
+				}
+				if !HasPermission(permissions, PermissionSuperAdmin) {
+					respond.Error(w, http.StatusForbidden, ErrCompanyAccessDenied)
+					return
+				}
+				roleStr = string(RoleAdmin)
 			}
 
 			role := CompanyRole(roleStr)
@@ -186,13 +274,47 @@ func ValidateCompanyAccess(_ repository.Repository) func(http.Handler) http.Hand
 			}
 
 			// Add company ID and role to context for downstream handlers/middlewares
-			ctx := context.WithValue(r.Context(), CompanyIDKey, companyID)
+			ctx = context.WithValue(ctx, CompanyIDKey, companyID)
 			ctx = context.WithValue(ctx, CompanyRoleKey, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// RequireCompanyMember is a middleware that 404s when the user of the param path parameter
+// (e.g. "id" in /users/{id}) has no role in the validated company, so company admins only
+// act on their company's users
+// MUST be used AFTER ValidateCompanyAccess middleware
+func RequireCompanyMember(authRepo repository.Repository, param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			companyID, ok := GetCompanyID(r.Context())
+			if !ok {
+				respond.Error(w, http.StatusInternalServerError, errors.New("company not found in context - ensure ValidateCompanyAccess runs first"))
+				return
+			}
+
+			userID, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+			if err != nil {
+				respond.Error(w, http.StatusBadRequest, errors.New("invalid user id"))
+				return
+			}
+
+			member, err := authRepo.UserHasCompanyAccess(r.Context(), userID, companyID)
+			if err != nil {
+				respond.Error(w, http.StatusInternalServerError, err)
+				return
+			}
+			if !member {
+				respond.Error(w, http.StatusNotFound, ErrUserNotInCompany)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RequireCompanyRole is a middleware that validates if user has at least the required role
 // MUST be used AFTER ValidateCompanyAccess middleware
 func RequireCompanyRole(requiredRole CompanyRole) func(http.Handler) http.Handler {
@@ -232,6 +354,12 @@ func GetCompanyRole(ctx context.Context) (CompanyRole, bool) {
 	return role, ok
 }
 
+// GetPermissions extracts the user's permissions cached by RequirePermission from the request context
+func GetPermissions(ctx context.Context) ([]string, bool) {
+	permissions, ok := ctx.Value(PermissionsKey).([]string)
+	return permissions, ok
+}
+
 // GetCompanyRoles extracts all company roles from the request context (from session cache)
 func GetCompanyRoles(ctx context.Context) (auth.CompanyRoles, bool) {
 	roles, ok := ctx.Value(CompanyRolesKey).(auth.CompanyRoles)
@@ -241,6 +369,7 @@ func GetCompanyRoles(ctx context.Context) (auth.CompanyRoles, bool) {
 // CheckCompanyAccess is a helper function that handlers can call to validate company access
 // Use this for endpoints where company_id comes from JSON body
 // Reads from session cache in context - NO database query needed
+// Super admins have the admin role when their permissions are cached (LoadPermissions)
 // Returns the user's role in the company if access is granted
 func CheckCompanyAccess(ctx context.Context, companyID int64) (CompanyRole, error) {
 	companyRoles, ok := GetCompanyRoles(ctx)
@@ -250,6 +379,9 @@ func CheckCompanyAccess(ctx context.Context, companyID int64) (CompanyRole, erro
 
 	roleStr := companyRoles.GetRole(companyID)
 	if roleStr == "" {
+		if permissions, _ := GetPermissions(ctx); HasPermission(permissions, PermissionSuperAdmin) {
+			return RoleAdmin, nil
+		}
 		return "", ErrCompanyAccessDenied
 	}
 
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gmhafiz/go8/internal/domain/auth"
+	"github.com/gmhafiz/go8/internal/domain/auth/repository"
+)
+
+// permissionsRepository counts permission lookups; other methods are not called
+type permissionsRepository struct {
+	repository.Repository
+	permissions []string
+	calls       int
+}
+
+func (r *permissionsRepository) GetUserPermissions(_ context.Context, _ int64) ([]string, error) {
+	r.calls++
+	return r.permissions, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		required    []string
+		want        int
+	}{
+		{name: "has permission", permissions: []string{"super_admin"}, required: []string{"super_admin"}, want: http.StatusOK},
+		{name: "missing permission", permissions: []string{"viewer"}, required: []string{"super_admin"}, want: http.StatusForbidden},
+		{name: "stacked", permissions: []string{"admin", "super_admin"}, required: []string{"admin", "super_admin"}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &permissionsRepository{permissions: tt.permissions}
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				permissions, ok := GetPermissions(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, tt.permissions, permissions)
+			})
+			for i := len(tt.required) - 1; i >= 0; i-- {
+				handler = RequirePermission(repo, tt.required[i])(handler)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+			assert.Equal(t, 1, repo.calls, "permissions are loaded once per request")
+		})
+	}
+}
+
+func TestRequirePermission_Unauthenticated(t *testing.T) {
+	repo := &permissionsRepository{}
+	handler := RequirePermission(repo, "admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Zero(t, repo.calls)
+}
+
+func TestValidateCompanyAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		companyID   string
+		permissions []string
+		want        int
+		wantRole    CompanyRole
+		wantCalls   int
+	}{
+		{name: "assigned company", companyID: "5", want: http.StatusOK, wantRole: RoleViewer},
+		{name: "other company", companyID: "9", permissions: []string{"admin"}, want: http.StatusForbidden, wantCalls: 1},
+		{name: "super admin bypass", companyID: "9", permissions: []string{PermissionSuperAdmin}, want: http.StatusOK, wantRole: RoleAdmin, wantCalls: 1},
+		{name: "missing company", companyID: "", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &permissionsRepository{permissions: tt.permissions}
+			handler := ValidateCompanyAccess(repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				role, ok := GetCompanyRole(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantRole, role)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/?company_id="+tt.companyID, nil)
+			ctx := context.WithValue(req.Context(), UserIDKey, int64(1))
+			ctx = context.WithValue(ctx, CompanyRolesKey, auth.CompanyRoles{"5": "viewer"})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			assert.Equal(t, tt.want, rr.Code)
+			assert.Equal(t, tt.wantCalls, repo.calls, "permissions are only loaded for unassigned companies")
+		})
+	}
+}
+
+func TestCheckCompanyAccess_SuperAdmin(t *testing.T) {
+	ctx := context.WithValue(context.Background(), CompanyRolesKey, auth.CompanyRoles{"5": "editor"})
+
+	_, err := CheckCompanyAccess(ctx, 9)
+	assert.ErrorIs(t, err, ErrCompanyAccessDenied)
+
+	role, err := CheckCompanyAccess(context.WithValue(ctx, PermissionsKey, []string{PermissionSuperAdmin}), 9)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleAdmin, role)
+}
+
+func TestRequirePermission_SuperAdminHoldsActionPermissions(t *testing.T) {
+	for permission, want := range map[string]int{
+		PermissionUsersCreate: http.StatusOK,
+		PermissionSuperAdmin:  http.StatusOK,
+		"admin":               http.StatusForbidden,
+	} {
+		repo := &permissionsRepository{permissions: []string{PermissionSuperAdmin}}
+		handler := RequirePermission(repo, permission)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req.WithContext(context.WithValue(req.Context(), UserIDKey, int64(1))))
+		assert.Equal(t, want, rr.Code, permission)
+	}
+
+	repo := &permissionsRepository{permissions: []string{"admin"}}
+	handler := RequirePermission(repo, PermissionUsersCreate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req.WithContext(context.WithValue(req.Context(), UserIDKey, int64(1))))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+// membersRepository reports user 7 as the only member of every company
+type membersRepository struct {
+	permissionsRepository
+}
+
+func (r *membersRepository) UserHasCompanyAccess(_ context.Context, userID int64, _ int64) (bool, error) {
+	return userID == 7, nil
+}
+
+func TestValidateCompanyAccess_PathCompany(t *testing.T) {
+	repo := &membersRepository{}
+	router := chi.NewRouter()
+	router.Route("/api/v1/company/{company_id}/users", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := context.WithValue(r.Context(), UserIDKey, int64(1))
+				ctx = context.WithValue(ctx, CompanyRolesKey, auth.CompanyRoles{"5": "admin"})
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		})
+		r.Use(ValidateCompanyAccess(repo))
+		r.With(RequireCompanyMember(repo, "user_id")).Put("/{user_id}/role", func(w http.ResponseWriter, r *http.Request) {
+			companyID, _ := GetCompanyID(r.Context())
+			assert.Equal(t, chi.URLParam(r, "company_id"), strconv.FormatInt(companyID, 10))
+		})
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "own company", path: "/api/v1/company/5/users/7/role", want: http.StatusOK},
+		{name: "query names the same company", path: "/api/v1/company/5/users/7/role?company_id=5", want: http.StatusOK},
+		{name: "other company in the path", path: "/api/v1/company/9/users/7/role?company_id=5", want: http.StatusBadRequest},
+		{name: "other company", path: "/api/v1/company/9/users/7/role", want: http.StatusForbidden},
+		{name: "user of another company", path: "/api/v1/company/5/users/8/role", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, tt.path, nil))
+			assert.Equal(t, tt.want, rr.Code, rr.Body.String())
+		})
+	}
+}
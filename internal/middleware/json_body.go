@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gmhafiz/go8/internal/utility/respond"
+)
+
+// MaxJSONBodyBytes is the largest JSON request body accepted, same as request.DecodeJSON
+const MaxJSONBodyBytes int64 = 1_048_576
+
+// RequireJSONBody rejects requests carrying a body that is not application/json (415)
+// or is larger than maxBytes (413), before the handler tries to decode it.
+// Requests without a body (GET, DELETE, an empty POST) pass through untouched.
+func RequireJSONBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				respond.Error(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+				return
+			}
+
+			tooLarge := fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+			if r.ContentLength > maxBytes {
+				respond.Error(w, http.StatusRequestEntityTooLarge, tooLarge)
+				return
+			}
+
+			// Content-Length may be absent (chunked), so read at most one byte past the limit
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+			_ = r.Body.Close()
+			if err != nil {
+				respond.Error(w, http.StatusBadRequest, err)
+				return
+			}
+			if int64(len(body)) > maxBytes {
+				respond.Error(w, http.StatusRequestEntityTooLarge, tooLarge)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
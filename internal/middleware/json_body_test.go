@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func jsonBodyHandler(t *testing.T, maxBytes int64) http.Handler {
+	return RequireJSONBody(maxBytes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestRequireJSONBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{name: "json body", method: http.MethodPost, contentType: "application/json", body: `{"email":"a@b.c"}`, want: http.StatusOK},
+		{name: "json with charset", method: http.MethodPut, contentType: "application/json; charset=utf-8", body: `{}`, want: http.StatusOK},
+		{name: "wrong content type", method: http.MethodPost, contentType: "text/plain", body: `{"email":"a@b.c"}`, want: http.StatusUnsupportedMediaType},
+		{name: "missing content type", method: http.MethodPost, body: `{}`, want: http.StatusUnsupportedMediaType},
+		{name: "oversized body", method: http.MethodPost, contentType: "application/json", body: `{"pad":"` + strings.Repeat("x", 64) + `"}`, want: http.StatusRequestEntityTooLarge},
+		{name: "no body", method: http.MethodGet, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, "/", body)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			jsonBodyHandler(t, 32).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, tt.body, rec.Body.String(), "body must reach the handler intact")
+			}
+		})
+	}
+}
+
+func TestRequireJSONBodyOversizedWithoutContentLength(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat(" ", 64)+"{}"))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1 // Chunked: size unknown up front
+	rec := httptest.NewRecorder()
+
+	jsonBodyHandler(t, 32).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -67,25 +67,55 @@ func (s *Server) initSwagger() {
 
 func (s *Server) initAuth() {
 	repo := authRepo.New(s.sqlx)
-	uc := authUseCase.New(repo)
+	opts := []authUseCase.Option{authUseCase.WithPasswordPolicy(authUseCase.PasswordPolicy{
+		MinLength:    s.Config().API.PasswordMinLength,
+		RequireUpper: s.Config().API.PasswordComplexity,
+		RequireLower: s.Config().API.PasswordComplexity,
+		RequireDigit: s.Config().API.PasswordComplexity,
+	})}
+	if s.Config().API.TokenType == authUseCase.TokenTypeJWT {
+		opts = append(opts, authUseCase.WithJWT([]byte(s.Config().API.JWTSecret)))
+	}
+	uc := authUseCase.New(repo, opts...)
 	handler := authHandler.RegisterHTTPEndPoints(s.router, s.validator, uc, repo)
 
-	// Store authRepo in server for RequirePermission middleware
+	// Store authRepo in server for RequirePermission middleware, and the use case for
+	// RequireAuth so every domain validates the same token type
 	s.authRepo = repo
+	s.authUseCase = uc
 
-	// Authenticated user can change their own password
+	// Authenticated user can change their own password and manage their sessions
 	s.router.Group(func(r chi.Router) {
 		r.Use(middleware.RequireAuth(uc))
+		r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
 		r.Put("/api/v1/auth/password", handler.ChangePassword)
+		r.Get("/api/v1/auth/sessions", handler.ListSessions)
+		r.Delete("/api/v1/auth/sessions/{tokenPrefix}", handler.RevokeSession)
+	})
+
+	// Super admin only: grant permissions to many users at once
+	s.router.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAuth(uc))
+		r.Use(middleware.RequirePermission(repo, middleware.PermissionSuperAdmin))
+		r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
+		r.Post("/api/v1/auth/permissions/bulk", handler.BulkAssignPermissions)
+	})
+
+	// Super admin only: create users from a CSV upload (multipart, not JSON)
+	s.router.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAuth(uc))
+		r.Use(middleware.RequirePermission(repo, middleware.PermissionSuperAdmin))
+		r.Post("/api/v1/auth/users/import", handler.ImportUsers)
 	})
 
 	// User management routes
 	s.router.Route("/api/v1/admin/users", func(r chi.Router) {
 		r.Use(middleware.RequireAuth(uc))
+		r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
 
 		// Super admin only: full user management (no company filter)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequirePermission(repo, "super_admin"))
+			r.Use(middleware.RequirePermission(repo, middleware.PermissionSuperAdmin))
 
 			r.Get("/", handler.ListUsers)         // List all users
 			r.Post("/", handler.CreateUser)       // Create new user
@@ -111,16 +141,24 @@ func (s *Server) initAuth() {
 		r.Use(middleware.RequireAuth(uc))
 		r.Use(middleware.ValidateCompanyAccess(repo))
 		r.Use(middleware.RequireCompanyRole(middleware.RoleAdmin)) // Must be admin in this company
+		r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
+
+		r.Get("/", handler.ListUsers) // List users in company (filtered by company_id)
+		r.With(middleware.RequirePermission(repo, middleware.PermissionUsersCreate)).
+			Post("/", handler.CreateUser) // Create user (will be assigned to this company)
 
-		r.Get("/", handler.ListUsers)         // List users in company (filtered by company_id)
-		r.Post("/", handler.CreateUser)       // Create user (will be assigned to this company)
-		r.Get("/{id}", handler.GetUser)       // Get specific user
-		r.Put("/{id}", handler.UpdateUser)    // Update user
-		r.Delete("/{id}", handler.DeactivateUser) // Deactivate user
+		// Only users of this company
+		r.With(middleware.RequireCompanyMember(repo, "id")).Get("/{id}", handler.GetUser)
+		r.With(middleware.RequireCompanyMember(repo, "id"), middleware.RequirePermission(repo, middleware.PermissionUsersUpdate)).
+			Put("/{id}", handler.UpdateUser)
+		r.With(middleware.RequireCompanyMember(repo, "id"), middleware.RequirePermission(repo, middleware.PermissionUsersDeactivate)).
+			Delete("/{id}", handler.DeactivateUser)
 
 		// Company role management within this company
-		r.Put("/{user_id}/role", handler.UpdateUserCompanyRole)
-		r.Delete("/{user_id}", handler.RemoveUserFromCompany)
+		r.With(middleware.RequireCompanyMember(repo, "user_id"), middleware.RequirePermission(repo, middleware.PermissionUsersRoles)).
+			Put("/{user_id}/role", handler.UpdateUserCompanyRole)
+		r.With(middleware.RequireCompanyMember(repo, "user_id"), middleware.RequirePermission(repo, middleware.PermissionUsersRoles)).
+			Delete("/{user_id}", handler.RemoveUserFromCompany)
 	})
 }
 
@@ -139,7 +177,7 @@ func (s *Server) initConfig() {
 	s.router.Route("/api/v1/config", func(r chi.Router) {
 		// Public endpoints (only authenticated)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireAuth(authUseCase.New(s.authRepo)))
+			r.Use(middleware.RequireAuth(s.authUseCase))
 
 			// Companies - Read
 			r.Get("/companies", companiesH.List)
@@ -155,8 +193,9 @@ func (s *Server) initConfig() {
 
 		// Admin only endpoints
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireAuth(authUseCase.New(s.authRepo)))
+			r.Use(middleware.RequireAuth(s.authUseCase))
 			r.Use(middleware.RequirePermission(s.authRepo, "admin"))
+			r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
 
 			// Companies - Write
 			r.Post("/companies", companiesH.Create)
@@ -175,69 +214,97 @@ func (s *Server) initConfig() {
 
 func (s *Server) initData() {
 	repo := data.NewRepository(s.sqlx)
-	uc := data.NewUseCase(repo)
+	uc := data.NewUseCase(repo, s.Config().API.MaxBudgetVersions, s.Config().API.GramsPerTroyOz)
 	h := data.NewHandler(uc, s.validator)
 
-	authUC := authUseCase.New(s.authRepo)
-
 	s.router.Route("/api/v1/data", func(r chi.Router) {
-		// All data endpoints require authentication + company access validation
-		r.Use(middleware.RequireAuth(authUC))
-		r.Use(middleware.ValidateCompanyAccess(s.authRepo))
+		// All data endpoints require authentication
+		r.Use(middleware.RequireAuth(s.authUseCase))
 
-		// Viewer role: can list/view data
-		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireCompanyRole(middleware.RoleViewer))
-			r.Get("/{type}/list", h.List)
-		})
+		// Import schema: not company specific, any authenticated user
+		r.Get("/schema", h.Schema)
 
-		// Editor role: can import data
+		// Company data endpoints also require company access validation
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireCompanyRole(middleware.RoleEditor))
-			r.Post("/import", h.Import)
-		})
-
-		// Admin role: can delete data
-		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireCompanyRole(middleware.RoleAdmin))
-			r.Delete("/{type}/{id}", h.Delete)
+			r.Use(middleware.ValidateCompanyAccess(s.authRepo))
+
+			// Viewer role: can list/view data
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireCompanyRole(middleware.RoleViewer))
+				r.Get("/{type}/list", h.List)
+				r.Get("/imports", h.ListImports) // Import history
+			})
+
+			// Editor role: can import data
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireCompanyRole(middleware.RoleEditor))
+				r.Post("/import", h.Import)
+				r.Post("/import/bundle", h.ImportBundle) // Several files in one transaction
+			})
+
+			// Admin role: can delete data, prune budget versions and run retention
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireCompanyRole(middleware.RoleAdmin))
+				r.Delete("/{type}/{id}", h.Delete)
+				r.Delete("/{type}", h.DeleteScope) // Every row of a company/month/data type/version
+				r.Post("/versions/prune", h.PruneVersions) // Archive old budget versions
+				r.Post("/archive", h.Archive)              // Move expired rows to the archive tables
+			})
 		})
 	})
 }
 
 func (s *Server) initReports() {
 	repo := reports.NewRepository(s.sqlx)
-	uc := reports.NewUseCase(repo)
-	detailUC := reports.NewDetailUseCase(repo)
+	uc := reports.NewUseCase(repo, s.Config().API.GramsPerTroyOz)
+	detailUC := reports.NewDetailUseCase(repo, s.Config().API.GramsPerTroyOz)
 	h := reports.NewHandler(uc, s.validator, s.authRepo)
 	detailH := reports.NewDetailHandler(detailUC, s.validator, s.authRepo)
 
-	authUC := authUseCase.New(s.authRepo)
-
 	s.router.Route("/api/v1/reports", func(r chi.Router) {
-		// All reports endpoints require authentication + company access validation
-		r.Use(middleware.RequireAuth(authUC))
-		r.Use(middleware.ValidateCompanyAccess(s.authRepo))
+		// All reports endpoints require authentication
+		r.Use(middleware.RequireAuth(s.authUseCase))
 
-		// Viewer role: can view reports (read-only)
+		// Viewer role: can view reports (read-only), company_id from the query string
 		r.Group(func(r chi.Router) {
+			r.Use(middleware.ValidateCompanyAccess(s.authRepo))
 			r.Use(middleware.RequireCompanyRole(middleware.RoleViewer))
 
 			// Summary and detailed reports
 			r.Get("/summary", h.GetSummary)
+			r.Get("/summary/export.csv", h.ExportSummaryCSV)   // Reference Summary.csv layout
+			r.Get("/summary/export.xlsx", h.ExportSummaryXLSX) // One sheet per section
 			r.Get("/saved", h.ListSavedReports)
+			r.Get("/benchmark", h.GetBenchmark)
+			r.Get("/integrity", h.GetIntegrity)              // Pre year-close referential integrity check
+			r.Get("/ttm", h.GetTTM)                          // Trailing twelve months, across year boundaries
+			r.Get("/mineral-margin", h.GetMineralMargin)     // Contribution margin by mineral
+			r.Get("/margin-waterfall", h.GetMarginWaterfall) // Budget to actual margin by driver
+			r.Get("/sensitivity", h.GetSensitivity)          // Margin under silver/gold price scenarios
+			r.Get("/variance", h.GetVariance)                // Month and YTD variance of a month
+			r.Get("/weighted-grade", h.GetWeightedGrade)     // Tonnage-weighted feed grades of selected months
+			r.Get("/reconcile", h.GetReconciliation)         // A month of live data against a saved report
 			r.Get("/pbr", detailH.GetPBRDetail)
 			r.Get("/dore", detailH.GetDoreDetail)
 			r.Get("/opex", detailH.GetOPEXDetail)
 			r.Get("/capex", detailH.GetCAPEXDetail)
+			r.Get("/financial", detailH.GetFinancialDetail)
+			r.Get("/production", detailH.GetPBRProduction) // Derived from PBR only (before Dore)
+			r.Get("/daily", detailH.GetDaily)              // Per-day rows of a month
 		})
 
 		// Editor role: can save reports and compare
-		// Note: SaveReport and CompareReports validate roles internally because company_id comes from JSON body
+		// Note: SaveReport, CompareReports and GetSummaries validate roles internally because company_id comes from JSON body
 		r.Group(func(r chi.Router) {
 			// No role middleware here - handlers validate internally
+			r.Use(middleware.LoadPermissions(s.authRepo)) // So super admins pass the handler checks
+			r.Use(middleware.RequireJSONBody(middleware.MaxJSONBodyBytes))
 			r.Post("/save", h.SaveReport)
 			r.Post("/compare", h.CompareReports)
+			r.Post("/summaries", h.GetSummaries) // Summaries of several companies (viewer role in each)
 		})
+
+		// Viewer role in the company of the compared reports, checked by the handler
+		r.With(middleware.LoadPermissions(s.authRepo)).Get("/compare", h.CompareScenarios)
 	})
 }
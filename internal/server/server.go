@@ -29,6 +29,7 @@ import (
 
 	"github.com/gmhafiz/go8/config"
 	authRepo "github.com/gmhafiz/go8/internal/domain/auth/repository"
+	authUseCase "github.com/gmhafiz/go8/internal/domain/auth/usecase"
 	"github.com/gmhafiz/go8/internal/middleware"
 	"github.com/gmhafiz/go8/logger"
 	db "github.com/gmhafiz/go8/third_party/database"
@@ -42,7 +43,8 @@ type Server struct {
 	db   *sql.DB
 	sqlx *sqlx.DB
 
-	authRepo authRepo.Repository
+	authRepo    authRepo.Repository
+	authUseCase authUseCase.UseCase // Issues and validates the configured token type
 
 	validator *validator.Validate
 	cors      *cors.Cors
@@ -82,6 +84,7 @@ func defaultServer() *Server {
 
 func (s *Server) Init() {
 	s.initLog()
+	s.validateConfig()
 	s.setCors()
 	s.NewDatabase()
 	s.newValidator()
@@ -97,6 +100,12 @@ func (s *Server) initLog() {
 	)))
 }
 
+func (s *Server) validateConfig() {
+	if err := s.cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func (s *Server) setCors() {
 	s.cors = cors.New(
 		cors.Options{
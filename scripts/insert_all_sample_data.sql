@@ -118,14 +118,14 @@ BEGIN
         vf := CASE WHEN m IN (1,4,7,10) THEN 1.0 WHEN m IN (2,5,8,11) THEN 1.02 ELSE 0.98 END;
         
         -- Budget
-        INSERT INTO financial_data (company_id, date, shipping_selling, sales_taxes_royalties, 
-            other_adjustments, currency, data_type, created_by)
-        VALUES (1, base_date, 150000, 280000, 25000, 'USD', 'budget', 1);
+        INSERT INTO financial_data (company_id, date, shipping_selling, sales_taxes, royalties,
+            other_sales_deductions, other_adjustments, currency, data_type, created_by)
+        VALUES (1, base_date, 150000, 200000, 80000, 0, 25000, 'USD', 'budget', 1);
         
         -- Actual
-        INSERT INTO financial_data (company_id, date, shipping_selling, sales_taxes_royalties, 
-            other_adjustments, currency, data_type, created_by)
-        VALUES (1, base_date, 150000*vf, 280000*vf, 25000*vf, 'USD', 'actual', 1);
+        INSERT INTO financial_data (company_id, date, shipping_selling, sales_taxes, royalties,
+            other_sales_deductions, other_adjustments, currency, data_type, created_by)
+        VALUES (1, base_date, 150000*vf, 200000*vf, 80000*vf, 0, 25000*vf, 'USD', 'actual', 1);
     END LOOP;
 END $$;
 
@@ -156,7 +156,7 @@ BEGIN
         (1, base_date, 'Mine', 'Mine Engineering', 'Labour', 396466, 'USD', 'budget', 1),
         (1, base_date, 'Mine', 'Mine Maintenance', 'Materials', 968061, 'USD', 'budget', 1),
         (1, base_date, 'Mine', 'General Operating', 'Other', 1383876, 'USD', 'budget', 1),
-        (1, base_date, 'Mine', 'Stockpile/WIP', 'Other', 1740162, 'USD', 'budget', 1),
+        (1, base_date, 'Mine', 'Inventory Variations', 'Other', 1740162, 'USD', 'budget', 1),
         -- Budget - Processing cost center
         (1, base_date, 'Processing', 'CO General Operating', 'Labour', 338318, 'USD', 'budget', 1),
         (1, base_date, 'Processing', 'CO Primary Crushing', 'Labour', 338119, 'USD', 'budget', 1),
@@ -198,7 +198,7 @@ BEGIN
         (1, base_date, 'Mine', 'Mine Engineering', 'Labour', 396466*vf, 'USD', 'actual', 1),
         (1, base_date, 'Mine', 'Mine Maintenance', 'Materials', 968061*vf, 'USD', 'actual', 1),
         (1, base_date, 'Mine', 'General Operating', 'Other', 1383876*vf, 'USD', 'actual', 1),
-        (1, base_date, 'Mine', 'Stockpile/WIP', 'Other', 1740162*vf, 'USD', 'actual', 1),
+        (1, base_date, 'Mine', 'Inventory Variations', 'Other', 1740162*vf, 'USD', 'actual', 1),
         -- Actual - Processing cost center
         (1, base_date, 'Processing', 'CO General Operating', 'Labour', 338318*vf, 'USD', 'actual', 1),
         (1, base_date, 'Processing', 'CO Primary Crushing', 'Labour', 338119*vf, 'USD', 'actual', 1),